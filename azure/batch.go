@@ -0,0 +1,63 @@
+package azure
+
+import (
+	"context"
+	"sync"
+
+	"github.com/aldor007/stow"
+	"github.com/pkg/errors"
+)
+
+// removeConcurrency is the number of blobs removed at once by
+// RemoveItems.
+const removeConcurrency = 32
+
+var (
+	_ stow.BatchRemover        = (*container)(nil)
+	_ stow.ContextBatchRemover = (*container)(nil)
+)
+
+// RemoveItems removes the blobs with concurrent delete requests, at
+// most 32 at a time, as the legacy SDK has no support for the batch
+// requests of the Blob service.
+func (c *container) RemoveItems(ids []string) (map[string]error, error) {
+	return c.RemoveItemsCtx(context.Background(), ids)
+}
+
+// RemoveItemsCtx is RemoveItems with a context. The legacy SDK has no
+// context support, so ctx is only checked between requests.
+func (c *container) RemoveItemsCtx(ctx context.Context, ids []string) (map[string]error, error) {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		failed = make(map[string]error)
+		queue  = make(chan string)
+	)
+	for i := 0; i < removeConcurrency && i < len(ids); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range queue {
+				err := c.RemoveItemCtx(ctx, id)
+				if err == nil || errors.Is(err, stow.ErrNotFound) {
+					continue
+				}
+				mu.Lock()
+				failed[id] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			break
+		}
+		queue <- id
+	}
+	close(queue)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return failed, errors.Wrap(err, "deleting blobs")
+	}
+	return failed, nil
+}
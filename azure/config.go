@@ -74,6 +74,14 @@ func init() {
 		return u.Scheme == Kind
 	}
 	stow.Register(Kind, makefn, kindfn, validatefn)
+	stow.RegisterDSN(Kind, stow.DSN{
+		User:     ConfigAccount,
+		Password: ConfigKey,
+	})
+	stow.RegisterSchema(Kind, stow.Schema{Keys: []stow.KeySchema{
+		{Name: ConfigAccount, Required: true, Description: "The name of the storage account."},
+		{Name: ConfigKey, Required: true, Secret: true, Description: "The shared key of the storage account."},
+	}})
 }
 
 func getAccount(cfg stow.Config) (account, key string, err error) {
@@ -2,6 +2,7 @@ package azure
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
 	"io"
 	"strings"
@@ -27,7 +28,24 @@ type container struct {
 	creds      *azblob.SharedKeyCredential
 }
 
-var _ stow.Container = (*container)(nil)
+var (
+	_ stow.Container                = (*container)(nil)
+	_ stow.ContextContainer         = (*container)(nil)
+	_ stow.CapabilityReporter       = (*container)(nil)
+	_ stow.Copier                   = (*container)(nil)
+	_ stow.ContextCopier            = (*container)(nil)
+	_ stow.ConditionalPutter        = (*container)(nil)
+	_ stow.ContextConditionalPutter = (*container)(nil)
+	_ stow.ItemWriter               = (*container)(nil)
+	_ stow.DelimitedLister          = (*container)(nil)
+	_ stow.ContextDelimitedLister   = (*container)(nil)
+	_ stow.MetadataSetter           = (*container)(nil)
+	_ stow.ContextMetadataSetter    = (*container)(nil)
+	_ stow.OptionsPutter            = (*container)(nil)
+	_ stow.ContextOptionsPutter     = (*container)(nil)
+	_ stow.Stater                   = (*container)(nil)
+	_ stow.ContextStater            = (*container)(nil)
+)
 
 func (c *container) ID() string {
 	return c.id
@@ -67,14 +85,28 @@ func (c *container) PreSignRequest(_ context.Context, method stow.ClientMethod,
 	return fmt.Sprintf("https://%s.blob.core.windows.net/%s/%s?%s", c.account, containerName, blobName, qp), nil
 }
 
+// Capabilities describes the features supported by this container.
+func (c *container) Capabilities() stow.Capabilities {
+	return capabilities()
+}
+
 func (c *container) Item(id string) (stow.Item, error) {
+	return c.ItemCtx(context.Background(), id)
+}
+
+// ItemCtx is Item with a context. The Azure SDK in use has no context
+// support, so ctx is checked before the request is sent.
+func (c *container) ItemCtx(ctx context.Context, id string) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	blob := c.client.GetContainerReference(c.id).GetBlobReference(id)
 	err := blob.GetProperties(nil)
 	if err != nil {
 		if strings.Contains(err.Error(), "404") {
 			return nil, stow.ErrNotFound
 		}
-		return nil, err
+		return nil, mapError(err)
 	}
 	item := &item{
 		id:         id,
@@ -89,58 +121,157 @@ func (c *container) Item(id string) (stow.Item, error) {
 	return item, nil
 }
 
-func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
-	params := az.ListBlobsParameters{
-		Prefix:     prefix,
-		MaxResults: uint(count),
-	}
-	if cursor != "" {
-		params.Marker = cursor
+// Stat gets the properties and metadata of a blob. The legacy SDK
+// gets them with a request each, so they are got with azblob instead.
+func (c *container) Stat(id string) (stow.ItemInfo, error) {
+	return c.StatCtx(context.Background(), id)
+}
+
+// StatCtx is Stat with a context.
+func (c *container) StatCtx(ctx context.Context, id string) (stow.ItemInfo, error) {
+	blob, err := c.blobClient(id)
+	if err != nil {
+		return stow.ItemInfo{}, err
 	}
-	listblobs, err := c.client.GetContainerReference(c.id).ListBlobs(params)
+	res, err := blob.GetProperties(ctx, nil)
 	if err != nil {
-		return nil, "", err
+		return stow.ItemInfo{}, mapError(err)
+	}
+	// Metadata keys are canonicalized as header names by azblob, and
+	// lowercased by the legacy SDK.
+	md := make(map[string]interface{}, len(res.Metadata))
+	for key, value := range res.Metadata {
+		md[strings.ToLower(key)] = value
+	}
+	info := stow.ItemInfo{ID: id, Name: id, Metadata: md}
+	if res.ContentLength != nil {
+		info.Size = *res.ContentLength
+	}
+	if res.ETag != nil {
+		info.ETag = cleanEtag(*res.ETag)
+	}
+	if res.LastModified != nil {
+		info.LastMod = *res.LastModified
+	}
+	if res.ContentType != nil {
+		info.ContentType = *res.ContentType
 	}
-	items := make([]stow.Item, len(listblobs.Blobs))
-	for i, blob := range listblobs.Blobs {
+	return info, nil
+}
 
-		// Clean Etag just in case.
-		blob.Properties.Etag = cleanEtag(blob.Properties.Etag)
+func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
+	return c.ItemsCtx(context.Background(), prefix, cursor, count)
+}
+
+// ItemsCtx is Items with a context.
+func (c *container) ItemsCtx(ctx context.Context, prefix, cursor string, count int) ([]stow.Item, string, error) {
+	items, _, next, err := c.ItemsDelimitedCtx(ctx, prefix, "", cursor, count)
+	return items, next, err
+}
 
-		items[i] = &item{
-			id:         blob.Name,
-			container:  c,
-			client:     c.client,
-			properties: blob.Properties,
+// ItemsDelimited lists the blobs with the delimiter of the Blob
+// service, which lists the common prefixes as blob prefixes. The blobs
+// under .stow-uploads/ record the pending multipart uploads, and are
+// never listed: the prefix is reserved.
+func (c *container) ItemsDelimited(prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	return c.ItemsDelimitedCtx(context.Background(), prefix, delimiter, cursor, count)
+}
+
+// ItemsDelimitedCtx is ItemsDelimited with a context. Pages whose
+// blobs are records of uploads are followed by the next ones, so that
+// they don't look like the end of the listing.
+func (c *container) ItemsDelimitedCtx(ctx context.Context, prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	items := []stow.Item{}
+	prefixes := []string{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, nil, "", err
+		}
+		params := az.ListBlobsParameters{
+			Prefix:    prefix,
+			Delimiter: delimiter,
+			Marker:    cursor,
+		}
+		if count > 0 {
+			params.MaxResults = uint(count - len(items) - len(prefixes))
+		}
+		listblobs, err := c.client.GetContainerReference(c.id).ListBlobs(params)
+		if err != nil {
+			return nil, nil, "", mapError(err)
+		}
+		for _, blob := range listblobs.Blobs {
+			if strings.HasPrefix(blob.Name, uploadsPrefix) {
+				// records of pending multipart uploads
+				continue
+			}
+
+			// Clean Etag just in case.
+			blob.Properties.Etag = cleanEtag(blob.Properties.Etag)
+
+			items = append(items, &item{
+				id:         blob.Name,
+				container:  c,
+				client:     c.client,
+				properties: blob.Properties,
+			})
+		}
+		for _, p := range listblobs.BlobPrefixes {
+			if !strings.HasPrefix(p, uploadsPrefix) {
+				prefixes = append(prefixes, p)
+			}
+		}
+		cursor = listblobs.NextMarker
+		// Pages are requested for the missing results only, so the
+		// cursor never skips any.
+		if cursor == "" || count <= 0 || len(items)+len(prefixes) >= count {
+			return items, prefixes, cursor, nil
 		}
 	}
-	return items, listblobs.NextMarker, nil
 }
 
 func (c *container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.PutCtx(context.Background(), name, r, size, metadata)
+}
+
+// PutCtx is Put with a context. Canceling ctx stops reading from r,
+// which aborts the upload.
+func (c *container) PutCtx(ctx context.Context, name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	mdParsed, err := prepMetadata(metadata)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create or update Item, preparing metadata")
 	}
+	r = stow.ContextReader(ctx, r)
 
 	name = strings.Replace(name, " ", "+", -1)
 
-	if size > maxPutSize {
+	if size < 0 {
+		// Upload blocks as they are read
+		size, err = c.streamUpload(name, r, nil, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "streaming upload")
+		}
+	} else if size > maxPutSize {
 		// Do a multipart upload
-		err := c.multipartUpload(name, r, size)
+		err := c.multipartUpload(name, r, size, nil, nil)
 		if err != nil {
-			return nil, errors.Wrap(err, "multipart upload")
+			return nil, errors.Wrap(mapError(err), "multipart upload")
 		}
 	} else {
 		err = c.client.GetContainerReference(c.id).GetBlobReference(name).CreateBlockBlobFromReader(r, nil)
 		if err != nil {
-			return nil, errors.Wrap(err, "unable to create or update Item")
+			return nil, errors.Wrap(mapError(err), "unable to create or update Item")
 		}
 	}
 
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	err = c.SetItemMetadata(name, mdParsed)
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to create or update item, setting Item metadata")
+		return nil, errors.Wrap(mapError(err), "unable to create or update item, setting Item metadata")
 	}
 
 	item := &item{
@@ -156,12 +287,261 @@ func (c *container) Put(name string, r io.Reader, size int64, metadata map[strin
 	return item, nil
 }
 
+// PutWithOptions is Put with the options sent as the properties of
+// the blob, which are set with the request that creates it. The access
+// tier and the blob index tags are set once the blob is created. Blobs
+// have no ACLs, so ACL is ignored, and ContentMD5 is stored as the
+// Content-MD5 of the blob without being checked.
+func (c *container) PutWithOptions(name string, r io.Reader, size int64, opts stow.PutOptions) (stow.Item, error) {
+	return c.PutWithOptionsCtx(context.Background(), name, r, size, opts)
+}
+
+// PutWithOptionsCtx is PutWithOptions with a context.
+func (c *container) PutWithOptionsCtx(ctx context.Context, name string, r io.Reader, size int64, opts stow.PutOptions) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r = stow.ContextReader(ctx, r)
+
+	name = strings.Replace(name, " ", "+", -1)
+	blob := c.client.GetContainerReference(c.id).GetBlobReference(name)
+	blob.Metadata = opts.Metadata
+	blob.Properties = az.BlobProperties{
+		ContentType:        opts.ContentType,
+		CacheControl:       opts.CacheControl,
+		ContentDisposition: opts.ContentDisposition,
+		ContentEncoding:    opts.ContentEncoding,
+		ContentLanguage:    opts.ContentLanguage,
+	}
+	if len(opts.ContentMD5) > 0 {
+		blob.Properties.ContentMD5 = base64.StdEncoding.EncodeToString(opts.ContentMD5)
+	}
+
+	var err error
+	if size < 0 {
+		_, err = c.streamUploadBlob(blob, r, nil)
+	} else if size > maxPutSize {
+		err = c.multipartUploadBlob(blob, r, size, nil)
+	} else {
+		err = blob.CreateBlockBlobFromReader(r, nil)
+	}
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "unable to create or update Item")
+	}
+
+	created, err := c.ItemCtx(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	item := created.(*item)
+	if opts.StorageClass != "" {
+		client, err := item.blobClient()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := client.SetTier(ctx, azblob.AccessTier(opts.StorageClass), nil); err != nil {
+			return nil, errors.Wrap(mapError(err), "unable to create or update Item, setting access tier")
+		}
+	}
+	if len(opts.Tags) > 0 {
+		if err := item.SetTagsCtx(ctx, opts.Tags); err != nil {
+			return nil, err
+		}
+	}
+	return item, nil
+}
+
+// CreateItem creates a new block blob whose contents are written to
+// the returned writer. They are uploaded in blocks as they are
+// written, and the blob is committed when the writer is closed.
+func (c *container) CreateItem(name string) (stow.Item, io.WriteCloser, error) {
+	name = strings.Replace(name, " ", "+", -1)
+	blob := c.client.GetContainerReference(c.id).GetBlobReference(name)
+	item := &item{
+		id:        name,
+		container: c,
+		client:    c.client,
+	}
+	w := &blobWriter{
+		blob: blob,
+		committed: func() error {
+			if err := blob.GetProperties(nil); err != nil {
+				return mapError(err)
+			}
+			item.properties = blob.Properties
+			item.properties.Etag = cleanEtag(item.properties.Etag)
+			return nil
+		},
+	}
+	return item, w, nil
+}
+
+// PutIf is Put with a condition, which is sent as the access
+// conditions of the request that creates the blob. The metadata is
+// sent with that request too, so it is only set when the write
+// happens.
+func (c *container) PutIf(name string, r io.Reader, size int64, metadata map[string]interface{}, cond stow.PutCondition) (stow.Item, error) {
+	return c.PutIfCtx(context.Background(), name, r, size, metadata, cond)
+}
+
+// PutIfCtx is PutIf with a context.
+func (c *container) PutIfCtx(ctx context.Context, name string, r io.Reader, size int64, metadata map[string]interface{}, cond stow.PutCondition) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	mdParsed, err := prepMetadata(metadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create or update Item, preparing metadata")
+	}
+	r = stow.ContextReader(ctx, r)
+
+	name = strings.Replace(name, " ", "+", -1)
+
+	var ifMatch, ifNoneMatch string
+	if cond.IfMatch != "" {
+		ifMatch = `"` + cond.IfMatch + `"`
+	}
+	if cond.IfNoneMatch {
+		ifNoneMatch = "*"
+	}
+	if size < 0 {
+		_, err = c.streamUpload(name, r, mdParsed, &az.PutBlockListOptions{
+			IfMatch:     ifMatch,
+			IfNoneMatch: ifNoneMatch,
+		})
+	} else if size > maxPutSize {
+		err = c.multipartUpload(name, r, size, mdParsed, &az.PutBlockListOptions{
+			IfMatch:     ifMatch,
+			IfNoneMatch: ifNoneMatch,
+		})
+	} else {
+		blob := c.client.GetContainerReference(c.id).GetBlobReference(name)
+		blob.Metadata = mdParsed
+		err = blob.CreateBlockBlobFromReader(r, &az.PutBlobOptions{
+			IfMatch:     ifMatch,
+			IfNoneMatch: ifNoneMatch,
+		})
+	}
+	if err != nil {
+		err = mapError(err)
+		// Blobs that exist fail If-None-Match: * with a conflict.
+		if cond.IfNoneMatch && errors.Is(err, stow.ErrAlreadyExists) {
+			err = stow.WrapError(stow.ErrPreconditionFailed, err)
+		}
+		return nil, errors.Wrap(err, "unable to create or update Item")
+	}
+
+	return c.ItemCtx(ctx, name)
+}
+
+// Copy copies the blob srcID to dstID in dstContainer, which must be
+// an Azure container of the same storage account. Copy waits for the
+// copy to complete.
+func (c *container) Copy(srcID string, dstContainer stow.Container, dstID string, metadata map[string]interface{}) (stow.Item, error) {
+	return c.CopyCtx(context.Background(), srcID, dstContainer, dstID, metadata)
+}
+
+// CopyCtx is Copy with a context.
+func (c *container) CopyCtx(ctx context.Context, srcID string, dstContainer stow.Container, dstID string, metadata map[string]interface{}) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	dst, ok := dstContainer.(*container)
+	if !ok {
+		return nil, stow.NotSupported("copy to another kind of container")
+	}
+	if dst.client != c.client {
+		// the source URL isn't signed, so dst can only read it when
+		// it is in the same storage account.
+		return nil, stow.NotSupported("copy to a container of another client")
+	}
+	dstID = strings.Replace(dstID, " ", "+", -1)
+	blob := dst.client.GetContainerReference(dst.id).GetBlobReference(dstID)
+	if metadata != nil {
+		mdParsed, err := prepMetadata(metadata)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to copy Item, preparing metadata")
+		}
+		blob.Metadata = mdParsed
+	}
+	source := c.client.GetContainerReference(c.id).GetBlobReference(srcID).GetURL()
+	if err := blob.Copy(source, nil); err != nil {
+		return nil, errors.Wrap(mapError(err), "unable to copy Item")
+	}
+	return dst.ItemCtx(ctx, dstID)
+}
+
 func (c *container) SetItemMetadata(itemName string, md map[string]string) error {
 	blob := c.client.GetContainerReference(c.id).GetBlobReference(itemName)
 	blob.Metadata = md
 	return blob.SetMetadata(nil)
 }
 
+// SetMetadata sets the metadata of the blob. The keys content-type,
+// content-encoding, content-language, content-disposition and
+// cache-control set the properties of the blob of the same names
+// instead, which are only changed when they are given.
+func (c *container) SetMetadata(id string, metadata map[string]interface{}, replace bool) error {
+	return c.SetMetadataCtx(context.Background(), id, metadata, replace)
+}
+
+// SetMetadataCtx is SetMetadata with a context.
+func (c *container) SetMetadataCtx(ctx context.Context, id string, metadata map[string]interface{}, replace bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	blob := c.client.GetContainerReference(c.id).GetBlobReference(id)
+	if err := blob.GetProperties(nil); err != nil {
+		return mapError(err)
+	}
+	properties := map[string]*string{
+		"content-type":        &blob.Properties.ContentType,
+		"content-encoding":    &blob.Properties.ContentEncoding,
+		"content-language":    &blob.Properties.ContentLanguage,
+		"content-disposition": &blob.Properties.ContentDisposition,
+		"cache-control":       &blob.Properties.CacheControl,
+	}
+	changes := make(map[string]interface{}, len(metadata))
+	var propertiesChanged bool
+	for key, value := range metadata {
+		property, ok := properties[strings.ToLower(key)]
+		if !ok {
+			changes[key] = value
+			continue
+		}
+		if value == nil {
+			value = ""
+		}
+		str, ok := value.(string)
+		if !ok {
+			return errors.Errorf(`value of key '%s' in metadata must be of type string`, key)
+		}
+		*property = str
+		propertiesChanged = true
+	}
+
+	md, err := parseMetadata(blob.Metadata)
+	if err != nil {
+		return err
+	}
+	if replace {
+		md = nil
+	}
+	blob.Metadata, err = prepMetadata(stow.MergeMetadata(md, changes))
+	if err != nil {
+		return errors.Wrap(err, "unable to set metadata, preparing metadata")
+	}
+	if err := blob.SetMetadata(nil); err != nil {
+		return errors.Wrap(mapError(err), "unable to set metadata")
+	}
+	if propertiesChanged {
+		if err := blob.SetProperties(nil); err != nil {
+			return errors.Wrap(mapError(err), "unable to set properties")
+		}
+	}
+	return nil
+}
+
 func parseMetadata(md map[string]string) (map[string]interface{}, error) {
 	rtnMap := make(map[string]interface{}, len(md))
 	for key, value := range md {
@@ -183,7 +563,15 @@ func prepMetadata(md map[string]interface{}) (map[string]string, error) {
 }
 
 func (c *container) RemoveItem(id string) error {
-	return c.client.GetContainerReference(c.id).GetBlobReference(id).Delete(nil)
+	return c.RemoveItemCtx(context.Background(), id)
+}
+
+// RemoveItemCtx is RemoveItem with a context.
+func (c *container) RemoveItemCtx(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return mapError(c.client.GetContainerReference(c.id).GetBlobReference(id).Delete(nil))
 }
 
 // Remove quotation marks from beginning and end. This includes quotations that
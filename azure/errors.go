@@ -0,0 +1,64 @@
+package azure
+
+import (
+	"errors"
+	"net/http"
+
+	azblob "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	az "github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/aldor007/stow"
+)
+
+// mapError maps errors returned by the Azure Storage API to the error
+// kinds declared by stow. The original error is kept in the chain.
+// Errors of both the legacy SDK and azblob are mapped.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var code string
+	var statusCode int
+	var serviceError az.AzureStorageServiceError
+	var storageError *azblob.StorageError
+	var unexpectedError az.UnexpectedStatusCodeError
+	switch {
+	case errors.As(err, &serviceError):
+		code, statusCode = serviceError.Code, serviceError.StatusCode
+	case errors.As(err, &storageError) && storageError.Response() != nil:
+		code, statusCode = string(storageError.ErrorCode), storageError.StatusCode()
+	case errors.As(err, &unexpectedError):
+		// Responses that aren't errors, like 304 Not Modified, have
+		// no code either.
+		statusCode = unexpectedError.Got()
+	default:
+		return err
+	}
+	switch code {
+	case "BlobNotFound", "ContainerNotFound", "ResourceNotFound":
+		return stow.WrapError(stow.ErrNotFound, err)
+	case "BlobAlreadyExists", "ContainerAlreadyExists":
+		return stow.WrapError(stow.ErrAlreadyExists, err)
+	case "AuthenticationFailed", "AuthorizationFailure", "AuthorizationPermissionMismatch", "InsufficientAccountPermissions":
+		return stow.WrapError(stow.ErrPermissionDenied, err)
+	case "ConditionNotMet", "SourceConditionNotMet", "TargetConditionNotMet":
+		return stow.WrapError(stow.ErrPreconditionFailed, err)
+	case "ServerBusy":
+		return stow.WrapError(stow.ErrThrottled, err)
+	case "InvalidResourceName", "OutOfRangeInput":
+		return stow.WrapError(stow.ErrInvalidName, err)
+	}
+	// HEAD requests have no body, so only the status code is known.
+	switch statusCode {
+	case http.StatusNotModified:
+		return stow.WrapError(stow.ErrNotModified, err)
+	case http.StatusNotFound:
+		return stow.WrapError(stow.ErrNotFound, err)
+	case http.StatusForbidden:
+		return stow.WrapError(stow.ErrPermissionDenied, err)
+	case http.StatusPreconditionFailed:
+		return stow.WrapError(stow.ErrPreconditionFailed, err)
+	case http.StatusServiceUnavailable:
+		return stow.WrapError(stow.ErrThrottled, err)
+	}
+	return err
+}
@@ -1,11 +1,13 @@
 package azure
 
 import (
+	"context"
 	"io"
 	"net/url"
 	"sync"
 	"time"
 
+	azblob "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
 	az "github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/aldor007/stow"
 	"github.com/pkg/errors"
@@ -21,11 +23,16 @@ type item struct {
 	infoOnce   sync.Once
 	infoErr    error
 	rangeData  stow.ContentRangeData
+	// versionID is the version of the blob got by ItemVersion.
+	versionID string
 }
 
 var (
-	_ stow.Item       = (*item)(nil)
-	_ stow.ItemRanger = (*item)(nil)
+	_ stow.Item                 = (*item)(nil)
+	_ stow.ContextItem          = (*item)(nil)
+	_ stow.ItemRanger           = (*item)(nil)
+	_ stow.OptionsOpener        = (*item)(nil)
+	_ stow.ContextOptionsOpener = (*item)(nil)
 )
 
 func (i *item) ID() string {
@@ -48,7 +55,23 @@ func (i *item) Size() (int64, error) {
 }
 
 func (i *item) Open() (io.ReadCloser, error) {
-	return i.client.GetContainerReference(i.container.id).GetBlobReference(i.id).Get(nil)
+	return i.OpenCtx(context.Background())
+}
+
+// OpenCtx is Open with a context. Reads from the returned body fail
+// once ctx is done.
+func (i *item) OpenCtx(ctx context.Context) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if i.versionID != "" {
+		return i.openVersion(ctx, stow.OpenOptions{})
+	}
+	rc, err := i.client.GetContainerReference(i.container.id).GetBlobReference(i.id).Get(nil)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return stow.ContextReadCloser(ctx, rc), nil
 }
 
 func (i *item) OpenParams(_ map[string]interface{}) (io.ReadCloser, error) {
@@ -109,6 +132,11 @@ func (i *item) getInfo() (stow.Item, error) {
 // OpenRange opens the item for reading starting at byte start and ending
 // at byte end.
 func (i *item) OpenRange(start, end uint64) (io.ReadCloser, error) {
+	if i.versionID != "" {
+		return i.openVersion(context.Background(), stow.OpenOptions{
+			Range: &stow.ByteRange{Start: int64(start), End: int64(end)},
+		})
+	}
 	opts := &az.GetBlobRangeOptions{
 		Range: &az.BlobRange{
 			Start: start,
@@ -118,3 +146,112 @@ func (i *item) OpenRange(start, end uint64) (io.ReadCloser, error) {
 
 	return i.client.GetContainerReference(i.container.id).GetBlobReference(i.id).GetRange(opts)
 }
+
+// OpenWithOptions opens the blob as described by opts. The conditions
+// are sent with the request and the response overrides are ignored.
+func (i *item) OpenWithOptions(opts stow.OpenOptions) (io.ReadCloser, error) {
+	return i.OpenWithOptionsCtx(context.Background(), opts)
+}
+
+// OpenWithOptionsCtx is OpenWithOptions with a context.
+func (i *item) OpenWithOptionsCtx(ctx context.Context, opts stow.OpenOptions) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if opts.VersionID != "" && opts.VersionID != i.versionID {
+		version, err := i.container.ItemVersionCtx(ctx, i.id, opts.VersionID)
+		if err != nil {
+			return nil, err
+		}
+		opts.VersionID = ""
+		return version.(*item).OpenWithOptionsCtx(ctx, opts)
+	}
+	if i.versionID != "" {
+		return i.openVersion(ctx, opts)
+	}
+	getOpts := &az.GetBlobOptions{}
+	if opts.IfNoneMatch != "" {
+		getOpts.IfNoneMatch = `"` + cleanEtag(opts.IfNoneMatch) + `"`
+	}
+	if !opts.IfModifiedSince.IsZero() {
+		since := opts.IfModifiedSince.UTC()
+		getOpts.IfModifiedSince = &since
+	}
+	blob := i.client.GetContainerReference(i.container.id).GetBlobReference(i.id)
+
+	// The Range header of the SDK can't express suffixes, nor single
+	// bytes at the start, so the range is resolved with the size of
+	// the blob and the body limited to it.
+	var offset, length int64
+	if opts.Range != nil {
+		var ok bool
+		offset, length, ok = opts.Range.Offsets(i.properties.ContentLength)
+		if !ok {
+			return nil, errors.Wrap(stow.ErrInvalidRange, opts.Range.String())
+		}
+	}
+	if length == 0 {
+		rc, err := blob.Get(getOpts)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		if opts.Range != nil {
+			rc = stow.LimitReadCloser(rc, 0)
+		}
+		return stow.ContextReadCloser(ctx, rc), nil
+	}
+	rc, err := blob.GetRange(&az.GetBlobRangeOptions{
+		Range: &az.BlobRange{
+			Start: uint64(offset),
+			End:   uint64(offset + length - 1),
+		},
+		GetBlobOptions: getOpts,
+	})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return stow.ContextReadCloser(ctx, stow.LimitReadCloser(rc, length)), nil
+}
+
+// openVersion opens the version of the blob got by ItemVersion, with
+// azblob as the legacy SDK has no support for blob versions.
+func (i *item) openVersion(ctx context.Context, opts stow.OpenOptions) (io.ReadCloser, error) {
+	client, err := i.container.versionClient(i.id, i.versionID)
+	if err != nil {
+		return nil, err
+	}
+	conditions := &azblob.ModifiedAccessConditions{}
+	if opts.IfNoneMatch != "" {
+		etag := `"` + cleanEtag(opts.IfNoneMatch) + `"`
+		conditions.IfNoneMatch = &etag
+	}
+	if !opts.IfModifiedSince.IsZero() {
+		since := opts.IfModifiedSince.UTC()
+		conditions.IfModifiedSince = &since
+	}
+	downloadOpts := &azblob.BlobDownloadOptions{
+		BlobAccessConditions: &azblob.BlobAccessConditions{ModifiedAccessConditions: conditions},
+	}
+	var length int64
+	if opts.Range != nil {
+		var offset int64
+		var ok bool
+		offset, length, ok = opts.Range.Offsets(i.properties.ContentLength)
+		if !ok {
+			return nil, errors.Wrap(stow.ErrInvalidRange, opts.Range.String())
+		}
+		// A count of 0 is to the end of the blob.
+		if length > 0 {
+			downloadOpts.Offset, downloadOpts.Count = &offset, &length
+		}
+	}
+	res, err := client.Download(ctx, downloadOpts)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	rc := res.Body(nil)
+	if opts.Range != nil {
+		rc = stow.LimitReadCloser(rc, length)
+	}
+	return stow.ContextReadCloser(ctx, rc), nil
+}
@@ -1,6 +1,7 @@
 package azure
 
 import (
+	"context"
 	"errors"
 	"net/url"
 	"strings"
@@ -19,6 +20,13 @@ type location struct {
 	sharedCreds *azblob.SharedKeyCredential
 }
 
+var (
+	_ stow.Location           = (*location)(nil)
+	_ stow.ContextLocation    = (*location)(nil)
+	_ stow.CapabilityReporter = (*location)(nil)
+	_ stow.URLParser          = (*location)(nil)
+)
+
 func (l *location) Close() error {
 	return nil // nothing to close
 }
@@ -28,26 +36,70 @@ func (l *location) HasRanges() bool {
 	return false
 }
 
+// Capabilities describes the features supported by this location.
+func (l *location) Capabilities() stow.Capabilities {
+	return capabilities()
+}
+
+// capabilities describes the features supported by Azure Blob Storage.
+func capabilities() stow.Capabilities {
+	return stow.Capabilities{
+		Ranges:            true,
+		Metadata:          true,
+		PresignMethods:    []stow.ClientMethod{stow.ClientMethodGet, stow.ClientMethodPut},
+		ServerSideCopy:    true,
+		Listing:           true,
+		Write:             true,
+		Delete:            true,
+		ConditionalWrites: true,
+		MultipartUploads:  true,
+		DelimitedListing:  true,
+		MetadataUpdates:   true,
+		Tags:              true,
+		TagQueries:        true,
+		BatchRemove:       true,
+		Versioning:        true,
+	}
+}
+
 func (l *location) CreateContainer(name string) (stow.Container, error) {
+	return l.CreateContainerCtx(context.Background(), name)
+}
+
+// CreateContainerCtx is CreateContainer with a context.
+func (l *location) CreateContainerCtx(ctx context.Context, name string) (stow.Container, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	err := l.client.GetContainerReference(name).Create(&az.CreateContainerOptions{Access: az.ContainerAccessTypeBlob})
 	if err != nil {
 		if strings.Contains(err.Error(), "ErrorCode=ContainerAlreadyExists") {
-			return l.Container(name)
+			return l.ContainerCtx(ctx, name)
 		}
-		return nil, err
+		return nil, mapError(err)
 	}
 	container := &container{
 		id: name,
 		properties: az.ContainerProperties{
 			LastModified: time.Now().Format(timeFormat),
 		},
-		client: l.client,
+		client:  l.client,
+		creds:   l.sharedCreds,
+		account: l.account,
 	}
 	time.Sleep(time.Second * 3)
 	return container, nil
 }
 
 func (l *location) Containers(prefix, cursor string, count int) ([]stow.Container, string, error) {
+	return l.ContainersCtx(context.Background(), prefix, cursor, count)
+}
+
+// ContainersCtx is Containers with a context.
+func (l *location) ContainersCtx(ctx context.Context, prefix, cursor string, count int) ([]stow.Container, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
 	params := az.ListContainersParameters{
 		MaxResults: uint(count),
 		Prefix:     prefix,
@@ -57,7 +109,7 @@ func (l *location) Containers(prefix, cursor string, count int) ([]stow.Containe
 	}
 	response, err := l.client.ListContainers(params)
 	if err != nil {
-		return nil, "", err
+		return nil, "", mapError(err)
 	}
 	containers := make([]stow.Container, len(response.Containers))
 	for i, azureContainer := range response.Containers {
@@ -73,10 +125,18 @@ func (l *location) Containers(prefix, cursor string, count int) ([]stow.Containe
 }
 
 func (l *location) Container(id string) (stow.Container, error) {
+	return l.ContainerCtx(context.Background(), id)
+}
+
+// ContainerCtx is Container with a context.
+func (l *location) ContainerCtx(ctx context.Context, id string) (stow.Container, error) {
 	cursor := stow.CursorStart
 	for {
-		containers, crsr, err := l.Containers(id[:3], cursor, 100)
+		containers, crsr, err := l.ContainersCtx(ctx, id[:3], cursor, 100)
 		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return nil, ctxErr
+			}
 			return nil, stow.ErrNotFound
 		}
 		for _, i := range containers {
@@ -95,30 +155,54 @@ func (l *location) Container(id string) (stow.Container, error) {
 }
 
 func (l *location) ItemByURL(url *url.URL) (stow.Item, error) {
+	return l.ItemByURLCtx(context.Background(), url)
+}
+
+// ItemByURLCtx is ItemByURL with a context.
+func (l *location) ItemByURLCtx(ctx context.Context, url *url.URL) (stow.Item, error) {
+	containerID, name, err := l.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+	c, err := l.ContainerCtx(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+	return c.(*container).ItemCtx(ctx, name)
+}
+
+// ParseURL gets the container and the name of the blob a URL points
+// to. URLs look like azure://<account>.blob.core.windows.net/<container>/<blob>,
+// and must be of the account of the location.
+func (l *location) ParseURL(url *url.URL) (string, string, error) {
 	if url.Scheme != "azure" {
-		return nil, errors.New("not valid azure URL")
+		return "", "", errors.New("not valid azure URL")
 	}
 	location := strings.Split(url.Host, ".")[0]
 	a, ok := l.config.Config(ConfigAccount)
 	if !ok {
 		// shouldn't really happen
-		return nil, errors.New("missing " + ConfigAccount + " config")
+		return "", "", errors.New("missing " + ConfigAccount + " config")
 	}
 	if a != location {
-		return nil, errors.New("wrong azure URL")
+		return "", "", errors.New("wrong azure URL")
 	}
 	path := strings.TrimLeft(url.Path, "/")
 	params := strings.SplitN(path, "/", 2)
-	if len(params) != 2 {
-		return nil, errors.New("wrong path")
+	if len(params) != 2 || params[1] == "" {
+		return "", "", errors.New("wrong path")
 	}
-	c, err := l.Container(params[0])
-	if err != nil {
-		return nil, err
-	}
-	return c.Item(params[1])
+	return params[0], params[1], nil
 }
 
 func (l *location) RemoveContainer(id string) error {
-	return l.client.GetContainerReference(id).Delete(nil)
+	return l.RemoveContainerCtx(context.Background(), id)
+}
+
+// RemoveContainerCtx is RemoveContainer with a context.
+func (l *location) RemoveContainerCtx(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return mapError(l.client.GetContainerReference(id).Delete(nil))
 }
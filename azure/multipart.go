@@ -1,12 +1,19 @@
 package azure
 
 import (
+	"bytes"
 	"encoding/base64"
 	"encoding/binary"
-	"errors"
+	"fmt"
 	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
 
 	az "github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/aldor007/stow"
+	"github.com/pkg/errors"
 )
 
 // constants related to multi-part uploads
@@ -56,8 +63,16 @@ func determineChunkSize(size int64) (int64, error) {
 }
 
 // multipartUpload performs a multi-part upload by chunking the data, putting each chunk, then
-// assembling the chunks into a blob
-func (c *container) multipartUpload(name string, r io.Reader, size int64) error {
+// assembling the chunks into a blob with the given metadata. options apply to the final assembly.
+func (c *container) multipartUpload(name string, r io.Reader, size int64, metadata map[string]string, options *az.PutBlockListOptions) error {
+	blob := c.client.GetContainerReference(c.id).GetBlobReference(name)
+	blob.Metadata = metadata
+	return c.multipartUploadBlob(blob, r, size, options)
+}
+
+// multipartUploadBlob is multipartUpload with the metadata and
+// properties of blob.
+func (c *container) multipartUploadBlob(blob *az.Blob, r io.Reader, size int64, options *az.PutBlockListOptions) error {
 	chunkSize, err := determineChunkSize(size)
 	if err != nil {
 		return err
@@ -66,7 +81,6 @@ func (c *container) multipartUpload(name string, r io.Reader, size int64) error
 
 	var blocks []az.Block
 	var rawID uint64
-	blob := c.client.GetContainerReference(c.id).GetBlobReference(name)
 
 	// TODO: upload the parts in parallel
 	for {
@@ -92,5 +106,300 @@ func (c *container) multipartUpload(name string, r io.Reader, size int64) error
 		rawID++
 	}
 
-	return blob.PutBlockList(blocks, nil)
+	return blob.PutBlockList(blocks, options)
+}
+
+// blobWriter uploads what is written to it as the blocks of a block
+// blob, which are committed on Close. As the size isn't known up
+// front, blocks start small and grow as more of them are uploaded, so
+// that the limit on the number of blocks isn't reached too early.
+type blobWriter struct {
+	blob   *az.Blob
+	buf    []byte
+	blocks []az.Block
+	err    error
+	// options apply to the commit of the blob.
+	options *az.PutBlockListOptions
+	// committed, if set, is called once the blob has been committed.
+	committed func() error
+}
+
+// chunkSize gets the size of the next block.
+func (w *blobWriter) chunkSize() int {
+	size := int64(startChunkSize) << uint(len(w.blocks)/(maxParts/10))
+	if size > maxChunkSize {
+		size = maxChunkSize
+	}
+	return int(size)
+}
+
+func (w *blobWriter) Write(p []byte) (int, error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	n := len(p)
+	for len(p) > 0 {
+		m := w.chunkSize() - len(w.buf)
+		if m > len(p) {
+			m = len(p)
+		}
+		w.buf = append(w.buf, p[:m]...)
+		p = p[m:]
+		if len(w.buf) == w.chunkSize() {
+			if err := w.putBlock(); err != nil {
+				return n - len(p) - m, err
+			}
+		}
+	}
+	return n, nil
+}
+
+func (w *blobWriter) putBlock() error {
+	if len(w.blocks) == maxParts {
+		w.err = errMultiPartUploadTooBig
+		return w.err
+	}
+	blockID := encodedBlockID(uint64(len(w.blocks)))
+	if err := w.blob.PutBlock(blockID, w.buf, nil); err != nil {
+		w.err = mapError(err)
+		return w.err
+	}
+	w.blocks = append(w.blocks, az.Block{
+		ID:     blockID,
+		Status: az.BlockStatusLatest,
+	})
+	w.buf = w.buf[:0]
+	return nil
+}
+
+func (w *blobWriter) Close() error {
+	if w.err != nil {
+		return w.err
+	}
+	if len(w.buf) > 0 {
+		if err := w.putBlock(); err != nil {
+			return err
+		}
+	}
+	// Fails further writes.
+	w.err = errors.New("write to closed blob writer")
+	if err := w.blob.PutBlockList(w.blocks, w.options); err != nil {
+		return mapError(err)
+	}
+	if w.committed == nil {
+		return nil
+	}
+	return w.committed()
+}
+
+// streamUpload uploads r, whose size isn't known up front, as the
+// blocks of a blob with the given metadata, and returns the number of
+// bytes uploaded. options apply to the final assembly.
+func (c *container) streamUpload(name string, r io.Reader, metadata map[string]string, options *az.PutBlockListOptions) (int64, error) {
+	blob := c.client.GetContainerReference(c.id).GetBlobReference(name)
+	blob.Metadata = metadata
+	return c.streamUploadBlob(blob, r, options)
+}
+
+// streamUploadBlob is streamUpload with the metadata and properties of
+// blob.
+func (c *container) streamUploadBlob(blob *az.Blob, r io.Reader, options *az.PutBlockListOptions) (int64, error) {
+	w := &blobWriter{blob: blob, options: options}
+	n, err := io.Copy(w, r)
+	if err != nil {
+		return n, err
+	}
+	return n, w.Close()
+}
+
+// uploadID is the ID of the uploads of this package. Azure keeps the
+// uncommitted blocks of a blob by the name of the blob, so the name is
+// all that identifies an upload; the ID only carries the metadata to
+// set when the blob is committed.
+const uploadID = "block-list"
+
+// uploadsPrefix is the prefix of the blobs that record the pending
+// uploads, with the metadata of the upload, by the name of the blob
+// uploaded. The prefix is reserved: the blobs under it aren't listed
+// as items of the container, whether they record uploads or not.
+const uploadsPrefix = ".stow-uploads/"
+
+var _ stow.MultipartUploader = (*container)(nil)
+
+// InitiateUpload starts a multipart upload, whose parts are uploaded
+// as uncommitted blocks of the blob. Azure has no request for this,
+// so the upload is recorded by a blob under uploadsPrefix, and the
+// metadata is kept in the ID of the upload until the blob is
+// committed.
+func (c *container) InitiateUpload(name string, metadata map[string]interface{}) (stow.Upload, error) {
+	mdParsed, err := prepMetadata(metadata)
+	if err != nil {
+		return stow.Upload{}, errors.Wrap(err, "unable to create upload, preparing metadata")
+	}
+	upload := stow.Upload{
+		ID:        encodeUploadID(mdParsed),
+		Name:      strings.Replace(name, " ", "+", -1),
+		Initiated: time.Now(),
+	}
+	record := c.uploadRecord(upload)
+	record.Metadata = mdParsed
+	if err := record.CreateBlockBlob(nil); err != nil {
+		return stow.Upload{}, errors.Wrap(mapError(err), "unable to create upload")
+	}
+	return upload, nil
+}
+
+// UploadPart uploads a part as a block of the blob. Azure allows at
+// most 50000 blocks of at most 100MiB per blob. Parts of unknown size
+// are buffered in memory.
+func (c *container) UploadPart(upload stow.Upload, number int, r io.Reader, size int64) (stow.Part, error) {
+	if number < 1 || number > maxParts {
+		return stow.Part{}, fmt.Errorf("invalid part number %d", number)
+	}
+	if size < 0 {
+		chunk, err := io.ReadAll(r)
+		if err != nil {
+			return stow.Part{}, errors.Wrap(err, "reading part")
+		}
+		r, size = bytes.NewReader(chunk), int64(len(chunk))
+	}
+	blob := c.uploadBlob(upload)
+	err := blob.PutBlockWithLength(encodedBlockID(uint64(number)), uint64(size), r, nil)
+	if err != nil {
+		return stow.Part{}, errors.Wrapf(mapError(err), "uploading part %d", number)
+	}
+	return stow.Part{Number: number, Size: size}, nil
+}
+
+// ListParts gets the uncommitted blocks of the blob.
+func (c *container) ListParts(upload stow.Upload) ([]stow.Part, error) {
+	blocks, err := c.uploadBlob(upload).GetBlockList(az.BlockListTypeUncommitted, nil)
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "listing parts")
+	}
+	var parts []stow.Part
+	for _, block := range blocks.UncommittedBlocks {
+		number, ok := decodeBlockID(block.Name)
+		if !ok || number < 1 || number > maxParts {
+			// not uploaded by UploadPart
+			continue
+		}
+		parts = append(parts, stow.Part{Number: int(number), Size: block.Size})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	return parts, nil
+}
+
+// CompleteUpload commits the blocks of the parts as the blob, with the
+// metadata given to InitiateUpload.
+func (c *container) CompleteUpload(upload stow.Upload, parts []stow.Part) (stow.Item, error) {
+	blob := c.uploadBlob(upload)
+	md, err := decodeUploadID(upload.ID)
+	if err != nil {
+		return nil, err
+	}
+	blob.Metadata = md
+	parts = append([]stow.Part(nil), parts...)
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	blocks := make([]az.Block, len(parts))
+	for i, part := range parts {
+		blocks[i] = az.Block{
+			ID:     encodedBlockID(uint64(part.Number)),
+			Status: az.BlockStatusUncommitted,
+		}
+	}
+	if err := blob.PutBlockList(blocks, nil); err != nil {
+		return nil, errors.Wrap(mapError(err), "completing multipart upload")
+	}
+	if _, err := c.uploadRecord(upload).DeleteIfExists(nil); err != nil {
+		return nil, errors.Wrap(mapError(err), "completing multipart upload")
+	}
+	return c.Item(upload.Name)
+}
+
+// AbortUpload removes the record of the upload, so that it isn't
+// listed as pending anymore. Uncommitted blocks can only be discarded
+// by committing the blob, which would create or replace it, so they
+// are left for Azure to discard, which it does after a week.
+func (c *container) AbortUpload(upload stow.Upload) error {
+	if _, err := c.uploadRecord(upload).DeleteIfExists(nil); err != nil {
+		return errors.Wrap(mapError(err), "aborting multipart upload")
+	}
+	return nil
+}
+
+// ListPendingUploads gets the uploads that have been initiated but
+// neither completed nor aborted, from their records.
+func (c *container) ListPendingUploads(prefix string) ([]stow.Upload, error) {
+	var uploads []stow.Upload
+	params := az.ListBlobsParameters{
+		Prefix:  uploadsPrefix + prefix,
+		Include: &az.IncludeBlobDataset{Metadata: true},
+	}
+	for {
+		res, err := c.client.GetContainerReference(c.id).ListBlobs(params)
+		if err != nil {
+			return nil, errors.Wrap(mapError(err), "listing multipart uploads")
+		}
+		for _, blob := range res.Blobs {
+			uploads = append(uploads, stow.Upload{
+				ID:        encodeUploadID(blob.Metadata),
+				Name:      strings.TrimPrefix(blob.Name, uploadsPrefix),
+				Initiated: time.Time(blob.Properties.LastModified),
+			})
+		}
+		if res.NextMarker == "" {
+			break
+		}
+		params.Marker = res.NextMarker
+	}
+	return uploads, nil
+}
+
+// encodeUploadID makes the ID of an upload with the metadata.
+func encodeUploadID(metadata map[string]string) string {
+	if len(metadata) == 0 {
+		return uploadID
+	}
+	md := url.Values{}
+	for k, v := range metadata {
+		md.Set(k, v)
+	}
+	return uploadID + "?" + md.Encode()
+}
+
+// decodeUploadID gets the metadata of an upload from its ID.
+func decodeUploadID(id string) (map[string]string, error) {
+	i := strings.Index(id, "?")
+	if i < 0 {
+		return nil, nil
+	}
+	md, err := url.ParseQuery(id[i+1:])
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing upload metadata")
+	}
+	metadata := make(map[string]string, len(md))
+	for k := range md {
+		metadata[k] = md.Get(k)
+	}
+	return metadata, nil
+}
+
+// uploadRecord gets the blob that records an upload.
+func (c *container) uploadRecord(upload stow.Upload) *az.Blob {
+	return c.client.GetContainerReference(c.id).GetBlobReference(uploadsPrefix + upload.Name)
+}
+
+// uploadBlob gets the blob of an upload.
+func (c *container) uploadBlob(upload stow.Upload) *az.Blob {
+	return c.client.GetContainerReference(c.id).GetBlobReference(upload.Name)
+}
+
+// decodeBlockID decodes a block id made by encodedBlockID.
+func decodeBlockID(blockID string) (uint64, bool) {
+	bytesID, err := base64.StdEncoding.DecodeString(blockID)
+	if err != nil || len(bytesID) != 8 {
+		return 0, false
+	}
+	return binary.LittleEndian.Uint64(bytesID), true
 }
@@ -7,6 +7,7 @@ import (
 	"os"
 	"testing"
 
+	az "github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/cheekybits/is"
 	"github.com/aldor007/stow"
 )
@@ -35,6 +36,21 @@ func TestChunkSize(t *testing.T) {
 	is.Equal(err, errMultiPartUploadTooBig)
 }
 
+func TestBlobWriterChunkSize(t *testing.T) {
+	is := is.New(t)
+
+	w := &blobWriter{}
+	is.Equal(w.chunkSize(), startChunkSize)
+
+	// Blocks grow as more of them are uploaded
+	w.blocks = make([]az.Block, maxParts/10)
+	is.Equal(w.chunkSize(), startChunkSize*2)
+
+	// Up to the maximum size
+	w.blocks = make([]az.Block, maxParts-1)
+	is.Equal(w.chunkSize(), maxChunkSize)
+}
+
 func TestEncodeBlockID(t *testing.T) {
 	is := is.New(t)
 
@@ -42,6 +58,33 @@ func TestEncodeBlockID(t *testing.T) {
 	is.Equal(encodedBlockID(600), "WAIAAAAAAAA=")
 }
 
+func TestDecodeBlockID(t *testing.T) {
+	is := is.New(t)
+
+	id, ok := decodeBlockID(encodedBlockID(600))
+	is.True(ok)
+	is.Equal(id, uint64(600))
+
+	_, ok = decodeBlockID("not a block id")
+	is.False(ok)
+	_, ok = decodeBlockID("AAAA")
+	is.False(ok)
+}
+
+func TestUploadID(t *testing.T) {
+	is := is.New(t)
+
+	is.Equal(encodeUploadID(nil), uploadID)
+	md, err := decodeUploadID(uploadID)
+	is.NoErr(err)
+	is.Equal(len(md), 0)
+
+	id := encodeUploadID(map[string]string{"author": "a&b=c", "kind": "test"})
+	md, err = decodeUploadID(id)
+	is.NoErr(err)
+	is.Equal(md, map[string]string{"author": "a&b=c", "kind": "test"})
+}
+
 func TestMultipartUpload(t *testing.T) {
 	is := is.New(t)
 
@@ -79,7 +122,7 @@ func TestMultipartUpload(t *testing.T) {
 	name := "bigfile/thebigfile"
 	azc, ok := cont.(*container)
 	is.OK(ok)
-	is.NoErr(azc.multipartUpload(name, f, fi.Size()))
+	is.NoErr(azc.multipartUpload(name, f, fi.Size(), nil, nil))
 
 	item, err := cont.Item(name)
 	is.NoErr(err)
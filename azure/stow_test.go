@@ -2,11 +2,16 @@ package azure
 
 import (
 	"context"
+	"encoding/xml"
 	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
 	"os"
 	"reflect"
 	"testing"
 
+	az "github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/cheekybits/is"
@@ -101,3 +106,59 @@ func TestContainer_PreSignRequest(t *testing.T) {
 
 	assert.NotEmpty(t, u)
 }
+
+func TestErrorMapping(t *testing.T) {
+	service := func(status int, code string) error {
+		return az.AzureStorageServiceError{StatusCode: status, Code: code}
+	}
+
+	test.ErrorMapping(t, mapError, map[error][]error{
+		stow.ErrNotFound:           {service(http.StatusNotFound, "BlobNotFound"), service(http.StatusNotFound, "")},
+		stow.ErrAlreadyExists:      {service(http.StatusConflict, "ContainerAlreadyExists")},
+		stow.ErrPermissionDenied:   {service(http.StatusForbidden, "AuthorizationFailure"), service(http.StatusForbidden, "")},
+		stow.ErrPreconditionFailed: {service(http.StatusPreconditionFailed, "ConditionNotMet")},
+		stow.ErrThrottled:          {service(http.StatusServiceUnavailable, "ServerBusy")},
+		stow.ErrInvalidName:        {service(http.StatusBadRequest, "InvalidResourceName")},
+	})
+}
+
+func TestItemsSkipsUploadRecords(t *testing.T) {
+	is := is.New(t)
+	pages := map[string]az.BlobListResponse{
+		"":   {Blobs: []az.Blob{{Name: uploadsPrefix + "a"}, {Name: uploadsPrefix + "b"}}, NextMarker: "m1"},
+		"m1": {Blobs: []az.Blob{{Name: "a"}}, NextMarker: "m2"},
+		"m2": {Blobs: []az.Blob{{Name: "b"}}, NextMarker: "m3"},
+	}
+	var maxResults []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		maxResults = append(maxResults, r.URL.Query().Get("maxresults"))
+		xml.NewEncoder(w).Encode(pages[r.URL.Query().Get("marker")])
+	}))
+	defer server.Close()
+	serverURL, err := url.Parse(server.URL)
+	is.NoErr(err)
+	client, err := az.NewBasicClient("account", "a2V5")
+	is.NoErr(err)
+	client.HTTPClient = &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		r.URL.Scheme, r.URL.Host = serverURL.Scheme, serverURL.Host
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+	blobClient := client.GetBlobService()
+	c := &container{id: "container", client: &blobClient}
+
+	// pages of records only are followed by the next ones, which are
+	// asked for the missing items only
+	items, cursor, err := c.Items("", stow.CursorStart, 2)
+	is.NoErr(err)
+	is.Equal(len(items), 2)
+	is.Equal(items[0].ID(), "a")
+	is.Equal(items[1].ID(), "b")
+	is.Equal(cursor, "m3")
+	is.Equal(maxResults, []string{"2", "2", "1"})
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
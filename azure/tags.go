@@ -0,0 +1,144 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	azblob "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/aldor007/stow"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ stow.Taggable          = (*item)(nil)
+	_ stow.ContextTaggable   = (*item)(nil)
+	_ stow.TagSetter         = (*item)(nil)
+	_ stow.ContextTagSetter  = (*item)(nil)
+	_ stow.TagQuerier        = (*container)(nil)
+	_ stow.ContextTagQuerier = (*container)(nil)
+)
+
+// Tags gets the blob index tags of the blob.
+func (i *item) Tags() (map[string]interface{}, error) {
+	return i.TagsCtx(context.Background())
+}
+
+// TagsCtx is Tags with a context.
+func (i *item) TagsCtx(ctx context.Context) (map[string]interface{}, error) {
+	blob, err := i.blobClient()
+	if err != nil {
+		return nil, err
+	}
+	res, err := blob.GetTags(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "Tags, getting blob tags")
+	}
+	tags := make(map[string]interface{}, len(res.BlobTagSet))
+	for _, tag := range res.BlobTagSet {
+		tags[*tag.Key] = *tag.Value
+	}
+	return tags, nil
+}
+
+// SetTags replaces the blob index tags of the blob.
+func (i *item) SetTags(tags map[string]string) error {
+	return i.SetTagsCtx(context.Background(), tags)
+}
+
+// SetTagsCtx is SetTags with a context.
+func (i *item) SetTagsCtx(ctx context.Context, tags map[string]string) error {
+	blob, err := i.blobClient()
+	if err != nil {
+		return err
+	}
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	if _, err := blob.SetTags(ctx, &azblob.BlobSetTagsOptions{TagsMap: tags}); err != nil {
+		return errors.Wrap(mapError(err), "SetTags, setting blob tags")
+	}
+	return nil
+}
+
+// DeleteTags removes all the blob index tags of the blob.
+func (i *item) DeleteTags() error {
+	return i.DeleteTagsCtx(context.Background())
+}
+
+// DeleteTagsCtx is DeleteTags with a context.
+func (i *item) DeleteTagsCtx(ctx context.Context) error {
+	return i.SetTagsCtx(ctx, nil)
+}
+
+// blobClient gets an azblob client for the blob, as the legacy SDK has
+// no support for blob index tags.
+func (i *item) blobClient() (*azblob.BlobClient, error) {
+	return i.container.blobClient(i.id)
+}
+
+// blobClient gets an azblob client for the blob with the specified
+// name.
+func (c *container) blobClient(id string) (*azblob.BlobClient, error) {
+	if c.creds == nil {
+		return nil, errors.New("azblob requests need shared key credentials")
+	}
+	u := c.client.GetContainerReference(c.id).GetBlobReference(id).GetURL()
+	return azblob.NewBlobClientWithSharedKey(u, c.creds, nil)
+}
+
+// ItemsByTag finds the blobs of the container by their blob index
+// tags. The index is updated asynchronously, so blobs are found some
+// time after their tags are set.
+func (c *container) ItemsByTag(key, value, cursor string, count int) ([]stow.Item, string, error) {
+	return c.ItemsByTagCtx(context.Background(), key, value, cursor, count)
+}
+
+// ItemsByTagCtx is ItemsByTag with a context.
+func (c *container) ItemsByTagCtx(ctx context.Context, key, value, cursor string, count int) ([]stow.Item, string, error) {
+	if c.creds == nil {
+		return nil, "", errors.New("blob index tags need shared key credentials")
+	}
+	u, err := url.Parse(c.client.GetContainerReference(c.id).GetURL())
+	if err != nil {
+		return nil, "", err
+	}
+	u.Path = "/"
+	service, err := azblob.NewServiceClientWithSharedKey(u.String(), c.creds, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Tag values can't contain quotes, so they need no escaping.
+	where := fmt.Sprintf(`@container='%s' AND "%s"='%s'`, c.id, key, value)
+	options := &azblob.ServiceFilterBlobsOptions{Where: &where}
+	if count > 0 {
+		maxResults := int32(count)
+		options.MaxResults = &maxResults
+	}
+	if cursor != stow.CursorStart {
+		options.Marker = &cursor
+	}
+	res, err := service.FindBlobsByTags(ctx, options)
+	if err != nil {
+		return nil, "", errors.Wrap(mapError(err), "ItemsByTag, finding blobs by tags")
+	}
+
+	// Found blobs have no properties, so they are got one by one.
+	items := make([]stow.Item, 0, len(res.Blobs))
+	for _, blob := range res.Blobs {
+		item, err := c.ItemCtx(ctx, *blob.Name)
+		if errors.Is(err, stow.ErrNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, "", err
+		}
+		items = append(items, item)
+	}
+	var next string
+	if res.NextMarker != nil {
+		next = *res.NextMarker
+	}
+	return items, next, nil
+}
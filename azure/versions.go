@@ -0,0 +1,191 @@
+package azure
+
+import (
+	"context"
+	"sort"
+
+	azblob "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	az "github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/aldor007/stow"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ stow.Versioned        = (*container)(nil)
+	_ stow.ContextVersioned = (*container)(nil)
+)
+
+// ItemVersions gets a page of the versions of a blob, which Azure
+// keeps when blob versioning is enabled on the storage account. The
+// cursor is the version ID the page starts after.
+//
+// Azure has no delete markers: once a blob is removed, none of its
+// versions is the latest.
+func (c *container) ItemVersions(id, cursor string, count int) ([]stow.Version, string, error) {
+	return c.ItemVersionsCtx(context.Background(), id, cursor, count)
+}
+
+// ItemVersionsCtx is ItemVersions with a context.
+func (c *container) ItemVersionsCtx(ctx context.Context, id, cursor string, count int) ([]stow.Version, string, error) {
+	client, err := c.containerClient()
+	if err != nil {
+		return nil, "", err
+	}
+	pager := client.ListBlobsFlat(&azblob.ContainerListBlobsFlatOptions{
+		Include: []azblob.ListBlobsIncludeItem{azblob.ListBlobsIncludeItemVersions},
+		Prefix:  &id,
+	})
+
+	// The prefix also matches the blobs whose names start with id,
+	// which are listed after it.
+	var versions []stow.Version
+pages:
+	for pager.NextPage(ctx) {
+		for _, blob := range pager.PageResponse().Segment.BlobItems {
+			if blob.Name == nil || *blob.Name > id {
+				break pages
+			}
+			// Version IDs are timestamps, so they sort as strings.
+			if *blob.Name != id || blob.VersionID == nil || (cursor != "" && *blob.VersionID >= cursor) {
+				continue
+			}
+			version := stow.Version{
+				ID:     *blob.VersionID,
+				Latest: blob.IsCurrentVersion != nil && *blob.IsCurrentVersion,
+			}
+			if props := blob.Properties; props != nil {
+				if props.LastModified != nil {
+					version.LastMod = *props.LastModified
+				}
+				if props.ContentLength != nil {
+					version.Size = *props.ContentLength
+				}
+				if props.Etag != nil {
+					version.ETag = cleanEtag(*props.Etag)
+				}
+			}
+			versions = append(versions, version)
+		}
+	}
+	if err := pager.Err(); err != nil {
+		return nil, "", errors.Wrap(mapError(err), "ItemVersions, listing blob versions")
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].ID > versions[j].ID
+	})
+
+	if count <= 0 || len(versions) <= count {
+		return versions, "", nil
+	}
+	versions = versions[:count]
+	return versions, versions[count-1].ID, nil
+}
+
+// ItemVersion gets a version of a blob. Its contents and metadata are
+// those of the version.
+func (c *container) ItemVersion(id, versionID string) (stow.Item, error) {
+	return c.ItemVersionCtx(context.Background(), id, versionID)
+}
+
+// ItemVersionCtx is ItemVersion with a context.
+func (c *container) ItemVersionCtx(ctx context.Context, id, versionID string) (stow.Item, error) {
+	client, err := c.versionClient(id, versionID)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GetProperties(ctx, nil)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	metadata, err := parseMetadata(res.Metadata)
+	if err != nil {
+		return nil, errors.Wrap(err, "ItemVersion, parsing metadata")
+	}
+	item := &item{
+		id:        id,
+		container: c,
+		client:    c.client,
+		metadata:  metadata,
+		versionID: versionID,
+	}
+	if res.ContentLength != nil {
+		item.properties.ContentLength = *res.ContentLength
+	}
+	if res.ContentType != nil {
+		item.properties.ContentType = *res.ContentType
+	}
+	if res.ETag != nil {
+		item.properties.Etag = cleanEtag(*res.ETag)
+	}
+	if res.LastModified != nil {
+		item.properties.LastModified = az.TimeRFC1123(*res.LastModified)
+	}
+	return item, nil
+}
+
+// RemoveItemVersion permanently removes a version of a blob. Azure
+// can't remove the current version, so the blob is removed first,
+// which makes it a previous version; the blob is then left without a
+// current version.
+func (c *container) RemoveItemVersion(id, versionID string) error {
+	return c.RemoveItemVersionCtx(context.Background(), id, versionID)
+}
+
+// RemoveItemVersionCtx is RemoveItemVersion with a context.
+func (c *container) RemoveItemVersionCtx(ctx context.Context, id, versionID string) error {
+	client, err := c.versionClient(id, versionID)
+	if err != nil {
+		return err
+	}
+	res, err := client.GetProperties(ctx, nil)
+	if err != nil {
+		return mapError(err)
+	}
+	if res.IsCurrentVersion != nil && *res.IsCurrentVersion {
+		// Only removed when it is still the version removed.
+		blob, err := c.blobClient(id)
+		if err != nil {
+			return err
+		}
+		_, err = blob.Delete(ctx, &azblob.BlobDeleteOptions{
+			BlobAccessConditions: &azblob.BlobAccessConditions{
+				ModifiedAccessConditions: &azblob.ModifiedAccessConditions{IfMatch: res.ETag},
+			},
+		})
+		if err != nil {
+			return errors.Wrap(mapError(err), "RemoveItemVersion, removing the current version")
+		}
+	}
+	_, err = client.Delete(ctx, nil)
+	return mapError(err)
+}
+
+// EnableVersioning returns an error satisfying stow.IsNotSupported, as
+// blob versioning is enabled for the whole storage account.
+func (c *container) EnableVersioning() error {
+	return c.EnableVersioningCtx(context.Background())
+}
+
+// EnableVersioningCtx is EnableVersioning with a context.
+func (c *container) EnableVersioningCtx(ctx context.Context) error {
+	return stow.NotSupported("enabling versioning per container")
+}
+
+// containerClient gets an azblob client for the container, as the
+// legacy SDK has no support for blob versions.
+func (c *container) containerClient() (*azblob.ContainerClient, error) {
+	if c.creds == nil {
+		return nil, errors.New("azblob requests need shared key credentials")
+	}
+	u := c.client.GetContainerReference(c.id).GetURL()
+	return azblob.NewContainerClientWithSharedKey(u, c.creds, nil)
+}
+
+// versionClient gets an azblob client for a version of a blob.
+func (c *container) versionClient(id, versionID string) (*azblob.BlobClient, error) {
+	client, err := c.blobClient(id)
+	if err != nil {
+		return nil, err
+	}
+	return client.WithVersionID(versionID)
+}
@@ -43,12 +43,23 @@ func init() {
 		if err != nil {
 			return nil, err
 		}
+		l.largeFiles = newLargeFileAPI(l.client.Credentials)
 		return l, nil
 	}
 	kindfn := func(u *url.URL) bool {
 		return u.Scheme == Kind
 	}
 	stow.Register(Kind, makefn, kindfn, validatefn)
+	stow.RegisterDSN(Kind, stow.DSN{
+		User:     ConfigAccountID,
+		Password: ConfigApplicationKey,
+		Query:    map[string]string{ConfigKeyID: ConfigKeyID},
+	})
+	stow.RegisterSchema(Kind, stow.Schema{Keys: []stow.KeySchema{
+		{Name: ConfigAccountID, Description: "The ID of the account, unless the ID of the application key is set."},
+		{Name: ConfigApplicationKey, Required: true, Secret: true, Description: "The application key."},
+		{Name: ConfigKeyID, Description: "The ID of the application key, unless the ID of the account is set."},
+	}})
 }
 
 func newB2Client(cfg stow.Config) (*backblaze.B2, error) {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"strings"
 	"time"
 
@@ -13,10 +14,25 @@ import (
 )
 
 type container struct {
-	bucket *backblaze.Bucket
+	bucket     *backblaze.Bucket
+	client     *backblaze.B2
+	largeFiles *largeFileAPI
 }
 
-var _ stow.Container = (*container)(nil)
+var (
+	_ stow.Container              = (*container)(nil)
+	_ stow.ContextContainer       = (*container)(nil)
+	_ stow.CapabilityReporter     = (*container)(nil)
+	_ stow.Copier                 = (*container)(nil)
+	_ stow.ContextCopier          = (*container)(nil)
+	_ stow.ItemWriter             = (*container)(nil)
+	_ stow.DelimitedLister        = (*container)(nil)
+	_ stow.ContextDelimitedLister = (*container)(nil)
+	_ stow.OptionsPutter          = (*container)(nil)
+	_ stow.ContextOptionsPutter   = (*container)(nil)
+	_ stow.Stater                 = (*container)(nil)
+	_ stow.ContextStater          = (*container)(nil)
+)
 
 // ID returns the name of a bucket
 func (c *container) ID() string {
@@ -31,6 +47,11 @@ func (c *container) PreSignRequest(_ context.Context, _ stow.ClientMethod, _ str
 	return "", fmt.Errorf("unsupported")
 }
 
+// Capabilities describes the features supported by this container.
+func (c *container) Capabilities() stow.Capabilities {
+	return capabilities()
+}
+
 // Name returns the name of the bucket
 func (c *container) Name() string {
 	return c.bucket.Name
@@ -38,17 +59,61 @@ func (c *container) Name() string {
 
 // Item returns a stow.Item given the item's ID
 func (c *container) Item(id string) (stow.Item, error) {
+	return c.ItemCtx(context.Background(), id)
+}
+
+// ItemCtx is Item with a context.
+func (c *container) ItemCtx(ctx context.Context, id string) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	return c.getItem(id)
 }
 
+// Stat gets the information of the file with the specified ID.
+func (c *container) Stat(id string) (stow.ItemInfo, error) {
+	return c.StatCtx(context.Background(), id)
+}
+
+// StatCtx is Stat with a context, which is checked before the request
+// is sent.
+func (c *container) StatCtx(ctx context.Context, id string) (stow.ItemInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return stow.ItemInfo{}, err
+	}
+	file, err := c.getFileInfo(id)
+	if err != nil {
+		return stow.ItemInfo{}, err
+	}
+	// The ETags of items are their modification times.
+	lastModified := time.Unix(file.UploadTimestamp/1000, 0)
+	return stow.ItemInfo{
+		ID:          file.ID,
+		Name:        file.Name,
+		Size:        file.ContentLength,
+		ETag:        lastModified.String(),
+		LastMod:     lastModified,
+		ContentType: file.ContentType,
+		Metadata:    parseMetadata(file.FileInfo),
+	}, nil
+}
+
 // Items retreives a list of items from b2. Since the b2 ListFileNames operation
 // does not natively support a prefix, we fake it ourselves
 func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
+	return c.ItemsCtx(context.Background(), prefix, cursor, count)
+}
+
+// ItemsCtx is Items with a context.
+func (c *container) ItemsCtx(ctx context.Context, prefix, cursor string, count int) ([]stow.Item, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
 	items := make([]stow.Item, 0, count)
 	for {
 		response, err := c.bucket.ListFileNames(cursor, count)
 		if err != nil {
-			return nil, "", err
+			return nil, "", mapError(err)
 		}
 
 		for _, obj := range response.Files {
@@ -61,6 +126,7 @@ func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string
 				size:         int64(obj.Size),
 				lastModified: time.Unix(obj.UploadTimestamp/1000, 0),
 				bucket:       c.bucket,
+				client:       c.client,
 			})
 			if len(items) == count {
 				break
@@ -90,29 +156,117 @@ func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string
 	return items, cursor, nil
 }
 
+// ItemsDelimited lists the files with the delimiter of
+// b2_list_file_names, which lists the common prefixes as folders.
+func (c *container) ItemsDelimited(prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	return c.ItemsDelimitedCtx(context.Background(), prefix, delimiter, cursor, count)
+}
+
+// ItemsDelimitedCtx is ItemsDelimited with a context.
+func (c *container) ItemsDelimitedCtx(ctx context.Context, prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, "", err
+	}
+	response, err := c.bucket.ListFileNamesWithPrefix(cursor, count, prefix, delimiter)
+	if err != nil {
+		return nil, nil, "", mapError(err)
+	}
+	items := make([]stow.Item, 0, len(response.Files))
+	var prefixes []string
+	for _, obj := range response.Files {
+		if obj.Action == folderAction {
+			prefixes = append(prefixes, obj.Name)
+			continue
+		}
+		items = append(items, &item{
+			id:           obj.ID,
+			name:         obj.Name,
+			size:         int64(obj.Size),
+			lastModified: time.Unix(obj.UploadTimestamp/1000, 0),
+			bucket:       c.bucket,
+			client:       c.client,
+		})
+	}
+	return items, prefixes, response.NextFileName, nil
+}
+
+// folderAction is the action of the common prefixes listed by
+// b2_list_file_names, which go-backblaze doesn't declare.
+const folderAction backblaze.FileAction = "folder"
+
 // Put uploads a file
 func (c *container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.PutCtx(context.Background(), name, r, size, metadata)
+}
+
+// PutCtx is Put with a context. Canceling ctx stops reading from r,
+// which aborts the upload.
+// Items larger than largeFilePartSize, or of unknown size, are
+// uploaded as large files, a part at a time.
+func (c *container) PutCtx(ctx context.Context, name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	// Convert map[string]interface{} to map[string]string
 	mdPrepped, err := prepMetadata(metadata)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create or update item, preparing metadata")
 	}
-
-	file, err := c.bucket.UploadFile(name, mdPrepped, r)
+	file, err := c.putFile(ctx, name, "b2/x-auto", mdPrepped, r, size)
 	if err != nil {
 		return nil, err
 	}
+	return file, nil
+}
 
-	return &item{
-		id:     file.ID,
-		name:   file.Name,
-		size:   file.ContentLength,
-		bucket: c.bucket,
-	}, nil
+// PutWithOptions is Put with the content type of the file set, and the
+// other headers kept in the b2-* file info that B2 serves them from.
+// B2 has no storage classes, ACLs or tags, and checks SHA1 rather than
+// MD5 digests, so those options are ignored.
+func (c *container) PutWithOptions(name string, r io.Reader, size int64, opts stow.PutOptions) (stow.Item, error) {
+	return c.PutWithOptionsCtx(context.Background(), name, r, size, opts)
+}
+
+// PutWithOptionsCtx is PutWithOptions with a context.
+func (c *container) PutWithOptionsCtx(ctx context.Context, name string, r io.Reader, size int64, opts stow.PutOptions) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	info := make(map[string]string, len(opts.Metadata)+4)
+	for key, value := range opts.Metadata {
+		info[key] = value
+	}
+	for key, value := range map[string]string{
+		"b2-cache-control":       opts.CacheControl,
+		"b2-content-disposition": opts.ContentDisposition,
+		"b2-content-encoding":    opts.ContentEncoding,
+		"b2-content-language":    opts.ContentLanguage,
+	} {
+		if value != "" {
+			info[key] = value
+		}
+	}
+	contentType := opts.ContentType
+	if contentType == "" {
+		contentType = "b2/x-auto"
+	}
+	file, err := c.putFile(ctx, name, contentType, info, r, size)
+	if err != nil {
+		return nil, err
+	}
+	return file, nil
 }
 
 // RemoveItem identifies the file by it's ID, then removes all versions of that file
 func (c *container) RemoveItem(id string) error {
+	return c.RemoveItemCtx(context.Background(), id)
+}
+
+// RemoveItemCtx is RemoveItem with a context.
+func (c *container) RemoveItemCtx(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	item, err := c.getItem(id)
 	if err != nil {
 		return err
@@ -123,7 +277,7 @@ func (c *container) RemoveItem(id string) error {
 	for {
 		response, err := item.bucket.ListFileNames(item.Name(), 1)
 		if err != nil {
-			return err
+			return mapError(err)
 		}
 
 		var fileStatus *backblaze.FileStatus
@@ -139,18 +293,94 @@ func (c *container) RemoveItem(id string) error {
 		}
 
 		if _, err := c.bucket.DeleteFileVersion(item.name, response.Files[0].ID); err != nil {
-			return err
+			return mapError(err)
 		}
 	}
 }
 
+// Copy copies the file srcID to a file named dstID in dstContainer,
+// which must be a B2 container of the same account. As with Put, the
+// ID of the returned Item is the file ID assigned by B2.
+// The b2 client can't replace the metadata of a copy, so Copy only
+// supports a nil metadata.
+func (c *container) Copy(srcID string, dstContainer stow.Container, dstID string, metadata map[string]interface{}) (stow.Item, error) {
+	return c.CopyCtx(context.Background(), srcID, dstContainer, dstID, metadata)
+}
+
+// CopyCtx is Copy with a context.
+func (c *container) CopyCtx(ctx context.Context, srcID string, dstContainer stow.Container, dstID string, metadata map[string]interface{}) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	dst, ok := dstContainer.(*container)
+	if !ok {
+		return nil, stow.NotSupported("copy to another kind of container")
+	}
+	if metadata != nil {
+		return nil, stow.NotSupported("replacing metadata on copy")
+	}
+	file, err := c.bucket.CopyFile(srcID, dstID, dst.bucket.ID, backblaze.FileMetaDirectiveCopy)
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &item{
+		id:     file.ID,
+		name:   file.Name,
+		size:   file.ContentLength,
+		bucket: dst.bucket,
+		client: dst.client,
+	}, nil
+}
+
+// CreateItem creates a new file whose contents are written to the
+// returned writer. B2 needs the length and checksum of a file before
+// uploading it, so the contents are spooled to a temporary file and
+// uploaded when the writer is closed. The ID of the Item is only
+// known once the writer has been closed.
+func (c *container) CreateItem(name string) (stow.Item, io.WriteCloser, error) {
+	f, err := os.CreateTemp("", "stow-b2-")
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "unable to create Item, creating temporary file")
+	}
+	item := &item{
+		name:   name,
+		bucket: c.bucket,
+		client: c.client,
+	}
+	return item, &fileWriter{File: f, item: item, container: c}, nil
+}
+
+// fileWriter uploads its temporary file on Close.
+type fileWriter struct {
+	*os.File
+	item      *item
+	container *container
+}
+
+func (w *fileWriter) Close() error {
+	defer os.Remove(w.File.Name())
+	defer w.File.Close()
+	size, err := w.File.Seek(0, io.SeekEnd)
+	if err != nil {
+		return errors.Wrap(err, "unable to create Item, measuring temporary file")
+	}
+	if _, err := w.File.Seek(0, io.SeekStart); err != nil {
+		return errors.Wrap(err, "unable to create Item, rewinding temporary file")
+	}
+	file, err := w.container.putFile(context.Background(), w.item.name, "b2/x-auto", nil, w.File, size)
+	if err != nil {
+		return err
+	}
+	w.item.id = file.id
+	w.item.name = file.name
+	w.item.size = file.size
+	return nil
+}
+
 func (c *container) getItem(id string) (*item, error) {
-	file, err := c.bucket.GetFileInfo(id)
+	file, err := c.getFileInfo(id)
 	if err != nil {
-		lowered := strings.ToLower(err.Error())
-		if (strings.Contains(lowered, "not") && strings.Contains(lowered, "found")) || (strings.Contains(lowered, "bad") && strings.Contains(lowered, "fileid")) {
-			return nil, stow.ErrNotFound
-		}
 		return nil, err
 	}
 
@@ -159,9 +389,23 @@ func (c *container) getItem(id string) (*item, error) {
 		name:   file.Name,
 		size:   file.ContentLength,
 		bucket: c.bucket,
+		client: c.client,
 	}, nil
 }
 
+// getFileInfo gets the info of the file with the specified ID.
+func (c *container) getFileInfo(id string) (*backblaze.File, error) {
+	file, err := c.bucket.GetFileInfo(id)
+	if err != nil {
+		lowered := strings.ToLower(err.Error())
+		if (strings.Contains(lowered, "not") && strings.Contains(lowered, "found")) || (strings.Contains(lowered, "bad") && strings.Contains(lowered, "fileid")) {
+			return nil, stow.ErrNotFound
+		}
+		return nil, mapError(err)
+	}
+	return file, nil
+}
+
 // prepMetadata parses a raw map into the native type required by b2 to set metadata (map[string]string).
 // This function also assumes that the value of a key value pair is a string.
 func prepMetadata(md map[string]interface{}) (map[string]string, error) {
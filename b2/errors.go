@@ -0,0 +1,44 @@
+package b2
+
+import (
+	"net/http"
+
+	"github.com/aldor007/stow"
+	"github.com/pkg/errors"
+	"gopkg.in/kothar/go-backblaze.v0"
+)
+
+// mapError maps errors returned by the B2 API to the error kinds
+// declared by stow. The original error is kept in the chain.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var b2Error *backblaze.B2Error
+	if !errors.As(err, &b2Error) {
+		return err
+	}
+	switch b2Error.Code {
+	case "not_found", "file_not_present", "no_such_file":
+		return stow.WrapError(stow.ErrNotFound, err)
+	case "duplicate_bucket_name":
+		return stow.WrapError(stow.ErrAlreadyExists, err)
+	case "unauthorized", "bad_auth_token", "expired_auth_token", "access_denied":
+		return stow.WrapError(stow.ErrPermissionDenied, err)
+	case "cannot_delete_non_empty_bucket":
+		return stow.WrapError(stow.ErrContainerNotEmpty, err)
+	case "too_many_requests":
+		return stow.WrapError(stow.ErrThrottled, err)
+	case "invalid_bucket_name":
+		return stow.WrapError(stow.ErrInvalidName, err)
+	}
+	switch b2Error.Status {
+	case http.StatusNotFound:
+		return stow.WrapError(stow.ErrNotFound, err)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return stow.WrapError(stow.ErrPermissionDenied, err)
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return stow.WrapError(stow.ErrThrottled, err)
+	}
+	return err
+}
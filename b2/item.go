@@ -1,9 +1,11 @@
 package b2
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -12,8 +14,6 @@ import (
 	"github.com/pkg/errors"
 	"gopkg.in/kothar/go-backblaze.v0"
 )
-var _ stow.Item = (*item)(nil)
-
 type item struct {
 	id           string
 	name         string
@@ -22,14 +22,22 @@ type item struct {
 	bucket       *backblaze.Bucket
 	rangeData  stow.ContentRangeData
 
+	client       *backblaze.B2
+	// byID is set for items got by ItemVersion, which are downloaded
+	// by file ID rather than by name.
+	byID bool
+
 	metadata map[string]interface{}
 	infoOnce sync.Once
 	infoErr  error
 }
 
 var (
-	_ stow.Item       = (*item)(nil)
-	_ stow.ItemRanger = (*item)(nil)
+	_ stow.Item                 = (*item)(nil)
+	_ stow.ContextItem          = (*item)(nil)
+	_ stow.ItemRanger           = (*item)(nil)
+	_ stow.OptionsOpener        = (*item)(nil)
+	_ stow.ContextOptionsOpener = (*item)(nil)
 )
 
 // ID returns this item's ID
@@ -44,13 +52,19 @@ func (i *item) Name() string {
 
 // URL returns the stow url for this item
 func (i *item) URL() *url.URL {
-	str, err := i.bucket.FileURL(i.name)
+	// The name is added to the path rather than to the string, which
+	// doesn't escape it.
+	str, err := i.bucket.FileURL("")
 	if err != nil {
 		return nil
 	}
 
-	url, _ := url.Parse(str)
+	url, err := url.Parse(str)
+	if err != nil {
+		return nil
+	}
 	url.Scheme = Kind
+	url.Path += i.name
 
 	return url
 }
@@ -70,23 +84,112 @@ func (i *item) Size() (int64, error) {
 
 // Open downloads the item
 func (i *item) Open() (io.ReadCloser, error) {
-	_, r, err := i.bucket.DownloadFileByName(i.name)
-	return r, err
+	return i.OpenCtx(context.Background())
+}
+
+// OpenCtx is Open with a context. Reads from the returned body fail
+// once ctx is done.
+func (i *item) OpenCtx(ctx context.Context) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	var r io.ReadCloser
+	var err error
+	if i.byID {
+		_, r, err = i.client.DownloadFileByID(i.id)
+	} else {
+		_, r, err = i.bucket.DownloadFileByName(i.name)
+	}
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return stow.ContextReadCloser(ctx, r), nil
 }
 
 // OpenRange opens the item for reading starting at byte start and ending
 // at byte end.
 func (i *item) OpenRange(start, end uint64) (io.ReadCloser, error) {
-	b, r, err := i.bucket.DownloadFileRangeByName(
-		i.name,
-		&backblaze.FileRange{Start: int64(start), End: int64(end)},
-	)
+	fileRange := &backblaze.FileRange{Start: int64(start), End: int64(end)}
+	var b *backblaze.File
+	var r io.ReadCloser
+	var err error
+	if i.byID {
+		b, r, err = i.client.DownloadFileRangeByID(i.id, fileRange)
+	} else {
+		b, r, err = i.bucket.DownloadFileRangeByName(i.name, fileRange)
+	}
 
 	if err != nil {
-		i.rangeData = stow.ContentRangeData{ContentRange:fmt.Sprintf("bytes %d-%d/*", start, end), ContentLength: b.ContentLength}
+		return nil, mapError(err)
 	}
+	i.rangeData = stow.ContentRangeData{ContentRange:fmt.Sprintf("bytes %d-%d/*", start, end), ContentLength: b.ContentLength}
 
-	return r, err
+	return r, nil
+}
+
+// OpenWithOptions opens the item as described by opts. VersionID is
+// the ID of the file version to download. B2 has no conditional
+// downloads, so the conditions are checked against the info of the
+// file. The response overrides are ignored.
+func (i *item) OpenWithOptions(opts stow.OpenOptions) (io.ReadCloser, error) {
+	return i.OpenWithOptionsCtx(context.Background(), opts)
+}
+
+// OpenWithOptionsCtx is OpenWithOptions with a context.
+func (i *item) OpenWithOptionsCtx(ctx context.Context, opts stow.OpenOptions) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	id, byID, size := i.id, i.byID, i.size
+	if opts.VersionID != "" {
+		id, byID = opts.VersionID, true
+	}
+	if byID != i.byID || opts.IfNoneMatch != "" || !opts.IfModifiedSince.IsZero() {
+		f, err := i.bucket.GetFileInfo(id)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		if f.Name != i.name {
+			return nil, stow.WrapError(stow.ErrNotFound, errors.Errorf("file %s is not a version of %q", id, i.name))
+		}
+		lastModified := time.Unix(f.UploadTimestamp/1000, 0)
+		if opts.NotModified(lastModified.String(), lastModified) {
+			return nil, stow.ErrNotModified
+		}
+		size = f.ContentLength
+	}
+
+	var fileRange *backblaze.FileRange
+	var length int64
+	if opts.Range != nil {
+		var offset int64
+		var ok bool
+		offset, length, ok = opts.Range.Offsets(size)
+		if !ok {
+			return nil, errors.Wrap(stow.ErrInvalidRange, opts.Range.String())
+		}
+		if length == 0 {
+			return io.NopCloser(strings.NewReader("")), nil
+		}
+		fileRange = &backblaze.FileRange{Start: offset, End: offset + length - 1}
+	}
+
+	var r io.ReadCloser
+	var err error
+	switch {
+	case byID && fileRange != nil:
+		_, r, err = i.client.DownloadFileRangeByID(id, fileRange)
+	case byID:
+		_, r, err = i.client.DownloadFileByID(id)
+	case fileRange != nil:
+		_, r, err = i.bucket.DownloadFileRangeByName(i.name, fileRange)
+	default:
+		_, r, err = i.bucket.DownloadFileByName(i.name)
+	}
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return stow.ContextReadCloser(ctx, r), nil
 }
 
 func (i *item) ContentRange() (stow.ContentRangeData, error) {
@@ -1,6 +1,7 @@
 package b2
 
 import (
+	"context"
 	"errors"
 	"net/url"
 	"strings"
@@ -10,10 +11,18 @@ import (
 )
 
 type location struct {
-	config stow.Config
-	client *backblaze.B2
+	config     stow.Config
+	client     *backblaze.B2
+	largeFiles *largeFileAPI
 }
 
+var (
+	_ stow.Location           = (*location)(nil)
+	_ stow.ContextLocation    = (*location)(nil)
+	_ stow.CapabilityReporter = (*location)(nil)
+	_ stow.URLParser          = (*location)(nil)
+)
+
 // Close closes the interface. It's a Noop for this B2 implementation
 func (l *location) Close() error {
 	return nil // nothing to close
@@ -24,22 +33,60 @@ func (l *location) HasRanges() bool {
 	return false
 }
 
+// Capabilities describes the features supported by this location.
+func (l *location) Capabilities() stow.Capabilities {
+	return capabilities()
+}
+
+// capabilities describes the features supported by B2.
+func capabilities() stow.Capabilities {
+	return stow.Capabilities{
+		Ranges:           true,
+		Metadata:         true,
+		ServerSideCopy:   true,
+		Listing:          true,
+		Write:            true,
+		Delete:           true,
+		MultipartUploads: true,
+		DelimitedListing: true,
+		Versioning:       true,
+	}
+}
+
 // CreateContainer creates a new container (bucket)
 func (l *location) CreateContainer(name string) (stow.Container, error) {
+	return l.CreateContainerCtx(context.Background(), name)
+}
+
+// CreateContainerCtx is CreateContainer with a context.
+func (l *location) CreateContainerCtx(ctx context.Context, name string) (stow.Container, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	bucket, err := l.client.CreateBucket(name, backblaze.AllPrivate)
 	if err != nil {
-		return nil, err
+		return nil, mapError(err)
 	}
 	return &container{
-		bucket: bucket,
+		bucket:     bucket,
+		client:     l.client,
+		largeFiles: l.largeFiles,
 	}, nil
 }
 
 // Containers lists all containers in the location
 func (l *location) Containers(prefix string, cursor string, count int) ([]stow.Container, string, error) {
+	return l.ContainersCtx(context.Background(), prefix, cursor, count)
+}
+
+// ContainersCtx is Containers with a context.
+func (l *location) ContainersCtx(ctx context.Context, prefix string, cursor string, count int) ([]stow.Container, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
 	response, err := l.client.ListBuckets()
 	if err != nil {
-		return nil, "", err
+		return nil, "", mapError(err)
 	}
 
 	containers := make([]stow.Container, 0, len(response))
@@ -47,7 +94,9 @@ func (l *location) Containers(prefix string, cursor string, count int) ([]stow.C
 		// api and/or library don't seem to support prefixes, so do it ourself
 		if strings.HasPrefix(cont.Name, prefix) {
 			containers = append(containers, &container{
-				bucket: cont,
+				bucket:     cont,
+				client:     l.client,
+				largeFiles: l.largeFiles,
 			})
 		}
 	}
@@ -58,53 +107,90 @@ func (l *location) Containers(prefix string, cursor string, count int) ([]stow.C
 // Container returns a stow.Contaner given a container id. In this case, the 'id'
 // is really the bucket name
 func (l *location) Container(id string) (stow.Container, error) {
+	return l.ContainerCtx(context.Background(), id)
+}
+
+// ContainerCtx is Container with a context.
+func (l *location) ContainerCtx(ctx context.Context, id string) (stow.Container, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	bucket, err := l.client.Bucket(id)
 	if err != nil || bucket == nil {
 		return nil, stow.ErrNotFound
 	}
 
 	return &container{
-		bucket: bucket,
+		bucket:     bucket,
+		client:     l.client,
+		largeFiles: l.largeFiles,
 	}, nil
 }
 
 // ItemByURL returns a stow.Item given a b2 stow url
 func (l *location) ItemByURL(u *url.URL) (stow.Item, error) {
-	if u.Scheme != Kind {
-		return nil, errors.New("not valid b2 URL")
-	}
-
-	// b2://f001.backblaze.com/file/<container_name>/<path_to_object>
-	pieces := strings.SplitN(u.Path, "/", 4)
+	return l.ItemByURLCtx(context.Background(), u)
+}
 
-	c, err := l.Container(pieces[2])
+// ItemByURLCtx is ItemByURL with a context.
+func (l *location) ItemByURLCtx(ctx context.Context, u *url.URL) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	containerID, filename, err := l.ParseURL(u)
 	if err != nil {
 		return nil, err
 	}
 
-	filename, err := url.QueryUnescape(pieces[3])
+	c, err := l.ContainerCtx(ctx, containerID)
 	if err != nil {
 		return nil, err
 	}
+
 	response, err := c.(*container).bucket.ListFileNames(filename, 1)
 	if err != nil {
 		return nil, stow.ErrNotFound
 	}
 
-	if len(response.Files) != 1 {
-		return nil, errors.New("unexpected number of responses from ListFileNames")
+	// The listing starts at the name, so it gets the next file when
+	// there's none with that name.
+	if len(response.Files) != 1 || response.Files[0].Name != filename {
+		return nil, stow.ErrNotFound
 	}
 
-	return c.Item(response.Files[0].ID)
+	return c.(*container).ItemCtx(ctx, response.Files[0].ID)
+}
+
+// ParseURL gets the bucket and the name of the file a URL points to.
+// The ID of the file is only known by listing the bucket.
+func (l *location) ParseURL(u *url.URL) (string, string, error) {
+	if u.Scheme != Kind {
+		return "", "", errors.New("not valid b2 URL")
+	}
+
+	// b2://f001.backblaze.com/file/<container_name>/<path_to_object>
+	pieces := strings.SplitN(u.Path, "/", 4)
+	if len(pieces) != 4 || pieces[1] != "file" || pieces[2] == "" || pieces[3] == "" {
+		return "", "", errors.New("not valid b2 URL, expecting /file/<bucket>/<name>")
+	}
+	return pieces[2], pieces[3], nil
 }
 
 // RemoveContainer removes the specified bucket. In this case, the 'id'
 // is really the bucket name
 func (l *location) RemoveContainer(id string) error {
-	stowCont, err := l.Container(id)
+	return l.RemoveContainerCtx(context.Background(), id)
+}
+
+// RemoveContainerCtx is RemoveContainer with a context.
+func (l *location) RemoveContainerCtx(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	stowCont, err := l.ContainerCtx(ctx, id)
 	if err != nil {
 		return err
 	}
 
-	return stowCont.(*container).bucket.Delete()
+	return mapError(stowCont.(*container).bucket.Delete())
 }
@@ -0,0 +1,401 @@
+package b2
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aldor007/stow"
+	"github.com/pkg/errors"
+	"gopkg.in/kothar/go-backblaze.v0"
+)
+
+// authorizeURL is where largeFileAPI gets its authorization.
+var authorizeURL = "https://api.backblazeb2.com/b2api/v2/b2_authorize_account"
+
+// largeFilePartSize is the size of the parts Items are uploaded in by
+// Put when they are larger, or of unknown size. It is what B2
+// recommends, and bounds the memory used to buffer the parts.
+var largeFilePartSize int64 = 100 << 20
+
+// maxParts is the number of parts a large file has at most.
+const maxParts = 10000
+
+// sha1Length is the length of the hex encoded SHA1 checksum appended
+// to the parts that are uploaded.
+const sha1Length = 40
+
+// largeFileAPI calls the large file API of B2, which go-backblaze
+// doesn't cover, with its own authorization.
+type largeFileAPI struct {
+	credentials backblaze.Credentials
+	client      *http.Client
+
+	mu     sync.Mutex
+	apiURL string
+	token  string
+}
+
+func newLargeFileAPI(credentials backblaze.Credentials) *largeFileAPI {
+	return &largeFileAPI{
+		credentials: credentials,
+		client:      http.DefaultClient,
+	}
+}
+
+// authorize gets the URL of the API and a token for it, unless it has
+// one already.
+func (a *largeFileAPI) authorize(force bool) (string, string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" && !force {
+		return a.apiURL, a.token, nil
+	}
+	req, err := http.NewRequest(http.MethodGet, authorizeURL, nil)
+	if err != nil {
+		return "", "", err
+	}
+	keyID := a.credentials.KeyID
+	if keyID == "" {
+		keyID = a.credentials.AccountID
+	}
+	req.SetBasicAuth(keyID, a.credentials.ApplicationKey)
+	var res struct {
+		APIURL             string `json:"apiUrl"`
+		AuthorizationToken string `json:"authorizationToken"`
+	}
+	if err := a.do(req, &res); err != nil {
+		return "", "", err
+	}
+	a.apiURL, a.token = res.APIURL, res.AuthorizationToken
+	return a.apiURL, a.token, nil
+}
+
+// call calls the API operation name, authorizing again once when the
+// token has expired.
+func (a *largeFileAPI) call(name string, request, response interface{}) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	for retried := false; ; retried = true {
+		apiURL, token, err := a.authorize(retried)
+		if err != nil {
+			return err
+		}
+		req, err := http.NewRequest(http.MethodPost, apiURL+"/b2api/v2/"+name, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", token)
+		err = a.do(req, response)
+		var b2Error *backblaze.B2Error
+		if !retried && errors.As(err, &b2Error) && b2Error.Code == "expired_auth_token" {
+			continue
+		}
+		return err
+	}
+}
+
+// do sends req and decodes the response into response, or into a
+// *backblaze.B2Error when the request failed.
+func (a *largeFileAPI) do(req *http.Request, response interface{}) error {
+	res, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		b2Error := &backblaze.B2Error{Status: res.StatusCode}
+		if err := json.NewDecoder(res.Body).Decode(b2Error); err != nil {
+			b2Error.Message = res.Status
+		}
+		return b2Error
+	}
+	return json.NewDecoder(res.Body).Decode(response)
+}
+
+// largeFile is a large file of the B2 API.
+type largeFile struct {
+	FileID          string `json:"fileId"`
+	FileName        string `json:"fileName"`
+	ContentLength   int64  `json:"contentLength"`
+	UploadTimestamp int64  `json:"uploadTimestamp"`
+}
+
+// filePart is a part of a large file of the B2 API.
+type filePart struct {
+	PartNumber    int    `json:"partNumber"`
+	ContentLength int64  `json:"contentLength"`
+	ContentSha1   string `json:"contentSha1"`
+}
+
+var _ stow.MultipartUploader = (*container)(nil)
+
+// InitiateUpload starts a large file, whose file ID is the ID of the
+// upload.
+func (c *container) InitiateUpload(name string, metadata map[string]interface{}) (stow.Upload, error) {
+	mdPrepped, err := prepMetadata(metadata)
+	if err != nil {
+		return stow.Upload{}, errors.Wrap(err, "unable to create upload, preparing metadata")
+	}
+	return c.startLargeFile(name, "b2/x-auto", mdPrepped)
+}
+
+// startLargeFile starts a large file with contentType and the file
+// info info.
+func (c *container) startLargeFile(name, contentType string, info map[string]string) (stow.Upload, error) {
+	var file largeFile
+	err := c.largeFiles.call("b2_start_large_file", map[string]interface{}{
+		"bucketId":    c.bucket.ID,
+		"fileName":    name,
+		"contentType": contentType,
+		"fileInfo":    info,
+	}, &file)
+	if err != nil {
+		return stow.Upload{}, errors.Wrap(mapError(err), "starting large file")
+	}
+	return fileUpload(file), nil
+}
+
+// UploadPart uploads a part of the large file. B2 requires parts of
+// at least 5MB, except for the last one, and at most 10000 of them.
+// Parts of unknown size are buffered in memory.
+func (c *container) UploadPart(upload stow.Upload, number int, r io.Reader, size int64) (stow.Part, error) {
+	if size < 0 {
+		buf, err := io.ReadAll(r)
+		if err != nil {
+			return stow.Part{}, errors.Wrap(err, "reading part")
+		}
+		r, size = bytes.NewReader(buf), int64(len(buf))
+	}
+	var uploadURL struct {
+		UploadURL          string `json:"uploadUrl"`
+		AuthorizationToken string `json:"authorizationToken"`
+	}
+	err := c.largeFiles.call("b2_get_upload_part_url", map[string]string{"fileId": upload.ID}, &uploadURL)
+	if err != nil {
+		return stow.Part{}, errors.Wrapf(mapError(err), "uploading part %d", number)
+	}
+
+	// The checksum of the part is appended to it, so that it doesn't
+	// have to be read twice.
+	hash := sha1.New()
+	body := io.MultiReader(
+		io.TeeReader(io.LimitReader(r, size), hash),
+		&checksumReader{hash: hash},
+	)
+	req, err := http.NewRequest(http.MethodPost, uploadURL.UploadURL, body)
+	if err != nil {
+		return stow.Part{}, err
+	}
+	req.ContentLength = size + sha1Length
+	req.Header.Set("Authorization", uploadURL.AuthorizationToken)
+	req.Header.Set("X-Bz-Part-Number", strconv.Itoa(number))
+	req.Header.Set("X-Bz-Content-Sha1", "hex_digits_at_end")
+	var part filePart
+	if err := c.largeFiles.do(req, &part); err != nil {
+		return stow.Part{}, errors.Wrapf(mapError(err), "uploading part %d", number)
+	}
+	return stow.Part{
+		Number: part.PartNumber,
+		ETag:   part.ContentSha1,
+		Size:   part.ContentLength,
+	}, nil
+}
+
+// checksumReader reads the hex encoded checksum of hash, once what
+// is hashed has been read.
+type checksumReader struct {
+	hash hash.Hash
+	r    io.Reader
+}
+
+func (r *checksumReader) Read(p []byte) (int, error) {
+	if r.r == nil {
+		r.r = strings.NewReader(hex.EncodeToString(r.hash.Sum(nil)))
+	}
+	return r.r.Read(p)
+}
+
+// ListParts gets the parts uploaded for the large file.
+func (c *container) ListParts(upload stow.Upload) ([]stow.Part, error) {
+	var parts []stow.Part
+	request := map[string]interface{}{
+		"fileId":       upload.ID,
+		"maxPartCount": 1000,
+	}
+	for {
+		var res struct {
+			Parts          []filePart `json:"parts"`
+			NextPartNumber *int       `json:"nextPartNumber"`
+		}
+		if err := c.largeFiles.call("b2_list_parts", request, &res); err != nil {
+			return nil, errors.Wrap(mapError(err), "listing parts")
+		}
+		for _, part := range res.Parts {
+			parts = append(parts, stow.Part{
+				Number: part.PartNumber,
+				ETag:   part.ContentSha1,
+				Size:   part.ContentLength,
+			})
+		}
+		if res.NextPartNumber == nil {
+			return parts, nil
+		}
+		request["startPartNumber"] = *res.NextPartNumber
+	}
+}
+
+// CompleteUpload finishes the large file. The parts must be all the
+// parts of the file, in order, as B2 doesn't leave any out.
+func (c *container) CompleteUpload(upload stow.Upload, parts []stow.Part) (stow.Item, error) {
+	checksums := make([]string, len(parts))
+	for i, part := range parts {
+		if part.Number != i+1 {
+			return nil, fmt.Errorf("missing part %d", i+1)
+		}
+		checksums[i] = part.ETag
+	}
+	var file largeFile
+	err := c.largeFiles.call("b2_finish_large_file", map[string]interface{}{
+		"fileId":        upload.ID,
+		"partSha1Array": checksums,
+	}, &file)
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "finishing large file")
+	}
+	return &item{
+		id:     file.FileID,
+		name:   file.FileName,
+		size:   file.ContentLength,
+		bucket: c.bucket,
+		client: c.client,
+	}, nil
+}
+
+// AbortUpload cancels the large file and deletes its parts.
+func (c *container) AbortUpload(upload stow.Upload) error {
+	var file largeFile
+	err := c.largeFiles.call("b2_cancel_large_file", map[string]string{"fileId": upload.ID}, &file)
+	if err != nil {
+		return errors.Wrap(mapError(err), "canceling large file")
+	}
+	return nil
+}
+
+// ListPendingUploads gets the large files of the bucket that have
+// neither been finished nor canceled.
+func (c *container) ListPendingUploads(prefix string) ([]stow.Upload, error) {
+	var uploads []stow.Upload
+	request := map[string]interface{}{
+		"bucketId":     c.bucket.ID,
+		"namePrefix":   prefix,
+		"maxFileCount": 100,
+	}
+	for {
+		var res struct {
+			Files      []largeFile `json:"files"`
+			NextFileID *string     `json:"nextFileId"`
+		}
+		if err := c.largeFiles.call("b2_list_unfinished_large_files", request, &res); err != nil {
+			return nil, errors.Wrap(mapError(err), "listing unfinished large files")
+		}
+		for _, file := range res.Files {
+			uploads = append(uploads, fileUpload(file))
+		}
+		if res.NextFileID == nil {
+			return uploads, nil
+		}
+		request["startFileId"] = *res.NextFileID
+	}
+}
+
+// putFile uploads the contents of r as the file name. Files of at
+// most largeFilePartSize are uploaded at once by go-backblaze, which
+// reads them into memory unless r is an io.Seeker; the others, and
+// those of unknown size, are uploaded as large files, with a single
+// part buffered at a time.
+func (c *container) putFile(ctx context.Context, name, contentType string, info map[string]string, r io.Reader, size int64) (*item, error) {
+	r = stow.ContextReader(ctx, r)
+	if size >= 0 && size <= largeFilePartSize {
+		return c.uploadFile(name, contentType, info, r)
+	}
+	partSize := largeFilePartSize
+	if minSize := (size + maxParts - 1) / maxParts; partSize < minSize {
+		partSize = minSize
+	}
+	buf := make([]byte, partSize)
+	n, err := io.ReadFull(r, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		// B2 needs two parts for a large file
+		return c.uploadFile(name, contentType, info, bytes.NewReader(buf[:n]))
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "reading part 1")
+	}
+
+	upload, err := c.startLargeFile(name, contentType, info)
+	if err != nil {
+		return nil, err
+	}
+	var parts []stow.Part
+	for number := 1; n > 0; number++ {
+		if number > maxParts {
+			err = fmt.Errorf("more than %d parts of %d bytes", maxParts, partSize)
+			break
+		}
+		var part stow.Part
+		if part, err = c.UploadPart(upload, number, bytes.NewReader(buf[:n]), int64(n)); err != nil {
+			break
+		}
+		parts = append(parts, part)
+		if n, err = io.ReadFull(r, buf); err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = nil
+		} else if err != nil {
+			err = errors.Wrapf(err, "reading part %d", number+1)
+			break
+		}
+	}
+	if err == nil {
+		var file stow.Item
+		if file, err = c.CompleteUpload(upload, parts); err == nil {
+			return file.(*item), nil
+		}
+	}
+	c.AbortUpload(upload)
+	return nil, err
+}
+
+// uploadFile uploads the contents of r as the file name at once.
+func (c *container) uploadFile(name, contentType string, info map[string]string, r io.Reader) (*item, error) {
+	file, err := c.bucket.UploadTypedFile(name, contentType, info, r)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &item{
+		id:     file.ID,
+		name:   file.Name,
+		size:   file.ContentLength,
+		bucket: c.bucket,
+		client: c.client,
+	}, nil
+}
+
+func fileUpload(file largeFile) stow.Upload {
+	return stow.Upload{
+		ID:        file.FileID,
+		Name:      file.FileName,
+		Initiated: time.Unix(0, file.UploadTimestamp*int64(time.Millisecond)),
+	}
+}
@@ -1,15 +1,24 @@
 package b2
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
 	"math/rand"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
+	"testing/iotest"
 	"time"
 
 	isi "github.com/cheekybits/is"
 	"github.com/aldor007/stow"
 	"github.com/aldor007/stow/test"
+	"gopkg.in/kothar/go-backblaze.v0"
 )
 
 func TestStow(t *testing.T) {
@@ -115,3 +124,136 @@ var letters = []rune("abcdefghijklmnopqrstuvwxyz")
 func init() {
 	rand.Seed(int64(time.Now().Nanosecond()))
 }
+
+func TestErrorMapping(t *testing.T) {
+	b2Error := func(status int, code string) error {
+		return &backblaze.B2Error{Status: status, Code: code}
+	}
+
+	test.ErrorMapping(t, mapError, map[error][]error{
+		stow.ErrNotFound:          {b2Error(http.StatusNotFound, "not_found"), b2Error(http.StatusBadRequest, "file_not_present")},
+		stow.ErrAlreadyExists:     {b2Error(http.StatusBadRequest, "duplicate_bucket_name")},
+		stow.ErrPermissionDenied:  {b2Error(http.StatusUnauthorized, "unauthorized"), b2Error(http.StatusForbidden, "")},
+		stow.ErrContainerNotEmpty: {b2Error(http.StatusBadRequest, "cannot_delete_non_empty_bucket")},
+		stow.ErrThrottled:         {b2Error(http.StatusTooManyRequests, "too_many_requests"), b2Error(http.StatusServiceUnavailable, "service_unavailable")},
+		stow.ErrInvalidName:       {b2Error(http.StatusBadRequest, "invalid_bucket_name")},
+	})
+}
+
+func TestUploadPart(t *testing.T) {
+	is := isi.New(t)
+	var server *httptest.Server
+	authorizations := 0
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/authorize":
+			authorizations++
+			json.NewEncoder(w).Encode(map[string]string{
+				"apiUrl":             server.URL,
+				"authorizationToken": fmt.Sprintf("token-%d", authorizations),
+			})
+		case "/b2api/v2/b2_get_upload_part_url":
+			if r.Header.Get("Authorization") == "token-1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				json.NewEncoder(w).Encode(map[string]interface{}{"status": 401, "code": "expired_auth_token"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]string{
+				"uploadUrl":          server.URL + "/upload",
+				"authorizationToken": "upload-token",
+			})
+		case "/upload":
+			body, _ := io.ReadAll(r.Body)
+			data, checksum := body[:len(body)-sha1Length], string(body[len(body)-sha1Length:])
+			sum := sha1.Sum(data)
+			if r.Header.Get("Authorization") != "upload-token" || checksum != hex.EncodeToString(sum[:]) {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(map[string]interface{}{"status": 400, "code": "bad_request"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"partNumber":    3,
+				"contentLength": len(data),
+				"contentSha1":   checksum,
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer func(url string) { authorizeURL = url }(authorizeURL)
+	authorizeURL = server.URL + "/authorize"
+
+	c := &container{largeFiles: newLargeFileAPI(backblaze.Credentials{})}
+	upload := stow.Upload{ID: "file", Name: "large"}
+
+	// the size of parts doesn't have to be known
+	part, err := c.UploadPart(upload, 3, iotest.OneByteReader(strings.NewReader("part")), -1)
+	is.NoErr(err)
+	is.Equal(authorizations, 2)
+	is.Equal(part.Number, 3)
+	is.Equal(part.Size, 4)
+	sum := sha1.Sum([]byte("part"))
+	is.Equal(part.ETag, hex.EncodeToString(sum[:]))
+}
+
+func TestPutLargeFile(t *testing.T) {
+	is := isi.New(t)
+	var server *httptest.Server
+	var parts []string
+	var finished []interface{}
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/authorize":
+			json.NewEncoder(w).Encode(map[string]string{
+				"apiUrl":             server.URL,
+				"authorizationToken": "token",
+			})
+		case "/b2api/v2/b2_start_large_file":
+			var req map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&req)
+			is.Equal(req["bucketId"], "bucket")
+			is.Equal(req["fileName"], "large")
+			json.NewEncoder(w).Encode(map[string]interface{}{"fileId": "file", "fileName": "large"})
+		case "/b2api/v2/b2_get_upload_part_url":
+			json.NewEncoder(w).Encode(map[string]string{"uploadUrl": server.URL + "/upload"})
+		case "/upload":
+			body, _ := io.ReadAll(r.Body)
+			data := body[:len(body)-sha1Length]
+			parts = append(parts, string(data))
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"partNumber":    len(parts),
+				"contentLength": len(data),
+				"contentSha1":   string(body[len(body)-sha1Length:]),
+			})
+		case "/b2api/v2/b2_finish_large_file":
+			var req map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&req)
+			finished = req["partSha1Array"].([]interface{})
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"fileId":        "file",
+				"fileName":      "large",
+				"contentLength": 10,
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer func(url string) { authorizeURL = url }(authorizeURL)
+	authorizeURL = server.URL + "/authorize"
+	defer func(size int64) { largeFilePartSize = size }(largeFilePartSize)
+	largeFilePartSize = 4
+
+	c := &container{
+		bucket:     &backblaze.Bucket{BucketInfo: &backblaze.BucketInfo{ID: "bucket"}},
+		largeFiles: newLargeFileAPI(backblaze.Credentials{}),
+	}
+
+	// streams of unknown size are uploaded a part at a time
+	item, err := c.Put("large", iotest.OneByteReader(strings.NewReader("0123456789")), -1, nil)
+	is.NoErr(err)
+	is.Equal(item.ID(), "file")
+	is.Equal(parts, []string{"0123", "4567", "89"})
+	is.Equal(len(finished), 3)
+}
@@ -0,0 +1,120 @@
+package b2
+
+import (
+	"context"
+	"time"
+
+	"github.com/aldor007/stow"
+	"github.com/pkg/errors"
+	"gopkg.in/kothar/go-backblaze.v0"
+)
+
+var (
+	_ stow.Versioned        = (*container)(nil)
+	_ stow.ContextVersioned = (*container)(nil)
+)
+
+// ItemVersions gets a page of the versions of the file with the
+// specified ID, which are files with the same name. Their IDs are the
+// IDs of the files. The cursor is the file ID the page starts at.
+func (c *container) ItemVersions(id, cursor string, count int) ([]stow.Version, string, error) {
+	return c.ItemVersionsCtx(context.Background(), id, cursor, count)
+}
+
+// ItemVersionsCtx is ItemVersions with a context.
+func (c *container) ItemVersionsCtx(ctx context.Context, id, cursor string, count int) ([]stow.Version, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+	file, err := c.getItem(id)
+	if err != nil {
+		return nil, "", err
+	}
+	response, err := c.bucket.ListFileVersions(file.name, cursor, count)
+	if err != nil {
+		return nil, "", mapError(err)
+	}
+
+	// Versions are listed by name, newest first, so the listing goes
+	// on with other files once they have all been listed.
+	var versions []stow.Version
+	for _, f := range response.Files {
+		if f.Name != file.name {
+			return versions, "", nil
+		}
+		if f.Action != backblaze.Upload && f.Action != backblaze.Hide {
+			// unfinished large files
+			continue
+		}
+		lastModified := time.Unix(f.UploadTimestamp/1000, 0)
+		versions = append(versions, stow.Version{
+			ID:      f.ID,
+			LastMod: lastModified,
+			Size:    f.ContentLength,
+			ETag:    lastModified.String(),
+			Latest:  cursor == "" && len(versions) == 0,
+			Deleted: f.Action == backblaze.Hide,
+		})
+	}
+	if response.NextFileName != file.name {
+		return versions, "", nil
+	}
+	return versions, response.NextFileID, nil
+}
+
+// ItemVersion gets a version of a file. Unlike other items, its
+// contents are downloaded by file ID.
+func (c *container) ItemVersion(id, versionID string) (stow.Item, error) {
+	return c.ItemVersionCtx(context.Background(), id, versionID)
+}
+
+// ItemVersionCtx is ItemVersion with a context.
+func (c *container) ItemVersionCtx(ctx context.Context, id, versionID string) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	file, err := c.getItem(id)
+	if err != nil {
+		return nil, err
+	}
+	version, err := c.getItem(versionID)
+	if err != nil {
+		return nil, err
+	}
+	if version.name != file.name {
+		return nil, stow.WrapError(stow.ErrNotFound, errors.Errorf("file %s is not a version of %q", versionID, file.name))
+	}
+	version.byID = true
+	return version, nil
+}
+
+// RemoveItemVersion deletes a version of a file.
+func (c *container) RemoveItemVersion(id, versionID string) error {
+	return c.RemoveItemVersionCtx(context.Background(), id, versionID)
+}
+
+// RemoveItemVersionCtx is RemoveItemVersion with a context.
+func (c *container) RemoveItemVersionCtx(ctx context.Context, id, versionID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	file, err := c.getItem(id)
+	if err != nil {
+		return err
+	}
+	if _, err := c.bucket.DeleteFileVersion(file.name, versionID); err != nil {
+		return errors.Wrap(mapError(err), "RemoveItemVersion, deleting file version")
+	}
+	return nil
+}
+
+// EnableVersioning does nothing, as B2 keeps all the versions of files
+// unless the lifecycle rules of the bucket say otherwise.
+func (c *container) EnableVersioning() error {
+	return c.EnableVersioningCtx(context.Background())
+}
+
+// EnableVersioningCtx is EnableVersioning with a context.
+func (c *container) EnableVersioningCtx(ctx context.Context) error {
+	return ctx.Err()
+}
@@ -0,0 +1,44 @@
+package stow
+
+import (
+	"context"
+	"io"
+)
+
+// The functions below call the Ctx variant of a method when the object
+// implements it, and the method itself otherwise.
+
+func itemCtx(ctx context.Context, container Container, id string) (Item, error) {
+	if c, ok := container.(ContextContainer); ok {
+		return c.ItemCtx(ctx, id)
+	}
+	return container.Item(id)
+}
+
+func itemsCtx(ctx context.Context, container Container, prefix, cursor string, count int) ([]Item, string, error) {
+	if c, ok := container.(ContextContainer); ok {
+		return c.ItemsCtx(ctx, prefix, cursor, count)
+	}
+	return container.Items(prefix, cursor, count)
+}
+
+func putCtx(ctx context.Context, container Container, name string, r io.Reader, size int64, metadata map[string]interface{}) (Item, error) {
+	if c, ok := container.(ContextContainer); ok {
+		return c.PutCtx(ctx, name, r, size, metadata)
+	}
+	return container.Put(name, r, size, metadata)
+}
+
+func removeItemCtx(ctx context.Context, container Container, id string) error {
+	if c, ok := container.(ContextContainer); ok {
+		return c.RemoveItemCtx(ctx, id)
+	}
+	return container.RemoveItem(id)
+}
+
+func openCtx(ctx context.Context, item Item) (io.ReadCloser, error) {
+	if i, ok := item.(ContextItem); ok {
+		return i.OpenCtx(ctx)
+	}
+	return item.Open()
+}
@@ -0,0 +1,105 @@
+package stow
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// ErrSameItem is returned by Move when the source and the destination
+// are the same Item.
+var ErrSameItem = errors.New("source and destination are the same item")
+
+// Copy copies the Item srcID of src to dstID in dst and returns the
+// new Item.
+// When src implements Copier the copy is made natively; otherwise, or
+// when the Copier reports the copy as not supported (e.g. between
+// different providers), the contents are streamed from src to dst.
+// When metadata is nil the copy keeps the metadata of the source, as
+// long as both Containers store metadata.
+func Copy(src Container, srcID string, dst Container, dstID string, metadata map[string]interface{}) (Item, error) {
+	return CopyCtx(context.Background(), src, srcID, dst, dstID, metadata)
+}
+
+// CopyCtx is Copy with a context, which is passed to the Ctx variants
+// of the methods it calls.
+func CopyCtx(ctx context.Context, src Container, srcID string, dst Container, dstID string, metadata map[string]interface{}) (Item, error) {
+	if copier, ok := As[ContextCopier](src); ok {
+		item, err := copier.CopyCtx(ctx, srcID, dst, dstID, metadata)
+		if !IsNotSupported(err) {
+			return item, err
+		}
+	} else if copier, ok := As[Copier](src); ok {
+		item, err := copier.Copy(srcID, dst, dstID, metadata)
+		if !IsNotSupported(err) {
+			return item, err
+		}
+	}
+	return streamCopy(ctx, src, srcID, dst, dstID, metadata)
+}
+
+// Move moves the Item srcID of src to dstID in dst and returns the
+// new Item.
+// The Item is copied with Copy and removed from src once the copy
+// succeeded.
+// ErrSameItem is returned, and nothing is done, when srcID and dstID
+// are the same and src and dst are the same Container, or Containers
+// of the same implementation with the same ID.
+func Move(src Container, srcID string, dst Container, dstID string, metadata map[string]interface{}) (Item, error) {
+	return MoveCtx(context.Background(), src, srcID, dst, dstID, metadata)
+}
+
+// MoveCtx is Move with a context.
+func MoveCtx(ctx context.Context, src Container, srcID string, dst Container, dstID string, metadata map[string]interface{}) (Item, error) {
+	if srcID == dstID && sameContainer(src, dst) {
+		return nil, ErrSameItem
+	}
+	item, err := CopyCtx(ctx, src, srcID, dst, dstID, metadata)
+	if err != nil {
+		return nil, err
+	}
+	if err := removeItemCtx(ctx, src, srcID); err != nil {
+		return nil, errors.Wrap(err, "removing source item")
+	}
+	return item, nil
+}
+
+// sameContainer gets whether a and b, once unwrapped, are the same
+// Container, or Containers of the same type with the same ID, which
+// may be distinct values for the same Container.
+func sameContainer(a, b Container) bool {
+	a, b = unwrapContainer(a), unwrapContainer(b)
+	ta, tb := reflect.TypeOf(a), reflect.TypeOf(b)
+	if ta != tb {
+		return false
+	}
+	if ta.Comparable() && a == b {
+		return true
+	}
+	return a.ID() == b.ID()
+}
+
+// streamCopy copies an Item by reading it from src and putting it
+// into dst.
+func streamCopy(ctx context.Context, src Container, srcID string, dst Container, dstID string, metadata map[string]interface{}) (Item, error) {
+	item, err := itemCtx(ctx, src, srcID)
+	if err != nil {
+		return nil, err
+	}
+	size, err := item.Size()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading source size")
+	}
+	if metadata == nil && CapabilitiesOf(src).Metadata && CapabilitiesOf(dst).Metadata {
+		if metadata, err = item.Metadata(); err != nil {
+			return nil, errors.Wrap(err, "reading source metadata")
+		}
+	}
+	r, err := openCtx(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return putCtx(ctx, dst, dstID, r, size, metadata)
+}
@@ -0,0 +1,238 @@
+package stow_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aldor007/stow"
+	"github.com/cheekybits/is"
+)
+
+func TestCopyStreams(t *testing.T) {
+	is := is.New(t)
+	src := newMemContainer(true)
+	dst := newMemContainer(true)
+	_, err := src.Put("a", strings.NewReader("contents"), 8, map[string]interface{}{"k": "v"})
+	is.NoErr(err)
+
+	item, err := stow.Copy(src, "a", dst, "b", nil)
+	is.NoErr(err)
+	is.Equal(item.ID(), "b")
+	is.Equal(string(dst.items["b"].data), "contents")
+	is.Equal(dst.items["b"].md["k"], "v")
+	is.Equal(string(src.items["a"].data), "contents")
+
+	_, err = stow.Copy(src, "a", dst, "c", map[string]interface{}{"x": "y"})
+	is.NoErr(err)
+	is.Equal(dst.items["c"].md, map[string]interface{}{"x": "y"})
+}
+
+func TestCopyWithoutMetadata(t *testing.T) {
+	is := is.New(t)
+	src := newMemContainer(true)
+	dst := newMemContainer(false)
+	_, err := src.Put("a", strings.NewReader("contents"), 8, map[string]interface{}{"k": "v"})
+	is.NoErr(err)
+
+	_, err = stow.Copy(src, "a", dst, "b", nil)
+	is.NoErr(err)
+	is.Equal(len(dst.items["b"].md), 0)
+}
+
+func TestCopyNative(t *testing.T) {
+	is := is.New(t)
+	src := &copierContainer{newMemContainer(true)}
+	_, err := src.Put("a", strings.NewReader("contents"), 8, nil)
+	is.NoErr(err)
+
+	// native copy within the same kind
+	_, err = stow.Copy(src, "a", src, "b", nil)
+	is.NoErr(err)
+	is.Equal(src.copies, 1)
+
+	// falls back to streaming
+	dst := newMemContainer(true)
+	_, err = stow.Copy(src, "a", dst, "b", nil)
+	is.NoErr(err)
+	is.Equal(src.copies, 1)
+	is.Equal(string(dst.items["b"].data), "contents")
+}
+
+func TestMove(t *testing.T) {
+	is := is.New(t)
+	src := newMemContainer(true)
+	dst := newMemContainer(true)
+	_, err := src.Put("a", strings.NewReader("contents"), 8, nil)
+	is.NoErr(err)
+
+	_, err = stow.Move(src, "a", dst, "b", nil)
+	is.NoErr(err)
+	is.Equal(string(dst.items["b"].data), "contents")
+	_, err = src.Item("a")
+	is.Equal(err, stow.ErrNotFound)
+
+	_, err = stow.Move(src, "a", dst, "c", nil)
+	is.Equal(err, stow.ErrNotFound)
+	is.Equal(len(dst.items), 1)
+}
+
+func TestMoveOntoItself(t *testing.T) {
+	is := is.New(t)
+	c := newMemContainer(true)
+	_, err := c.Put("a", strings.NewReader("contents"), 8, nil)
+	is.NoErr(err)
+
+	_, err = stow.Move(c, "a", c, "a", nil)
+	is.Equal(err, stow.ErrSameItem)
+	_, err = c.Item("a")
+	is.NoErr(err)
+
+	// through a wrapper, and with another value for the same Container
+	wrapped, err := stow.Wrap(&memLocation{container: c}).Container("mem")
+	is.NoErr(err)
+	_, err = stow.Move(wrapped, "a", c, "a", nil)
+	is.Equal(err, stow.ErrSameItem)
+	_, err = stow.Move(c, "a", newMemContainer(true), "a", nil)
+	is.Equal(err, stow.ErrSameItem)
+	_, err = c.Item("a")
+	is.NoErr(err)
+}
+
+// memContainer is an in-memory stow.Container.
+type memContainer struct {
+	mu       sync.Mutex
+	metadata bool
+	items    map[string]*memItem
+	copies   int
+}
+
+func newMemContainer(metadata bool) *memContainer {
+	return &memContainer{metadata: metadata, items: make(map[string]*memItem)}
+}
+
+func (c *memContainer) ID() string   { return "mem" }
+func (c *memContainer) Name() string { return "mem" }
+
+func (c *memContainer) Item(id string) (stow.Item, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.items[id]
+	if !ok {
+		return nil, stow.ErrNotFound
+	}
+	return item, nil
+}
+
+// Items lists the items in the order of their IDs. The cursor is the
+// ID of the last item listed.
+func (c *memContainer) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var ids []string
+	for id := range c.items {
+		if strings.HasPrefix(id, prefix) && id > cursor {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	cursor = ""
+	if len(ids) > count {
+		ids = ids[:count]
+		cursor = ids[count-1]
+	}
+	items := make([]stow.Item, len(ids))
+	for i, id := range ids {
+		items[i] = c.items[id]
+	}
+	return items, cursor, nil
+}
+
+func (c *memContainer) RemoveItem(id string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.items[id]; !ok {
+		return stow.ErrNotFound
+	}
+	delete(c.items, id)
+	return nil
+}
+
+func (c *memContainer) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	if !c.metadata {
+		metadata = nil
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) != size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	item := &memItem{id: name, data: data, md: metadata}
+	c.mu.Lock()
+	c.items[name] = item
+	c.mu.Unlock()
+	return item, nil
+}
+
+func (c *memContainer) PreSignRequest(_ context.Context, _ stow.ClientMethod, _ string, _ stow.PresignRequestParams) (string, error) {
+	return "", stow.NotSupported("presign")
+}
+
+func (c *memContainer) Capabilities() stow.Capabilities {
+	return stow.Capabilities{Metadata: c.metadata, Write: true, Delete: true}
+}
+
+// copierContainer is a memContainer that copies natively within
+// itself.
+type copierContainer struct {
+	*memContainer
+}
+
+func (c *copierContainer) Copy(srcID string, dstContainer stow.Container, dstID string, metadata map[string]interface{}) (stow.Item, error) {
+	dst, ok := dstContainer.(*copierContainer)
+	if !ok {
+		return nil, stow.NotSupported("copy to another kind of container")
+	}
+	item, ok := c.items[srcID]
+	if !ok {
+		return nil, stow.ErrNotFound
+	}
+	if metadata == nil {
+		metadata = item.md
+	}
+	c.copies++
+	copied := &memItem{id: dstID, data: item.data, md: metadata}
+	dst.items[dstID] = copied
+	return copied, nil
+}
+
+type memItem struct {
+	id   string
+	data []byte
+	md   map[string]interface{}
+}
+
+func (i *memItem) ID() string                                   { return i.id }
+func (i *memItem) Name() string                                 { return i.id }
+func (i *memItem) URL() *url.URL                                { return &url.URL{Scheme: "mem", Path: i.id} }
+func (i *memItem) Size() (int64, error)                         { return int64(len(i.data)), nil }
+func (i *memItem) ETag() (string, error)                        { return "", nil }
+func (i *memItem) LastMod() (time.Time, error)                  { return time.Time{}, nil }
+func (i *memItem) Metadata() (map[string]interface{}, error)    { return i.md, nil }
+func (i *memItem) ContentRange() (stow.ContentRangeData, error) { return stow.ContentRangeData{}, nil }
+
+func (i *memItem) Open() (io.ReadCloser, error) {
+	return io.NopCloser(bytes.NewReader(i.data)), nil
+}
+
+func (i *memItem) OpenParams(_ map[string]interface{}) (io.ReadCloser, error) {
+	return i.Open()
+}
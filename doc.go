@@ -1,2 +1,6 @@
 // Package stow provides an abstraction on cloud storage capabilities.
+//
+// The module supports Go 1.20. AllItems and AllContainers, which return
+// iterators for range-over-func loops, are only built with Go 1.23 or
+// later; Walk and WalkContainers list the same with older toolchains.
 package stow
@@ -0,0 +1,295 @@
+package stow
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// redacted replaces secrets in the connection strings got with
+// RedactedDSN, as it does in url.URL.Redacted.
+const redacted = "xxxxx"
+
+// DSN declares how the parts of a connection string, a URL such as
+// s3://key:secret@minio.local:9000/?region=eu-west-1, map onto the
+// configuration keys of a kind of Location. The scheme is the kind.
+// Parts mapped onto no key are rejected by ParseDSN.
+type DSN struct {
+	// User is the key the user name is set to.
+	User string
+	// Password is the key the password is set to.
+	Password string
+	// Host is the key host[:port] is set to, or only the host when
+	// Port is set.
+	Host string
+	// Port is the key the port is set to.
+	Port string
+	// Endpoint is the key the URL of the host and path is set to. It
+	// is https://host[:port]/path, or http:// when the tls query
+	// parameter is false.
+	Endpoint string
+	// Path is the key the path is set to, unless Endpoint is set.
+	Path string
+	// Query maps query parameters onto the keys they are set to.
+	Query map[string]string
+	// Secrets lists the keys whose values are replaced by
+	// RedactedDSN, such as passwords, along with those marked as
+	// Secret by the schema of the kind.
+	Secrets []string
+	// Parse sets the configuration from the query parameters Query
+	// doesn't map, for those that don't map onto a single key. It
+	// must fail on parameters it doesn't know.
+	Parse func(params url.Values, config Config) error
+	// Format is the reverse of Parse, adding the parameters of the
+	// configuration to params. When redact is true, the values of
+	// secrets are replaced by "xxxxx".
+	Format func(config Config, params url.Values, redact bool)
+}
+
+// RegisterDSN declares the connection strings of a kind, for DialURL,
+// ParseDSN, FormatDSN and RedactedDSN. It is usually called in an
+// implementation package's init method, after Register.
+func RegisterDSN(kind string, dsn DSN) {
+	DefaultRegistry.RegisterDSN(kind, dsn)
+}
+
+// RegisterDSN declares the connection strings of a kind, as the
+// package-level RegisterDSN does.
+func (r *Registry) RegisterDSN(kind string, dsn DSN) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dsns[kind] = dsn
+}
+
+// DialURL gets a new Location from a connection string, whose scheme
+// is the kind of the Location. The Config got with ParseDSN is
+// validated before dialing.
+func DialURL(dsn string) (Location, error) {
+	return DefaultRegistry.DialURL(dsn)
+}
+
+// DialURL gets a new Location from a connection string, as the
+// package-level DialURL does.
+func (r *Registry) DialURL(dsn string) (Location, error) {
+	kind, config, err := r.ParseDSN(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if err := r.Validate(kind, config); err != nil {
+		return nil, err
+	}
+	return r.Dial(kind, config)
+}
+
+// ParseDSN gets the kind and the Config of a connection string, as
+// declared by the DSN registered for the kind.
+func ParseDSN(dsn string) (string, ConfigMap, error) {
+	return DefaultRegistry.ParseDSN(dsn)
+}
+
+// ParseDSN gets the kind and the Config of a connection string, as the
+// package-level ParseDSN does.
+func (r *Registry) ParseDSN(dsn string) (string, ConfigMap, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return "", nil, err
+	}
+	kind := u.Scheme
+	d, err := r.dsnOf(kind)
+	if err != nil {
+		return "", nil, err
+	}
+
+	config := ConfigMap{}
+	set := func(part, key, value string) error {
+		if key == "" {
+			return fmt.Errorf("stow: %s connection strings have no %s", kind, part)
+		}
+		config[key] = value
+		return nil
+	}
+	if u.User != nil {
+		if err := set("user", d.User, u.User.Username()); err != nil {
+			return "", nil, err
+		}
+		if password, ok := u.User.Password(); ok {
+			if err := set("password", d.Password, password); err != nil {
+				return "", nil, err
+			}
+		}
+	}
+
+	params := u.Query()
+	path := u.Path
+	if u.Opaque != "" {
+		// scheme:relative/path
+		path = u.Opaque
+	}
+	switch {
+	case d.Endpoint != "" && u.Host != "":
+		scheme := "https"
+		if tls := params.Get("tls"); tls != "" {
+			if tls == "false" {
+				scheme = "http"
+			} else if tls != "true" {
+				return "", nil, fmt.Errorf("stow: bad tls parameter %q", tls)
+			}
+		}
+		params.Del("tls")
+		if path == "/" {
+			path = ""
+		}
+		// The path is kept unescaped, as endpoints may be templates
+		// such as https://host/<container>/<item>.
+		config[d.Endpoint] = scheme + "://" + u.Host + path
+		path = ""
+	case d.Port != "" && u.Port() != "":
+		if err := set("host", d.Host, u.Hostname()); err != nil {
+			return "", nil, err
+		}
+		config[d.Port] = u.Port()
+	case u.Host != "":
+		if err := set("host", d.Host, u.Host); err != nil {
+			return "", nil, err
+		}
+	}
+	if path != "" && (path != "/" || d.Path != "") {
+		if err := set("path", d.Path, path); err != nil {
+			return "", nil, err
+		}
+	}
+
+	for param, key := range d.Query {
+		if values, ok := params[param]; ok {
+			config[key] = values[len(values)-1]
+			params.Del(param)
+		}
+	}
+	if d.Parse != nil {
+		if err := d.Parse(params, config); err != nil {
+			return "", nil, err
+		}
+	} else {
+		for param := range params {
+			return "", nil, fmt.Errorf("stow: unknown %s connection string parameter %q", kind, param)
+		}
+	}
+	return kind, config, nil
+}
+
+// FormatDSN makes the connection string of a Config, the reverse of
+// ParseDSN. Keys that the DSN of the kind doesn't map are left out.
+func FormatDSN(kind string, config Config) (string, error) {
+	return DefaultRegistry.FormatDSN(kind, config)
+}
+
+// RedactedDSN makes the connection string of a Config as FormatDSN
+// does, with secrets such as passwords replaced, so that it can be
+// logged.
+func RedactedDSN(kind string, config Config) (string, error) {
+	return DefaultRegistry.RedactedDSN(kind, config)
+}
+
+// FormatDSN makes the connection string of a Config, as the
+// package-level FormatDSN does.
+func (r *Registry) FormatDSN(kind string, config Config) (string, error) {
+	return r.formatDSN(kind, config, false)
+}
+
+// RedactedDSN makes the connection string of a Config with secrets
+// replaced, as the package-level RedactedDSN does.
+func (r *Registry) RedactedDSN(kind string, config Config) (string, error) {
+	return r.formatDSN(kind, config, true)
+}
+
+func (r *Registry) formatDSN(kind string, config Config, redact bool) (string, error) {
+	d, err := r.dsnOf(kind)
+	if err != nil {
+		return "", err
+	}
+	schema, _ := r.ConfigSchema(kind)
+	secret := func(key string) bool {
+		if !redact {
+			return false
+		}
+		for _, s := range d.Secrets {
+			if s == key {
+				return true
+			}
+		}
+		k, _ := schema.Key(key)
+		return k.Secret
+	}
+	get := func(key string) (string, bool) {
+		if key == "" {
+			return "", false
+		}
+		value, ok := config.Config(key)
+		if ok && secret(key) {
+			value = redacted
+		}
+		return value, ok
+	}
+
+	u := &url.URL{Scheme: kind}
+	params := url.Values{}
+	if user, ok := get(d.User); ok {
+		u.User = url.User(user)
+		if password, ok := get(d.Password); ok {
+			u.User = url.UserPassword(user, password)
+		}
+	}
+	if endpoint, ok := get(d.Endpoint); ok && endpoint != "" {
+		e, err := url.Parse(endpoint)
+		if err != nil {
+			return "", err
+		}
+		u.Host, u.Path = e.Host, e.Path
+		if e.Scheme == "http" {
+			params.Set("tls", "false")
+		}
+	} else if host, ok := get(d.Host); ok {
+		u.Host = host
+		if port, ok := get(d.Port); ok && port != "" {
+			u.Host = net.JoinHostPort(host, port)
+		}
+	}
+	if path, ok := get(d.Path); ok && d.Endpoint == "" {
+		if u.Host == "" && u.User == nil && !strings.HasPrefix(path, "/") {
+			u.Opaque = path
+		} else {
+			u.Path = path
+		}
+	}
+
+	for param, key := range d.Query {
+		if value, ok := get(key); ok {
+			params.Set(param, value)
+		}
+	}
+	if d.Format != nil {
+		d.Format(config, params, redact)
+	}
+	if u.Host != "" || u.User != nil {
+		if u.Path == "" && len(params) > 0 {
+			u.Path = "/"
+		}
+	}
+	u.RawQuery = params.Encode()
+	return u.String(), nil
+}
+
+// dsnOf gets the DSN registered for kind.
+func (r *Registry) dsnOf(kind string) (DSN, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	d, ok := r.dsns[kind]
+	if !ok {
+		if r.registered(kind) {
+			return DSN{}, fmt.Errorf("stow: %s has no connection strings", kind)
+		}
+		return DSN{}, errUnknownKind(kind)
+	}
+	return d, nil
+}
@@ -0,0 +1,117 @@
+package google
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/googleapi"
+
+	"github.com/aldor007/stow"
+)
+
+// batchEndpoint is where batches of requests are sent.
+var batchEndpoint = "https://storage.googleapis.com/batch/storage/v1"
+
+// batchLimit is the maximum number of requests in a batch.
+const batchLimit = 100
+
+var (
+	_ stow.BatchRemover        = (*Container)(nil)
+	_ stow.ContextBatchRemover = (*Container)(nil)
+)
+
+// RemoveItems removes the objects with batch requests of the JSON
+// API, 100 objects per batch.
+func (c *Container) RemoveItems(ids []string) (map[string]error, error) {
+	return c.RemoveItemsCtx(c.ctx, ids)
+}
+
+// RemoveItemsCtx is RemoveItems with a context.
+func (c *Container) RemoveItemsCtx(ctx context.Context, ids []string) (map[string]error, error) {
+	failed := make(map[string]error)
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > batchLimit {
+			batch = batch[:batchLimit]
+		}
+		ids = ids[len(batch):]
+		if err := c.removeBatch(ctx, batch, failed); err != nil {
+			return failed, errors.Wrap(err, "deleting objects")
+		}
+	}
+	return failed, nil
+}
+
+// removeBatch sends a batch deleting the objects ids, and adds those
+// that couldn't be deleted to failed.
+func (c *Container) removeBatch(ctx context.Context, ids []string, failed map[string]error) error {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for i, id := range ids {
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", "application/http")
+		header.Set("Content-ID", "<"+strconv.Itoa(i)+">")
+		part, err := w.CreatePart(header)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(part, "DELETE /storage/v1/b/%s/o/%s HTTP/1.1\r\n\r\n", url.PathEscape(c.name), url.PathEscape(id))
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, batchEndpoint, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "multipart/mixed; boundary="+w.Boundary())
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := googleapi.CheckResponse(res); err != nil {
+		return mapError(err)
+	}
+	_, params, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil {
+		return errors.Wrap(err, "reading batch response")
+	}
+	r := multipart.NewReader(res.Body, params["boundary"])
+	for {
+		part, err := r.NextPart()
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return errors.Wrap(err, "reading batch response")
+		}
+		// The Content-ID of a response is that of its request,
+		// prefixed with "response-".
+		contentID := strings.Trim(part.Header.Get("Content-ID"), "<>")
+		i, err := strconv.Atoi(strings.TrimPrefix(contentID, "response-"))
+		if err != nil || i < 0 || i >= len(ids) {
+			return fmt.Errorf("reading batch response: unexpected Content-ID %q", contentID)
+		}
+		partRes, err := http.ReadResponse(bufio.NewReader(part), req)
+		if err != nil {
+			return errors.Wrap(err, "reading batch response")
+		}
+		err = mapError(googleapi.CheckResponse(partRes))
+		partRes.Body.Close()
+		if err != nil && !errors.Is(err, stow.ErrNotFound) {
+			failed[ids[i]] = err
+		}
+	}
+}
@@ -3,10 +3,12 @@ package google
 import (
 	"context"
 	"errors"
+	"net/http"
 	"net/url"
 	"strings"
 
 	"cloud.google.com/go/storage"
+	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/option"
 
@@ -48,16 +50,17 @@ func init() {
 		}
 
 		// Create a new client
-		ctx, client, err := newGoogleStorageClient(config)
+		ctx, client, httpClient, err := newGoogleStorageClient(config)
 		if err != nil {
 			return nil, err
 		}
 
 		// Create a location with given config and client
 		loc := &Location{
-			config: config,
-			client: client,
-			ctx:    ctx,
+			config:     config,
+			client:     client,
+			httpClient: httpClient,
+			ctx:        ctx,
 		}
 
 		return loc, nil
@@ -68,10 +71,24 @@ func init() {
 	}
 
 	stow.Register(Kind, makefn, kindfn, validatefn)
+	stow.RegisterDSN(Kind, stow.DSN{
+		Query: map[string]string{
+			ConfigProjectId: ConfigProjectId,
+			ConfigJSON:      ConfigJSON,
+			ConfigScopes:    ConfigScopes,
+		},
+	})
+	stow.RegisterSchema(Kind, stow.Schema{Keys: []stow.KeySchema{
+		{Name: ConfigJSON, Type: stow.TypeJSON, Required: true, Secret: true, Description: "The JSON key of the service account."},
+		{Name: ConfigProjectId, Required: true, Description: "The ID of the project of the buckets."},
+		{Name: ConfigScopes, Type: stow.TypeList, Default: storage.ScopeFullControl, Description: "The OAuth2 scopes of the credentials."},
+	}})
 }
 
-// Attempts to create a session based on the information given.
-func newGoogleStorageClient(config stow.Config) (context.Context, *storage.Client, error) {
+// Attempts to create a session based on the information given. Besides
+// the storage client, it returns an HTTP client with the same
+// credentials for the requests the storage client doesn't make.
+func newGoogleStorageClient(config stow.Config) (context.Context, *storage.Client, *http.Client, error) {
 	json, _ := config.Config(ConfigJSON)
 
 	scopes := []string{storage.ScopeFullControl}
@@ -85,18 +102,18 @@ func newGoogleStorageClient(config stow.Config) (context.Context, *storage.Clien
 	if json != "" {
 		creds, err = google.CredentialsFromJSON(ctx, []byte(json), scopes...)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 	} else {
 		creds, err = google.FindDefaultCredentials(ctx, scopes...)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
 	}
 
 	client, err := storage.NewClient(ctx, option.WithCredentials(creds))
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
-	return ctx, client, nil
+	return ctx, client, oauth2.NewClient(ctx, creds.TokenSource), nil
 }
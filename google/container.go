@@ -20,10 +20,30 @@ type Container struct {
 	// Client is responsible for performing the requests.
 	client *storage.Client
 
+	// httpClient performs the requests of resumable uploads.
+	httpClient *http.Client
+
 	// ctx is used on google storage API calls
 	ctx context.Context
 }
 
+var (
+	_ stow.Container                = (*Container)(nil)
+	_ stow.ContextContainer         = (*Container)(nil)
+	_ stow.CapabilityReporter       = (*Container)(nil)
+	_ stow.Copier                   = (*Container)(nil)
+	_ stow.ContextCopier            = (*Container)(nil)
+	_ stow.ConditionalPutter        = (*Container)(nil)
+	_ stow.ContextConditionalPutter = (*Container)(nil)
+	_ stow.ItemWriter               = (*Container)(nil)
+	_ stow.DelimitedLister          = (*Container)(nil)
+	_ stow.ContextDelimitedLister   = (*Container)(nil)
+	_ stow.OptionsPutter            = (*Container)(nil)
+	_ stow.ContextOptionsPutter     = (*Container)(nil)
+	_ stow.Stater                   = (*Container)(nil)
+	_ stow.ContextStater            = (*Container)(nil)
+)
+
 // ID returns a string value which represents the name of the container.
 func (c *Container) ID() string {
 	return c.name
@@ -57,31 +77,72 @@ func (c *Container) PreSignRequest(_ context.Context, clientMethod stow.ClientMe
 	})
 }
 
+// Capabilities describes the features supported by this container.
+func (c *Container) Capabilities() stow.Capabilities {
+	return capabilities()
+}
+
 // Item returns a stow.Item instance of a container based on the
 // name of the container
 func (c *Container) Item(id string) (stow.Item, error) {
-	item, err := c.Bucket().Object(id).Attrs(c.ctx)
+	return c.ItemCtx(c.ctx, id)
+}
+
+// ItemCtx is Item with a context.
+func (c *Container) ItemCtx(ctx context.Context, id string) (stow.Item, error) {
+	item, err := c.Bucket().Object(id).Attrs(ctx)
 	if err != nil {
 		if err == storage.ErrObjectNotExist {
 			return nil, stow.ErrNotFound
 		}
-		return nil, err
+		return nil, mapError(err)
 	}
 
 	return c.convertToStowItem(item)
 }
 
+// Stat gets the information of an object from its attributes.
+func (c *Container) Stat(id string) (stow.ItemInfo, error) {
+	return c.StatCtx(c.ctx, id)
+}
+
+// StatCtx is Stat with a context.
+func (c *Container) StatCtx(ctx context.Context, id string) (stow.ItemInfo, error) {
+	attrs, err := c.Bucket().Object(id).Attrs(ctx)
+	if err != nil {
+		if err == storage.ErrObjectNotExist {
+			return stow.ItemInfo{}, stow.ErrNotFound
+		}
+		return stow.ItemInfo{}, mapError(err)
+	}
+	item, err := c.convertToStowItem(attrs)
+	if err != nil {
+		return stow.ItemInfo{}, err
+	}
+	info, err := stow.NewItemInfo(item)
+	if err != nil {
+		return stow.ItemInfo{}, err
+	}
+	info.ContentType = attrs.ContentType
+	return info, nil
+}
+
 // Items retrieves a list of items that are prepended with
 // the prefix argument. The 'cursor' variable facilitates pagination.
 func (c *Container) Items(prefix string, cursor string, count int) ([]stow.Item, string, error) {
+	return c.ItemsCtx(c.ctx, prefix, cursor, count)
+}
+
+// ItemsCtx is Items with a context.
+func (c *Container) ItemsCtx(ctx context.Context, prefix string, cursor string, count int) ([]stow.Item, string, error) {
 	query := &storage.Query{Prefix: prefix}
-	call := c.Bucket().Objects(c.ctx, query)
+	call := c.Bucket().Objects(ctx, query)
 
 	p := iterator.NewPager(call, count, cursor)
 	var results []*storage.ObjectAttrs
 	nextPageToken, err := p.NextPage(&results)
 	if err != nil {
-		return nil, "", err
+		return nil, "", mapError(err)
 	}
 
 	var items []stow.Item
@@ -97,34 +158,217 @@ func (c *Container) Items(prefix string, cursor string, count int) ([]stow.Item,
 	return items, nextPageToken, nil
 }
 
+// ItemsDelimited lists the objects with the delimiter of the Cloud
+// Storage listing, whose results include the common prefixes.
+func (c *Container) ItemsDelimited(prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	return c.ItemsDelimitedCtx(c.ctx, prefix, delimiter, cursor, count)
+}
+
+// ItemsDelimitedCtx is ItemsDelimited with a context.
+func (c *Container) ItemsDelimitedCtx(ctx context.Context, prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	query := &storage.Query{Prefix: prefix, Delimiter: delimiter}
+	call := c.Bucket().Objects(ctx, query)
+
+	p := iterator.NewPager(call, count, cursor)
+	var results []*storage.ObjectAttrs
+	nextPageToken, err := p.NextPage(&results)
+	if err != nil {
+		return nil, nil, "", mapError(err)
+	}
+
+	var items []stow.Item
+	var prefixes []string
+	for _, attrs := range results {
+		// Common prefixes are results with only a prefix.
+		if attrs.Prefix != "" {
+			prefixes = append(prefixes, attrs.Prefix)
+			continue
+		}
+		i, err := c.convertToStowItem(attrs)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		items = append(items, i)
+	}
+
+	return items, prefixes, nextPageToken, nil
+}
+
 // RemoveItem will delete a google storage Object
 func (c *Container) RemoveItem(id string) error {
-	return c.Bucket().Object(id).Delete(c.ctx)
+	return c.RemoveItemCtx(c.ctx, id)
+}
+
+// RemoveItemCtx is RemoveItem with a context.
+func (c *Container) RemoveItemCtx(ctx context.Context, id string) error {
+	return mapError(c.Bucket().Object(id).Delete(ctx))
 }
 
 // Put sends a request to upload content to the container. The arguments
 // received are the name of the item, a reader representing the
 // content, and the size of the file.
 func (c *Container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.PutCtx(c.ctx, name, r, size, metadata)
+}
+
+// PutCtx is Put with a context. Canceling ctx aborts the upload and
+// leaves the previous object, if any, in place.
+func (c *Container) PutCtx(ctx context.Context, name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.put(ctx, c.Bucket().Object(name), r, metadata)
+}
+
+// PutIf is Put with a condition. Cloud Storage preconditions are on
+// object generations, so an IfMatch ETag is first resolved to the
+// generation it belongs to, which the write is then conditional on.
+func (c *Container) PutIf(name string, r io.Reader, size int64, metadata map[string]interface{}, cond stow.PutCondition) (stow.Item, error) {
+	return c.PutIfCtx(c.ctx, name, r, size, metadata, cond)
+}
+
+// PutIfCtx is PutIf with a context.
+func (c *Container) PutIfCtx(ctx context.Context, name string, r io.Reader, size int64, metadata map[string]interface{}, cond stow.PutCondition) (stow.Item, error) {
 	obj := c.Bucket().Object(name)
+	switch {
+	case cond.IfNoneMatch && cond.IfMatch != "":
+		return nil, stow.WrapError(stow.ErrPreconditionFailed, errors.New("an object can't match both IfMatch and IfNoneMatch"))
+	case cond.IfNoneMatch:
+		obj = obj.If(storage.Conditions{DoesNotExist: true})
+	case cond.IfMatch != "":
+		attrs, err := obj.Attrs(ctx)
+		if err == storage.ErrObjectNotExist {
+			return nil, stow.WrapError(stow.ErrPreconditionFailed, err)
+		}
+		if err != nil {
+			return nil, mapError(err)
+		}
+		if attrs.Etag != cond.IfMatch {
+			return nil, stow.WrapError(stow.ErrPreconditionFailed, errors.Errorf("etag %q does not match %q", attrs.Etag, cond.IfMatch))
+		}
+		obj = obj.If(storage.Conditions{GenerationMatch: attrs.Generation})
+	}
+	return c.put(ctx, obj, r, metadata)
+}
+
+// PutWithOptions is Put with the options set as the attributes of the
+// object. Tags are kept in the custom metadata, as by SetTags, and
+// ContentMD5 is checked by Cloud Storage.
+func (c *Container) PutWithOptions(name string, r io.Reader, size int64, opts stow.PutOptions) (stow.Item, error) {
+	return c.PutWithOptionsCtx(c.ctx, name, r, size, opts)
+}
 
+// PutWithOptionsCtx is PutWithOptions with a context.
+func (c *Container) PutWithOptionsCtx(ctx context.Context, name string, r io.Reader, size int64, opts stow.PutOptions) (stow.Item, error) {
+	md := make(map[string]string, len(opts.Metadata)+len(opts.Tags))
+	for key, value := range opts.Metadata {
+		md[key] = value
+	}
+	for key, value := range opts.Tags {
+		md[tagPrefix+key] = value
+	}
+	return c.write(ctx, c.Bucket().Object(name), r, func(attrs *storage.ObjectAttrs) {
+		attrs.Metadata = merge(attrs.Metadata, md)
+		attrs.ContentType = opts.ContentType
+		attrs.CacheControl = opts.CacheControl
+		attrs.ContentDisposition = opts.ContentDisposition
+		attrs.ContentEncoding = opts.ContentEncoding
+		attrs.ContentLanguage = opts.ContentLanguage
+		attrs.StorageClass = opts.StorageClass
+		attrs.PredefinedACL = opts.ACL
+		attrs.MD5 = opts.ContentMD5
+	})
+}
+
+func (c *Container) put(ctx context.Context, obj *storage.ObjectHandle, r io.Reader, metadata map[string]interface{}) (stow.Item, error) {
 	mdPrepped, err := prepMetadata(metadata)
 	if err != nil {
 		return nil, err
 	}
+	return c.write(ctx, obj, r, func(attrs *storage.ObjectAttrs) {
+		attrs.Metadata = merge(attrs.Metadata, mdPrepped)
+	})
+}
 
-	w := obj.NewWriter(c.ctx)
-	w.ObjectAttrs.Metadata = merge(w.ObjectAttrs.Metadata, mdPrepped)
+// write uploads r to obj, with the attributes set by setAttrs.
+func (c *Container) write(ctx context.Context, obj *storage.ObjectHandle, r io.Reader, setAttrs func(*storage.ObjectAttrs)) (stow.Item, error) {
+	w := obj.NewWriter(ctx)
+	setAttrs(&w.ObjectAttrs)
 	if _, err := io.Copy(w, r); err != nil {
-		return nil, err
+		return nil, mapError(err)
 	}
-	if err = w.Close(); err != nil {
-		return nil, err
+	if err := w.Close(); err != nil {
+		return nil, mapError(err)
 	}
 
 	return c.convertToStowItem(w.Attrs())
 }
 
+// CreateItem creates a new object whose contents are written to the
+// returned writer, which uploads them as they are written.
+func (c *Container) CreateItem(name string) (stow.Item, io.WriteCloser, error) {
+	item := &Item{
+		name:      name,
+		container: c,
+		client:    c.client,
+		ctx:       c.ctx,
+	}
+	w := &itemWriter{
+		Writer:    c.Bucket().Object(name).NewWriter(c.ctx),
+		container: c,
+		item:      item,
+	}
+	return item, w, nil
+}
+
+// itemWriter is a storage.Writer that fills in the details of its
+// Item once the object has been written.
+type itemWriter struct {
+	*storage.Writer
+	container *Container
+	item      *Item
+}
+
+func (w *itemWriter) Close() error {
+	if err := w.Writer.Close(); err != nil {
+		return mapError(err)
+	}
+	created, err := w.container.convertToStowItem(w.Writer.Attrs())
+	if err != nil {
+		return err
+	}
+	*w.item = *created.(*Item)
+	return nil
+}
+
+// Copy copies the object srcID to dstID in dstContainer, which must be
+// a Google Cloud Storage container too. The copy is made with the
+// rewrite API, which handles objects of any size.
+func (c *Container) Copy(srcID string, dstContainer stow.Container, dstID string, metadata map[string]interface{}) (stow.Item, error) {
+	return c.CopyCtx(c.ctx, srcID, dstContainer, dstID, metadata)
+}
+
+// CopyCtx is Copy with a context.
+func (c *Container) CopyCtx(ctx context.Context, srcID string, dstContainer stow.Container, dstID string, metadata map[string]interface{}) (stow.Item, error) {
+	dst, ok := dstContainer.(*Container)
+	if !ok {
+		return nil, stow.NotSupported("copy to another kind of container")
+	}
+	copier := dst.Bucket().Object(dstID).CopierFrom(c.Bucket().Object(srcID))
+	if metadata != nil {
+		mdPrepped, err := prepMetadata(metadata)
+		if err != nil {
+			return nil, err
+		}
+		copier.ObjectAttrs.Metadata = mdPrepped
+	}
+	attrs, err := copier.Run(ctx)
+	if err != nil {
+		if err == storage.ErrObjectNotExist {
+			return nil, stow.ErrNotFound
+		}
+		return nil, mapError(err)
+	}
+	return dst.convertToStowItem(attrs)
+}
+
 func merge(metadata ...map[string]string) map[string]string {
 	res := map[string]string{}
 	for _, mt := range metadata {
@@ -0,0 +1,51 @@
+package google
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/googleapi"
+
+	"github.com/aldor007/stow"
+)
+
+// mapError maps errors returned by the Google Cloud Storage API to the
+// error kinds declared by stow. The original error is kept in the chain.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, storage.ErrObjectNotExist) || errors.Is(err, storage.ErrBucketNotExist) {
+		return stow.WrapError(stow.ErrNotFound, err)
+	}
+	var apiError *googleapi.Error
+	if !errors.As(err, &apiError) {
+		return err
+	}
+	switch apiError.Code {
+	case http.StatusNotFound, http.StatusGone:
+		// Expired upload sessions are gone.
+		return stow.WrapError(stow.ErrNotFound, err)
+	case http.StatusConflict:
+		// Deleting a bucket that holds objects is reported as a conflict too.
+		if strings.Contains(strings.ToLower(apiError.Message), "not empty") {
+			return stow.WrapError(stow.ErrContainerNotEmpty, err)
+		}
+		return stow.WrapError(stow.ErrAlreadyExists, err)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return stow.WrapError(stow.ErrPermissionDenied, err)
+	case http.StatusPreconditionFailed:
+		return stow.WrapError(stow.ErrPreconditionFailed, err)
+	case http.StatusRequestedRangeNotSatisfiable:
+		return stow.WrapError(stow.ErrInvalidRange, err)
+	case http.StatusTooManyRequests:
+		return stow.WrapError(stow.ErrThrottled, err)
+	case http.StatusBadRequest:
+		if strings.Contains(strings.ToLower(apiError.Message), "name") {
+			return stow.WrapError(stow.ErrInvalidName, err)
+		}
+	}
+	return err
+}
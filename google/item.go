@@ -10,7 +10,11 @@ import (
 	"github.com/aldor007/stow"
 	"errors"
 )
-var _ stow.Item = (*Item)(nil)
+var (
+	_ stow.Item        = (*Item)(nil)
+	_ stow.ContextItem = (*Item)(nil)
+	_ stow.ItemRanger  = (*Item)(nil)
+)
 
 type Item struct {
 	container    *Container       // Container information is required by a few methods.
@@ -23,6 +27,8 @@ type Item struct {
 	lastModified time.Time
 	metadata     map[string]interface{}
 	object       *storage.ObjectAttrs
+	// generation is set for items got by ItemVersion.
+	generation int64
 	ctx          context.Context
 }
 
@@ -48,14 +54,25 @@ func (i *Item) URL() *url.URL {
 
 // Open returns an io.ReadCloser to the object. Useful for downloading/streaming the object.
 func (i *Item) Open() (io.ReadCloser, error) {
-	obj := i.container.Bucket().Object(i.name)
-	return obj.NewReader(i.ctx)
+	return i.OpenCtx(i.ctx)
+}
+
+// OpenCtx is Open with a context.
+func (i *Item) OpenCtx(ctx context.Context) (io.ReadCloser, error) {
+	r, err := i.handle().NewReader(ctx)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return r, nil
 }
 
 // OpenRange returns an io.Reader to the object for a specific byte range
 func (i *Item) OpenRange(start, end uint64) (io.ReadCloser, error) {
-	obj := i.container.Bucket().Object(i.name)
-	return obj.NewRangeReader(i.ctx, int64(start), int64(end - start) + 1)
+	r, err := i.handle().NewRangeReader(i.ctx, int64(start), int64(end - start) + 1)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return r, nil
 }
 
 func (i *Item) ContentRange() (stow.ContentRangeData, error) {
@@ -86,6 +103,16 @@ func (i *Item) StorageObject() *storage.ObjectAttrs {
 	return i.object
 }
 
+// handle returns the handle of the object, or of its generation for
+// items got by ItemVersion.
+func (i *Item) handle() *storage.ObjectHandle {
+	obj := i.container.Bucket().Object(i.name)
+	if i.generation != 0 {
+		obj = obj.Generation(i.generation)
+	}
+	return obj
+}
+
 // prepUrl takes a MediaLink string and returns a url
 func prepUrl(str string) (*url.URL, error) {
 	u, err := url.Parse(str)
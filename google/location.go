@@ -3,6 +3,7 @@ package google
 import (
 	"context"
 	"errors"
+	"net/http"
 	"net/url"
 	"strings"
 
@@ -15,11 +16,19 @@ import (
 
 // A Location contains a client + the configurations used to create the client.
 type Location struct {
-	config stow.Config
-	client *storage.Client
-	ctx    context.Context
+	config     stow.Config
+	client     *storage.Client
+	httpClient *http.Client
+	ctx        context.Context
 }
 
+var (
+	_ stow.Location           = (*Location)(nil)
+	_ stow.ContextLocation    = (*Location)(nil)
+	_ stow.CapabilityReporter = (*Location)(nil)
+	_ stow.URLParser          = (*Location)(nil)
+)
+
 func (l *Location) Service() *storage.Client {
 	return l.client
 }
@@ -35,31 +44,69 @@ func (l *Location) HasRanges() bool {
 	return false
 }
 
+// Capabilities describes the features supported by this location.
+func (l *Location) Capabilities() stow.Capabilities {
+	return capabilities()
+}
+
+// capabilities describes the features supported by Google Cloud Storage.
+func capabilities() stow.Capabilities {
+	return stow.Capabilities{
+		Ranges:            true,
+		Metadata:          true,
+		PresignMethods:    []stow.ClientMethod{stow.ClientMethodGet, stow.ClientMethodPut},
+		ServerSideCopy:    true,
+		Listing:           true,
+		Write:             true,
+		Delete:            true,
+		ConditionalWrites: true,
+		MultipartUploads:  true,
+		BatchRemove:       true,
+		DelimitedListing:  true,
+		Versioning:        true,
+		MetadataUpdates:   true,
+		Tags:              true,
+	}
+}
+
 // CreateContainer creates a new container, in this case a bucket.
 func (l *Location) CreateContainer(containerName string) (stow.Container, error) {
+	return l.CreateContainerCtx(l.ctx, containerName)
+}
+
+// CreateContainerCtx is CreateContainer with a context.
+func (l *Location) CreateContainerCtx(ctx context.Context, containerName string) (stow.Container, error) {
 	projId, _ := l.config.Config(ConfigProjectId)
 	bucket := l.client.Bucket(containerName)
-	if err := bucket.Create(l.ctx, projId, nil); err != nil {
+	if err := bucket.Create(ctx, projId, nil); err != nil {
 		if e, ok := err.(*googleapi.Error); ok && e.Code == 409 {
 			return &Container{
-				name:   containerName,
-				client: l.client,
+				name:       containerName,
+				client:     l.client,
+				httpClient: l.httpClient,
+				ctx:        l.ctx,
 			}, nil
 		}
-		return nil, err
+		return nil, mapError(err)
 	}
 
 	return &Container{
-		name:   containerName,
-		client: l.client,
-		ctx:    l.ctx,
+		name:       containerName,
+		client:     l.client,
+		httpClient: l.httpClient,
+		ctx:        l.ctx,
 	}, nil
 }
 
 // Containers returns a slice of the Container interface, a cursor, and an error.
 func (l *Location) Containers(prefix string, cursor string, count int) ([]stow.Container, string, error) {
+	return l.ContainersCtx(l.ctx, prefix, cursor, count)
+}
+
+// ContainersCtx is Containers with a context.
+func (l *Location) ContainersCtx(ctx context.Context, prefix string, cursor string, count int) ([]stow.Container, string, error) {
 	projId, _ := l.config.Config(ConfigProjectId)
-	call := l.client.Buckets(l.ctx, projId)
+	call := l.client.Buckets(ctx, projId)
 	if prefix != "" {
 		call.Prefix = prefix
 	}
@@ -68,15 +115,16 @@ func (l *Location) Containers(prefix string, cursor string, count int) ([]stow.C
 	var results []*storage.BucketAttrs
 	nextPageToken, err := p.NextPage(&results)
 	if err != nil {
-		return nil, "", err
+		return nil, "", mapError(err)
 	}
 
 	var containers []stow.Container
 	for _, container := range results {
 		containers = append(containers, &Container{
-			name:   container.Name,
-			client: l.client,
-			ctx:    l.ctx,
+			name:       container.Name,
+			client:     l.client,
+			httpClient: l.httpClient,
+			ctx:        l.ctx,
 		})
 	}
 
@@ -86,18 +134,24 @@ func (l *Location) Containers(prefix string, cursor string, count int) ([]stow.C
 // Container retrieves a stow.Container based on its name which must be
 // exact.
 func (l *Location) Container(id string) (stow.Container, error) {
-	attrs, err := l.client.Bucket(id).Attrs(l.ctx)
+	return l.ContainerCtx(l.ctx, id)
+}
+
+// ContainerCtx is Container with a context.
+func (l *Location) ContainerCtx(ctx context.Context, id string) (stow.Container, error) {
+	attrs, err := l.client.Bucket(id).Attrs(ctx)
 	if err != nil {
 		if err == storage.ErrBucketNotExist {
 			return nil, stow.ErrNotFound
 		}
-		return nil, err
+		return nil, mapError(err)
 	}
 
 	c := &Container{
-		name:   attrs.Name,
-		client: l.client,
-		ctx:    l.ctx,
+		name:       attrs.Name,
+		client:     l.client,
+		httpClient: l.httpClient,
+		ctx:        l.ctx,
 	}
 
 	return c, nil
@@ -105,11 +159,16 @@ func (l *Location) Container(id string) (stow.Container, error) {
 
 // RemoveContainer removes a container simply by name.
 func (l *Location) RemoveContainer(id string) error {
-	if err := l.client.Bucket(id).Delete(l.ctx); err != nil {
+	return l.RemoveContainerCtx(l.ctx, id)
+}
+
+// RemoveContainerCtx is RemoveContainer with a context.
+func (l *Location) RemoveContainerCtx(ctx context.Context, id string) error {
+	if err := l.client.Bucket(id).Delete(ctx); err != nil {
 		if e, ok := err.(*googleapi.Error); ok && e.Code == 404 {
 			return stow.ErrNotFound
 		}
-		return err
+		return mapError(err)
 	}
 
 	return nil
@@ -118,22 +177,51 @@ func (l *Location) RemoveContainer(id string) error {
 // ItemByURL retrieves a stow.Item by parsing the URL, in this
 // case an item is an object.
 func (l *Location) ItemByURL(url *url.URL) (stow.Item, error) {
-	if url.Scheme != Kind {
-		return nil, errors.New("not valid google storage URL")
-	}
+	return l.ItemByURLCtx(l.ctx, url)
+}
 
-	// /download/storage/v1/b/stowtesttoudhratik/o/a_first%2Fthe%20item
-	pieces := strings.SplitN(url.Path, "/", 8)
+// ItemByURLCtx is ItemByURL with a context.
+func (l *Location) ItemByURLCtx(ctx context.Context, url *url.URL) (stow.Item, error) {
+	bucket, object, err := l.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
 
-	c, err := l.Container(pieces[5])
+	c, err := l.ContainerCtx(ctx, bucket)
 	if err != nil {
 		return nil, stow.ErrNotFound
 	}
 
-	i, err := c.Item(pieces[7])
+	i, err := c.(*Container).ItemCtx(ctx, object)
 	if err != nil {
 		return nil, stow.ErrNotFound
 	}
 
 	return i, nil
 }
+
+// ParseURL gets the bucket and the name of the object a URL points to.
+// The URLs of items are their media links with the google scheme,
+// google://storage.googleapis.com/download/storage/v1/b/<bucket>/o/<object>;
+// shorter google://<bucket>/<object> URLs are accepted too.
+func (l *Location) ParseURL(url *url.URL) (string, string, error) {
+	if url.Scheme != Kind {
+		return "", "", errors.New("not valid google storage URL")
+	}
+
+	path := url.Path
+	if i := strings.Index(path, "/storage/v1/b/"); i >= 0 {
+		// /download/storage/v1/b/stowtesttoudhratik/o/a_first%2Fthe%20item
+		pieces := strings.SplitN(path[i+len("/storage/v1/b/"):], "/", 3)
+		if len(pieces) != 3 || pieces[1] != "o" || pieces[0] == "" || pieces[2] == "" {
+			return "", "", errors.New("not valid google storage URL")
+		}
+		return pieces[0], pieces[2], nil
+	}
+
+	object := strings.TrimPrefix(path, "/")
+	if url.Host == "" || object == "" {
+		return "", "", errors.New("not valid google storage URL, expecting google://<bucket>/<object>")
+	}
+	return url.Host, object, nil
+}
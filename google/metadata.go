@@ -0,0 +1,84 @@
+package google
+
+import (
+	"context"
+
+	"cloud.google.com/go/storage"
+
+	"github.com/aldor007/stow"
+)
+
+var (
+	_ stow.MetadataSetter        = (*Container)(nil)
+	_ stow.ContextMetadataSetter = (*Container)(nil)
+)
+
+// SetMetadata updates the custom metadata of the object.
+//
+// Updates can add and change keys of the metadata but not remove them,
+// so when keys are removed the metadata is cleared first and then set
+// again. Both updates are conditional on the object not having been
+// updated meanwhile.
+func (c *Container) SetMetadata(id string, metadata map[string]interface{}, replace bool) error {
+	return c.SetMetadataCtx(c.ctx, id, metadata, replace)
+}
+
+// SetMetadataCtx is SetMetadata with a context.
+func (c *Container) SetMetadataCtx(ctx context.Context, id string, metadata map[string]interface{}, replace bool) error {
+	_, err := updateMetadata(ctx, c.Bucket().Object(id), func(current map[string]interface{}) map[string]interface{} {
+		if replace {
+			current = map[string]interface{}{}
+		}
+		return stow.MergeMetadata(current, metadata)
+	})
+	return err
+}
+
+// updateMetadata sets the custom metadata of obj to the one update
+// makes of its current metadata, as SetMetadata does, and returns it.
+func updateMetadata(ctx context.Context, obj *storage.ObjectHandle, update func(map[string]interface{}) map[string]interface{}) (map[string]interface{}, error) {
+	attrs, err := obj.Attrs(ctx)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	current, err := parseMetadata(attrs.Metadata)
+	if err != nil {
+		return nil, err
+	}
+	md := update(current)
+	mdPrepped, err := prepMetadata(md)
+	if err != nil {
+		return nil, err
+	}
+
+	metageneration := attrs.Metageneration
+	if removesKeys(attrs.Metadata, mdPrepped) {
+		cleared, err := obj.If(storage.Conditions{MetagenerationMatch: metageneration}).Update(ctx, storage.ObjectAttrsToUpdate{
+			Metadata: map[string]string{},
+		})
+		if err != nil {
+			return nil, mapError(err)
+		}
+		metageneration = cleared.Metageneration
+	}
+	if len(mdPrepped) == 0 {
+		return md, nil
+	}
+	_, err = obj.If(storage.Conditions{MetagenerationMatch: metageneration}).Update(ctx, storage.ObjectAttrsToUpdate{
+		Metadata: mdPrepped,
+	})
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return md, nil
+}
+
+// removesKeys gets whether some keys of md are missing from updated.
+func removesKeys(md, updated map[string]string) bool {
+	for key := range md {
+		if _, ok := updated[key]; !ok {
+			return true
+		}
+	}
+	return false
+}
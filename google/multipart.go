@@ -0,0 +1,259 @@
+package google
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/api/googleapi"
+
+	"github.com/aldor007/stow"
+)
+
+// uploadEndpoint is where resumable upload sessions are created.
+var uploadEndpoint = "https://storage.googleapis.com/upload/storage/v1/b/"
+
+// chunkAlignment is the size all chunks of a resumable upload but the
+// last one must be a multiple of.
+const chunkAlignment = 256 * 1024
+
+// statusResumeIncomplete is the status of the responses to chunks that
+// don't complete a resumable upload.
+const statusResumeIncomplete = 308
+
+var (
+	_ stow.MultipartUploader        = (*Container)(nil)
+	_ stow.ContextMultipartUploader = (*Container)(nil)
+)
+
+// InitiateUpload creates a resumable upload session, whose URI is the
+// ID of the upload. Sessions expire after a week.
+//
+// A session keeps the bytes it has been sent rather than parts, so
+// parts are appended in the order they are uploaded: they must be
+// uploaded one after another in the order of their numbers, and can't
+// be replaced. All parts but the last must be a multiple of 256KiB in
+// size; a part that isn't is taken as the last and completes the
+// upload.
+func (c *Container) InitiateUpload(name string, metadata map[string]interface{}) (stow.Upload, error) {
+	return c.InitiateUploadCtx(c.ctx, name, metadata)
+}
+
+// InitiateUploadCtx is InitiateUpload with a context.
+func (c *Container) InitiateUploadCtx(ctx context.Context, name string, metadata map[string]interface{}) (stow.Upload, error) {
+	mdPrepped, err := prepMetadata(metadata)
+	if err != nil {
+		return stow.Upload{}, err
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"name":     name,
+		"metadata": mdPrepped,
+	})
+	if err != nil {
+		return stow.Upload{}, err
+	}
+	uri := uploadEndpoint + url.PathEscape(c.name) + "/o?uploadType=resumable"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uri, bytes.NewReader(body))
+	if err != nil {
+		return stow.Upload{}, err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return stow.Upload{}, errors.Wrap(err, "creating upload session")
+	}
+	defer res.Body.Close()
+	if err := googleapi.CheckResponse(res); err != nil {
+		return stow.Upload{}, errors.Wrap(mapError(err), "creating upload session")
+	}
+	return stow.Upload{
+		ID:        res.Header.Get("Location"),
+		Name:      name,
+		Initiated: time.Now(),
+	}, nil
+}
+
+// UploadPart appends a part to the session. Parts of unknown size are
+// buffered in memory.
+func (c *Container) UploadPart(upload stow.Upload, number int, r io.Reader, size int64) (stow.Part, error) {
+	return c.UploadPartCtx(c.ctx, upload, number, r, size)
+}
+
+// UploadPartCtx is UploadPart with a context.
+func (c *Container) UploadPartCtx(ctx context.Context, upload stow.Upload, number int, r io.Reader, size int64) (stow.Part, error) {
+	if size < 0 {
+		buf, err := io.ReadAll(r)
+		if err != nil {
+			return stow.Part{}, errors.Wrap(err, "reading part")
+		}
+		r, size = bytes.NewReader(buf), int64(len(buf))
+	}
+	offset, done, err := c.sessionStatus(ctx, upload)
+	if err != nil {
+		return stow.Part{}, err
+	}
+	if done {
+		return stow.Part{}, fmt.Errorf("upload of %q is complete", upload.Name)
+	}
+
+	contentRange := fmt.Sprintf("bytes %d-%d/*", offset, offset+size-1)
+	if size%chunkAlignment != 0 {
+		// the last part
+		contentRange = fmt.Sprintf("bytes %d-%d/%d", offset, offset+size-1, offset+size)
+		if size == 0 {
+			contentRange = fmt.Sprintf("bytes */%d", offset)
+		}
+	}
+	res, err := c.sessionRequest(ctx, upload, r, size, contentRange)
+	if err != nil {
+		return stow.Part{}, errors.Wrapf(err, "uploading part %d", number)
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case statusResumeIncomplete:
+		if persisted := persistedBytes(res); persisted != offset+size {
+			return stow.Part{}, fmt.Errorf("uploading part %d: %d bytes of the part were persisted", number, persisted-offset)
+		}
+	default:
+		return stow.Part{}, errors.Wrapf(mapError(googleapi.CheckResponse(res)), "uploading part %d", number)
+	}
+	return stow.Part{Number: number, Size: size}, nil
+}
+
+// ListParts gets the bytes the session has persisted. As the session
+// doesn't keep the parts it has been sent, these are listed as a
+// single part.
+func (c *Container) ListParts(upload stow.Upload) ([]stow.Part, error) {
+	return c.ListPartsCtx(c.ctx, upload)
+}
+
+// ListPartsCtx is ListParts with a context.
+func (c *Container) ListPartsCtx(ctx context.Context, upload stow.Upload) ([]stow.Part, error) {
+	persisted, done, err := c.sessionStatus(ctx, upload)
+	if err != nil {
+		return nil, err
+	}
+	if done {
+		return nil, fmt.Errorf("upload of %q is complete", upload.Name)
+	}
+	if persisted == 0 {
+		return nil, nil
+	}
+	return []stow.Part{{Number: 1, Size: persisted}}, nil
+}
+
+// CompleteUpload completes the session, unless its last part did
+// already. The sizes of parts must add up to the bytes persisted by
+// the session.
+func (c *Container) CompleteUpload(upload stow.Upload, parts []stow.Part) (stow.Item, error) {
+	return c.CompleteUploadCtx(c.ctx, upload, parts)
+}
+
+// CompleteUploadCtx is CompleteUpload with a context.
+func (c *Container) CompleteUploadCtx(ctx context.Context, upload stow.Upload, parts []stow.Part) (stow.Item, error) {
+	persisted, done, err := c.sessionStatus(ctx, upload)
+	if err != nil {
+		return nil, err
+	}
+	if !done {
+		var size int64
+		for _, part := range parts {
+			size += part.Size
+		}
+		if size != persisted {
+			return nil, fmt.Errorf("parts of %d bytes don't match the %d bytes uploaded", size, persisted)
+		}
+		res, err := c.sessionRequest(ctx, upload, nil, 0, fmt.Sprintf("bytes */%d", persisted))
+		if err != nil {
+			return nil, errors.Wrap(err, "completing upload")
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+			return nil, errors.Wrap(mapError(googleapi.CheckResponse(res)), "completing upload")
+		}
+	}
+	return c.ItemCtx(ctx, upload.Name)
+}
+
+// AbortUpload cancels the session.
+func (c *Container) AbortUpload(upload stow.Upload) error {
+	return c.AbortUploadCtx(c.ctx, upload)
+}
+
+// AbortUploadCtx is AbortUpload with a context.
+func (c *Container) AbortUploadCtx(ctx context.Context, upload stow.Upload) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, upload.ID, nil)
+	if err != nil {
+		return err
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "canceling upload")
+	}
+	defer res.Body.Close()
+	// Canceled sessions respond with the non-standard 499.
+	if res.StatusCode == 499 {
+		return nil
+	}
+	return errors.Wrap(mapError(googleapi.CheckResponse(res)), "canceling upload")
+}
+
+// ListPendingUploads is not supported: Cloud Storage has no way to
+// list upload sessions.
+func (c *Container) ListPendingUploads(prefix string) ([]stow.Upload, error) {
+	return c.ListPendingUploadsCtx(c.ctx, prefix)
+}
+
+// ListPendingUploadsCtx is ListPendingUploads with a context.
+func (c *Container) ListPendingUploadsCtx(ctx context.Context, prefix string) ([]stow.Upload, error) {
+	return nil, stow.NotSupported("listing pending uploads")
+}
+
+// sessionStatus gets the number of bytes persisted by the session, and
+// whether it is complete.
+func (c *Container) sessionStatus(ctx context.Context, upload stow.Upload) (int64, bool, error) {
+	res, err := c.sessionRequest(ctx, upload, nil, 0, "bytes */*")
+	if err != nil {
+		return 0, false, errors.Wrap(err, "getting upload status")
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return 0, true, nil
+	case statusResumeIncomplete:
+		return persistedBytes(res), false, nil
+	}
+	return 0, false, errors.Wrap(mapError(googleapi.CheckResponse(res)), "getting upload status")
+}
+
+// sessionRequest sends size bytes of r to the session.
+func (c *Container) sessionRequest(ctx context.Context, upload stow.Upload, r io.Reader, size int64, contentRange string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, upload.ID, r)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	if size == 0 {
+		req.Body = http.NoBody
+	}
+	req.Header.Set("Content-Range", contentRange)
+	return c.httpClient.Do(req)
+}
+
+// persistedBytes gets the number of bytes persisted by a session from
+// the Range header of a response, which is missing when there are
+// none.
+func persistedBytes(res *http.Response) int64 {
+	var first, last int64
+	if _, err := fmt.Sscanf(res.Header.Get("Range"), "bytes=%d-%d", &first, &last); err != nil {
+		return 0
+	}
+	return last + 1
+}
@@ -0,0 +1,63 @@
+package google
+
+import (
+	"context"
+	"io"
+	"strconv"
+
+	"cloud.google.com/go/storage"
+	"github.com/pkg/errors"
+
+	"github.com/aldor007/stow"
+)
+
+var (
+	_ stow.OptionsOpener        = (*Item)(nil)
+	_ stow.ContextOptionsOpener = (*Item)(nil)
+)
+
+// OpenWithOptions opens the object as described by opts. VersionID is
+// the generation to read. Cloud Storage has no conditional reads, so
+// the conditions are checked against the attributes of the object and
+// the read is then made conditional on its generation not having
+// changed. The response overrides are ignored.
+func (i *Item) OpenWithOptions(opts stow.OpenOptions) (io.ReadCloser, error) {
+	return i.OpenWithOptionsCtx(i.ctx, opts)
+}
+
+// OpenWithOptionsCtx is OpenWithOptions with a context.
+func (i *Item) OpenWithOptionsCtx(ctx context.Context, opts stow.OpenOptions) (io.ReadCloser, error) {
+	obj := i.handle()
+	if opts.VersionID != "" {
+		generation, err := strconv.ParseInt(opts.VersionID, 10, 64)
+		if err != nil {
+			return nil, stow.WrapError(stow.ErrNotFound, errors.Errorf("bad generation %q", opts.VersionID))
+		}
+		obj = obj.Generation(generation)
+	}
+	if opts.IfNoneMatch != "" || !opts.IfModifiedSince.IsZero() {
+		attrs, err := obj.Attrs(ctx)
+		if err != nil {
+			return nil, mapError(err)
+		}
+		if opts.NotModified(attrs.Etag, attrs.Updated) {
+			return nil, stow.ErrNotModified
+		}
+		if opts.VersionID == "" && i.generation == 0 {
+			obj = obj.If(storage.Conditions{GenerationMatch: attrs.Generation})
+		}
+	}
+
+	offset, length := int64(0), int64(-1)
+	if r := opts.Range; r != nil {
+		offset = r.Start
+		if r.Start >= 0 && r.End >= 0 {
+			length = r.End - r.Start + 1
+		}
+	}
+	r, err := obj.NewRangeReader(ctx, offset, length)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return r, nil
+}
@@ -1,12 +1,27 @@
 package google
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 
+	"cloud.google.com/go/storage"
 	"github.com/cheekybits/is"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
 
 	"github.com/aldor007/stow"
 	"github.com/aldor007/stow/test"
@@ -66,3 +81,321 @@ func TestPrepMetadataFailureWithNonStringValues(t *testing.T) {
 	_, err := prepMetadata(m)
 	is.Err(err)
 }
+
+func TestErrorMapping(t *testing.T) {
+	status := func(code int, message string) error {
+		return &googleapi.Error{Code: code, Message: message}
+	}
+
+	test.ErrorMapping(t, mapError, map[error][]error{
+		stow.ErrNotFound:           {storage.ErrObjectNotExist, storage.ErrBucketNotExist, status(http.StatusNotFound, "No such object")},
+		stow.ErrAlreadyExists:      {status(http.StatusConflict, "You already own this bucket.")},
+		stow.ErrPermissionDenied:   {status(http.StatusForbidden, "Access denied."), status(http.StatusUnauthorized, "Invalid Credentials")},
+		stow.ErrPreconditionFailed: {status(http.StatusPreconditionFailed, "Precondition Failed")},
+		stow.ErrContainerNotEmpty:  {status(http.StatusConflict, "The bucket you tried to delete is not empty.")},
+		stow.ErrThrottled:          {status(http.StatusTooManyRequests, "The rate of change requests to the bucket is too high.")},
+		stow.ErrInvalidName:        {status(http.StatusBadRequest, "Invalid bucket name: 'a/b'")},
+		stow.ErrInvalidRange:       {status(http.StatusRequestedRangeNotSatisfiable, "The requested range cannot be satisfied.")},
+	})
+}
+
+// sessionServer is a resumable upload session of Cloud Storage.
+type sessionServer struct {
+	metadata map[string]string
+	data     []byte
+	done     bool
+	canceled bool
+}
+
+func (s *sessionServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		var object struct{ Metadata map[string]string }
+		json.NewDecoder(r.Body).Decode(&object)
+		s.metadata = object.Metadata
+		w.Header().Set("Location", "http://"+r.Host+"/session")
+		return
+	case http.MethodDelete:
+		s.canceled = true
+		w.WriteHeader(499)
+		return
+	}
+	b, _ := ioutil.ReadAll(r.Body)
+	var first, last, total int64
+	contentRange := r.Header.Get("Content-Range")
+	if contentRange != "bytes */*" {
+		if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/%d", &first, &last, &total); err == nil {
+			s.done = true
+		} else if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/*", &first, &last); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if int64(len(s.data)) != first {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.data = append(s.data, b...)
+	}
+	if s.done {
+		return
+	}
+	if len(s.data) > 0 {
+		w.Header().Set("Range", fmt.Sprintf("bytes=0-%d", len(s.data)-1))
+	}
+	w.WriteHeader(statusResumeIncomplete)
+}
+
+func TestMultipartUpload(t *testing.T) {
+	is := is.New(t)
+	session := &sessionServer{}
+	server := httptest.NewServer(session)
+	defer server.Close()
+	defer func(endpoint string) { uploadEndpoint = endpoint }(uploadEndpoint)
+	uploadEndpoint = server.URL + "/"
+	c := &Container{name: "bucket", httpClient: server.Client(), ctx: context.Background()}
+
+	upload, err := c.InitiateUpload("item", map[string]interface{}{"key": "value"})
+	is.NoErr(err)
+	is.Equal(upload.ID, server.URL+"/session")
+	is.Equal(session.metadata, map[string]string{"key": "value"})
+
+	parts, err := c.ListParts(upload)
+	is.NoErr(err)
+	is.Equal(len(parts), 0)
+
+	first := bytes.Repeat([]byte("a"), chunkAlignment)
+	_, err = c.UploadPart(upload, 1, bytes.NewReader(first), int64(len(first)))
+	is.NoErr(err)
+	is.False(session.done)
+	parts, err = c.ListParts(upload)
+	is.NoErr(err)
+	is.Equal(parts, []stow.Part{{Number: 1, Size: chunkAlignment}})
+
+	// a part that isn't aligned is the last one
+	part, err := c.UploadPart(upload, 2, strings.NewReader("end"), -1)
+	is.NoErr(err)
+	is.Equal(part, stow.Part{Number: 2, Size: 3})
+	is.True(session.done)
+	is.Equal(string(session.data), string(first)+"end")
+
+	is.NoErr(c.AbortUpload(upload))
+	is.True(session.canceled)
+}
+
+// batchServer answers batches of delete requests, in reverse order,
+// refusing to delete the object "locked" and not finding "missing".
+type batchServer struct {
+	batches int
+	deleted []string
+}
+
+func (s *batchServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.batches++
+	_, params, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	reader := multipart.NewReader(r.Body, params["boundary"])
+	var responses []string
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		req, err := http.ReadRequest(bufio.NewReader(part))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		response := "HTTP/1.1 204 No Content\r\n\r\n"
+		switch name := strings.TrimPrefix(req.URL.EscapedPath(), "/storage/v1/b/bucket/o/"); name {
+		case "locked":
+			body := `{"error":{"code":403,"message":"Forbidden"}}`
+			response = fmt.Sprintf("HTTP/1.1 403 Forbidden\r\nContent-Type: application/json\r\nContent-Length: %d\r\n\r\n%s", len(body), body)
+		case "missing":
+			response = "HTTP/1.1 404 Not Found\r\nContent-Length: 0\r\n\r\n"
+		default:
+			s.deleted = append(s.deleted, name)
+		}
+		contentID := strings.Replace(part.Header.Get("Content-ID"), "<", "<response-", 1)
+		responses = append(responses, contentID+"\n"+response)
+	}
+	mw := multipart.NewWriter(w)
+	w.Header().Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	for i := len(responses) - 1; i >= 0; i-- {
+		lines := strings.SplitN(responses[i], "\n", 2)
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", "application/http")
+		header.Set("Content-ID", lines[0])
+		part, _ := mw.CreatePart(header)
+		part.Write([]byte(lines[1]))
+	}
+	mw.Close()
+}
+
+func TestRemoveItems(t *testing.T) {
+	is := is.New(t)
+	batches := &batchServer{}
+	server := httptest.NewServer(batches)
+	defer server.Close()
+	defer func(endpoint string) { batchEndpoint = endpoint }(batchEndpoint)
+	batchEndpoint = server.URL
+	c := &Container{name: "bucket", httpClient: server.Client(), ctx: context.Background()}
+
+	ids := []string{"locked", "missing", "dir/item"}
+	for i := 0; i < 150; i++ {
+		ids = append(ids, fmt.Sprintf("item%d", i))
+	}
+	failed, err := c.RemoveItems(ids)
+	is.NoErr(err)
+	is.Equal(batches.batches, 2)
+	is.Equal(len(batches.deleted), 151)
+	is.Equal(batches.deleted[0], "dir%2Fitem")
+	is.Equal(len(failed), 1)
+	is.True(errors.Is(failed["locked"], stow.ErrPermissionDenied))
+}
+
+// versionsServer lists three generations of the object "item", and
+// records the generation of the other requests.
+type versionsServer struct {
+	generation string
+}
+
+func (s *versionsServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.URL.Path == "/storage/v1/b/bucket/o" {
+		fmt.Fprint(w, `{"items":[
+			{"name":"item","generation":"1","size":"3","updated":"2023-01-01T00:00:00Z","timeDeleted":"2023-01-02T00:00:00Z"},
+			{"name":"item","generation":"2","size":"5","updated":"2023-01-02T00:00:00Z","timeDeleted":"2023-01-03T00:00:00Z"},
+			{"name":"item","generation":"3","size":"7","updated":"2023-01-03T00:00:00Z"}
+		]}`)
+		return
+	}
+	s.generation = r.URL.Query().Get("generation")
+	if r.Method == http.MethodDelete {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	fmt.Fprintf(w, `{"name":"item","generation":%q,"size":"5"}`, s.generation)
+}
+
+func TestItemVersions(t *testing.T) {
+	is := is.New(t)
+	versions := &versionsServer{}
+	server := httptest.NewServer(versions)
+	defer server.Close()
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx, option.WithEndpoint(server.URL+"/storage/v1/"), option.WithoutAuthentication())
+	is.NoErr(err)
+	c := &Container{name: "bucket", client: client, ctx: ctx}
+
+	page, cursor, err := c.ItemVersions("item", stow.CursorStart, 2)
+	is.NoErr(err)
+	is.Equal(len(page), 2)
+	is.Equal(page[0].ID, "3")
+	is.True(page[0].Latest)
+	is.Equal(page[0].Size, int64(7))
+	is.Equal(page[1].ID, "2")
+	is.False(page[1].Latest)
+	is.Equal(cursor, "2")
+	page, cursor, err = c.ItemVersions("item", cursor, 2)
+	is.NoErr(err)
+	is.Equal(len(page), 1)
+	is.Equal(page[0].ID, "1")
+	is.Equal(cursor, "")
+
+	item, err := c.ItemVersion("item", "2")
+	is.NoErr(err)
+	is.Equal(versions.generation, "2")
+	size, err := item.Size()
+	is.NoErr(err)
+	is.Equal(size, int64(5))
+
+	is.NoErr(c.RemoveItemVersion("item", "1"))
+	is.Equal(versions.generation, "1")
+	_, err = c.ItemVersion("item", "latest")
+	is.True(errors.Is(err, stow.ErrNotFound))
+}
+
+// metadataServer serves an object with some metadata, and records
+// the patches of its metadata.
+type metadataServer struct {
+	// metadata is the JSON metadata of the object, some by default.
+	metadata string
+	patches  []string
+}
+
+func (s *metadataServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	if r.Method == http.MethodPatch {
+		var patch struct {
+			Metadata map[string]string `json:"metadata"`
+		}
+		json.NewDecoder(r.Body).Decode(&patch)
+		b, _ := json.Marshal(patch.Metadata)
+		s.patches = append(s.patches, r.URL.Query().Get("ifMetagenerationMatch")+" "+string(b))
+		fmt.Fprintf(w, `{"name":"item","metageneration":"%d"}`, len(s.patches)+3)
+		return
+	}
+	metadata := s.metadata
+	if metadata == "" {
+		metadata = `{"owner":"me","content-type":"text/plain"}`
+	}
+	fmt.Fprintf(w, `{"name":"item","metageneration":"3","metadata":%s}`, metadata)
+}
+
+func TestSetMetadata(t *testing.T) {
+	is := is.New(t)
+	patches := &metadataServer{}
+	server := httptest.NewServer(patches)
+	defer server.Close()
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx, option.WithEndpoint(server.URL+"/storage/v1/"), option.WithoutAuthentication())
+	is.NoErr(err)
+	c := &Container{name: "bucket", client: client, ctx: ctx}
+
+	is.NoErr(c.SetMetadata("item", map[string]interface{}{"content-type": "text/html"}, false))
+	is.Equal(patches.patches, []string{`3 {"content-type":"text/html","owner":"me"}`})
+
+	// removing keys clears the metadata first
+	patches.patches = nil
+	is.NoErr(c.SetMetadata("item", map[string]interface{}{"owner": nil}, false))
+	is.Equal(patches.patches, []string{`3 null`, `4 {"content-type":"text/plain"}`})
+
+	patches.patches = nil
+	is.NoErr(c.SetMetadata("item", nil, true))
+	is.Equal(patches.patches, []string{`3 null`})
+}
+
+func TestSetTags(t *testing.T) {
+	is := is.New(t)
+	patches := &metadataServer{metadata: `{"owner":"me","stow-tag-stage":"raw"}`}
+	server := httptest.NewServer(patches)
+	defer server.Close()
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx, option.WithEndpoint(server.URL+"/storage/v1/"), option.WithoutAuthentication())
+	is.NoErr(err)
+	c := &Container{name: "bucket", client: client, ctx: ctx}
+	item := &Item{
+		container: c,
+		client:    client,
+		name:      "item",
+		metadata:  map[string]interface{}{"owner": "me", "stow-tag-stage": "raw"},
+		ctx:       ctx,
+	}
+
+	tags, err := item.Tags()
+	is.NoErr(err)
+	is.Equal(tags, map[string]interface{}{"stage": "raw"})
+
+	// tags are replaced, the rest of the metadata is kept
+	is.NoErr(item.SetTags(map[string]string{"team": "data"}))
+	is.Equal(patches.patches, []string{`3 null`, `4 {"owner":"me","stow-tag-team":"data"}`})
+	tags, err = item.Tags()
+	is.NoErr(err)
+	is.Equal(tags, map[string]interface{}{"team": "data"})
+
+	patches.patches = nil
+	is.NoErr(item.DeleteTags())
+	is.Equal(patches.patches, []string{`3 null`, `4 {"owner":"me"}`})
+	tags, err = item.Tags()
+	is.NoErr(err)
+	is.Equal(len(tags), 0)
+}
@@ -0,0 +1,71 @@
+package google
+
+import (
+	"context"
+	"strings"
+
+	"github.com/aldor007/stow"
+)
+
+var (
+	_ stow.Taggable         = (*Item)(nil)
+	_ stow.TagSetter        = (*Item)(nil)
+	_ stow.ContextTagSetter = (*Item)(nil)
+)
+
+// tagPrefix is the prefix of the custom metadata keys that hold the
+// tags of objects, as Cloud Storage has no object tags.
+const tagPrefix = "stow-tag-"
+
+// Tags gets the tags of the object, which are kept in its custom
+// metadata under keys starting with "stow-tag-".
+func (i *Item) Tags() (map[string]interface{}, error) {
+	tags := make(map[string]interface{})
+	for key, value := range i.metadata {
+		if strings.HasPrefix(key, tagPrefix) {
+			tags[strings.TrimPrefix(key, tagPrefix)] = value
+		}
+	}
+	return tags, nil
+}
+
+// SetTags replaces the tags of the object. The rest of its custom
+// metadata is kept.
+func (i *Item) SetTags(tags map[string]string) error {
+	return i.SetTagsCtx(i.ctx, tags)
+}
+
+// SetTagsCtx is SetTags with a context.
+func (i *Item) SetTagsCtx(ctx context.Context, tags map[string]string) error {
+	return i.updateTags(ctx, tags)
+}
+
+// DeleteTags removes all the tags of the object.
+func (i *Item) DeleteTags() error {
+	return i.DeleteTagsCtx(i.ctx)
+}
+
+// DeleteTagsCtx is DeleteTags with a context.
+func (i *Item) DeleteTagsCtx(ctx context.Context) error {
+	return i.updateTags(ctx, nil)
+}
+
+// updateTags replaces the tags in the current metadata of the object.
+func (i *Item) updateTags(ctx context.Context, tags map[string]string) error {
+	md, err := updateMetadata(ctx, i.handle(), func(current map[string]interface{}) map[string]interface{} {
+		for key := range current {
+			if strings.HasPrefix(key, tagPrefix) {
+				delete(current, key)
+			}
+		}
+		for key, value := range tags {
+			current[tagPrefix+key] = value
+		}
+		return current
+	})
+	if err != nil {
+		return err
+	}
+	i.metadata = md
+	return nil
+}
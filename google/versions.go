@@ -0,0 +1,130 @@
+package google
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"cloud.google.com/go/storage"
+	"github.com/pkg/errors"
+	"google.golang.org/api/iterator"
+
+	"github.com/aldor007/stow"
+)
+
+var (
+	_ stow.Versioned        = (*Container)(nil)
+	_ stow.ContextVersioned = (*Container)(nil)
+)
+
+// ItemVersions gets a page of the generations of an object, which are
+// its versions. The cursor is the generation the page starts after.
+//
+// Cloud Storage lists generations oldest first, so all of them are
+// listed to return them newest first.
+func (c *Container) ItemVersions(id, cursor string, count int) ([]stow.Version, string, error) {
+	return c.ItemVersionsCtx(c.ctx, id, cursor, count)
+}
+
+// ItemVersionsCtx is ItemVersions with a context.
+func (c *Container) ItemVersionsCtx(ctx context.Context, id, cursor string, count int) ([]stow.Version, string, error) {
+	var after int64
+	if cursor != "" {
+		var err error
+		if after, err = strconv.ParseInt(cursor, 10, 64); err != nil {
+			return nil, "", stow.ErrBadCursor
+		}
+	}
+	query := &storage.Query{
+		Versions:    true,
+		StartOffset: id,
+		EndOffset:   id + "\x00",
+	}
+	it := c.Bucket().Objects(ctx, query)
+	var generations []*storage.ObjectAttrs
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, "", mapError(err)
+		}
+		if attrs.Name != id || (after != 0 && attrs.Generation >= after) {
+			continue
+		}
+		generations = append(generations, attrs)
+	}
+	sort.Slice(generations, func(i, j int) bool {
+		return generations[i].Generation > generations[j].Generation
+	})
+
+	versions := make([]stow.Version, len(generations))
+	for i, attrs := range generations {
+		versions[i] = stow.Version{
+			ID:      strconv.FormatInt(attrs.Generation, 10),
+			LastMod: attrs.Updated,
+			Size:    attrs.Size,
+			ETag:    attrs.Etag,
+			Latest:  attrs.Deleted.IsZero(),
+		}
+	}
+
+	if count <= 0 || len(versions) <= count {
+		return versions, "", nil
+	}
+	versions = versions[:count]
+	return versions, versions[count-1].ID, nil
+}
+
+// ItemVersion gets a generation of an object.
+func (c *Container) ItemVersion(id, versionID string) (stow.Item, error) {
+	return c.ItemVersionCtx(c.ctx, id, versionID)
+}
+
+// ItemVersionCtx is ItemVersion with a context.
+func (c *Container) ItemVersionCtx(ctx context.Context, id, versionID string) (stow.Item, error) {
+	generation, err := strconv.ParseInt(versionID, 10, 64)
+	if err != nil {
+		return nil, stow.WrapError(stow.ErrNotFound, errors.Errorf("bad generation %q", versionID))
+	}
+	attrs, err := c.Bucket().Object(id).Generation(generation).Attrs(ctx)
+	if err != nil {
+		if err == storage.ErrObjectNotExist {
+			return nil, stow.ErrNotFound
+		}
+		return nil, mapError(err)
+	}
+	item, err := c.convertToStowItem(attrs)
+	if err != nil {
+		return nil, err
+	}
+	item.(*Item).generation = generation
+	return item, nil
+}
+
+// RemoveItemVersion permanently removes a generation of an object.
+func (c *Container) RemoveItemVersion(id, versionID string) error {
+	return c.RemoveItemVersionCtx(c.ctx, id, versionID)
+}
+
+// RemoveItemVersionCtx is RemoveItemVersion with a context.
+func (c *Container) RemoveItemVersionCtx(ctx context.Context, id, versionID string) error {
+	generation, err := strconv.ParseInt(versionID, 10, 64)
+	if err != nil {
+		return stow.WrapError(stow.ErrNotFound, errors.Errorf("bad generation %q", versionID))
+	}
+	return mapError(c.Bucket().Object(id).Generation(generation).Delete(ctx))
+}
+
+// EnableVersioning enables Object Versioning on the bucket, so that
+// overwritten and removed objects are kept as noncurrent generations.
+func (c *Container) EnableVersioning() error {
+	return c.EnableVersioningCtx(c.ctx)
+}
+
+// EnableVersioningCtx is EnableVersioning with a context.
+func (c *Container) EnableVersioningCtx(ctx context.Context) error {
+	_, err := c.Bucket().Update(ctx, storage.BucketAttrsToUpdate{VersioningEnabled: true})
+	return mapError(err)
+}
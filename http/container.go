@@ -8,7 +8,6 @@ import (
 	"net/http"
 	"strings"
 	"time"
-	"fmt"
 )
 
 type container struct {
@@ -18,6 +17,14 @@ type container struct {
 	headers  map[string]string
 }
 
+var (
+	_ stow.Container          = (*container)(nil)
+	_ stow.ContextContainer   = (*container)(nil)
+	_ stow.CapabilityReporter = (*container)(nil)
+	_ stow.Stater             = (*container)(nil)
+	_ stow.ContextStater      = (*container)(nil)
+)
+
 // ID returns a string value which represents the name of the container.
 func (c *container) ID() string {
 	return c.name
@@ -31,20 +38,60 @@ func (c *container) Name() string {
 // Item returns a stow.Item instance of a container based on the
 // name of the container and the key representing
 func (c *container) Item(id string) (stow.Item, error) {
-	return c.getItem(id)
+	return c.ItemCtx(context.Background(), id)
+}
+
+// ItemCtx is Item with a context.
+func (c *container) ItemCtx(ctx context.Context, id string) (stow.Item, error) {
+	return c.getItem(ctx, id)
+}
+
+// Stat gets the information of an item with a HEAD request.
+func (c *container) Stat(id string) (stow.ItemInfo, error) {
+	return c.StatCtx(context.Background(), id)
+}
+
+// StatCtx is Stat with a context.
+func (c *container) StatCtx(ctx context.Context, id string) (stow.ItemInfo, error) {
+	item, err := c.getItem(ctx, id)
+	if err != nil {
+		return stow.ItemInfo{}, err
+	}
+	info, err := stow.NewItemInfo(item)
+	if err != nil {
+		return stow.ItemInfo{}, err
+	}
+	// The metadata holds all the headers of the response.
+	info.ContentType, _ = info.Metadata["Content-Type"].(string)
+	return info, nil
 }
 
 // Items sends a request to retrieve a list of items that are prepended with
 // the prefix argument. The 'cursor' variable facilitates pagination.
 func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
+	return c.ItemsCtx(context.Background(), prefix, cursor, count)
+}
+
+// ItemsCtx is Items with a context.
+func (c *container) ItemsCtx(ctx context.Context, prefix, cursor string, count int) ([]stow.Item, string, error) {
 	return nil, "", errors.New("not implemented")
 }
 
 func (c *container) RemoveItem(id string) error {
+	return c.RemoveItemCtx(context.Background(), id)
+}
+
+// RemoveItemCtx is RemoveItem with a context.
+func (c *container) RemoveItemCtx(ctx context.Context, id string) error {
 	return errors.New("not implemented")
 }
 
 func (c *container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.PutCtx(context.Background(), name, r, size, metadata)
+}
+
+// PutCtx is Put with a context.
+func (c *container) PutCtx(ctx context.Context, name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
 	return nil, errors.New("not implemented")
 }
 
@@ -53,6 +100,11 @@ func (c *container) PreSignRequest(ctx context.Context, clientMethod stow.Client
 	return "", errors.New("not implemented")
 }
 
+// Capabilities describes the features supported by this container.
+func (c *container) Capabilities() stow.Capabilities {
+	return capabilities()
+}
+
 
 // A request to retrieve a single item includes information that is more specific than
 // a PUT. Instead of doing a request within the PUT, make this method available so that the
@@ -61,9 +113,9 @@ func (c *container) PreSignRequest(ctx context.Context, clientMethod stow.Client
 // done only once since the requested information is retained.
 // May be simpler to just stick it in PUT and and do a request every time, please vouch
 // for this if so.
-func (c *container) getItem(id string) (*item, error) {
+func (c *container) getItem(ctx context.Context, id string) (*item, error) {
 	endpoint := strings.Replace(c.endpoint, "<item>", id, 1)
-	req, err := http.NewRequest("HEAD", endpoint, nil)
+	req, err := http.NewRequestWithContext(ctx, "HEAD", endpoint, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -88,7 +140,7 @@ func (c *container) getItem(id string) (*item, error) {
 	}
 
 	if res.StatusCode != 200 {
-		return nil, errors.New(fmt.Sprintf("wrong response status code %d", res.StatusCode))
+		return nil, mapError(statusError(res.StatusCode))
 	}
 
 	var etag string
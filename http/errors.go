@@ -0,0 +1,39 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/aldor007/stow"
+	"github.com/pkg/errors"
+)
+
+// statusError is returned when the server answers with an unexpected
+// status code.
+type statusError int
+
+func (e statusError) Error() string {
+	return fmt.Sprintf("wrong response status code %d", int(e))
+}
+
+// mapError maps unexpected status codes to the error kinds declared by
+// stow. The original error is kept in the chain.
+func mapError(err error) error {
+	var status statusError
+	if !errors.As(err, &status) {
+		return err
+	}
+	switch int(status) {
+	case http.StatusNotModified:
+		return stow.WrapError(stow.ErrNotModified, err)
+	case http.StatusNotFound, http.StatusGone:
+		return stow.WrapError(stow.ErrNotFound, err)
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return stow.WrapError(stow.ErrPermissionDenied, err)
+	case http.StatusPreconditionFailed:
+		return stow.WrapError(stow.ErrPreconditionFailed, err)
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return stow.WrapError(stow.ErrThrottled, err)
+	}
+	return err
+}
@@ -0,0 +1,21 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/aldor007/stow"
+	"github.com/aldor007/stow/test"
+)
+
+func TestErrorMapping(t *testing.T) {
+	test.ErrorMapping(t, mapError, map[error][]error{
+		stow.ErrNotModified:        {statusError(http.StatusNotModified)},
+		stow.ErrNotFound:           {statusError(http.StatusNotFound), statusError(http.StatusGone), errors.Wrap(statusError(http.StatusNotFound), "getting item")},
+		stow.ErrPermissionDenied:   {statusError(http.StatusUnauthorized), statusError(http.StatusForbidden)},
+		stow.ErrPreconditionFailed: {statusError(http.StatusPreconditionFailed)},
+		stow.ErrThrottled:          {statusError(http.StatusTooManyRequests), statusError(http.StatusServiceUnavailable)},
+	})
+}
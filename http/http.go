@@ -3,9 +3,11 @@ package http
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/aldor007/stow"
@@ -28,6 +30,12 @@ func init() {
 		if !ok {
 			return  errors.New("missing url")
 		}
+		if headersStr, ok := config.Config(ConfigHeader); ok && headersStr != "" {
+			var headers map[string]string
+			if err := json.Unmarshal([]byte(headersStr), &headers); err != nil {
+				return fmt.Errorf("parsing headers: %w", err)
+			}
+		}
 		return nil
 	}
 	makefn := func(config stow.Config) (stow.Location, error) {
@@ -40,10 +48,10 @@ func init() {
 		var headers map[string]string
 
 		headersStr, ok := config.Config(ConfigHeader)
-		if !ok {
+		if !ok || headersStr == "" {
 			headers = make(map[string]string)
-		} else {
-			json.Unmarshal([]byte(headersStr), &headers)
+		} else if err := json.Unmarshal([]byte(headersStr), &headers); err != nil {
+			return nil, fmt.Errorf("parsing headers: %w", err)
 		}
 
 		client := &http.Client{
@@ -70,8 +78,64 @@ func init() {
 	}
 
 	kindfn := func(u *url.URL) bool {
-		return u.Scheme == Kind
+		// Items have the URLs they are got from.
+		return u.Scheme == Kind || u.Scheme == "https"
 	}
 
 	stow.Register(Kind, makefn, kindfn, validatefn)
+	stow.RegisterDSN(Kind, stow.DSN{
+		Endpoint: ConfigUrl,
+		Parse:    parseHeaderParams,
+		Format:   formatHeaderParams,
+	})
+	stow.RegisterSchema(Kind, stow.Schema{Keys: []stow.KeySchema{
+		{Name: ConfigUrl, Required: true,
+			Description: "The URL of the items, with <container> and <item> in place of their names."},
+		{Name: ConfigHeader, Type: stow.TypeJSON, Secret: true,
+			Description: "A JSON object of the headers sent with requests, such as Authorization."},
+	}})
+}
+
+// headerParam prefixes the connection string parameters of the
+// headers sent with requests, such as header.Authorization.
+const headerParam = "header."
+
+// parseHeaderParams sets ConfigHeader from the header parameters of a
+// connection string.
+func parseHeaderParams(params url.Values, config stow.Config) error {
+	headers := map[string]string{}
+	for param := range params {
+		name := strings.TrimPrefix(param, headerParam)
+		if name == param || name == "" {
+			return fmt.Errorf("unknown http connection string parameter %q", param)
+		}
+		headers[name] = params.Get(param)
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+	b, err := json.Marshal(headers)
+	if err != nil {
+		return err
+	}
+	config.Set(ConfigHeader, string(b))
+	return nil
+}
+
+// formatHeaderParams adds the headers of ConfigHeader to the
+// parameters of a connection string. Their values may hold
+// credentials, so they are all redacted.
+func formatHeaderParams(config stow.Config, params url.Values, redact bool) {
+	headersStr, ok := config.Config(ConfigHeader)
+	if !ok {
+		return
+	}
+	var headers map[string]string
+	json.Unmarshal([]byte(headersStr), &headers)
+	for name, value := range headers {
+		if redact {
+			value = "xxxxx"
+		}
+		params.Set(headerParam+name, value)
+	}
 }
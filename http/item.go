@@ -1,6 +1,7 @@
 package http
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"net/url"
@@ -9,9 +10,14 @@ import (
 
 	"github.com/aldor007/stow"
 	"github.com/pkg/errors"
-	"fmt"
 )
-var _ stow.Item = (*item)(nil)
+
+var (
+	_ stow.Item                 = (*item)(nil)
+	_ stow.ContextItem          = (*item)(nil)
+	_ stow.OptionsOpener        = (*item)(nil)
+	_ stow.ContextOptionsOpener = (*item)(nil)
+)
 
 // The item struct contains an id (also the name of the file/S3 Object/Item),
 // a container which it belongs to (s3 Bucket), a client, and a URL. The last
@@ -64,7 +70,12 @@ func (i *item) URL() *url.URL {
 // and path of the file within the container. This response includes the body of
 // resource which is returned along with an error.
 func (i *item) Open() (io.ReadCloser, error) {
-	req, err := http.NewRequest("GET", i.url, nil)
+	return i.OpenCtx(context.Background())
+}
+
+// OpenCtx is Open with a context.
+func (i *item) OpenCtx(ctx context.Context) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", i.url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -79,12 +90,74 @@ func (i *item) Open() (io.ReadCloser, error) {
 	}
 
 	if response.StatusCode != 200 {
-		return nil, errors.New(fmt.Sprintf("wrong response status code %d", response.StatusCode))
+		response.Body.Close()
+		return nil, mapError(statusError(response.StatusCode))
 	}
 
 	return response.Body, err
 }
 
+// OpenWithOptions opens the item as described by opts, which are sent
+// as the headers of the request. Servers which ignore the Range header
+// send the whole item, which is then cut to the range. Versions aren't
+// supported and the response overrides are ignored.
+func (i *item) OpenWithOptions(opts stow.OpenOptions) (io.ReadCloser, error) {
+	return i.OpenWithOptionsCtx(context.Background(), opts)
+}
+
+// OpenWithOptionsCtx is OpenWithOptions with a context.
+func (i *item) OpenWithOptionsCtx(ctx context.Context, opts stow.OpenOptions) (io.ReadCloser, error) {
+	if opts.VersionID != "" {
+		return nil, stow.NotSupported("opening versions")
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", i.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for h, v := range i.container.headers {
+		req.Header.Set(h, v)
+	}
+	if opts.Range != nil {
+		req.Header.Set("Range", opts.Range.String())
+	}
+	if opts.IfNoneMatch != "" {
+		req.Header.Set("If-None-Match", `"`+opts.IfNoneMatch+`"`)
+	}
+	if !opts.IfModifiedSince.IsZero() {
+		req.Header.Set("If-Modified-Since", opts.IfModifiedSince.UTC().Format(http.TimeFormat))
+	}
+
+	response, err := i.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case response.StatusCode == http.StatusPartialContent && opts.Range != nil:
+		return response.Body, nil
+	case response.StatusCode != http.StatusOK:
+		response.Body.Close()
+		return nil, mapError(statusError(response.StatusCode))
+	case opts.Range == nil:
+		return response.Body, nil
+	}
+	size := response.ContentLength
+	if size < 0 {
+		size = *i.properties.Size
+	}
+	offset, length, ok := opts.Range.Offsets(size)
+	if !ok {
+		response.Body.Close()
+		return nil, errors.Wrap(stow.ErrInvalidRange, opts.Range.String())
+	}
+	if _, err := io.CopyN(io.Discard, response.Body, offset); err != nil {
+		response.Body.Close()
+		return nil, err
+	}
+	return stow.LimitReadCloser(response.Body, length), nil
+}
+
 // LastMod returns the last modified date of the item. The response of an item that is PUT
 // does not contain this field. Solution? Detect when the LastModified field (a *time.Time)
 // is nil, then do a manual request for it via the Item() method of the container which
@@ -141,7 +214,7 @@ func (i *item) ensureInfo() error {
 }
 
 func (i *item) getInfo() (stow.Item, error) {
-	itemInfo, err := i.container.getItem(i.ID())
+	itemInfo, err := i.container.getItem(context.Background(), i.ID())
 	if err != nil {
 		return nil, err
 	}
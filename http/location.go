@@ -1,8 +1,10 @@
 package http
 
 import (
+	"context"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strings"
 
 	"github.com/aldor007/stow"
@@ -17,16 +19,44 @@ type location struct {
 	headers  map[string]string
 }
 
+var (
+	_ stow.Location           = (*location)(nil)
+	_ stow.ContextLocation    = (*location)(nil)
+	_ stow.CapabilityReporter = (*location)(nil)
+	_ stow.URLParser          = (*location)(nil)
+)
+
 func (l *location) HasRanges() bool {
 	// TODO add implementation and change to true
 	return false
 }
 
+// Capabilities describes the features supported by this location.
+func (l *location) Capabilities() stow.Capabilities {
+	return capabilities()
+}
+
+// capabilities describes the features supported by plain HTTP. Items
+// can only be retrieved by name and read.
+func capabilities() stow.Capabilities {
+	return stow.Capabilities{}
+}
+
 func (l *location) CreateContainer(containerName string) (stow.Container, error) {
+	return l.CreateContainerCtx(context.Background(), containerName)
+}
+
+// CreateContainerCtx is CreateContainer with a context.
+func (l *location) CreateContainerCtx(ctx context.Context, containerName string) (stow.Container, error) {
 	return nil, errors.New("not implemented")
 }
 
 func (l *location) Containers(prefix, cursor string, count int) ([]stow.Container, string, error) {
+	return l.ContainersCtx(context.Background(), prefix, cursor, count)
+}
+
+// ContainersCtx is Containers with a context.
+func (l *location) ContainersCtx(ctx context.Context, prefix, cursor string, count int) ([]stow.Container, string, error) {
 	var containers []stow.Container
 
 	return containers, "", errors.New("not implemented")
@@ -41,6 +71,11 @@ func (l *location) Close() error {
 // Container retrieves a stow.Container based on its name which must be
 // exact.
 func (l *location) Container(id string) (stow.Container, error) {
+	return l.ContainerCtx(context.Background(), id)
+}
+
+// ContainerCtx is Container with a context.
+func (l *location) ContainerCtx(ctx context.Context, id string) (stow.Container, error) {
 	endpoint := strings.Replace(l.endpoint, "<container>", id, 1)
 	return &container{
 		client:   l.client,
@@ -52,11 +87,65 @@ func (l *location) Container(id string) (stow.Container, error) {
 
 // RemoveContainer removes a container simply by name.
 func (l *location) RemoveContainer(id string) error {
+	return l.RemoveContainerCtx(context.Background(), id)
+}
+
+// RemoveContainerCtx is RemoveContainer with a context.
+func (l *location) RemoveContainerCtx(ctx context.Context, id string) error {
 	return errors.New("not implemeted")
 }
 
 // ItemByURL retrieves a stow.Item by parsing the URL, in this
 // case an item is an object.
 func (l *location) ItemByURL(url *url.URL) (stow.Item, error) {
-	return nil, errors.New("not implemeted")
+	return l.ItemByURLCtx(context.Background(), url)
+}
+
+// ItemByURLCtx is ItemByURL with a context.
+func (l *location) ItemByURLCtx(ctx context.Context, url *url.URL) (stow.Item, error) {
+	containerName, itemName, err := l.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := l.ContainerCtx(ctx, containerName)
+	if err != nil {
+		return nil, err
+	}
+	return c.(*container).ItemCtx(ctx, itemName)
+}
+
+// ParseURL gets the container and the name of the item a URL points to
+// by matching it against the url template of the configuration. URLs
+// that don't match it aren't of this location.
+func (l *location) ParseURL(u *url.URL) (string, string, error) {
+	if !strings.Contains(l.endpoint, "<item>") {
+		return "", "", errors.New("url config has no <item>")
+	}
+	pattern := regexp.QuoteMeta(l.endpoint)
+	pattern = strings.Replace(pattern, "<container>", "(?P<container>[^/]*)", 1)
+	pattern = strings.Replace(pattern, "<item>", "(?P<item>.+)", 1)
+	re, err := regexp.Compile("^" + pattern + "$")
+	if err != nil {
+		return "", "", errors.Wrap(err, "ParseURL, compiling the url config")
+	}
+
+	match := re.FindStringSubmatch(u.String())
+	if match == nil {
+		return "", "", errors.Errorf("URL %s doesn't match %s", u, l.endpoint)
+	}
+	var containerName, itemName string
+	for i, name := range re.SubexpNames() {
+		var err error
+		switch name {
+		case "container":
+			containerName, err = url.PathUnescape(match[i])
+		case "item":
+			itemName, err = url.PathUnescape(match[i])
+		}
+		if err != nil {
+			return "", "", err
+		}
+	}
+	return containerName, itemName, nil
 }
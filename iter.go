@@ -0,0 +1,76 @@
+//go:build go1.23
+
+// The iterators need the iter package of Go 1.23, while the module
+// supports Go 1.20, so they are left out of builds with older
+// toolchains.
+
+package stow
+
+import (
+	"context"
+	"iter"
+)
+
+// iterPageSize is the number of Items or Containers listed per request
+// by AllItems and AllContainers.
+const iterPageSize = 100
+
+// AllItems iterates over the Items in the Container whose IDs start
+// with prefix, listing them a page at a time. ItemsCtx is used when
+// container is a ContextContainer.
+// An error stops the iteration: it is yielded once with a nil Item,
+// and no more pages are listed. Breaking out of the loop stops the
+// listing too.
+func AllItems(ctx context.Context, container Container, prefix string) iter.Seq2[Item, error] {
+	list := container.Items
+	if c, ok := container.(ContextContainer); ok {
+		list = func(prefix, cursor string, count int) ([]Item, string, error) {
+			return c.ItemsCtx(ctx, prefix, cursor, count)
+		}
+	}
+	return all(ctx, prefix, list)
+}
+
+// AllContainers iterates over the Containers in the Location whose
+// names start with prefix, as AllItems does over Items.
+// ContainersCtx is used when location is a ContextLocation.
+func AllContainers(ctx context.Context, location Location, prefix string) iter.Seq2[Container, error] {
+	list := location.Containers
+	if l, ok := location.(ContextLocation); ok {
+		list = func(prefix, cursor string, count int) ([]Container, string, error) {
+			return l.ContainersCtx(ctx, prefix, cursor, count)
+		}
+	}
+	return all(ctx, prefix, list)
+}
+
+// all iterates over the values listed by list, following the cursors
+// until the last page. It stops after a page fails, as the cursor of
+// the next one is unknown, and when a cursor is returned again, which
+// would list the same page forever.
+func all[T any](ctx context.Context, prefix string, list func(prefix, cursor string, count int) ([]T, string, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		cursor := CursorStart
+		for {
+			if err := ctx.Err(); err != nil {
+				yield(zero, err)
+				return
+			}
+			values, next, err := list(prefix, cursor, iterPageSize)
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			for _, value := range values {
+				if !yield(value, nil) {
+					return
+				}
+			}
+			if IsCursorEnd(next) || next == cursor {
+				return
+			}
+			cursor = next
+		}
+	}
+}
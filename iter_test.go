@@ -0,0 +1,111 @@
+//go:build go1.23
+
+package stow_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aldor007/stow"
+	"github.com/cheekybits/is"
+)
+
+// countingContainer is a memContainer that counts the pages listed,
+// failing them with err when it is set.
+type countingContainer struct {
+	*memContainer
+	pages int
+	err   error
+}
+
+func (c *countingContainer) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
+	c.pages++
+	if c.err != nil {
+		return nil, "", c.err
+	}
+	return c.memContainer.Items(prefix, cursor, count)
+}
+
+// containersLocation is a testLocation listing its containers a page
+// at a time.
+type containersLocation struct {
+	testLocation
+	containers []stow.Container
+}
+
+func (l *containersLocation) Containers(prefix string, cursor string, count int) ([]stow.Container, string, error) {
+	var start int
+	fmt.Sscan(cursor, &start)
+	end := start + count
+	if end >= len(l.containers) {
+		return l.containers[start:], "", nil
+	}
+	return l.containers[start:end], fmt.Sprint(end), nil
+}
+
+func TestAllItems(t *testing.T) {
+	is := is.New(t)
+	c := &countingContainer{memContainer: newMemContainer(true)}
+	for i := 0; i < 250; i++ {
+		_, err := c.Put(fmt.Sprintf("item%03d", i), strings.NewReader("item"), 4, nil)
+		is.NoErr(err)
+	}
+
+	var ids []string
+	for item, err := range stow.AllItems(context.Background(), c, stow.NoPrefix) {
+		is.NoErr(err)
+		ids = append(ids, item.ID())
+	}
+	is.Equal(len(ids), 250)
+	is.Equal(ids[0], "item000")
+	is.Equal(ids[249], "item249")
+	is.Equal(c.pages, 3)
+
+	// breaking out stops the listing
+	c.pages = 0
+	for range stow.AllItems(context.Background(), c, stow.NoPrefix) {
+		break
+	}
+	is.Equal(c.pages, 1)
+}
+
+func TestAllItemsError(t *testing.T) {
+	is := is.New(t)
+	failure := errors.New("failure")
+	c := &countingContainer{memContainer: newMemContainer(true), err: failure}
+
+	var errs []error
+	for item, err := range stow.AllItems(context.Background(), c, stow.NoPrefix) {
+		is.Nil(item)
+		errs = append(errs, err)
+	}
+	is.Equal(len(errs), 1)
+	is.Equal(errs[0], failure)
+	is.Equal(c.pages, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.err = nil
+	for _, err := range stow.AllItems(ctx, c, stow.NoPrefix) {
+		is.Equal(err, context.Canceled)
+	}
+}
+
+func TestAllContainers(t *testing.T) {
+	is := is.New(t)
+	l := &containersLocation{}
+	for i := 0; i < 150; i++ {
+		l.containers = append(l.containers, newMemContainer(true))
+	}
+
+	var n int
+	for container, err := range stow.AllContainers(context.Background(), l, stow.NoPrefix) {
+		is.NoErr(err)
+		is.OK(container)
+		n++
+	}
+	is.Equal(n, 150)
+}
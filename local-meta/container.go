@@ -2,11 +2,15 @@ package local_meta
 
 import (
 	"context"
+	"crypto/md5"
 	"errors"
 	"io"
+	"math/rand"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"bytes"
 	"encoding/binary"
 	"io/ioutil"
@@ -21,6 +25,23 @@ type container struct {
 	path string
 }
 
+var (
+	_ stow.Container              = (*container)(nil)
+	_ stow.ContextContainer       = (*container)(nil)
+	_ stow.CapabilityReporter     = (*container)(nil)
+	_ stow.Copier                 = (*container)(nil)
+	_ stow.ConditionalPutter      = (*container)(nil)
+	_ stow.ItemWriter             = (*container)(nil)
+	_ stow.DelimitedLister        = (*container)(nil)
+	_ stow.ContextDelimitedLister = (*container)(nil)
+	_ stow.MetadataSetter         = (*container)(nil)
+	_ stow.ContextMetadataSetter  = (*container)(nil)
+	_ stow.OptionsPutter          = (*container)(nil)
+	_ stow.ContextOptionsPutter   = (*container)(nil)
+	_ stow.Stater                 = (*container)(nil)
+	_ stow.ContextStater          = (*container)(nil)
+)
+
 func (c *container) ID() string {
 	return c.name
 }
@@ -42,18 +63,48 @@ func (c *container) CreateItem(name string) (stow.Item, io.WriteCloser, error) {
 		path: path,
 		name: name,
 	}
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return nil, nil, mapError(err)
+	}
 	f, err := os.Create(path)
 	if err != nil {
+		return nil, nil, mapError(err)
+	}
+	// Same empty metadata header as Put writes.
+	metaReader, err := prepareMetaReader(parseMetadata(nil))
+	if err == nil {
+		_, err = io.Copy(f, metaReader)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(path)
 		return nil, nil, err
 	}
 	return item, f, nil
 }
 
 func (c *container) RemoveItem(id string) error {
-	return os.RemoveAll(filepath.Join(c.path, id))
+	return c.RemoveItemCtx(context.Background(), id)
+}
+
+// RemoveItemCtx is RemoveItem with a context.
+func (c *container) RemoveItemCtx(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return mapError(os.RemoveAll(filepath.Join(c.path, id)))
 }
 
 func (c *container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.PutCtx(context.Background(), name, r, size, metadata)
+}
+
+// PutCtx is Put with a context. Canceling ctx stops reading from r
+// and removes the partially written file.
+func (c *container) PutCtx(ctx context.Context, name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	path := filepath.Join(c.path, name)
 	item := &item{
 		path: path,
@@ -69,7 +120,7 @@ func (c *container) Put(name string, r io.Reader, size int64, metadata map[strin
 	if size == 0  {
 		err := os.MkdirAll(path, 0777)
 		if err != nil {
-			return nil, err
+			return nil, mapError(err)
 		}
 		return item, nil
 	}
@@ -77,43 +128,251 @@ func (c *container) Put(name string, r io.Reader, size int64, metadata map[strin
 	dirPath := filepath.Dir(path)
 	err := os.MkdirAll(dirPath, 0777)
 	if err != nil {
-		return nil, err
+		return nil, mapError(err)
 	}
 
 
 	f, err := os.Create(path)
 	defer f.Close()
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	if err := writeItem(f, stow.ContextReader(ctx, r), size, metadata); err != nil {
+		defer os.Remove(path)
+		return nil, err
+	}
+	return item, nil
+}
+
+// PutWithOptions is Put with the content type and other headers kept
+// in the metadata under their lowercase names, and ContentMD5 checked
+// before the file is moved into place, so contents that don't match it
+// leave the item as it was. Storage classes, ACLs and tags are ignored.
+func (c *container) PutWithOptions(name string, r io.Reader, size int64, opts stow.PutOptions) (stow.Item, error) {
+	return c.PutWithOptionsCtx(context.Background(), name, r, size, opts)
+}
+
+// PutWithOptionsCtx is PutWithOptions with a context.
+func (c *container) PutWithOptionsCtx(ctx context.Context, name string, r io.Reader, size int64, opts stow.PutOptions) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	metadata := make(map[string]interface{}, len(opts.Metadata)+5)
+	for key, value := range opts.Metadata {
+		metadata[key] = value
+	}
+	for key, value := range map[string]string{
+		"content-type":        opts.ContentType,
+		"cache-control":       opts.CacheControl,
+		"content-disposition": opts.ContentDisposition,
+		"content-encoding":    opts.ContentEncoding,
+		"content-language":    opts.ContentLanguage,
+	} {
+		if value != "" {
+			metadata[key] = value
+		}
+	}
+
+	// Put stores empty items as directories, without their metadata.
+	if size == 0 {
+		if sum := md5.New().Sum(nil); len(opts.ContentMD5) > 0 && !bytes.Equal(sum, opts.ContentMD5) {
+			return nil, &stow.ChecksumError{Expected: opts.ContentMD5, Actual: sum}
+		}
+		return c.PutCtx(ctx, name, r, size, metadata)
+	}
+
+	path := filepath.Join(c.path, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return nil, mapError(err)
+	}
+	tmp, err := createTemp(c.path)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer os.Remove(tmp.Name())
+	hash := md5.New()
+	err = writeItem(tmp, io.TeeReader(stow.ContextReader(ctx, r), hash), size, metadata)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
 	if err != nil {
 		return nil, err
 	}
+	if sum := hash.Sum(nil); len(opts.ContentMD5) > 0 && !bytes.Equal(sum, opts.ContentMD5) {
+		return nil, &stow.ChecksumError{Expected: opts.ContentMD5, Actual: sum}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, mapError(err)
+	}
+	return &item{
+		path: path,
+		name: name,
+	}, nil
+}
 
+// writeItem writes the metadata header followed by the contents read
+// from r to f.
+func writeItem(f io.Writer, r io.Reader, size int64, metadata map[string]interface{}) error {
 	md := parseMetadata(metadata)
 	metaReader, err := prepareMetaReader(md)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	metaLen, err := io.Copy(f, metaReader)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	n, err := io.Copy(f, r)
 	if err != nil {
-		defer os.Remove(path)
+		return err
+	}
+	if size >= 0 && n != size {
+		return errors.New(fmt.Sprintf("bad size %d != %d %d", n, size, metaLen))
+	}
+	return nil
+}
+
+// PutIf is Put with a condition.
+// The item is written to a temporary file in the container, which is
+// then moved into place: with a hard link when the item must not
+// exist, as creating the link fails atomically if it does, and with a
+// rename otherwise. IfMatch is checked just before the rename, so unlike
+// IfNoneMatch it doesn't guard against concurrent writers outside of
+// this process.
+func (c *container) PutIf(name string, r io.Reader, size int64, metadata map[string]interface{}, cond stow.PutCondition) (stow.Item, error) {
+	if cond.IfNoneMatch && cond.IfMatch != "" {
+		return nil, stow.WrapError(stow.ErrPreconditionFailed, errors.New("an item can't match both IfMatch and IfNoneMatch"))
+	}
+
+	path := filepath.Join(c.path, name)
+	item := &item{
+		path: path,
+		name: name,
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return nil, mapError(err)
+	}
+
+	// Put stores empty items as directories.
+	if size == 0 {
+		if cond.IfNoneMatch {
+			if err := os.Mkdir(path, 0777); err != nil {
+				if os.IsExist(err) {
+					return nil, stow.WrapError(stow.ErrPreconditionFailed, err)
+				}
+				return nil, mapError(err)
+			}
+			return item, nil
+		}
+		if err := checkETag(path, cond.IfMatch); err != nil {
+			return nil, err
+		}
+		if err := os.MkdirAll(path, 0777); err != nil {
+			return nil, mapError(err)
+		}
+		return item, nil
+	}
+
+	tmp, err := createTemp(c.path)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer os.Remove(tmp.Name())
+	err = writeItem(tmp, r, size, metadata)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
 		return nil, err
 	}
-	if n != size {
-		defer os.Remove(path)
-		return nil, errors.New(fmt.Sprintf("bad size %d != %d %d", n, size, metaLen))
+
+	if cond.IfNoneMatch {
+		if err := os.Link(tmp.Name(), path); err != nil {
+			if os.IsExist(err) {
+				return nil, stow.WrapError(stow.ErrPreconditionFailed, err)
+			}
+			return nil, mapError(err)
+		}
+	} else {
+		if err := checkETag(path, cond.IfMatch); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			return nil, mapError(err)
+		}
 	}
 	return item, nil
 }
 
+// checkETag returns an error matching stow.ErrPreconditionFailed
+// unless the item at path exists with the given ETag. An empty etag
+// matches anything.
+func checkETag(path, etag string) error {
+	if etag == "" {
+		return nil
+	}
+	info, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return stow.WrapError(stow.ErrPreconditionFailed, err)
+		}
+		return mapError(err)
+	}
+	current := &item{path: path, info: info}
+	current.setMetadata(info)
+	if currentETag, _ := current.ETag(); currentETag != etag {
+		return stow.WrapError(stow.ErrPreconditionFailed, fmt.Errorf("etag %q does not match %q", currentETag, etag))
+	}
+	return nil
+}
+
+// tempDir is the directory of a container in which the contents of
+// items are staged before they are moved into place. It is left out
+// of listings, so the files of writes that failed, or of a process
+// that crashed, aren't listed as items.
+const tempDir = ".stow-tmp"
+
+// createTemp creates a new file in the staging directory of the
+// container at root to write the contents of an item to. Unlike
+// ioutil.TempFile the file gets the same permissions as one made by
+// os.Create.
+func createTemp(root string) (*os.File, error) {
+	dir := filepath.Join(root, tempDir)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	for i := 0; ; i++ {
+		name := filepath.Join(dir, fmt.Sprintf("%d-%d.tmp", os.Getpid(), rand.Uint32()))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) && i < 100 {
+			continue
+		}
+		return f, err
+	}
+}
+
 func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
-	files, err := flatdirs(filepath.Join(c.path, prefix))
+	return c.ItemsCtx(context.Background(), prefix, cursor, count)
+}
+
+// ItemsCtx is Items with a context.
+func (c *container) ItemsCtx(ctx context.Context, prefix, cursor string, count int) ([]stow.Item, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
+	files, err := flatdirs(c.path)
 	if err != nil {
 		return nil, "", err
 	}
+	matching := files[:0]
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), prefix) {
+			matching = append(matching, f)
+		}
+	}
+	files = matching
 
 	if cursor != stow.CursorStart {
 		// seek to the cursor
@@ -136,19 +395,12 @@ func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string
 		cursor = "" // end
 	}
 
-	lenFiles := len(files)
 	var items []stow.Item
 	for _, f := range files {
-
-		path, err := filepath.Abs(filepath.Join(c.path, prefix, f.Name()))
+		path, err := filepath.Abs(filepath.Join(c.path, f.Name()))
 		if err != nil {
 			return nil, "", err
 		}
-
-		if path == c.path && lenFiles != 1 {
-			continue
-		}
-
 		item := &item{
 			path: path,
 			name: f.Name(),
@@ -158,13 +410,102 @@ func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string
 	return items, cursor, nil
 }
 
+// ItemsDelimited lists the items like Items does, except for those
+// whose names contain delimiter after prefix, which are listed under
+// their common prefix. With "/" as the delimiter, this lists the files
+// and the directories of a directory; directories are listed as common
+// prefixes rather than items. The cursor is the name of the first item
+// or common prefix of the next page.
+func (c *container) ItemsDelimited(prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	return c.ItemsDelimitedCtx(context.Background(), prefix, delimiter, cursor, count)
+}
+
+// ItemsDelimitedCtx is ItemsDelimited with a context.
+func (c *container) ItemsDelimitedCtx(ctx context.Context, prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, "", err
+	}
+	files, err := flatdirs(c.path)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	// The files are sorted, so the files listed under a common
+	// prefix follow each other.
+	var names []string
+	commonPrefixes := make(map[string]bool)
+	for _, f := range files {
+		name := filepath.ToSlash(f.Name())
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if commonPrefix, ok := stow.CommonPrefix(name, prefix, delimiter); ok {
+			if !commonPrefixes[commonPrefix] {
+				commonPrefixes[commonPrefix] = true
+				names = append(names, commonPrefix)
+			}
+			continue
+		}
+		names = append(names, name)
+	}
+	if cursor != stow.CursorStart {
+		// seek to the cursor
+		ok := false
+		for i, name := range names {
+			if name == cursor {
+				names = names[i:]
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return nil, nil, "", stow.ErrBadCursor
+		}
+	}
+	cursor = "" // end
+	if len(names) > count {
+		cursor = names[count]
+		names = names[:count]
+	}
+
+	var items []stow.Item
+	var prefixes []string
+	for _, name := range names {
+		if commonPrefixes[name] {
+			prefixes = append(prefixes, name)
+			continue
+		}
+		path, err := filepath.Abs(filepath.Join(c.path, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, nil, "", err
+		}
+		items = append(items, &item{
+			path: path,
+			name: name,
+		})
+	}
+	return items, prefixes, cursor, nil
+}
+
 func (c *container) PreSignRequest(ctx context.Context, clientMethod stow.ClientMethod, id string,
 	params stow.PresignRequestParams) (url string, err error) {
 	return "", errors.New("not implemented")
 }
 
+// Capabilities describes the features supported by this container.
+func (c *container) Capabilities() stow.Capabilities {
+	return capabilities()
+}
+
 
 func (c *container) Item(id string) (stow.Item, error) {
+	return c.ItemCtx(context.Background(), id)
+}
+
+// ItemCtx is Item with a context.
+func (c *container) ItemCtx(ctx context.Context, id string) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	path := filepath.Join(c.path, id)
 	info, err := os.Stat(path)
 	if os.IsNotExist(err) {
@@ -172,7 +513,7 @@ func (c *container) Item(id string) (stow.Item, error) {
 	}
 
 	if err != nil {
-		return nil, err
+		return nil, mapError(err)
 	}
 
 	item := &item{
@@ -186,8 +527,32 @@ func (c *container) Item(id string) (stow.Item, error) {
 	return item, nil
 }
 
+// Stat gets the information of a file, which is read from the metadata
+// at its start.
+func (c *container) Stat(id string) (stow.ItemInfo, error) {
+	return c.StatCtx(context.Background(), id)
+}
+
+// StatCtx is Stat with a context.
+func (c *container) StatCtx(ctx context.Context, id string) (stow.ItemInfo, error) {
+	item, err := c.ItemCtx(ctx, id)
+	if err != nil {
+		return stow.ItemInfo{}, err
+	}
+	info, err := stow.NewItemInfo(item)
+	if err != nil {
+		return stow.ItemInfo{}, err
+	}
+	if info.ContentType == "" {
+		// Metadata written as HTTP headers has canonical keys.
+		info.ContentType, _ = info.Metadata["Content-Type"].(string)
+	}
+	return info, nil
+}
+
 // flatdirs walks the entire tree returning a list of
-// os.FileInfo for all items encountered.
+// os.FileInfo for all items encountered, sorted by name.
+// Directories are items too, whose names end with a slash.
 func flatdirs(path string) ([]os.FileInfo, error) {
 	var list []os.FileInfo
 
@@ -195,11 +560,18 @@ func flatdirs(path string) ([]os.FileInfo, error) {
 		if err != nil {
 			return err
 		}
-
+		if p == path {
+			// the container itself
+			return nil
+		}
 		flatname, err := filepath.Rel(path, p)
 		if err != nil {
 			return err
 		}
+		if info.IsDir() && flatname == tempDir {
+			// only the staging directory at the root is reserved
+			return filepath.SkipDir
+		}
 
 		if info.IsDir() {
 			flatname = flatname + "/"
@@ -213,6 +585,7 @@ func flatdirs(path string) ([]os.FileInfo, error) {
 	if err != nil {
 		return nil, err
 	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
 	return list, nil
 }
 
@@ -256,4 +629,134 @@ func prepareMetaReader(meta map[string]string)  (io.ReadCloser, error) {
 
 	metadata := ioutil.NopCloser(bytes.NewReader(bufMeta[:]))
 	return metadata, nil
-}
\ No newline at end of file
+}
+// Copy copies the file srcID to dstID in dstContainer, which must be
+// a local-meta container too.
+// When metadata is nil the file is copied as is, keeping the
+// metadata stored in its header; otherwise the contents are written
+// again after a new header.
+func (c *container) Copy(srcID string, dstContainer stow.Container, dstID string, metadata map[string]interface{}) (stow.Item, error) {
+	dst, ok := dstContainer.(*container)
+	if !ok {
+		return nil, stow.NotSupported("copy to another kind of container")
+	}
+	srcPath := filepath.Join(c.path, srcID)
+	dstPath := filepath.Join(dst.path, dstID)
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	if info.IsDir() {
+		// Put stores empty items as directories.
+		return dst.Put(dstID, bytes.NewReader(nil), 0, metadata)
+	}
+	if metadata != nil {
+		return c.copyContents(srcID, dst, dstID, metadata, srcPath == dstPath)
+	}
+	if srcPath == dstPath {
+		return dst.Item(dstID)
+	}
+
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer src.Close()
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0777); err != nil {
+		return nil, mapError(err)
+	}
+	f, err := os.Create(dstPath)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		os.Remove(dstPath)
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return dst.Item(dstID)
+}
+
+// copyContents copies the contents of srcID to dst with Put, so the
+// copy gets a new metadata header.
+func (c *container) copyContents(srcID string, dst *container, dstID string, metadata map[string]interface{}, inPlace bool) (stow.Item, error) {
+	i, err := c.Item(srcID)
+	if err != nil {
+		return nil, err
+	}
+	// Size is only known once the header has been read.
+	r, err := i.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	size, err := i.Size()
+	if err != nil {
+		return nil, err
+	}
+	var contents io.Reader = r
+	if inPlace {
+		// Put truncates the file before writing it again.
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		contents = bytes.NewReader(b)
+	}
+	return dst.Put(dstID, contents, size, metadata)
+}
+
+// SetMetadata rewrites the metadata header of the file. The file is
+// written again to a temporary file in the container, which then
+// replaces it. Empty items are directories, which have no metadata header.
+func (c *container) SetMetadata(id string, metadata map[string]interface{}, replace bool) error {
+	return c.SetMetadataCtx(context.Background(), id, metadata, replace)
+}
+
+// SetMetadataCtx is SetMetadata with a context.
+func (c *container) SetMetadataCtx(ctx context.Context, id string, metadata map[string]interface{}, replace bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path := filepath.Join(c.path, id)
+	info, err := os.Stat(path)
+	if err != nil {
+		return mapError(err)
+	}
+	if info.IsDir() {
+		return stow.NotSupported("metadata of empty items")
+	}
+	i := &item{path: path, name: id}
+	r, err := i.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	size, err := i.Size()
+	if err != nil {
+		return err
+	}
+
+	md := make(map[string]interface{}, len(i.properties))
+	if !replace {
+		for k, v := range i.properties {
+			md[k] = v
+		}
+	}
+	tmp, err := createTemp(c.path)
+	if err != nil {
+		return mapError(err)
+	}
+	defer os.Remove(tmp.Name())
+	err = writeItem(tmp, stow.ContextReader(ctx, r), size, stow.MergeMetadata(md, metadata))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return mapError(os.Rename(tmp.Name(), path))
+}
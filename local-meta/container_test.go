@@ -1,13 +1,17 @@
 package local_meta_test
 
 import (
+	"crypto/md5"
+	"errors"
 	"fmt"
+	"io"
 	"strings"
 	"testing"
 
 	"github.com/cheekybits/is"
 	"github.com/aldor007/stow"
 	"github.com/aldor007/stow/local"
+	local_meta "github.com/aldor007/stow/local-meta"
 )
 
 func TestItemsPaging(t *testing.T) {
@@ -64,3 +68,201 @@ func TestItemsPaging(t *testing.T) {
 	is.NoErr(err)
 	is.OK(item)
 }
+
+func TestCopy(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+	cfg := stow.ConfigMap{"path": testDir}
+	l, err := stow.Dial(local_meta.Kind, cfg)
+	is.NoErr(err)
+	is.OK(l)
+
+	src, err := l.Container("one")
+	is.NoErr(err)
+	dst, err := l.Container("three")
+	is.NoErr(err)
+
+	_, err = src.Put("item", strings.NewReader(`item`), 4, map[string]interface{}{"key": "value"})
+	is.NoErr(err)
+
+	copied, err := stow.Copy(src, "item", dst, "copy", nil)
+	is.NoErr(err)
+	is.Equal(readItemContents(is, copied), "item")
+	md, err := copied.Metadata()
+	is.NoErr(err)
+	is.Equal(md["key"], "value")
+
+	copied, err = stow.Copy(src, "item", dst, "replaced", map[string]interface{}{"other": "value"})
+	is.NoErr(err)
+	is.Equal(readItemContents(is, copied), "item")
+	md, err = copied.Metadata()
+	is.NoErr(err)
+	is.Equal(md["other"], "value")
+	is.Nil(md["key"])
+
+	// replacing the metadata of the item itself
+	copied, err = stow.Copy(src, "item", src, "item", map[string]interface{}{"other": "value"})
+	is.NoErr(err)
+	is.Equal(readItemContents(is, copied), "item")
+	md, err = copied.Metadata()
+	is.NoErr(err)
+	is.Equal(md["other"], "value")
+}
+
+func TestSetMetadata(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+	cfg := stow.ConfigMap{"path": testDir}
+	l, err := stow.Dial(local_meta.Kind, cfg)
+	is.NoErr(err)
+	is.OK(l)
+
+	c, err := l.Container("one")
+	is.NoErr(err)
+	setter := c.(stow.MetadataSetter)
+	_, err = c.Put("item", strings.NewReader(`item`), 4, map[string]interface{}{"key": "value", "other": "value"})
+	is.NoErr(err)
+
+	is.NoErr(setter.SetMetadata("item", map[string]interface{}{"key": "changed", "other": nil}, false))
+	item, err := c.Item("item")
+	is.NoErr(err)
+	is.Equal(readItemContents(is, item), "item")
+	md, err := item.Metadata()
+	is.NoErr(err)
+	is.Equal(md["key"], "changed")
+	is.Nil(md["other"])
+
+	is.NoErr(setter.SetMetadata("item", map[string]interface{}{"new": "value"}, true))
+	item, err = c.Item("item")
+	is.NoErr(err)
+	is.Equal(readItemContents(is, item), "item")
+	md, err = item.Metadata()
+	is.NoErr(err)
+	is.Equal(md["new"], "value")
+	is.Nil(md["key"])
+
+	err = setter.SetMetadata("missing", map[string]interface{}{"key": "value"}, false)
+	is.True(errors.Is(err, stow.ErrNotFound))
+}
+
+func TestPutWithOptions(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+	cfg := stow.ConfigMap{"path": testDir}
+	l, err := stow.Dial(local_meta.Kind, cfg)
+	is.NoErr(err)
+	is.OK(l)
+
+	c, err := l.Container("one")
+	is.NoErr(err)
+	sum := md5.Sum([]byte("item"))
+	_, err = stow.PutWithOptions(c, "item", strings.NewReader("item"), 4, stow.PutOptions{
+		ContentType: "text/plain",
+		ContentMD5:  sum[:],
+		Metadata:    map[string]string{"key": "value"},
+	})
+	is.NoErr(err)
+	item, err := c.Item("item")
+	is.NoErr(err)
+	is.Equal(readItemContents(is, item), "item")
+	md, err := item.Metadata()
+	is.NoErr(err)
+	is.Equal(md["content-type"], "text/plain")
+	is.Equal(md["key"], "value")
+
+	// contents that don't match are not kept
+	_, err = stow.PutWithOptions(c, "bad", strings.NewReader("bad"), 3, stow.PutOptions{ContentMD5: sum[:]})
+	is.True(errors.Is(err, stow.ErrPreconditionFailed))
+	var checksumErr *stow.ChecksumError
+	is.True(errors.As(err, &checksumErr))
+	is.Equal(checksumErr.Expected, sum[:])
+	_, err = c.Item("bad")
+	is.True(errors.Is(err, stow.ErrNotFound))
+
+	// nor do they replace the item
+	_, err = stow.PutWithOptions(c, "item", strings.NewReader("other"), 5, stow.PutOptions{ContentMD5: sum[:]})
+	is.True(errors.Is(err, stow.ErrPreconditionFailed))
+	item, err = c.Item("item")
+	is.NoErr(err)
+	is.Equal(readItemContents(is, item), "item")
+}
+
+func TestPutUnknownSize(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+	cfg := stow.ConfigMap{"path": testDir}
+	l, err := stow.Dial(local_meta.Kind, cfg)
+	is.NoErr(err)
+	is.OK(l)
+
+	containers, _, err := l.Containers("", stow.CursorStart, 10)
+	is.NoErr(err)
+	c := containers[1]
+
+	r := io.MultiReader(strings.NewReader("unknown "), strings.NewReader("size"))
+	item, err := c.Put("unknown", r, -1, nil)
+	is.NoErr(err)
+	size, err := item.Size()
+	is.NoErr(err)
+	is.Equal(size, int64(12))
+	is.Equal(readItemContents(is, item), "unknown size")
+
+	// known sizes are still checked
+	_, err = c.Put("short", strings.NewReader("short"), 10, nil)
+	is.Err(err)
+}
+
+func TestPutIf(t *testing.T) {
+	is := is.New(t)
+	cfg := stow.ConfigMap{"path": t.TempDir()}
+	l, err := stow.Dial(local_meta.Kind, cfg)
+	is.NoErr(err)
+	is.OK(l)
+
+	container, err := l.CreateContainer("one")
+	is.NoErr(err)
+	putter := container.(stow.ConditionalPutter)
+
+	md := map[string]interface{}{"key": "value"}
+	_, err = putter.PutIf("item", strings.NewReader(`item`), 4, md, stow.PutCondition{IfNoneMatch: true})
+	is.NoErr(err)
+	item, err := container.Item("item")
+	is.NoErr(err)
+	is.Equal(readItemContents(is, item), "item")
+	etag, err := item.ETag()
+	is.NoErr(err)
+
+	_, err = putter.PutIf("item", strings.NewReader(`other`), 5, nil, stow.PutCondition{IfNoneMatch: true})
+	is.True(errors.Is(err, stow.ErrPreconditionFailed))
+	_, err = putter.PutIf("item", strings.NewReader(`other`), 5, nil, stow.PutCondition{IfMatch: "nope"})
+	is.True(errors.Is(err, stow.ErrPreconditionFailed))
+
+	_, err = putter.PutIf("item", strings.NewReader(`updated`), 7, md, stow.PutCondition{IfMatch: etag})
+	is.NoErr(err)
+	item, err = container.Item("item")
+	is.NoErr(err)
+	is.Equal(readItemContents(is, item), "updated")
+	itemMd, err := item.Metadata()
+	is.NoErr(err)
+	is.Equal(itemMd["key"], "value")
+
+	// only the staging directory at the root is left out of listings
+	_, err = container.Put("a/.stow-tmp/file", strings.NewReader(`file`), 4, nil)
+	is.NoErr(err)
+	items, _, err := container.Items(stow.NoPrefix, stow.CursorStart, 10)
+	is.NoErr(err)
+	var ids []string
+	for _, item := range items {
+		is.False(strings.HasPrefix(item.ID(), ".stow-tmp"))
+		ids = append(ids, item.ID())
+	}
+	is.True(strings.Contains(strings.Join(ids, " "), "a/.stow-tmp/file"))
+}
@@ -0,0 +1,31 @@
+package local_meta
+
+import (
+	"errors"
+	"os"
+	"syscall"
+
+	"github.com/aldor007/stow"
+)
+
+// mapError maps filesystem errors to the error kinds declared by stow.
+// The original error is kept in the chain.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch {
+	// syscall.ENOTEMPTY also matches os.ErrExist, so check it first.
+	case errors.Is(err, syscall.ENOTEMPTY):
+		return stow.WrapError(stow.ErrContainerNotEmpty, err)
+	case errors.Is(err, os.ErrNotExist):
+		return stow.WrapError(stow.ErrNotFound, err)
+	case errors.Is(err, os.ErrExist):
+		return stow.WrapError(stow.ErrAlreadyExists, err)
+	case errors.Is(err, os.ErrPermission):
+		return stow.WrapError(stow.ErrPermissionDenied, err)
+	case errors.Is(err, syscall.ENAMETOOLONG), errors.Is(err, syscall.EINVAL):
+		return stow.WrapError(stow.ErrInvalidName, err)
+	}
+	return err
+}
@@ -1,6 +1,7 @@
 package local_meta
 
 import (
+	"context"
 	"io"
 	"net/url"
 	"net/http"
@@ -14,10 +15,16 @@ import (
 	"github.com/vmihailenco/msgpack"
 	"crypto/md5"
 	"encoding/hex"
+	"fmt"
 	"github.com/aldor007/stow"
 )
 
-var _ stow.Item = (*item)(nil)
+var (
+	_ stow.Item                 = (*item)(nil)
+	_ stow.ContextItem          = (*item)(nil)
+	_ stow.OptionsOpener        = (*item)(nil)
+	_ stow.ContextOptionsOpener = (*item)(nil)
+)
 
 // Metadata constants describe the metadata available
 // for a local Item.
@@ -91,9 +98,18 @@ func (i *item) ETag() (string, error) {
 
 // Open opens the file for reading.
 func (i *item) Open() (io.ReadCloser, error) {
+	return i.OpenCtx(context.Background())
+}
+
+// OpenCtx is Open with a context. ctx is only consulted before the
+// file is opened, so the returned file keeps its io.Seeker support.
+func (i *item) OpenCtx(ctx context.Context) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	r, err := os.Open(i.path)
 	if err != nil {
-		return nil, err
+		return nil, mapError(err)
 	}
 
 	var bufMeta [3]byte
@@ -163,6 +179,59 @@ func (i *item) OpenParams(_ map[string]interface{}) (io.ReadCloser, error) {
 	return i.Open()
 }
 
+// OpenWithOptions opens the file as described by opts. The conditions
+// are checked against the ETag and last modification time of the
+// item, and ranges are read by seeking past the metadata of the file.
+// Versions aren't supported and the response overrides are ignored.
+func (i *item) OpenWithOptions(opts stow.OpenOptions) (io.ReadCloser, error) {
+	return i.OpenWithOptionsCtx(context.Background(), opts)
+}
+
+// OpenWithOptionsCtx is OpenWithOptions with a context.
+func (i *item) OpenWithOptionsCtx(ctx context.Context, opts stow.OpenOptions) (io.ReadCloser, error) {
+	if opts.VersionID != "" {
+		return nil, stow.NotSupported("opening versions")
+	}
+	r, err := i.OpenCtx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if opts.IfNoneMatch != "" || !opts.IfModifiedSince.IsZero() {
+		etag, err := i.ETag()
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		lastMod, err := i.LastMod()
+		if err != nil {
+			r.Close()
+			return nil, err
+		}
+		if opts.NotModified(etag, lastMod) {
+			r.Close()
+			return nil, stow.ErrNotModified
+		}
+	}
+	if opts.Range == nil {
+		return r, nil
+	}
+	size, err := i.Size()
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	offset, length, ok := opts.Range.Offsets(size)
+	if !ok {
+		r.Close()
+		return nil, fmt.Errorf("%s: %w", opts.Range, stow.ErrInvalidRange)
+	}
+	if _, err := r.(io.Seeker).Seek(offset, io.SeekStart); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return stow.LimitReadCloser(r, length), nil
+}
+
 
 func (i *item) ContentRange() (stow.ContentRangeData, error) {
 	return stow.ContentRangeData{}, errors.New("not implemented")
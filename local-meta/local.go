@@ -52,4 +52,8 @@ func init() {
 		return u.Scheme == "file-meta"
 	}
 	stow.Register(Kind, makefn, kindfn, validatefn)
+	stow.RegisterDSN(Kind, stow.DSN{Path: ConfigKeyPath})
+	stow.RegisterSchema(Kind, stow.Schema{Keys: []stow.KeySchema{
+		{Name: ConfigKeyPath, Required: true, Description: "The directory the containers are in."},
+	}})
 }
@@ -1,6 +1,7 @@
 package local_meta
 
 import (
+	"context"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -15,6 +16,13 @@ type location struct {
 	config stow.Config
 }
 
+var (
+	_ stow.Location           = (*location)(nil)
+	_ stow.ContextLocation    = (*location)(nil)
+	_ stow.CapabilityReporter = (*location)(nil)
+	_ stow.URLParser          = (*location)(nil)
+)
+
 func (l *location) Close() error {
 	return nil // nothing to close
 }
@@ -24,52 +32,115 @@ func (l *location) HasRanges() bool {
 	return false
 }
 
-func (l *location) ItemByURL(u *url.URL) (stow.Item, error) {
-	rootPath, ok := l.config.Config(ConfigKeyPath)
-	if !ok {
-		return nil, errors.New("missing " + ConfigKeyPath + " configuration")
-	}
+// Capabilities describes the features supported by this location.
+func (l *location) Capabilities() stow.Capabilities {
+	return capabilities()
+}
 
-	cleanRootPath := filepath.Clean(rootPath)
-	rootPathLen := len(cleanRootPath)
-	if len(u.Path) < rootPathLen {
-		return nil, errors.New("Url is too short")
+// capabilities describes the features supported by the local filesystem with metadata.
+func capabilities() stow.Capabilities {
+	return stow.Capabilities{
+		Metadata:          true,
+		ServerSideCopy:    true,
+		Listing:           true,
+		Write:             true,
+		Delete:            true,
+		ConditionalWrites: true,
+		DelimitedListing:  true,
+		MetadataUpdates:   true,
 	}
+}
 
-	rootIndex := strings.Index(u.Path, cleanRootPath)
-	path := u.Path[rootIndex + rootPathLen + 1:]
+func (l *location) ItemByURL(u *url.URL) (stow.Item, error) {
+	return l.ItemByURLCtx(context.Background(), u)
+}
 
-	urlParts := strings.Split(path, "/")
-	if len(urlParts) < 2 {
-		return nil, errors.New("parsing ItemByURL URL")
+// ItemByURLCtx is ItemByURL with a context.
+func (l *location) ItemByURLCtx(ctx context.Context, u *url.URL) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	containerName, itemName, err := l.ParseURL(u)
+	if err != nil {
+		return nil, err
 	}
-	containerName := urlParts[0]
-	itemName := strings.Join(urlParts[1:], "/")
 
-	c, err := l.Container(containerName)
+	c, err := l.ContainerCtx(ctx, containerName)
 	if err != nil {
 		return nil, errors.Wrapf(err, "ItemByURL, getting container by the name %s", containerName)
 	}
 
-	i, err := c.Item(itemName)
+	i, err := c.(*container).ItemCtx(ctx, itemName)
 	if err != nil {
 		return nil, errors.Wrapf(err, "ItemByURL, getting item by object name %s", itemName)
 	}
 	return i, nil
 }
 
+// ParseURL gets the container and the name of the item a URL points
+// to. The path of URLs is the path of the file, whose first directory
+// under the root path is the container.
+func (l *location) ParseURL(u *url.URL) (string, string, error) {
+	rootPath, ok := l.config.Config(ConfigKeyPath)
+	if !ok {
+		return "", "", errors.New("missing " + ConfigKeyPath + " configuration")
+	}
+
+	cleanRootPath := filepath.Clean(rootPath)
+	rootPathLen := len(cleanRootPath)
+	rootIndex := strings.Index(u.Path, cleanRootPath)
+	if rootIndex < 0 || len(u.Path) <= rootIndex+rootPathLen+1 {
+		return "", "", errors.New("Url is too short")
+	}
+	path := u.Path[rootIndex+rootPathLen+1:]
+
+	urlParts := strings.Split(path, "/")
+	if len(urlParts) < 2 {
+		return "", "", errors.New("parsing ItemByURL URL")
+	}
+	return urlParts[0], strings.Join(urlParts[1:], "/"), nil
+}
+
 func (l *location) RemoveContainer(id string) error {
-	return os.RemoveAll(id)
+	return l.RemoveContainerCtx(context.Background(), id)
+}
+
+// RemoveContainerCtx is RemoveContainer with a context.
+func (l *location) RemoveContainerCtx(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	path, ok := l.config.Config(ConfigKeyPath)
+	if !ok {
+		return errors.New("missing " + ConfigKeyPath + " configuration")
+	}
+	if !filepath.IsAbs(id) {
+		id = filepath.Join(path, id)
+	}
+	// the files staged by failed writes don't keep it from being
+	// empty
+	if err := os.RemoveAll(filepath.Join(id, tempDir)); err != nil {
+		return mapError(err)
+	}
+	return mapError(os.Remove(id))
 }
 
 func (l *location) CreateContainer(name string) (stow.Container, error) {
+	return l.CreateContainerCtx(context.Background(), name)
+}
+
+// CreateContainerCtx is CreateContainer with a context.
+func (l *location) CreateContainerCtx(ctx context.Context, name string) (stow.Container, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	path, ok := l.config.Config(ConfigKeyPath)
 	if !ok {
 		return nil, errors.New("missing " + ConfigKeyPath + " configuration")
 	}
 	fullpath := filepath.Join(path, name)
 	if err := os.Mkdir(fullpath, 0777); err != nil {
-		return nil, err
+		return nil, mapError(err)
 	}
 	abspath, err := filepath.Abs(fullpath)
 	if err != nil {
@@ -84,6 +155,14 @@ func (l *location) CreateContainer(name string) (stow.Container, error) {
 }
 
 func (l *location) Containers(prefix string, cursor string, count int) ([]stow.Container, string, error) {
+	return l.ContainersCtx(context.Background(), prefix, cursor, count)
+}
+
+// ContainersCtx is Containers with a context.
+func (l *location) ContainersCtx(ctx context.Context, prefix string, cursor string, count int) ([]stow.Container, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
 	path, ok := l.config.Config(ConfigKeyPath)
 	if !ok {
 		return nil, "", errors.New("missing " + ConfigKeyPath + " configuration")
@@ -136,6 +215,14 @@ func (l *location) Containers(prefix string, cursor string, count int) ([]stow.C
 }
 
 func (l *location) Container(id string) (stow.Container, error) {
+	return l.ContainerCtx(context.Background(), id)
+}
+
+// ContainerCtx is Container with a context.
+func (l *location) ContainerCtx(ctx context.Context, id string) (stow.Container, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	path, ok := l.config.Config(ConfigKeyPath)
 	if !ok {
 		return nil, errors.New("missing " + ConfigKeyPath + " configuration")
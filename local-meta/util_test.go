@@ -177,3 +177,12 @@ func isDir(is is.I, path string) {
 	is.NoErr(err)
 	is.True(info.IsDir())
 }
+
+func readItemContents(is is.I, item stow.Item) string {
+	r, err := item.Open()
+	is.NoErr(err)
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	is.NoErr(err)
+	return string(b)
+}
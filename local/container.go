@@ -1,13 +1,17 @@
 package local
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
 	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/aldor007/stow"
@@ -19,6 +23,21 @@ type container struct {
 	allowMetadata bool
 }
 
+var (
+	_ stow.Container              = (*container)(nil)
+	_ stow.ContextContainer       = (*container)(nil)
+	_ stow.CapabilityReporter     = (*container)(nil)
+	_ stow.Copier                 = (*container)(nil)
+	_ stow.ConditionalPutter      = (*container)(nil)
+	_ stow.ItemWriter             = (*container)(nil)
+	_ stow.DelimitedLister        = (*container)(nil)
+	_ stow.ContextDelimitedLister = (*container)(nil)
+	_ stow.OptionsPutter          = (*container)(nil)
+	_ stow.ContextOptionsPutter   = (*container)(nil)
+	_ stow.Stater                 = (*container)(nil)
+	_ stow.ContextStater          = (*container)(nil)
+)
+
 func (c *container) ID() string {
 	return c.name
 }
@@ -39,6 +58,11 @@ func (c *container) PreSignRequest(_ context.Context, _ stow.ClientMethod, _ str
 	return "", fmt.Errorf("unsupported")
 }
 
+// Capabilities describes the features supported by this container.
+func (c *container) Capabilities() stow.Capabilities {
+	return capabilities()
+}
+
 func (c *container) CreateItem(name string) (stow.Item, io.WriteCloser, error) {
 	path := filepath.Join(c.path, filepath.FromSlash(name))
 	item := &item{
@@ -46,18 +70,38 @@ func (c *container) CreateItem(name string) (stow.Item, io.WriteCloser, error) {
 		name:          name,
 		contPrefixLen: len(c.path) + 1,
 	}
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return nil, nil, mapError(err)
+	}
 	f, err := os.Create(path)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, mapError(err)
 	}
 	return item, f, nil
 }
 
 func (c *container) RemoveItem(id string) error {
-	return os.Remove(filepath.Join(c.path, id))
+	return c.RemoveItemCtx(context.Background(), id)
+}
+
+// RemoveItemCtx is RemoveItem with a context.
+func (c *container) RemoveItemCtx(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return mapError(os.Remove(filepath.Join(c.path, id)))
 }
 
 func (c *container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.PutCtx(context.Background(), name, r, size, metadata)
+}
+
+// PutCtx is Put with a context. Canceling ctx stops reading from r
+// and removes the partially written file.
+func (c *container) PutCtx(ctx context.Context, name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	if c.allowMetadata == false && len(metadata) > 0 {
 		return nil, stow.NotSupported("metadata")
 	}
@@ -70,29 +114,95 @@ func (c *container) Put(name string, r io.Reader, size int64, metadata map[strin
 	}
 	err := os.MkdirAll(filepath.Dir(path), 0777)
 	if err != nil {
-		return nil, err
+		return nil, mapError(err)
 	}
 	f, err := os.Create(path)
 	if err != nil {
-		return nil, err
+		return nil, mapError(err)
 	}
 	defer f.Close()
-	n, err := io.Copy(f, r)
+	n, err := io.Copy(f, stow.ContextReader(ctx, r))
 	if err != nil {
+		defer os.Remove(path)
 		return nil, err
 	}
-	if n != size {
+	if size >= 0 && n != size {
 		return nil, errors.New("bad size")
 	}
 	return item, nil
 }
 
+// PutWithOptions is Put with ContentMD5 checked before the file is
+// moved into place, so contents that don't match it leave the item as
+// it was. Files have no headers, storage classes, ACLs or tags, so
+// those options are ignored.
+func (c *container) PutWithOptions(name string, r io.Reader, size int64, opts stow.PutOptions) (stow.Item, error) {
+	return c.PutWithOptionsCtx(context.Background(), name, r, size, opts)
+}
+
+// PutWithOptionsCtx is PutWithOptions with a context.
+func (c *container) PutWithOptionsCtx(ctx context.Context, name string, r io.Reader, size int64, opts stow.PutOptions) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if c.allowMetadata == false && len(opts.Metadata) > 0 {
+		return nil, stow.NotSupported("metadata")
+	}
+
+	path := filepath.Join(c.path, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return nil, mapError(err)
+	}
+	tmp, err := createTemp(c.path)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer os.Remove(tmp.Name())
+	hash := md5.New()
+	n, err := io.Copy(io.MultiWriter(tmp, hash), stow.ContextReader(ctx, r))
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 && n != size {
+		return nil, errors.New("bad size")
+	}
+	if sum := hash.Sum(nil); len(opts.ContentMD5) > 0 && !bytes.Equal(sum, opts.ContentMD5) {
+		return nil, &stow.ChecksumError{Expected: opts.ContentMD5, Actual: sum}
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, mapError(err)
+	}
+	return &item{
+		path:          path,
+		name:          name,
+		contPrefixLen: len(c.path) + 1,
+	}, nil
+}
+
 func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
-	prefix = filepath.FromSlash(prefix)
+	return c.ItemsCtx(context.Background(), prefix, cursor, count)
+}
+
+// ItemsCtx is Items with a context.
+func (c *container) ItemsCtx(ctx context.Context, prefix, cursor string, count int) ([]stow.Item, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
 	files, err := flatdirs(c.path)
 	if err != nil {
 		return nil, "", err
 	}
+	prefix = filepath.FromSlash(prefix)
+	matching := files[:0]
+	for _, f := range files {
+		if strings.HasPrefix(f.Name(), prefix) {
+			matching = append(matching, f)
+		}
+	}
+	files = matching
 	if cursor != stow.CursorStart {
 		// seek to the cursor
 		ok := false
@@ -114,16 +224,12 @@ func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string
 		cursor = "" // end
 	}
 
-	files = files[1:]
 	var items []stow.Item
 	for _, f := range files {
 		path, err := filepath.Abs(filepath.Join(c.path, f.Name()))
 		if err != nil {
 			return nil, "", err
 		}
-		if !strings.HasPrefix(f.Name(), prefix) {
-			continue
-		}
 		item := &item{
 			path:          path,
 			name:          f.Name(),
@@ -134,32 +240,156 @@ func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string
 	return items, cursor, nil
 }
 
+// ItemsDelimited lists the files like Items does, except for those
+// whose names contain delimiter after prefix, which are listed under
+// their common prefix. With "/" as the delimiter, this lists the files
+// and the directories of a directory. Directories are only listed when
+// they hold files. The cursor is the name of the first file or common
+// prefix of the next page.
+func (c *container) ItemsDelimited(prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	return c.ItemsDelimitedCtx(context.Background(), prefix, delimiter, cursor, count)
+}
+
+// ItemsDelimitedCtx is ItemsDelimited with a context.
+func (c *container) ItemsDelimitedCtx(ctx context.Context, prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, "", err
+	}
+	files, err := flatdirs(c.path)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	// The files are sorted, so the files listed under a common
+	// prefix follow each other.
+	var names []string
+	commonPrefixes := make(map[string]bool)
+	for _, f := range files {
+		name := filepath.ToSlash(f.Name())
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		if commonPrefix, ok := stow.CommonPrefix(name, prefix, delimiter); ok {
+			if !commonPrefixes[commonPrefix] {
+				commonPrefixes[commonPrefix] = true
+				names = append(names, commonPrefix)
+			}
+			continue
+		}
+		names = append(names, name)
+	}
+	if cursor != stow.CursorStart {
+		// seek to the cursor
+		ok := false
+		for i, name := range names {
+			if name == cursor {
+				names = names[i:]
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			return nil, nil, "", stow.ErrBadCursor
+		}
+	}
+	cursor = "" // end
+	if len(names) > count {
+		cursor = names[count]
+		names = names[:count]
+	}
+
+	var items []stow.Item
+	var prefixes []string
+	for _, name := range names {
+		if commonPrefixes[name] {
+			prefixes = append(prefixes, name)
+			continue
+		}
+		path, err := filepath.Abs(filepath.Join(c.path, filepath.FromSlash(name)))
+		if err != nil {
+			return nil, nil, "", err
+		}
+		items = append(items, &item{
+			path:          path,
+			name:          name,
+			contPrefixLen: len(c.path) + 1,
+		})
+	}
+	return items, prefixes, cursor, nil
+}
+
 func (c *container) Item(id string) (stow.Item, error) {
+	return c.ItemCtx(context.Background(), id)
+}
+
+// ItemCtx is Item with a context.
+func (c *container) ItemCtx(ctx context.Context, id string) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	path, _, err := c.stat(id)
+	if err != nil {
+		return nil, err
+	}
+	item := &item{
+		path:          path,
+		name:          id,
+		contPrefixLen: len(c.path) + 1,
+	}
+	return item, nil
+}
+
+// Stat gets the information of a file with a single stat call.
+func (c *container) Stat(id string) (stow.ItemInfo, error) {
+	return c.StatCtx(context.Background(), id)
+}
+
+// StatCtx is Stat with a context.
+func (c *container) StatCtx(ctx context.Context, id string) (stow.ItemInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return stow.ItemInfo{}, err
+	}
+	path, info, err := c.stat(id)
+	if err != nil {
+		return stow.ItemInfo{}, err
+	}
+	return stow.ItemInfo{
+		ID:       id,
+		Name:     filepath.ToSlash(path[len(c.path)+1:]),
+		Size:     info.Size(),
+		ETag:     info.ModTime().String(),
+		LastMod:  info.ModTime(),
+		Metadata: getFileMetadata(path, info),
+	}, nil
+}
+
+// stat gets the path and the info of the file with the specified ID.
+func (c *container) stat(id string) (string, os.FileInfo, error) {
 	path := filepath.Join(c.path, id)
 	if !filepath.IsAbs(id) {
 		path = filepath.Join(c.path, filepath.FromSlash(id))
 	}
 	info, err := os.Stat(path)
 	if os.IsNotExist(err) {
-		return nil, stow.ErrNotFound
+		return "", nil, stow.ErrNotFound
+	}
+	if err != nil {
+		return "", nil, mapError(err)
 	}
 	if info.IsDir() {
-		return nil, errors.New("unexpected directory")
+		return "", nil, errors.New("unexpected directory")
 	}
 	_, err = filepath.Rel(c.path, path)
 	if err != nil {
-		return nil, err
+		return "", nil, err
 	}
-	item := &item{
-		path:          path,
-		name:          id,
-		contPrefixLen: len(c.path) + 1,
-	}
-	return item, nil
+	return path, info, nil
 }
 
 // flatdirs walks the entire tree returning a list of
-// os.FileInfo for all items encountered.
+// os.FileInfo for all files encountered, sorted by name.
+// Directories are left out: they are only listed through the files
+// they hold. So are the directories the container reserves at its
+// root, but not the directories of the same names deeper in the tree.
 func flatdirs(path string) ([]os.FileInfo, error) {
 	var list []os.FileInfo
 	err := filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
@@ -170,9 +400,11 @@ func flatdirs(path string) ([]os.FileInfo, error) {
 		if err != nil {
 			return err
 		}
-
 		if info.IsDir() {
-			flatname = flatname + "/"
+			if flatname == uploadsDir || flatname == tempDir {
+				return filepath.SkipDir
+			}
+			return nil
 		}
 		list = append(list, fileinfo{
 			FileInfo: info,
@@ -183,6 +415,9 @@ func flatdirs(path string) ([]os.FileInfo, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Walk visits the files of a directory before the files next to
+	// it whose names sort before them, such as "a/b" before "a-b".
+	sort.Slice(list, func(i, j int) bool { return list[i].Name() < list[j].Name() })
 	return list, nil
 }
 
@@ -194,3 +429,155 @@ type fileinfo struct {
 func (f fileinfo) Name() string {
 	return f.name
 }
+
+// Copy copies the file srcID to dstID in dstContainer, which must be
+// a local container too.
+// The contents are copied with io.Copy, which lets the kernel copy
+// them (copy_file_range) where supported. Hard links aren't used:
+// Put rewrites files in place, so a change to one item would show up
+// in the other.
+// Files have no metadata of their own to replace, so a non nil
+// metadata makes it return an error satisfying stow.IsNotSupported,
+// even when allowMetadata is set.
+func (c *container) Copy(srcID string, dstContainer stow.Container, dstID string, metadata map[string]interface{}) (stow.Item, error) {
+	dst, ok := dstContainer.(*container)
+	if !ok {
+		return nil, stow.NotSupported("copy to another kind of container")
+	}
+	if metadata != nil {
+		return nil, stow.NotSupported("metadata")
+	}
+	srcPath := filepath.Join(c.path, filepath.FromSlash(srcID))
+	dstPath := filepath.Join(dst.path, filepath.FromSlash(dstID))
+	if srcPath == dstPath {
+		return dst.Item(dstID)
+	}
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer src.Close()
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0777); err != nil {
+		return nil, mapError(err)
+	}
+	f, err := os.Create(dstPath)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		os.Remove(dstPath)
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return &item{
+		path:          dstPath,
+		name:          dstID,
+		contPrefixLen: len(dst.path) + 1,
+	}, nil
+}
+
+// PutIf is Put with a condition.
+// The contents are written to a temporary file in the container,
+// which is then moved into place: with a hard link when the item must
+// not exist, as creating the link fails atomically if it does, and
+// with a rename otherwise. IfMatch is checked just before the rename,
+// so unlike IfNoneMatch it doesn't guard against concurrent writers
+// outside of this process.
+func (c *container) PutIf(name string, r io.Reader, size int64, metadata map[string]interface{}, cond stow.PutCondition) (stow.Item, error) {
+	if c.allowMetadata == false && len(metadata) > 0 {
+		return nil, stow.NotSupported("metadata")
+	}
+
+	if cond.IfNoneMatch && cond.IfMatch != "" {
+		return nil, stow.WrapError(stow.ErrPreconditionFailed, errors.New("an item can't match both IfMatch and IfNoneMatch"))
+	}
+
+	path := filepath.Join(c.path, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return nil, mapError(err)
+	}
+	tmp, err := createTemp(c.path)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer os.Remove(tmp.Name())
+	n, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if size >= 0 && n != size {
+		return nil, errors.New("bad size")
+	}
+
+	if cond.IfNoneMatch {
+		if err := os.Link(tmp.Name(), path); err != nil {
+			if os.IsExist(err) {
+				return nil, stow.WrapError(stow.ErrPreconditionFailed, err)
+			}
+			return nil, mapError(err)
+		}
+	} else {
+		if err := checkETag(path, cond.IfMatch); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			return nil, mapError(err)
+		}
+	}
+	return &item{
+		path:          path,
+		name:          name,
+		contPrefixLen: len(c.path) + 1,
+	}, nil
+}
+
+// checkETag returns an error matching stow.ErrPreconditionFailed
+// unless the file at path exists with the given ETag. An empty etag
+// matches anything.
+func checkETag(path, etag string) error {
+	if etag == "" {
+		return nil
+	}
+	current := &item{path: path}
+	if err := current.ensureInfo(); err != nil {
+		if os.IsNotExist(err) {
+			return stow.WrapError(stow.ErrPreconditionFailed, err)
+		}
+		return mapError(err)
+	}
+	if currentETag, _ := current.ETag(); currentETag != etag {
+		return stow.WrapError(stow.ErrPreconditionFailed, fmt.Errorf("etag %q does not match %q", currentETag, etag))
+	}
+	return nil
+}
+
+// tempDir is the directory of a container in which the contents of
+// items are staged before they are moved into place. It is left out
+// of listings, so the files of writes that failed, or of a process
+// that crashed, aren't listed as items.
+const tempDir = ".stow-tmp"
+
+// createTemp creates a new file in the staging directory of the
+// container at root to write the contents of an item to. Unlike
+// os.CreateTemp the file gets the same permissions as one made by
+// os.Create.
+func createTemp(root string) (*os.File, error) {
+	dir := filepath.Join(root, tempDir)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+	for i := 0; ; i++ {
+		name := filepath.Join(dir, fmt.Sprintf("%d-%d.tmp", os.Getpid(), rand.Uint32()))
+		f, err := os.OpenFile(name, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) && i < 100 {
+			continue
+		}
+		return f, err
+	}
+}
@@ -1,7 +1,13 @@
 package local_test
 
 import (
+	"context"
+	"crypto/md5"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -64,3 +70,253 @@ func TestItemsPaging(t *testing.T) {
 	is.NoErr(err)
 	is.OK(item)
 }
+
+func TestContextCanceled(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+	cfg := stow.ConfigMap{"path": testDir}
+	l, err := stow.Dial(local.Kind, cfg)
+	is.NoErr(err)
+	is.OK(l)
+
+	containers, _, err := l.Containers("", stow.CursorStart, 10)
+	is.NoErr(err)
+	is.True(len(containers) > 0)
+	container := containers[1].(stow.ContextContainer)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = container.PutCtx(ctx, "canceled", strings.NewReader(`item`), 4, nil)
+	is.Equal(err, context.Canceled)
+	_, err = containers[1].Item("canceled")
+	is.Equal(err, stow.ErrNotFound)
+
+	_, err = container.PutCtx(context.Background(), "item", strings.NewReader(`item`), 4, nil)
+	is.NoErr(err)
+	_, err = container.ItemCtx(ctx, "item")
+	is.Equal(err, context.Canceled)
+	_, _, err = container.ItemsCtx(ctx, stow.NoPrefix, stow.CursorStart, 10)
+	is.Equal(err, context.Canceled)
+	is.Equal(container.RemoveItemCtx(ctx, "item"), context.Canceled)
+
+	item, err := container.ItemCtx(context.Background(), "item")
+	is.NoErr(err)
+	_, err = item.(stow.ContextItem).OpenCtx(ctx)
+	is.Equal(err, context.Canceled)
+
+	_, err = l.(stow.ContextLocation).ContainerCtx(ctx, containers[1].ID())
+	is.Equal(err, context.Canceled)
+}
+
+func TestCopy(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+	cfg := stow.ConfigMap{"path": testDir}
+	l, err := stow.Dial(local.Kind, cfg)
+	is.NoErr(err)
+	is.OK(l)
+
+	containers, _, err := l.Containers("", stow.CursorStart, 10)
+	is.NoErr(err)
+	is.True(len(containers) > 2)
+	src, dst := containers[1], containers[2]
+
+	_, err = src.Put("item", strings.NewReader(`item`), 4, nil)
+	is.NoErr(err)
+
+	copied, err := src.(stow.Copier).Copy("item", dst, "dir/copy", nil)
+	is.NoErr(err)
+	is.Equal(copied.Name(), "dir/copy")
+	is.Equal(readItemContents(is, copied), "item")
+
+	// the copy doesn't share its contents with the source
+	_, err = src.Put("item", strings.NewReader(`changed`), 7, nil)
+	is.NoErr(err)
+	is.Equal(readItemContents(is, copied), "item")
+
+	_, err = src.(stow.Copier).Copy("missing", dst, "copy", nil)
+	is.True(errors.Is(err, stow.ErrNotFound))
+
+	// new metadata can't be written, even where it is allowed
+	metaLocation, err := stow.Dial(local.Kind, stow.ConfigMap{"path": testDir, local.ConfigKeyMetaAllow: "true"})
+	is.NoErr(err)
+	metaDst, err := metaLocation.CreateContainer("meta")
+	is.NoErr(err)
+	_, err = src.(stow.Copier).Copy("item", metaDst, "copy", map[string]interface{}{"key": "value"})
+	is.True(stow.IsNotSupported(err))
+	_, err = metaDst.Item("copy")
+	is.Equal(err, stow.ErrNotFound)
+
+	moved, err := stow.Move(src, "item", dst, "moved", nil)
+	is.NoErr(err)
+	is.Equal(readItemContents(is, moved), "changed")
+	_, err = src.Item("item")
+	is.Equal(err, stow.ErrNotFound)
+}
+
+func TestPutUnknownSize(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+	cfg := stow.ConfigMap{"path": testDir}
+	l, err := stow.Dial(local.Kind, cfg)
+	is.NoErr(err)
+	is.OK(l)
+
+	containers, _, err := l.Containers("", stow.CursorStart, 10)
+	is.NoErr(err)
+	c := containers[1]
+
+	r := io.MultiReader(strings.NewReader("unknown "), strings.NewReader("size"))
+	item, err := c.Put("unknown", r, -1, nil)
+	is.NoErr(err)
+	size, err := item.Size()
+	is.NoErr(err)
+	is.Equal(size, int64(12))
+	is.Equal(readItemContents(is, item), "unknown size")
+
+	// known sizes are still checked
+	_, err = c.Put("short", strings.NewReader("short"), 10, nil)
+	is.Err(err)
+}
+
+func TestPutWithOptions(t *testing.T) {
+	is := is.New(t)
+	l, err := stow.Dial(local.Kind, stow.ConfigMap{"path": t.TempDir()})
+	is.NoErr(err)
+	c, err := l.CreateContainer("one")
+	is.NoErr(err)
+
+	sum := md5.Sum([]byte("item"))
+	item, err := stow.PutWithOptions(c, "dir/item", strings.NewReader("item"), 4, stow.PutOptions{ContentMD5: sum[:]})
+	is.NoErr(err)
+	is.Equal(readItemContents(is, item), "item")
+
+	// contents that don't match leave the item as it was
+	_, err = stow.PutWithOptions(c, "dir/item", strings.NewReader("other"), 5, stow.PutOptions{ContentMD5: sum[:]})
+	is.True(errors.Is(err, stow.ErrPreconditionFailed))
+	var checksumErr *stow.ChecksumError
+	is.True(errors.As(err, &checksumErr))
+	is.Equal(checksumErr.Expected, sum[:])
+	is.Equal(readItemContents(is, item), "item")
+	_, err = stow.PutWithOptions(c, "dir/bad", strings.NewReader("bad"), 3, stow.PutOptions{ContentMD5: sum[:]})
+	is.True(errors.Is(err, stow.ErrPreconditionFailed))
+	_, err = c.Item("dir/bad")
+	is.Equal(err, stow.ErrNotFound)
+}
+
+func TestPutIf(t *testing.T) {
+	is := is.New(t)
+	testDir := t.TempDir()
+	cfg := stow.ConfigMap{"path": testDir}
+	l, err := stow.Dial(local.Kind, cfg)
+	is.NoErr(err)
+	is.OK(l)
+
+	container, err := l.CreateContainer("one")
+	is.NoErr(err)
+	putter := container.(stow.ConditionalPutter)
+
+	item, err := putter.PutIf("dir/item", strings.NewReader(`item`), 4, nil, stow.PutCondition{IfNoneMatch: true})
+	is.NoErr(err)
+	is.Equal(readItemContents(is, item), "item")
+	etag, err := item.ETag()
+	is.NoErr(err)
+
+	_, err = putter.PutIf("dir/item", strings.NewReader(`other`), 5, nil, stow.PutCondition{IfNoneMatch: true})
+	is.True(errors.Is(err, stow.ErrPreconditionFailed))
+	_, err = putter.PutIf("dir/item", strings.NewReader(`other`), 5, nil, stow.PutCondition{IfMatch: "nope"})
+	is.True(errors.Is(err, stow.ErrPreconditionFailed))
+	_, err = putter.PutIf("dir/missing", strings.NewReader(`other`), 5, nil, stow.PutCondition{IfMatch: etag})
+	is.True(errors.Is(err, stow.ErrPreconditionFailed))
+	is.Equal(readItemContents(is, item), "item")
+
+	item, err = putter.PutIf("dir/item", strings.NewReader(`updated`), 7, nil, stow.PutCondition{IfMatch: etag})
+	is.NoErr(err)
+	is.Equal(readItemContents(is, item), "updated")
+
+	// no temporary files are left behind
+	files, err := filepath.Glob(filepath.Join(testDir, "one", "dir", "*"))
+	is.NoErr(err)
+	is.Equal(files, []string{filepath.Join(testDir, "one", "dir", "item")})
+
+	// nor listed, when a crash left them
+	err = os.WriteFile(filepath.Join(testDir, "one", ".stow-tmp", "1-1.tmp"), []byte("crash"), 0666)
+	is.NoErr(err)
+	items, _, err := container.Items(stow.NoPrefix, stow.CursorStart, 10)
+	is.NoErr(err)
+	is.Equal(len(items), 1)
+	is.Equal(items[0].ID(), "dir/item")
+
+	// directories of the same names below the root are listed
+	for _, name := range []string{"a/b/.stow-tmp/file", "a/.stow-uploads/file"} {
+		_, err = container.Put(name, strings.NewReader(name), int64(len(name)), nil)
+		is.NoErr(err)
+	}
+	items, _, err = container.Items(stow.NoPrefix, stow.CursorStart, 10)
+	is.NoErr(err)
+	is.Equal(len(items), 3)
+	is.Equal(items[0].ID(), "a/.stow-uploads/file")
+	is.Equal(items[1].ID(), "a/b/.stow-tmp/file")
+}
+
+func TestItemsDelimited(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+	cfg := stow.ConfigMap{"path": testDir}
+	l, err := stow.Dial(local.Kind, cfg)
+	is.NoErr(err)
+
+	containers, _, err := l.Containers("", stow.CursorStart, 10)
+	is.NoErr(err)
+	container := containers[1]
+	lister := container.(stow.DelimitedLister)
+	for _, name := range []string{"b", "a/1", "a/2", "a-b", "c/d/e"} {
+		_, err := container.Put(name, strings.NewReader(name), int64(len(name)), nil)
+		is.NoErr(err)
+	}
+	is.NoErr(os.MkdirAll(filepath.Join(testDir, container.ID(), "empty"), 0777))
+
+	// empty directories are not listed
+	items, prefixes, cursor, err := lister.ItemsDelimited("", "/", stow.CursorStart, 10)
+	is.NoErr(err)
+	is.Equal(cursor, "")
+	is.Equal(itemNames(items), []string{"a-b", "b"})
+	is.Equal(prefixes, []string{"a/", "c/"})
+
+	// pages hold both items and common prefixes, in order
+	items, prefixes, cursor, err = lister.ItemsDelimited("", "/", stow.CursorStart, 2)
+	is.NoErr(err)
+	is.Equal(itemNames(items), []string{"a-b"})
+	is.Equal(prefixes, []string{"a/"})
+	is.Equal(cursor, "b")
+	items, prefixes, cursor, err = lister.ItemsDelimited("", "/", cursor, 2)
+	is.NoErr(err)
+	is.Equal(itemNames(items), []string{"b"})
+	is.Equal(prefixes, []string{"c/"})
+	is.True(stow.IsCursorEnd(cursor))
+
+	items, prefixes, _, err = lister.ItemsDelimited("c/", "/", stow.CursorStart, 10)
+	is.NoErr(err)
+	is.Equal(len(items), 0)
+	is.Equal(prefixes, []string{"c/d/"})
+
+	_, _, _, err = lister.ItemsDelimited("", "/", "made up cursor", 10)
+	is.Equal(err, stow.ErrBadCursor)
+}
+
+func itemNames(items []stow.Item) []string {
+	names := make([]string, len(items))
+	for i, item := range items {
+		names[i] = item.Name()
+	}
+	return names
+}
@@ -0,0 +1,24 @@
+package local
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/aldor007/stow"
+	"github.com/aldor007/stow/test"
+)
+
+func TestErrorMapping(t *testing.T) {
+	pathError := func(err error) error {
+		return &os.PathError{Op: "mkdir", Path: "/tmp/stow", Err: err}
+	}
+
+	test.ErrorMapping(t, mapError, map[error][]error{
+		stow.ErrNotFound:          {os.ErrNotExist, pathError(syscall.ENOENT)},
+		stow.ErrAlreadyExists:     {os.ErrExist, pathError(syscall.EEXIST)},
+		stow.ErrPermissionDenied:  {os.ErrPermission, pathError(syscall.EACCES)},
+		stow.ErrContainerNotEmpty: {pathError(syscall.ENOTEMPTY)},
+		stow.ErrInvalidName:       {pathError(syscall.ENAMETOOLONG)},
+	})
+}
@@ -1,6 +1,7 @@
 package local
 
 import (
+	"context"
 	"io"
 	"net/url"
 	"os"
@@ -10,7 +11,13 @@ import (
 	"github.com/pkg/errors"
 	"github.com/aldor007/stow"
 )
-var _ stow.Item = (*item)(nil)
+
+var (
+	_ stow.Item                 = (*item)(nil)
+	_ stow.ContextItem          = (*item)(nil)
+	_ stow.OptionsOpener        = (*item)(nil)
+	_ stow.ContextOptionsOpener = (*item)(nil)
+)
 
 // Metadata constants describe the metadata available
 // for a local Item.
@@ -72,13 +79,70 @@ func (i *item) ETag() (string, error) {
 
 // Open opens the file for reading.
 func (i *item) Open() (io.ReadCloser, error) {
-	return os.Open(i.path)
+	return i.OpenCtx(context.Background())
+}
+
+// OpenCtx is Open with a context. ctx is only consulted before the
+// file is opened, so the returned file keeps its io.Seeker support.
+func (i *item) OpenCtx(ctx context.Context) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(i.path)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return f, nil
 }
 
 func (i *item) OpenParams(_ map[string]interface{}) (io.ReadCloser, error) {
 	return i.Open()
 }
 
+// OpenWithOptions opens the file as described by opts. The conditions
+// are checked against the info of the opened file, and ranges are read
+// by seeking in it. Versions aren't supported and the response
+// overrides are ignored.
+func (i *item) OpenWithOptions(opts stow.OpenOptions) (io.ReadCloser, error) {
+	return i.OpenWithOptionsCtx(context.Background(), opts)
+}
+
+// OpenWithOptionsCtx is OpenWithOptions with a context.
+func (i *item) OpenWithOptionsCtx(ctx context.Context, opts stow.OpenOptions) (io.ReadCloser, error) {
+	if opts.VersionID != "" {
+		return nil, stow.NotSupported("opening versions")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f, err := os.Open(i.path)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if opts.NotModified(info.ModTime().String(), info.ModTime()) {
+		f.Close()
+		return nil, stow.ErrNotModified
+	}
+	if opts.Range == nil {
+		return f, nil
+	}
+	offset, length, ok := opts.Range.Offsets(info.Size())
+	if !ok {
+		f.Close()
+		return nil, errors.Wrap(stow.ErrInvalidRange, opts.Range.String())
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return stow.LimitReadCloser(f, length), nil
+}
+
 func (i *item) ContentRange() (stow.ContentRangeData, error) {
 	return stow.ContentRangeData{}, errors.New("not implemented")
 }
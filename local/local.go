@@ -50,4 +50,13 @@ func init() {
 		return u.Scheme == "file"
 	}
 	stow.Register(Kind, makefn, kindfn, validatefn)
+	stow.RegisterDSN(Kind, stow.DSN{
+		Path:  ConfigKeyPath,
+		Query: map[string]string{ConfigKeyMetaAllow: ConfigKeyMetaAllow},
+	})
+	stow.RegisterSchema(Kind, stow.Schema{Keys: []stow.KeySchema{
+		{Name: ConfigKeyPath, Required: true, Description: "The directory the containers are in."},
+		{Name: ConfigKeyMetaAllow, Type: stow.TypeBool, Default: "false",
+			Description: "Whether metadata is kept in the extended attributes of the files."},
+	}})
 }
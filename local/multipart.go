@@ -0,0 +1,249 @@
+package local
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/aldor007/stow"
+	"github.com/pkg/errors"
+)
+
+// uploadsDir is the directory of a container in which the parts of
+// multipart uploads are staged. It is left out of listings.
+const uploadsDir = ".stow-uploads"
+
+// uploadNameFile is the file of a staged upload holding the name of
+// the item being uploaded.
+const uploadNameFile = "name"
+
+var _ stow.MultipartUploader = (*container)(nil)
+
+// InitiateUpload starts a multipart upload. Its parts are staged as
+// files in a directory of the container until the upload is completed
+// or aborted.
+func (c *container) InitiateUpload(name string, metadata map[string]interface{}) (stow.Upload, error) {
+	if c.allowMetadata == false && len(metadata) > 0 {
+		return stow.Upload{}, stow.NotSupported("metadata")
+	}
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return stow.Upload{}, err
+	}
+	upload := stow.Upload{
+		ID:   hex.EncodeToString(id[:]),
+		Name: name,
+	}
+	dir := c.uploadPath(upload)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return stow.Upload{}, mapError(err)
+	}
+	err := os.WriteFile(filepath.Join(dir, uploadNameFile), []byte(name), 0666)
+	if err != nil {
+		os.RemoveAll(dir)
+		return stow.Upload{}, mapError(err)
+	}
+	info, err := os.Stat(dir)
+	if err != nil {
+		return stow.Upload{}, mapError(err)
+	}
+	upload.Initiated = info.ModTime()
+	return upload, nil
+}
+
+// UploadPart stages a part of the upload. The part is written to a
+// temporary file first, so a part that was only partly written never
+// replaces a complete one.
+func (c *container) UploadPart(upload stow.Upload, number int, r io.Reader, size int64) (stow.Part, error) {
+	if number < 1 {
+		return stow.Part{}, fmt.Errorf("invalid part number %d", number)
+	}
+	dir, err := c.openUpload(upload)
+	if err != nil {
+		return stow.Part{}, err
+	}
+	path := filepath.Join(dir, partName(number))
+	tmp, err := createTemp(c.path)
+	if err != nil {
+		return stow.Part{}, mapError(err)
+	}
+	defer os.Remove(tmp.Name())
+	n, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return stow.Part{}, err
+	}
+	if size >= 0 && n != size {
+		return stow.Part{}, errors.New("bad size")
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return stow.Part{}, mapError(err)
+	}
+	return stow.Part{Number: number, Size: n}, nil
+}
+
+// ListParts gets the parts staged for the upload.
+func (c *container) ListParts(upload stow.Upload) ([]stow.Part, error) {
+	dir, err := c.openUpload(upload)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	var parts []stow.Part
+	for _, entry := range entries {
+		var number int
+		if _, err := fmt.Sscanf(entry.Name(), "part-%d", &number); err != nil || entry.Name() != partName(number) {
+			// the name file or a temporary file
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, mapError(err)
+		}
+		parts = append(parts, stow.Part{Number: number, Size: info.Size()})
+	}
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	return parts, nil
+}
+
+// CompleteUpload concatenates the parts into the item and removes the
+// staged upload.
+func (c *container) CompleteUpload(upload stow.Upload, parts []stow.Part) (stow.Item, error) {
+	dir, err := c.openUpload(upload)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(c.path, filepath.FromSlash(upload.Name))
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		return nil, mapError(err)
+	}
+	tmp, err := createTemp(c.path)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer os.Remove(tmp.Name())
+	err = concatParts(tmp, dir, parts)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return nil, mapError(err)
+	}
+	if err := c.removeUpload(dir); err != nil {
+		return nil, err
+	}
+	return c.Item(upload.Name)
+}
+
+// concatParts copies the parts staged in dir to w, in order.
+func concatParts(w io.Writer, dir string, parts []stow.Part) error {
+	parts = append([]stow.Part(nil), parts...)
+	sort.Slice(parts, func(i, j int) bool { return parts[i].Number < parts[j].Number })
+	for _, part := range parts {
+		f, err := os.Open(filepath.Join(dir, partName(part.Number)))
+		if err != nil {
+			return errors.Wrapf(mapError(err), "part %d", part.Number)
+		}
+		n, err := io.Copy(w, f)
+		f.Close()
+		if err != nil {
+			return err
+		}
+		if part.Size > 0 && n != part.Size {
+			return fmt.Errorf("part %d: bad size", part.Number)
+		}
+	}
+	return nil
+}
+
+// AbortUpload removes the staged upload.
+func (c *container) AbortUpload(upload stow.Upload) error {
+	dir, err := c.openUpload(upload)
+	if err != nil {
+		return err
+	}
+	return c.removeUpload(dir)
+}
+
+// removeUpload removes the directory of a staged upload, and the
+// directory of the uploads once there are none left, so it doesn't
+// keep the container from being removed.
+func (c *container) removeUpload(dir string) error {
+	if err := os.RemoveAll(dir); err != nil {
+		return mapError(err)
+	}
+	os.Remove(filepath.Dir(dir))
+	return nil
+}
+
+// ListPendingUploads gets the uploads staged in the container.
+func (c *container) ListPendingUploads(prefix string) ([]stow.Upload, error) {
+	entries, err := os.ReadDir(filepath.Join(c.path, uploadsDir))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, mapError(err)
+	}
+	var uploads []stow.Upload
+	for _, entry := range entries {
+		dir := filepath.Join(c.path, uploadsDir, entry.Name())
+		name, err := os.ReadFile(filepath.Join(dir, uploadNameFile))
+		if err != nil {
+			// completed or aborted meanwhile
+			continue
+		}
+		if !strings.HasPrefix(string(name), prefix) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		uploads = append(uploads, stow.Upload{
+			ID:        entry.Name(),
+			Name:      string(name),
+			Initiated: info.ModTime(),
+		})
+	}
+	return uploads, nil
+}
+
+// uploadPath gets the directory in which the upload is staged.
+func (c *container) uploadPath(upload stow.Upload) string {
+	return filepath.Join(c.path, uploadsDir, filepath.Base(upload.ID))
+}
+
+// openUpload gets the directory of an upload that is still pending,
+// checking that it is an upload of the same item.
+func (c *container) openUpload(upload stow.Upload) (string, error) {
+	dir := c.uploadPath(upload)
+	name, err := os.ReadFile(filepath.Join(dir, uploadNameFile))
+	if os.IsNotExist(err) {
+		return "", stow.WrapError(stow.ErrNotFound, err)
+	}
+	if err != nil {
+		return "", mapError(err)
+	}
+	if string(name) != upload.Name {
+		return "", stow.WrapError(stow.ErrNotFound, fmt.Errorf("upload %s is not an upload of %q", upload.ID, upload.Name))
+	}
+	return dir, nil
+}
+
+func partName(number int) string {
+	return fmt.Sprintf("part-%05d", number)
+}
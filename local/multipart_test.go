@@ -0,0 +1,85 @@
+package local_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aldor007/stow"
+	"github.com/aldor007/stow/local"
+	"github.com/cheekybits/is"
+)
+
+func TestMultipartUpload(t *testing.T) {
+	is := is.New(t)
+	testDir, teardown, err := setup()
+	is.NoErr(err)
+	defer teardown()
+	cfg := stow.ConfigMap{"path": testDir}
+	l, err := stow.Dial(local.Kind, cfg)
+	is.NoErr(err)
+	is.OK(l)
+
+	containers, _, err := l.Containers("", stow.CursorStart, 10)
+	is.NoErr(err)
+	c := containers[1].(stow.MultipartUploader)
+
+	upload, err := c.InitiateUpload("dir/large", nil)
+	is.NoErr(err)
+	is.Equal(upload.Name, "dir/large")
+	_, err = c.UploadPart(upload, 2, strings.NewReader("second"), 6)
+	is.NoErr(err)
+	_, err = c.UploadPart(upload, 1, strings.NewReader("broken"), 6)
+	is.NoErr(err)
+	// uploading a part again replaces it
+	_, err = c.UploadPart(upload, 1, strings.NewReader("first "), 6)
+	is.NoErr(err)
+
+	// the staged parts are not listed as items
+	items, _, err := containers[1].Items(stow.NoPrefix, stow.CursorStart, 10)
+	is.NoErr(err)
+	for _, item := range items {
+		is.False(strings.Contains(item.Name(), ".stow-uploads"))
+	}
+
+	// the upload can be resumed from another location
+	l, err = stow.Dial(local.Kind, cfg)
+	is.NoErr(err)
+	containers, _, err = l.Containers("", stow.CursorStart, 10)
+	is.NoErr(err)
+	c = containers[1].(stow.MultipartUploader)
+
+	uploads, err := c.ListPendingUploads("dir/")
+	is.NoErr(err)
+	is.Equal(len(uploads), 1)
+	is.Equal(uploads[0].ID, upload.ID)
+	uploads, err = c.ListPendingUploads("other/")
+	is.NoErr(err)
+	is.Equal(len(uploads), 0)
+
+	parts, err := c.ListParts(upload)
+	is.NoErr(err)
+	is.Equal(parts, []stow.Part{{Number: 1, Size: 6}, {Number: 2, Size: 6}})
+
+	item, err := c.CompleteUpload(upload, parts)
+	is.NoErr(err)
+	is.Equal(item.Name(), "dir/large")
+	is.Equal(readItemContents(is, item), "first second")
+
+	uploads, err = c.ListPendingUploads("")
+	is.NoErr(err)
+	is.Equal(len(uploads), 0)
+	_, err = c.ListParts(upload)
+	is.True(errors.Is(err, stow.ErrNotFound))
+
+	// aborting discards the parts
+	upload, err = c.InitiateUpload("aborted", nil)
+	is.NoErr(err)
+	_, err = c.UploadPart(upload, 1, strings.NewReader("part"), 4)
+	is.NoErr(err)
+	is.NoErr(c.AbortUpload(upload))
+	_, err = c.UploadPart(upload, 2, strings.NewReader("part"), 4)
+	is.True(errors.Is(err, stow.ErrNotFound))
+	_, err = containers[1].Item("aborted")
+	is.True(errors.Is(err, stow.ErrNotFound))
+}
@@ -1,8 +1,10 @@
 package local_test
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/cheekybits/is"
@@ -20,3 +22,71 @@ func TestStow(t *testing.T) {
 
 	test.All(t, "local", cfg)
 }
+
+func TestWrap(t *testing.T) {
+	is := is.New(t)
+
+	dir, err := ioutil.TempDir("testdata", "stow")
+	is.NoErr(err)
+	defer os.RemoveAll(dir)
+	location, err := stow.Dial("local", stow.ConfigMap{"path": dir})
+	is.NoErr(err)
+	defer location.Close()
+
+	var methods []string
+	wrapped := stow.Wrap(location, func(ctx context.Context, call *stow.Call, next stow.Handler) error {
+		methods = append(methods, call.Method)
+		return next(ctx, call)
+	})
+	_, ok := stow.As[stow.URLParser](wrapped)
+	is.True(ok)
+
+	container, err := wrapped.CreateContainer("wrapped")
+	is.NoErr(err)
+	item, err := container.Put("src", strings.NewReader("contents"), 8, nil)
+	is.NoErr(err)
+
+	// As gets the optional interfaces of the local objects from the
+	// wrapped ones, and only those
+	unwrapped, err := location.Container("wrapped")
+	is.NoErr(err)
+	for _, check := range []func(interface{}) bool{
+		func(x interface{}) bool { _, ok := stow.As[stow.DelimitedLister](x); return ok },
+		func(x interface{}) bool { _, ok := stow.As[stow.Copier](x); return ok },
+		func(x interface{}) bool { _, ok := stow.As[stow.ConditionalPutter](x); return ok },
+		func(x interface{}) bool { _, ok := stow.As[stow.MetadataSetter](x); return ok },
+		func(x interface{}) bool { _, ok := stow.As[stow.ItemWriter](x); return ok },
+		func(x interface{}) bool { _, ok := stow.As[stow.MultipartUploader](x); return ok },
+		func(x interface{}) bool { _, ok := stow.As[stow.BatchRemover](x); return ok },
+		func(x interface{}) bool { _, ok := stow.As[stow.Versioned](x); return ok },
+		func(x interface{}) bool { _, ok := stow.As[stow.TagQuerier](x); return ok },
+	} {
+		is.Equal(check(container), check(unwrapped))
+	}
+	unwrappedItem, err := unwrapped.Item("src")
+	is.NoErr(err)
+	for _, check := range []func(interface{}) bool{
+		func(x interface{}) bool { _, ok := stow.As[stow.ItemRanger](x); return ok },
+		func(x interface{}) bool { _, ok := stow.As[stow.OptionsOpener](x); return ok },
+		func(x interface{}) bool { _, ok := stow.As[stow.Taggable](x); return ok },
+		func(x interface{}) bool { _, ok := stow.As[stow.TagSetter](x); return ok },
+	} {
+		is.Equal(check(item), check(unwrappedItem))
+	}
+	is.Equal(stow.CapabilitiesOf(container), stow.CapabilitiesOf(unwrapped))
+
+	// the calls made through the optional interfaces are intercepted
+	methods = nil
+	copier, ok := stow.As[stow.Copier](container)
+	is.True(ok)
+	_, err = copier.Copy("src", container, "dst", nil)
+	is.NoErr(err)
+	writer, ok := stow.As[stow.ItemWriter](container)
+	is.True(ok)
+	_, w, err := writer.CreateItem("written")
+	is.NoErr(err)
+	_, err = w.Write([]byte("contents"))
+	is.NoErr(err)
+	is.NoErr(w.Close())
+	is.Equal(methods, []string{"Copy", "CreateItem"})
+}
@@ -2,6 +2,7 @@ package stow_test
 
 import (
 	"net/url"
+	"sync/atomic"
 
 	"github.com/aldor007/stow"
 )
@@ -11,6 +12,10 @@ func init() {
 		return nil
 	}
 	makefn := func(config stow.Config) (stow.Location, error) {
+		if _, ok := config.Config("mem"); ok {
+			atomic.AddInt32(&memDials, 1)
+			return &memLocation{container: newMemContainer(true)}, nil
+		}
 		return &testLocation{
 			config: config,
 		}, nil
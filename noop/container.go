@@ -11,6 +11,14 @@ type container struct {
 	name string
 }
 
+var (
+	_ stow.Container          = (*container)(nil)
+	_ stow.ContextContainer   = (*container)(nil)
+	_ stow.CapabilityReporter = (*container)(nil)
+	_ stow.OptionsPutter      = (*container)(nil)
+	_ stow.Stater             = (*container)(nil)
+)
+
 // ID returns a string value which represents the name of the container.
 func (c *container) ID() string {
 	return c.name
@@ -24,12 +32,27 @@ func (c *container) Name() string {
 // Item returns a stow.Item instance of a container based on the
 // name of the container and the key representing
 func (c *container) Item(id string) (stow.Item, error) {
-	return &item{name: id}, stow.ErrNotFound
+	return c.ItemCtx(context.Background(), id)
+}
+
+// ItemCtx is Item with a context.
+func (c *container) ItemCtx(ctx context.Context, id string) (stow.Item, error) {
+	return nil, stow.ErrNotFound
+}
+
+// Stat returns stow.ErrNotFound, as the container holds no items.
+func (c *container) Stat(id string) (stow.ItemInfo, error) {
+	return stow.ItemInfo{}, stow.ErrNotFound
 }
 
 // Items sends a request to retrieve a list of items that are prepended with
 // the prefix argument. The 'cursor' variable facilitates pagination.
 func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
+	return c.ItemsCtx(context.Background(), prefix, cursor, count)
+}
+
+// ItemsCtx is Items with a context.
+func (c *container) ItemsCtx(ctx context.Context, prefix, cursor string, count int) ([]stow.Item, string, error) {
 	return nil, "", errors.New("not implemented")
 }
 
@@ -39,10 +62,30 @@ func (c *container) PreSignRequest(ctx context.Context, clientMethod stow.Client
 	return "", errors.New("not implemented")
 }
 
+// Capabilities describes the features supported by this container.
+func (c *container) Capabilities() stow.Capabilities {
+	return capabilities()
+}
+
 func (c *container) RemoveItem(id string) error {
+	return c.RemoveItemCtx(context.Background(), id)
+}
+
+// RemoveItemCtx is RemoveItem with a context.
+func (c *container) RemoveItemCtx(ctx context.Context, id string) error {
 	return nil
 }
 
 func (c *container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.PutCtx(context.Background(), name, r, size, metadata)
+}
+
+// PutCtx is Put with a context.
+func (c *container) PutCtx(ctx context.Context, name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return &item{name: name}, nil
+}
+
+// PutWithOptions discards the contents and the options, as Put does.
+func (c *container) PutWithOptions(name string, r io.Reader, size int64, opts stow.PutOptions) (stow.Item, error) {
 	return &item{name: name}, nil
 }
@@ -2,6 +2,7 @@ package noop
 
 import (
 	"bytes"
+	"context"
 	"io"
 	"net/url"
 	"time"
@@ -11,7 +12,10 @@ import (
 	"github.com/aldor007/stow"
 )
 
-var _ stow.Item = (*item)(nil)
+var (
+	_ stow.Item        = (*item)(nil)
+	_ stow.ContextItem = (*item)(nil)
+)
 
 // The item struct contains an id (also the name of the file/S3 Object/Item),
 // a container which it belongs to (s3 Bucket), a client, and a URL. The last
@@ -51,6 +55,11 @@ func (i *item) URL() *url.URL {
 // and path of the file within the container. This response includes the body of
 // resource which is returned along with an error.
 func (i *item) Open() (io.ReadCloser, error) {
+	return i.OpenCtx(context.Background())
+}
+
+// OpenCtx is Open with a context.
+func (i *item) OpenCtx(ctx context.Context) (io.ReadCloser, error) {
 	return ioutil.NopCloser(bytes.NewReader([]byte(""))), nil
 }
 
@@ -1,7 +1,9 @@
 package noop
 
 import (
+	"context"
 	"net/url"
+	"strings"
 
 	"github.com/aldor007/stow"
 	"github.com/pkg/errors"
@@ -12,15 +14,45 @@ type location struct {
 	config stow.Config
 }
 
+var (
+	_ stow.Location           = (*location)(nil)
+	_ stow.ContextLocation    = (*location)(nil)
+	_ stow.CapabilityReporter = (*location)(nil)
+	_ stow.URLParser          = (*location)(nil)
+)
+
 func (l *location) HasRanges() bool {
 	return false
 }
 
+// Capabilities describes the features supported by this location.
+func (l *location) Capabilities() stow.Capabilities {
+	return capabilities()
+}
+
+// capabilities describes the features supported by noop.
+func capabilities() stow.Capabilities {
+	return stow.Capabilities{
+		Write:  true,
+		Delete: true,
+	}
+}
+
 func (l *location) CreateContainer(containerName string) (stow.Container, error) {
+	return l.CreateContainerCtx(context.Background(), containerName)
+}
+
+// CreateContainerCtx is CreateContainer with a context.
+func (l *location) CreateContainerCtx(ctx context.Context, containerName string) (stow.Container, error) {
 	return nil, errors.New("not implemented")
 }
 
 func (l *location) Containers(prefix, cursor string, count int) ([]stow.Container, string, error) {
+	return l.ContainersCtx(context.Background(), prefix, cursor, count)
+}
+
+// ContainersCtx is Containers with a context.
+func (l *location) ContainersCtx(ctx context.Context, prefix, cursor string, count int) ([]stow.Container, string, error) {
 	var containers []stow.Container
 
 	return containers, "", errors.New("not implemented")
@@ -35,17 +67,46 @@ func (l *location) Close() error {
 // Container retrieves a stow.Container based on its name which must be
 // exact.
 func (l *location) Container(id string) (stow.Container, error) {
+	return l.ContainerCtx(context.Background(), id)
+}
+
+// ContainerCtx is Container with a context.
+func (l *location) ContainerCtx(ctx context.Context, id string) (stow.Container, error) {
 	return &container{}, nil
 
 }
 
 // RemoveContainer removes a container simply by name.
 func (l *location) RemoveContainer(id string) error {
+	return l.RemoveContainerCtx(context.Background(), id)
+}
+
+// RemoveContainerCtx is RemoveContainer with a context.
+func (l *location) RemoveContainerCtx(ctx context.Context, id string) error {
 	return errors.New("not implemeted")
 }
 
 // ItemByURL retrieves a stow.Item by parsing the URL, in this
 // case an item is an object.
 func (l *location) ItemByURL(url *url.URL) (stow.Item, error) {
-	return nil, errors.New("not implemeted")
+	return l.ItemByURLCtx(context.Background(), url)
+}
+
+// ItemByURLCtx is ItemByURL with a context. Nothing is kept, so no
+// item is found.
+func (l *location) ItemByURLCtx(ctx context.Context, url *url.URL) (stow.Item, error) {
+	if _, _, err := l.ParseURL(url); err != nil {
+		return nil, err
+	}
+	return nil, stow.ErrNotFound
+}
+
+// ParseURL gets the container and the name of the item of a
+// noop://<container>/<item> URL.
+func (l *location) ParseURL(u *url.URL) (string, string, error) {
+	name := strings.TrimPrefix(u.Path, "/")
+	if u.Scheme != Kind || name == "" {
+		return "", "", errors.New("not valid noop URL, expecting noop://<container>/<item>")
+	}
+	return u.Host, name, nil
 }
@@ -28,4 +28,6 @@ func init() {
 	}
 
 	stow.Register(Kind, makefn, kindfn, validatefn)
+	stow.RegisterDSN(Kind, stow.DSN{})
+	stow.RegisterSchema(Kind, stow.Schema{})
 }
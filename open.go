@@ -0,0 +1,82 @@
+package stow
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// OpenWithOptions opens item for reading as described by opts.
+// When item implements OptionsOpener the options are handled by the
+// implementation. Otherwise the conditions are checked against the
+// ETag and LastMod of item, and a range is read with ItemRanger, or by
+// skipping the contents before it. Versions can't be read that way, so
+// an error satisfying IsNotSupported is returned when VersionID is set.
+func OpenWithOptions(item Item, opts OpenOptions) (io.ReadCloser, error) {
+	return OpenWithOptionsCtx(context.Background(), item, opts)
+}
+
+// OpenWithOptionsCtx is OpenWithOptions with a context, which is
+// passed to the Ctx variants of the methods it calls.
+func OpenWithOptionsCtx(ctx context.Context, item Item, opts OpenOptions) (io.ReadCloser, error) {
+	if opener, ok := As[ContextOptionsOpener](item); ok {
+		return opener.OpenWithOptionsCtx(ctx, opts)
+	}
+	if opener, ok := As[OptionsOpener](item); ok {
+		return opener.OpenWithOptions(opts)
+	}
+	if opts.VersionID != "" {
+		return nil, NotSupported("opening versions")
+	}
+	if opts.IfNoneMatch != "" || !opts.IfModifiedSince.IsZero() {
+		etag, err := item.ETag()
+		if err != nil {
+			return nil, err
+		}
+		lastMod, err := item.LastMod()
+		if err != nil {
+			return nil, err
+		}
+		if opts.NotModified(etag, lastMod) {
+			return nil, ErrNotModified
+		}
+	}
+	if opts.Range == nil {
+		return openCtx(ctx, item)
+	}
+
+	size, err := item.Size()
+	if err != nil {
+		return nil, err
+	}
+	offset, length, ok := opts.Range.Offsets(size)
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", opts.Range, ErrInvalidRange)
+	}
+	if ranger, ok := As[ContextItemRanger](item); ok && length > 0 {
+		return ranger.OpenRangeCtx(ctx, uint64(offset), uint64(offset+length-1))
+	}
+	if ranger, ok := As[ItemRanger](item); ok && length > 0 {
+		return ranger.OpenRange(uint64(offset), uint64(offset+length-1))
+	}
+	r, err := openCtx(ctx, item)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.CopyN(io.Discard, r, offset); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return LimitReadCloser(r, length), nil
+}
+
+// LimitReadCloser returns a ReadCloser that reads at most n bytes
+// from r and closes r.
+func LimitReadCloser(r io.ReadCloser, n int64) io.ReadCloser {
+	return &limitReadCloser{Reader: io.LimitReader(r, n), Closer: r}
+}
+
+type limitReadCloser struct {
+	io.Reader
+	io.Closer
+}
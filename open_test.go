@@ -0,0 +1,76 @@
+package stow_test
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aldor007/stow"
+	"github.com/cheekybits/is"
+)
+
+func TestByteRange(t *testing.T) {
+	is := is.New(t)
+	for _, test := range []struct {
+		r              stow.ByteRange
+		str            string
+		offset, length int64
+		ok             bool
+	}{
+		{r: stow.ByteRange{Start: 0, End: 3}, str: "bytes=0-3", offset: 0, length: 4, ok: true},
+		{r: stow.ByteRange{Start: 2, End: -1}, str: "bytes=2-", offset: 2, length: 8, ok: true},
+		{r: stow.ByteRange{Start: 5, End: 100}, str: "bytes=5-100", offset: 5, length: 5, ok: true},
+		{r: stow.ByteRange{Start: -3}, str: "bytes=-3", offset: 7, length: 3, ok: true},
+		{r: stow.ByteRange{Start: -20}, str: "bytes=-20", offset: 0, length: 10, ok: true},
+		{r: stow.ByteRange{Start: 10, End: 12}, str: "bytes=10-12", ok: false},
+	} {
+		is.Equal(test.r.String(), test.str)
+		offset, length, ok := test.r.Offsets(10)
+		is.Equal(ok, test.ok)
+		if ok {
+			is.Equal(offset, test.offset)
+			is.Equal(length, test.length)
+		}
+	}
+}
+
+func TestOpenOptionsNotModified(t *testing.T) {
+	is := is.New(t)
+	lastMod := time.Date(2020, 1, 2, 3, 4, 5, 600, time.UTC)
+
+	is.False(stow.OpenOptions{}.NotModified("etag", lastMod))
+	is.True(stow.OpenOptions{IfNoneMatch: "etag"}.NotModified("etag", lastMod))
+	is.False(stow.OpenOptions{IfNoneMatch: "other"}.NotModified("etag", lastMod))
+	// the ETag takes precedence over the modification time
+	is.False(stow.OpenOptions{IfNoneMatch: "other", IfModifiedSince: lastMod}.NotModified("etag", lastMod))
+	// times are compared to the second
+	is.True(stow.OpenOptions{IfModifiedSince: lastMod.Truncate(time.Second)}.NotModified("etag", lastMod))
+	is.False(stow.OpenOptions{IfModifiedSince: lastMod.Add(-time.Second)}.NotModified("etag", lastMod))
+}
+
+func TestOpenWithOptionsFallback(t *testing.T) {
+	is := is.New(t)
+	c := newMemContainer(true)
+	item, err := c.Put("item", strings.NewReader("0123456789"), 10, nil)
+	is.NoErr(err)
+
+	read := func(opts stow.OpenOptions) string {
+		r, err := stow.OpenWithOptions(item, opts)
+		is.NoErr(err)
+		defer r.Close()
+		b, err := io.ReadAll(r)
+		is.NoErr(err)
+		return string(b)
+	}
+	is.Equal(read(stow.OpenOptions{}), "0123456789")
+	is.Equal(read(stow.OpenOptions{Range: &stow.ByteRange{Start: 2, End: 4}}), "234")
+	is.Equal(read(stow.OpenOptions{Range: &stow.ByteRange{Start: -3}}), "789")
+	is.Equal(read(stow.OpenOptions{Range: &stow.ByteRange{Start: 8, End: -1}}), "89")
+
+	_, err = stow.OpenWithOptions(item, stow.OpenOptions{Range: &stow.ByteRange{Start: 10, End: 12}})
+	is.True(errors.Is(err, stow.ErrInvalidRange))
+	_, err = stow.OpenWithOptions(item, stow.OpenOptions{VersionID: "1"})
+	is.True(stow.IsNotSupported(err))
+}
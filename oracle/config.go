@@ -77,6 +77,16 @@ func init() {
 	}
 
 	stow.Register(Kind, makefn, kindfn, validatefn)
+	stow.RegisterDSN(Kind, stow.DSN{
+		User:     ConfigUsername,
+		Password: ConfigPassword,
+		Endpoint: ConfigAuthEndpoint,
+	})
+	stow.RegisterSchema(Kind, stow.Schema{Keys: []stow.KeySchema{
+		{Name: ConfigUsername, Required: true, Description: "The name of the user."},
+		{Name: ConfigPassword, Required: true, Secret: true, Description: "The password of the user."},
+		{Name: ConfigAuthEndpoint, Type: stow.TypeURL, Required: true, Description: "The URL of the authentication service."},
+	}})
 }
 
 func newSwiftClient(cfg stow.Config) (*swift.Connection, error) {
@@ -3,13 +3,18 @@ package oracle
 import (
 	"errors"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/cheekybits/is"
 	"github.com/aldor007/stow"
 	"github.com/aldor007/stow/test"
+	"github.com/ncw/swift"
+	"github.com/ncw/swift/swifttest"
 )
 
 var cfgUnmetered = stow.ConfigMap{
@@ -132,3 +137,106 @@ func TestPrepMetadataFailureWithNonStringValues(t *testing.T) {
 	_, err := prepMetadata(m)
 	is.Err(err)
 }
+
+func TestErrorMapping(t *testing.T) {
+	test.ErrorMapping(t, mapError, map[error][]error{
+		stow.ErrNotFound:           {swift.ObjectNotFound, swift.ContainerNotFound},
+		stow.ErrPermissionDenied:   {swift.Forbidden, swift.AuthorizationFailed},
+		stow.ErrPreconditionFailed: {&swift.Error{StatusCode: http.StatusPreconditionFailed, Text: "Precondition Failed"}},
+		stow.ErrContainerNotEmpty:  {swift.ContainerNotEmpty},
+		stow.ErrThrottled:          {swift.TooManyRequests, swift.RateLimit},
+		stow.ErrInvalidRange:       {&swift.Error{StatusCode: http.StatusRequestedRangeNotSatisfiable, Text: "Range Not Satisfiable"}},
+	})
+}
+
+func TestPutLargeObject(t *testing.T) {
+	is := is.New(t)
+	server, err := swifttest.NewSwiftServer("localhost")
+	is.NoErr(err)
+	defer server.Close()
+	// Without the info of the cluster, dynamic large objects are used.
+	server.SetOverride("/info", func(w http.ResponseWriter, r *http.Request, recorder *httptest.ResponseRecorder) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	client := &swift.Connection{
+		UserName: swifttest.TEST_ACCOUNT,
+		ApiKey:   swifttest.TEST_ACCOUNT,
+		AuthUrl:  server.AuthURL,
+	}
+	is.NoErr(client.Authenticate())
+	is.NoErr(client.ContainerCreate("container", nil))
+	defer func(size int64) { largeObjectSegmentSize = size }(largeObjectSegmentSize)
+	largeObjectSegmentSize = 4
+	c := &container{id: "container", client: client}
+
+	// streams of unknown size are uploaded a segment at a time, and
+	// the manifest is kept by the update of the metadata
+	_, err = c.Put("large", iotest.OneByteReader(strings.NewReader("0123456789")), -1, map[string]interface{}{"key": "value"})
+	is.NoErr(err)
+	contents, err := client.ObjectGetString("container", "large")
+	is.NoErr(err)
+	is.Equal(contents, "0123456789")
+	_, headers, err := client.Object("container", "large")
+	is.NoErr(err)
+	is.True(headers.IsLargeObjectDLO())
+	is.Equal(headers.ObjectMetadata()["key"], "value")
+	segments, err := client.ObjectNamesAll("container_segments", nil)
+	is.NoErr(err)
+	is.Equal(len(segments), 3)
+
+	// the segments are removed with the object
+	is.NoErr(c.RemoveItem("large"))
+	segments, err = client.ObjectNamesAll("container_segments", nil)
+	is.NoErr(err)
+	is.Equal(len(segments), 0)
+}
+
+func TestSetMetadataLargeObject(t *testing.T) {
+	is := is.New(t)
+	server, err := swifttest.NewSwiftServer("localhost")
+	is.NoErr(err)
+	defer server.Close()
+	// Without the info of the cluster, dynamic large objects are used.
+	server.SetOverride("/info", func(w http.ResponseWriter, r *http.Request, recorder *httptest.ResponseRecorder) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	// The fake server keeps the manifest of updated objects, which
+	// Swift doesn't, so the updates are checked to send it.
+	var manifests []string
+	server.SetOverride("/v1/AUTH_"+swifttest.TEST_ACCOUNT+"/container/large", func(w http.ResponseWriter, r *http.Request, recorder *httptest.ResponseRecorder) {
+		if r.Method == http.MethodPost {
+			manifests = append(manifests, r.Header.Get("X-Object-Manifest"))
+		}
+		for key, values := range recorder.Header() {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(recorder.Code)
+		w.Write(recorder.Body.Bytes())
+	})
+	client := &swift.Connection{
+		UserName: swifttest.TEST_ACCOUNT,
+		ApiKey:   swifttest.TEST_ACCOUNT,
+		AuthUrl:  server.AuthURL,
+	}
+	is.NoErr(client.Authenticate())
+	is.NoErr(client.ContainerCreate("container", nil))
+	defer func(size int64) { largeObjectSegmentSize = size }(largeObjectSegmentSize)
+	largeObjectSegmentSize = 4
+	c := &container{id: "container", client: client}
+
+	_, err = c.Put("large", strings.NewReader("0123456789"), -1, nil)
+	is.NoErr(err)
+	manifests = nil
+	is.NoErr(c.SetMetadata("large", map[string]interface{}{"key": "value"}, false))
+	is.NoErr(c.SetMetadata("large", map[string]interface{}{"other": "value"}, true))
+	is.Equal(len(manifests), 2)
+	is.Equal(manifests[0], manifests[1])
+	is.True(strings.HasPrefix(manifests[0], "container_segments/large/"))
+
+	contents, err := client.ObjectGetString("container", "large")
+	is.NoErr(err)
+	is.Equal(contents, "0123456789")
+	_, headers, err := client.Object("container", "large")
+	is.NoErr(err)
+	is.Equal(headers.ObjectMetadata()["other"], "value")
+}
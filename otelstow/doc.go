@@ -0,0 +1,29 @@
+/*
+Package otelstow traces the calls made to stow Locations with OpenTelemetry.
+
+Middleware is a stow.Middleware that makes a span for each call intercepted by
+stow.Wrap, such as Put, Items or Open, with the kind of the Location, the
+container, the key of the item, the bytes written or read and the status of the
+call as attributes. Wrap and Dial wrap Locations with it.
+
+The context of the span is passed to the Ctx methods of the Location, such as
+PutCtx, which pass it on to the HTTP clients of the SDKs. otelstow doesn't
+instrument those clients: the requests they send only get spans, children of
+the span of the call, when their transports are instrumented, such as with
+otelhttp. The Locations whose clients use http.DefaultTransport, such as those
+of swift, oracle and b2, are instrumented by replacing it before they are
+dialed:
+
+	http.DefaultTransport = otelhttp.NewTransport(http.DefaultTransport)
+
+	location, err := otelstow.Dial("swift", config, otelstow.Options{})
+	if err != nil {
+		return err
+	}
+	contextLocation, ok := location.(stow.ContextLocation)
+	if !ok {
+		return errors.New("no context support")
+	}
+	container, err := contextLocation.ContainerCtx(ctx, "container")
+*/
+package otelstow
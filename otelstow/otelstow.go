@@ -0,0 +1,191 @@
+package otelstow
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/aldor007/stow"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName is the name of the tracer of the spans.
+const instrumentationName = "github.com/aldor007/stow/otelstow"
+
+// The attributes of the spans.
+const (
+	// KindKey is the kind of the Location, such as s3.
+	KindKey = attribute.Key("stow.kind")
+	// ContainerKey is the ID of the Container.
+	ContainerKey = attribute.Key("stow.container")
+	// KeyKey is the ID of the Item.
+	KeyKey = attribute.Key("stow.key")
+	// BytesKey is the number of bytes written by Put, or read from
+	// the reader returned by Open once it is closed.
+	BytesKey = attribute.Key("stow.bytes")
+	// CountKey is the number of Items or Containers listed.
+	CountKey = attribute.Key("stow.count")
+	// StatusKey is ok, or the kind of error the call failed with,
+	// such as not_found.
+	StatusKey = attribute.Key("stow.status")
+)
+
+// Options describes how calls are traced. The zero value uses the
+// global TracerProvider.
+type Options struct {
+	// TracerProvider provides the tracer of the spans. The global
+	// one is used when it is nil.
+	TracerProvider trace.TracerProvider
+}
+
+// Wrap returns location with its calls traced by Middleware.
+func Wrap(location stow.Location, kind string, opts Options) stow.Location {
+	return stow.Wrap(location, Middleware(kind, opts))
+}
+
+// Dial dials a Location with stow.Dial and wraps it with Wrap.
+func Dial(kind string, config stow.Config, opts Options) (stow.Location, error) {
+	location, err := stow.Dial(kind, config)
+	if err != nil {
+		return nil, err
+	}
+	return Wrap(location, kind, opts), nil
+}
+
+// Middleware makes a span named after the method, such as stow.Put, for
+// each call to a Location of the specified kind wrapped by stow.Wrap.
+// The spans of the calls that open Items end when their readers are
+// closed, so they include the reading.
+func Middleware(kind string, opts Options) stow.Middleware {
+	provider := opts.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	tracer := provider.Tracer(instrumentationName)
+
+	return func(ctx context.Context, call *stow.Call, next stow.Handler) error {
+		attrs := []attribute.KeyValue{KindKey.String(kind)}
+		if call.Container != "" {
+			attrs = append(attrs, ContainerKey.String(call.Container))
+		}
+		if call.Item != "" {
+			attrs = append(attrs, KeyKey.String(call.Item))
+		}
+		if size, ok := written(call); ok {
+			attrs = append(attrs, BytesKey.Int64(size))
+		}
+		ctx, span := tracer.Start(ctx, "stow."+call.Method,
+			trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+
+		err := next(ctx, call)
+		if err != nil {
+			end(span, err)
+			return err
+		}
+		if len(call.Results) > 0 {
+			switch result := call.Results[0].(type) {
+			case io.ReadCloser:
+				call.Results[0] = &readCloser{ReadCloser: result, span: span}
+				return nil
+			case []stow.Item:
+				span.SetAttributes(CountKey.Int(len(result)))
+			case []stow.Container:
+				span.SetAttributes(CountKey.Int(len(result)))
+			}
+		}
+		end(span, nil)
+		return nil
+	}
+}
+
+// written gets the size of the contents written by call, if it is
+// known.
+func written(call *stow.Call) (int64, bool) {
+	switch call.Method {
+	case "Put", "PutIf", "PutWithOptions", "UploadPart":
+	default:
+		return 0, false
+	}
+	for _, arg := range call.Args {
+		if size, ok := arg.(int64); ok {
+			return size, size >= 0
+		}
+	}
+	return 0, false
+}
+
+// end ends span with the status of err.
+func end(span trace.Span, err error) {
+	span.SetAttributes(StatusKey.String(status(err)))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// statuses are the values of StatusKey for the errors declared by stow.
+var statuses = []struct {
+	err    error
+	status string
+}{
+	{stow.ErrNotFound, "not_found"},
+	{stow.ErrBadCursor, "bad_cursor"},
+	{stow.ErrAlreadyExists, "already_exists"},
+	{stow.ErrPermissionDenied, "permission_denied"},
+	{stow.ErrPreconditionFailed, "precondition_failed"},
+	{stow.ErrContainerNotEmpty, "container_not_empty"},
+	{stow.ErrThrottled, "throttled"},
+	{stow.ErrInvalidName, "invalid_name"},
+	{stow.ErrNotModified, "not_modified"},
+	{context.Canceled, "canceled"},
+	{context.DeadlineExceeded, "deadline_exceeded"},
+}
+
+// status gets the value of StatusKey for err.
+func status(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	if stow.IsNotSupported(err) {
+		return "not_supported"
+	}
+	for _, s := range statuses {
+		if errors.Is(err, s.err) {
+			return s.status
+		}
+	}
+	return "error"
+}
+
+// readCloser counts the bytes read from an opened Item, and ends the
+// span of the call that opened it when it is closed.
+type readCloser struct {
+	io.ReadCloser
+	span trace.Span
+	n    int64
+	err  error
+}
+
+func (r *readCloser) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
+
+func (r *readCloser) Close() error {
+	err := r.ReadCloser.Close()
+	r.span.SetAttributes(BytesKey.Int64(r.n))
+	if r.err != nil {
+		end(r.span, r.err)
+	} else {
+		end(r.span, err)
+	}
+	return err
+}
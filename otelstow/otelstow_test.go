@@ -0,0 +1,84 @@
+package otelstow_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aldor007/stow"
+	"github.com/aldor007/stow/local"
+	"github.com/aldor007/stow/otelstow"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// attrs gets the attributes of span by key.
+func attrs(span tracetest.SpanStub) map[attribute.Key]attribute.Value {
+	m := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes {
+		m[kv.Key] = kv.Value
+	}
+	return m
+}
+
+func TestSpans(t *testing.T) {
+	r := require.New(t)
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	opts := otelstow.Options{TracerProvider: provider}
+
+	location, err := otelstow.Dial(local.Kind, stow.ConfigMap{local.ConfigKeyPath: t.TempDir()}, opts)
+	r.NoError(err)
+	defer location.Close()
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	container, err := location.(stow.ContextLocation).CreateContainerCtx(ctx, "container")
+	r.NoError(err)
+	_, err = container.(stow.ContextContainer).PutCtx(ctx, "item", strings.NewReader("contents"), 8, nil)
+	r.NoError(err)
+	parent.End()
+
+	spans := exporter.GetSpans()
+	r.Len(spans, 3)
+	r.Equal("stow.CreateContainer", spans[0].Name)
+	put := spans[1]
+	r.Equal("stow.Put", put.Name)
+	r.Equal(parent.SpanContext().SpanID(), put.Parent.SpanID())
+	a := attrs(put)
+	r.Equal(local.Kind, a[otelstow.KindKey].AsString())
+	r.Equal(container.ID(), a[otelstow.ContainerKey].AsString())
+	r.Equal("item", a[otelstow.KeyKey].AsString())
+	r.Equal(int64(8), a[otelstow.BytesKey].AsInt64())
+	r.Equal("ok", a[otelstow.StatusKey].AsString())
+	exporter.Reset()
+
+	// the spans of opened Items end when they are closed
+	items, _, err := container.Items(stow.NoPrefix, stow.CursorStart, 10)
+	r.NoError(err)
+	r.Len(items, 1)
+	rc, err := items[0].Open()
+	r.NoError(err)
+	b, err := io.ReadAll(rc)
+	r.NoError(err)
+	r.Equal("contents", string(b))
+	r.Len(exporter.GetSpans(), 1)
+	r.NoError(rc.Close())
+	spans = exporter.GetSpans()
+	r.Len(spans, 2)
+	r.Equal(int64(1), attrs(spans[0])[otelstow.CountKey].AsInt64())
+	r.Equal("stow.Open", spans[1].Name)
+	r.Equal(int64(8), attrs(spans[1])[otelstow.BytesKey].AsInt64())
+	exporter.Reset()
+
+	_, err = container.Item("nope")
+	r.True(errors.Is(err, stow.ErrNotFound))
+	spans = exporter.GetSpans()
+	r.Len(spans, 1)
+	r.Equal(codes.Error, spans[0].Status.Code)
+	r.Equal("not_found", attrs(spans[0])[otelstow.StatusKey].AsString())
+}
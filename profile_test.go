@@ -0,0 +1,110 @@
+package stow_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/aldor007/stow"
+	"github.com/cheekybits/is"
+)
+
+func TestLoadProfiles(t *testing.T) {
+	is := is.New(t)
+	dir := t.TempDir()
+	secret := filepath.Join(dir, "secret")
+	is.NoErr(os.WriteFile(secret, []byte("from file\n"), 0600))
+	path := filepath.Join(dir, "profiles.yaml")
+	is.NoErr(os.WriteFile(path, []byte(`
+production:
+  kind: test
+  config:
+    user: someone
+    password: file:`+secret+`
+    token: env:STOW_TEST_TOKEN_SOURCE
+    region: eu-west-1
+    port: 9000
+    prefix: literal:env:HOME
+`), 0600))
+	t.Setenv("STOW_TEST_REGION", "us-east-1")
+	t.Setenv("STOW_TEST_TOKEN_SOURCE", "from env")
+	t.Setenv("STOW_TEST_NOTE", "env:STOW_TEST_TOKEN_SOURCE")
+
+	profiles, err := stow.LoadProfiles(path)
+	is.NoErr(err)
+	is.Equal(len(profiles), 1)
+	p := profiles["production"]
+	is.Equal(p.Name, "production")
+	is.Equal(p.Kind, testKind)
+
+	value, ok := p.Config.Config("user")
+	is.True(ok)
+	is.Equal(value, "someone")
+	value, _ = p.Config.Config("port")
+	is.Equal(value, "9000")
+	value, _ = p.Config.Config("password")
+	is.Equal(value, "from file")
+	value, _ = p.Config.Config("token")
+	is.Equal(value, "from env")
+	// literal values are not references
+	value, _ = p.Config.Config("prefix")
+	is.Equal(value, "env:HOME")
+	// and neither are those of the environment
+	value, _ = p.Config.Config("note")
+	is.Equal(value, "env:STOW_TEST_TOKEN_SOURCE")
+	// the environment overrides the file
+	value, _ = p.Config.Config("region")
+	is.Equal(value, "us-east-1")
+	// and Set overrides both
+	p.Config.Set("region", "ap-south-1")
+	value, _ = p.Config.Config("region")
+	is.Equal(value, "ap-south-1")
+	p.Config.Set("token", "file:/nope")
+	value, _ = p.Config.Config("token")
+	is.Equal(value, "file:/nope")
+	_, ok = p.Config.Config("nope")
+	is.False(ok)
+	is.NoErr(p.Config.Err())
+
+	// references are resolved when values are got
+	is.NoErr(os.WriteFile(secret, []byte("rotated"), 0600))
+	value, _ = p.Config.Config("password")
+	is.Equal(value, "rotated")
+	is.NoErr(os.Remove(secret))
+	_, ok = p.Config.Config("password")
+	is.False(ok)
+	is.Err(p.Config.Err())
+	// the error is reset when the reference resolves again
+	is.NoErr(os.WriteFile(secret, []byte("restored"), 0600))
+	value, _ = p.Config.Config("password")
+	is.Equal(value, "restored")
+	is.NoErr(p.Config.Err())
+
+	location, err := p.Dial()
+	is.NoErr(err)
+	is.OK(location)
+}
+
+func TestLoadProfileJSON(t *testing.T) {
+	is := is.New(t)
+	path := filepath.Join(t.TempDir(), "profiles.json")
+	is.NoErr(os.WriteFile(path, []byte(`{"dev": {"kind": "test", "config": {"user": "dev"}}}`), 0600))
+
+	p, err := stow.LoadProfile(path, "dev")
+	is.NoErr(err)
+	value, _ := p.Config.Config("user")
+	is.Equal(value, "dev")
+
+	_, err = stow.LoadProfile(path, "nope")
+	is.Err(err)
+}
+
+func TestEnvConfig(t *testing.T) {
+	is := is.New(t)
+	config := stow.NewEnvConfig("local-meta")
+	is.Equal(config, stow.EnvConfig("STOW_LOCAL_META_"))
+	t.Setenv("STOW_LOCAL_META_ALLOWMETADATA", "true")
+	value, ok := config.Config("allowMetadata")
+	is.True(ok)
+	is.Equal(value, "true")
+}
@@ -0,0 +1,47 @@
+package stow
+
+import (
+	"context"
+	"io"
+)
+
+// PutWithOptions puts an Item into container, stored as described by
+// opts, and returns it.
+// When container implements OptionsPutter the options are mapped by
+// the implementation. Otherwise the Item is put with Put, which only
+// takes the user metadata, so an error satisfying IsNotSupported is
+// returned when other options are set.
+func PutWithOptions(container Container, name string, r io.Reader, size int64, opts PutOptions) (Item, error) {
+	return PutWithOptionsCtx(context.Background(), container, name, r, size, opts)
+}
+
+// PutWithOptionsCtx is PutWithOptions with a context, which is passed
+// to the Ctx variants of the methods it calls.
+func PutWithOptionsCtx(ctx context.Context, container Container, name string, r io.Reader, size int64, opts PutOptions) (Item, error) {
+	if putter, ok := As[ContextOptionsPutter](container); ok {
+		return putter.PutWithOptionsCtx(ctx, name, r, size, opts)
+	}
+	if putter, ok := As[OptionsPutter](container); ok {
+		return putter.PutWithOptions(name, r, size, opts)
+	}
+	if opts.storageOptions() {
+		return nil, NotSupported("put options")
+	}
+	var metadata map[string]interface{}
+	if opts.Metadata != nil {
+		metadata = make(map[string]interface{}, len(opts.Metadata))
+		for key, value := range opts.Metadata {
+			metadata[key] = value
+		}
+	}
+	return putCtx(ctx, container, name, r, size, metadata)
+}
+
+// storageOptions gets whether o sets options other than the user
+// metadata.
+func (o PutOptions) storageOptions() bool {
+	return o.ContentType != "" || o.CacheControl != "" ||
+		o.ContentDisposition != "" || o.ContentEncoding != "" ||
+		o.ContentLanguage != "" || o.StorageClass != "" || o.ACL != "" ||
+		len(o.Tags) > 0 || len(o.ContentMD5) > 0
+}
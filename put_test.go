@@ -0,0 +1,52 @@
+package stow_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aldor007/stow"
+	"github.com/cheekybits/is"
+)
+
+// optionsContainer is a memContainer that records the options it is
+// given.
+type optionsContainer struct {
+	*memContainer
+	opts stow.PutOptions
+}
+
+func (c *optionsContainer) PutWithOptions(name string, r io.Reader, size int64, opts stow.PutOptions) (stow.Item, error) {
+	c.opts = opts
+	return c.Put(name, r, size, nil)
+}
+
+func TestPutWithOptions(t *testing.T) {
+	is := is.New(t)
+	c := &optionsContainer{memContainer: newMemContainer(true)}
+	opts := stow.PutOptions{ContentType: "text/plain", Metadata: map[string]string{"owner": "me"}}
+	_, err := stow.PutWithOptions(c, "item", strings.NewReader("item"), 4, opts)
+	is.NoErr(err)
+	is.Equal(c.opts.ContentType, "text/plain")
+}
+
+func TestPutWithOptionsFallback(t *testing.T) {
+	is := is.New(t)
+	c := newMemContainer(true)
+
+	// the user metadata is passed to Put
+	item, err := stow.PutWithOptions(c, "item", strings.NewReader("item"), 4, stow.PutOptions{
+		Metadata: map[string]string{"owner": "me"},
+	})
+	is.NoErr(err)
+	md, err := item.Metadata()
+	is.NoErr(err)
+	is.Equal(md["owner"], "me")
+
+	// other options can't be
+	_, err = stow.PutWithOptions(c, "other", strings.NewReader("item"), 4, stow.PutOptions{
+		ContentType: "text/plain",
+	})
+	is.True(stow.IsNotSupported(err))
+	is.Equal(len(c.items), 1)
+}
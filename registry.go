@@ -0,0 +1,151 @@
+package stow
+
+import (
+	"net/url"
+	"sync"
+)
+
+// DefaultRegistry is the Registry the package-level functions, such as
+// Register, Dial and KindByURL, use. Implementation packages register
+// their kinds in it when they are imported.
+var DefaultRegistry = NewRegistry()
+
+// Registry holds kinds of Location, their configuration schemas and
+// their connection strings. A Registry is safe for concurrent use.
+//
+// Most code uses DefaultRegistry through the package-level functions.
+// Separate registries let tests install fakes, and let subsystems
+// use different implementations of the same kind.
+type Registry struct {
+	mu sync.RWMutex // protects all the fields
+	// kinds holds a list of location kinds, in the order they were
+	// registered.
+	kinds []string
+	// locations is a map of installed location providers,
+	// supplying a function that creates a new instance of
+	// that Location.
+	locations map[string]func(Config) (Location, error)
+	// configurations is a map of installed location providers,
+	// supplying a function that validates the configuration
+	configurations map[string]func(Config) error
+	// kindmatches is a map of functions that take turns trying to
+	// match the kind of Location for a given URL.
+	kindmatches map[string]func(*url.URL) bool
+	// dsns is a map of the connection string mappings registered
+	// with RegisterDSN.
+	dsns map[string]DSN
+	// schemas is a map of the configuration schemas registered with
+	// RegisterSchema.
+	schemas map[string]Schema
+	// urlLocations holds the Locations dialed by OpenURL, PutURL,
+	// RemoveURL and StatURL. It has a lock of its own, as Locations
+	// are closed while it is held.
+	urlLocations urlLocations
+}
+
+// NewRegistry makes an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		locations:      map[string]func(Config) (Location, error){},
+		configurations: map[string]func(Config) error{},
+		kindmatches:    map[string]func(*url.URL) bool{},
+		dsns:           map[string]DSN{},
+		schemas:        map[string]Schema{},
+	}
+}
+
+// Register adds a Location implementation, as the package-level
+// Register does. A kind that is already registered is replaced, and
+// keeps its place in Kinds; the Locations of the kind kept by the URL
+// functions are closed.
+func (r *Registry) Register(kind string, makefn func(Config) (Location, error), kindmatchfn func(*url.URL) bool, validatefn func(Config) error) {
+	defer r.urlLocations.drop(kind)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.locations[kind]; !ok {
+		r.kinds = append(r.kinds, kind)
+	}
+	r.locations[kind] = makefn
+	r.configurations[kind] = validatefn
+	r.kindmatches[kind] = kindmatchfn
+}
+
+// Unregister removes a kind, along with its schema and connection
+// strings. Locations of the kind kept by the URL functions are closed,
+// while those dialed otherwise are left alone.
+func (r *Registry) Unregister(kind string) {
+	defer r.urlLocations.drop(kind)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, k := range r.kinds {
+		if k == kind {
+			r.kinds = append(r.kinds[:i:i], r.kinds[i+1:]...)
+			break
+		}
+	}
+	delete(r.locations, kind)
+	delete(r.configurations, kind)
+	delete(r.kindmatches, kind)
+	delete(r.dsns, kind)
+	delete(r.schemas, kind)
+}
+
+// Dial gets a new Location with the given kind and configuration.
+func (r *Registry) Dial(kind string, config Config) (Location, error) {
+	r.mu.RLock()
+	fn, ok := r.locations[kind]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, errUnknownKind(kind)
+	}
+	return fn(config)
+}
+
+// Validate validates the config for a location, as the package-level
+// Validate does.
+func (r *Registry) Validate(kind string, config Config) error {
+	r.mu.RLock()
+	fn, ok := r.configurations[kind]
+	schema, hasSchema := r.schemas[kind]
+	r.mu.RUnlock()
+	if !ok {
+		return errUnknownKind(kind)
+	}
+	if hasSchema {
+		if problems := schema.check(config); len(problems) > 0 {
+			return &ConfigError{Kind: kind, Problems: problems}
+		}
+	}
+	if fn == nil {
+		return nil
+	}
+	return fn(config)
+}
+
+// Kinds gets a list of the registered kinds.
+func (r *Registry) Kinds() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	kinds := make([]string, len(r.kinds))
+	copy(kinds, r.kinds)
+	return kinds
+}
+
+// KindByURL gets the kind represented by the given URL, consulting
+// the kinds in the order they were registered.
+func (r *Registry) KindByURL(u *url.URL) (string, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, kind := range r.kinds {
+		if fn := r.kindmatches[kind]; fn != nil && fn(u) {
+			return kind, nil
+		}
+	}
+	return "", errUnknownKind("")
+}
+
+// registered reports whether kind is registered. r.mu must be held.
+func (r *Registry) registered(kind string) bool {
+	_, ok := r.locations[kind]
+	return ok
+}
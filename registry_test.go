@@ -0,0 +1,73 @@
+package stow_test
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/aldor007/stow"
+	"github.com/cheekybits/is"
+)
+
+func TestRegistry(t *testing.T) {
+	is := is.New(t)
+	r := stow.NewRegistry()
+	is.Equal(len(r.Kinds()), 0)
+
+	dial := func(name string) func(stow.Config) (stow.Location, error) {
+		return func(config stow.Config) (stow.Location, error) {
+			config.Set("dialed", name)
+			return &testLocation{config: config}, nil
+		}
+	}
+	match := func(u *url.URL) bool { return u.Scheme == "fake" }
+	r.Register("fake", dial("first"), match, nil)
+	r.Register("other", dial("other"), nil, func(stow.Config) error { return errors.New("invalid") })
+	is.Equal(r.Kinds(), []string{"fake", "other"})
+	// registries are separate
+	_, err := stow.Dial("fake", stow.ConfigMap{})
+	is.Err(err)
+
+	config := stow.ConfigMap{}
+	_, err = r.Dial("fake", config)
+	is.NoErr(err)
+	is.Equal(config["dialed"], "first")
+	is.NoErr(r.Validate("fake", config))
+	is.Err(r.Validate("other", config))
+
+	// kinds are replaced in place
+	r.Register("fake", dial("second"), match, nil)
+	is.Equal(r.Kinds(), []string{"fake", "other"})
+	_, err = r.Dial("fake", config)
+	is.NoErr(err)
+	is.Equal(config["dialed"], "second")
+
+	u, err := url.Parse("fake://container/item")
+	is.NoErr(err)
+	kind, err := r.KindByURL(u)
+	is.NoErr(err)
+	is.Equal(kind, "fake")
+	// kinds without a match function match no URL
+	u, err = url.Parse("other://container/item")
+	is.NoErr(err)
+	_, err = r.KindByURL(u)
+	is.Err(err)
+
+	r.RegisterDSN("fake", stow.DSN{Host: "host"})
+	r.RegisterSchema("fake", stow.Schema{Keys: []stow.KeySchema{{Name: "host", Required: true}}})
+	location, err := r.DialURL("fake://minio.local")
+	is.NoErr(err)
+	host, _ := location.(*testLocation).config.Config("host")
+	is.Equal(host, "minio.local")
+	var configErr *stow.ConfigError
+	is.True(errors.As(r.Validate("fake", stow.ConfigMap{}), &configErr))
+
+	r.Unregister("fake")
+	is.Equal(r.Kinds(), []string{"other"})
+	_, err = r.Dial("fake", config)
+	is.Err(err)
+	_, err = r.ConfigSchema("fake")
+	is.Err(err)
+	_, _, err = r.ParseDSN("fake://minio.local")
+	is.Err(err)
+}
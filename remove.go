@@ -0,0 +1,121 @@
+package stow
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// removeConcurrency is the number of Items removed at once by
+// RemoveItems when the Container isn't a BatchRemover.
+const removeConcurrency = 16
+
+// removePageSize is the number of Items listed and removed at once by
+// RemovePrefix.
+const removePageSize = 1000
+
+// RemoveItems removes the Items ids of container. It returns the Items
+// that couldn't be removed, with the reason, as BatchRemover does.
+// When container implements BatchRemover the Items are removed in
+// batches; otherwise, or when the BatchRemover reports batches as not
+// supported, they are removed concurrently with RemoveItem.
+func RemoveItems(container Container, ids []string) (map[string]error, error) {
+	return RemoveItemsCtx(context.Background(), container, ids)
+}
+
+// RemoveItemsCtx is RemoveItems with a context, which is passed to the
+// Ctx variants of the methods it calls.
+func RemoveItemsCtx(ctx context.Context, container Container, ids []string) (map[string]error, error) {
+	if remover, ok := As[ContextBatchRemover](container); ok {
+		failed, err := remover.RemoveItemsCtx(ctx, ids)
+		if !IsNotSupported(err) {
+			return failed, err
+		}
+	} else if remover, ok := As[BatchRemover](container); ok {
+		failed, err := remover.RemoveItems(ids)
+		if !IsNotSupported(err) {
+			return failed, err
+		}
+	}
+	return removeConcurrently(ctx, container, ids), nil
+}
+
+// removeConcurrently removes the Items ids of container with
+// removeConcurrency calls to RemoveItem at a time.
+func removeConcurrently(ctx context.Context, container Container, ids []string) map[string]error {
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		failed = make(map[string]error)
+		queue  = make(chan string)
+	)
+	for i := 0; i < removeConcurrency && i < len(ids); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for id := range queue {
+				err := removeItemCtx(ctx, container, id)
+				if err == nil || errors.Is(err, ErrNotFound) {
+					continue
+				}
+				mu.Lock()
+				failed[id] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, id := range ids {
+		queue <- id
+	}
+	close(queue)
+	wg.Wait()
+	return failed
+}
+
+// RemovePrefix removes all Items of container whose names start with
+// prefix. The Items are listed with Walk and removed with RemoveItems,
+// a page at a time.
+// It stops at the first page with Items that couldn't be removed and
+// returns the error of one of them.
+func RemovePrefix(container Container, prefix string) error {
+	return RemovePrefixCtx(context.Background(), container, prefix)
+}
+
+// RemovePrefixCtx is RemovePrefix with a context, which is passed to
+// WalkCtx and RemoveItemsCtx, so that the removal stops once ctx is
+// done.
+func RemovePrefixCtx(ctx context.Context, container Container, prefix string) error {
+	var ids []string
+	remove := func() error {
+		failed, err := RemoveItemsCtx(ctx, container, ids)
+		if err != nil {
+			return err
+		}
+		for id, err := range failed {
+			if len(failed) > 1 {
+				return fmt.Errorf("removing %s and %d more items: %w", id, len(failed)-1, err)
+			}
+			return fmt.Errorf("removing %s: %w", id, err)
+		}
+		ids = ids[:0]
+		return nil
+	}
+	err := WalkCtx(ctx, container, prefix, removePageSize, func(item Item, err error) error {
+		if err != nil {
+			return err
+		}
+		ids = append(ids, item.ID())
+		if len(ids) < removePageSize {
+			return nil
+		}
+		return remove()
+	})
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return remove()
+}
@@ -0,0 +1,102 @@
+package stow_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/aldor007/stow"
+	"github.com/cheekybits/is"
+)
+
+func TestRemoveItemsConcurrently(t *testing.T) {
+	is := is.New(t)
+	c := newMemContainer(false)
+	var ids []string
+	for i := 0; i < 50; i++ {
+		id := fmt.Sprintf("item%02d", i)
+		_, err := c.Put(id, strings.NewReader(id), 6, nil)
+		is.NoErr(err)
+		ids = append(ids, id)
+	}
+
+	// items that don't exist are not failures
+	failed, err := stow.RemoveItems(c, append(ids, "missing"))
+	is.NoErr(err)
+	is.Equal(len(failed), 0)
+	is.Equal(len(c.items), 0)
+}
+
+func TestRemoveItemsBatch(t *testing.T) {
+	is := is.New(t)
+	c := &batchContainer{memContainer: newMemContainer(false)}
+	for _, id := range []string{"a", "b", "locked"} {
+		_, err := c.Put(id, strings.NewReader(id), int64(len(id)), nil)
+		is.NoErr(err)
+	}
+
+	failed, err := stow.RemoveItems(c, []string{"a", "b", "locked"})
+	is.NoErr(err)
+	is.Equal(c.batches, 1)
+	is.Equal(len(failed), 1)
+	is.True(errors.Is(failed["locked"], stow.ErrPermissionDenied))
+	is.Equal(len(c.items), 1)
+}
+
+func TestRemovePrefix(t *testing.T) {
+	is := is.New(t)
+	c := &batchContainer{memContainer: newMemContainer(false)}
+	for i := 0; i < 2500; i++ {
+		id := fmt.Sprintf("logs/%04d", i)
+		_, err := c.Put(id, strings.NewReader(id), 9, nil)
+		is.NoErr(err)
+	}
+	_, err := c.Put("other", strings.NewReader("other"), 5, nil)
+	is.NoErr(err)
+
+	is.NoErr(stow.RemovePrefix(c, "logs/"))
+	is.Equal(c.batches, 3)
+	is.Equal(len(c.items), 1)
+	is.OK(c.items["other"])
+
+	_, err = c.Put("logs/locked", strings.NewReader("locked"), 6, nil)
+	is.NoErr(err)
+	err = stow.RemovePrefix(c, "logs/")
+	is.True(errors.Is(err, stow.ErrPermissionDenied))
+}
+
+func TestRemovePrefixCtxCanceled(t *testing.T) {
+	is := is.New(t)
+	c := &batchContainer{memContainer: newMemContainer(false)}
+	_, err := c.Put("logs/a", strings.NewReader("a"), 1, nil)
+	is.NoErr(err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = stow.RemovePrefixCtx(ctx, c, "logs/")
+	is.True(errors.Is(err, context.Canceled))
+	is.Equal(c.batches, 0)
+	is.Equal(len(c.items), 1)
+}
+
+// batchContainer is a memContainer that removes items in batches,
+// failing to remove the items whose IDs end with "locked".
+type batchContainer struct {
+	*memContainer
+	batches int
+}
+
+func (c *batchContainer) RemoveItems(ids []string) (map[string]error, error) {
+	c.batches++
+	failed := make(map[string]error)
+	for _, id := range ids {
+		if strings.HasSuffix(id, "locked") {
+			failed[id] = stow.ErrPermissionDenied
+			continue
+		}
+		delete(c.items, id)
+	}
+	return failed, nil
+}
@@ -0,0 +1,60 @@
+package s3
+
+import (
+	"context"
+
+	"github.com/aldor007/stow"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/pkg/errors"
+)
+
+// deleteObjectsLimit is the maximum number of objects deleted by a
+// DeleteObjects request.
+const deleteObjectsLimit = 1000
+
+var (
+	_ stow.BatchRemover        = (*container)(nil)
+	_ stow.ContextBatchRemover = (*container)(nil)
+)
+
+// RemoveItems removes the objects with DeleteObjects requests, 1000
+// objects per request.
+func (c *container) RemoveItems(ids []string) (map[string]error, error) {
+	return c.RemoveItemsCtx(context.Background(), ids)
+}
+
+// RemoveItemsCtx is RemoveItems with a context.
+func (c *container) RemoveItemsCtx(ctx context.Context, ids []string) (map[string]error, error) {
+	failed := make(map[string]error)
+	for len(ids) > 0 {
+		batch := ids
+		if len(batch) > deleteObjectsLimit {
+			batch = batch[:deleteObjectsLimit]
+		}
+		ids = ids[len(batch):]
+		objects := make([]types.ObjectIdentifier, len(batch))
+		for i, id := range batch {
+			objects[i] = types.ObjectIdentifier{Key: aws.String(id)}
+		}
+		res, err := c.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(c.name),
+			Delete: &types.Delete{Objects: objects, Quiet: true},
+		})
+		if err != nil {
+			return failed, errors.Wrap(mapError(err), "deleting objects")
+		}
+		for _, e := range res.Errors {
+			err := mapError(&smithy.GenericAPIError{
+				Code:    aws.ToString(e.Code),
+				Message: aws.ToString(e.Message),
+			})
+			if !errors.Is(err, stow.ErrNotFound) {
+				failed[aws.ToString(e.Key)] = err
+			}
+		}
+	}
+	return failed, nil
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httptrace"
 	"net/url"
+	"strconv"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/credentials"
@@ -47,8 +48,20 @@ const (
 	// used for e.g. minio.io
 	ConfigEndpoint = "endpoint"
 
-	// ConfigHTTPTracing enable verbose logs for http requests
-	ConfigHTTPTracing = "false"
+	// ConfigHTTPTracing is an optional "true" or "false" setting
+	// whether the HTTP requests are logged.
+	ConfigHTTPTracing = "http_tracing"
+
+	// ConfigHTTPTracingLegacy is the key ConfigHTTPTracing used to
+	// have, which is still read when ConfigHTTPTracing isn't set.
+	//
+	// Deprecated: Use ConfigHTTPTracing.
+	ConfigHTTPTracingLegacy = "false"
+
+	// ConfigPathStyle is an optional "true" or "false" setting whether
+	// buckets are addressed in the path of requests rather than in the
+	// host. It defaults to true with a custom endpoint.
+	ConfigPathStyle = "path_style"
 )
 
 // transport is an http.RoundTripper that keeps track of the in-flight
@@ -70,7 +83,7 @@ func (t *tracingTransport) RoundTrip(req *http.Request) (*http.Response, error)
 			if !info.Reused {
 				log.Printf("REQ_TRACE Method=%s GotConn: %+v url: %s NEW_CONN\n", req.Method, info, req.URL.String())
 			}
-			log.Printf("REQ_TRACE Method=%s GotConn: %+v url:  \n", req.Method, info, req.URL.String())
+			log.Printf("REQ_TRACE Method=%s GotConn: %+v url: %s\n", req.Method, info, req.URL.String())
 		},
 		ConnectStart: func(network, addr string) {
 			log.Printf("REQ_TRACE Method=%s ConnectStart\n", req.Method)
@@ -94,6 +107,12 @@ func init() {
 			return errors.New("invalid auth_type")
 		}
 
+		if pathStyle, ok := config.Config(ConfigPathStyle); ok && pathStyle != "" {
+			if _, err := strconv.ParseBool(pathStyle); err != nil {
+				return errors.New("invalid path_style")
+			}
+		}
+
 		if authType == authTypeAccessKey {
 			_, ok := config.Config(ConfigAccessKeyID)
 			if !ok {
@@ -151,6 +170,38 @@ func init() {
 	}
 
 	stow.Register(Kind, makefn, kindfn, validatefn)
+	stow.RegisterDSN(Kind, stow.DSN{
+		User:     ConfigAccessKeyID,
+		Password: ConfigSecretKey,
+		Endpoint: ConfigEndpoint,
+		Query: map[string]string{
+			ConfigAuthType:  ConfigAuthType,
+			ConfigRegion:    ConfigRegion,
+			ConfigPathStyle: ConfigPathStyle,
+		},
+	})
+	stow.RegisterSchema(Kind, stow.Schema{Keys: []stow.KeySchema{
+		{Name: ConfigAuthType, Default: authTypeAccessKey, Values: []string{authTypeAccessKey, authTypeIAM},
+			Description: "Whether to authenticate with an access key or the IAM role of the instance."},
+		{Name: ConfigAccessKeyID, Description: "The ID of the access key, required with accesskey authentication."},
+		{Name: ConfigSecretKey, Secret: true, Description: "The secret of the access key, required with accesskey authentication."},
+		{Name: ConfigRegion, Default: "us-east-1", Description: "The region of the buckets."},
+		{Name: ConfigEndpoint, Type: stow.TypeURL, Description: "The endpoint of an S3 compatible service, such as minio."},
+		{Name: ConfigPathStyle, Type: stow.TypeBool,
+			Description: "Whether buckets are addressed in the path of requests. It defaults to true with an endpoint."},
+		{Name: ConfigHTTPTracing, Type: stow.TypeBool, Default: "false", Description: "Whether to log the HTTP requests."},
+		{Name: ConfigHTTPTracingLegacy, Type: stow.TypeBool, Description: "Deprecated: use http_tracing."},
+	}})
+}
+
+// httpTracing gets whether the HTTP requests are logged, from
+// ConfigHTTPTracing or else its legacy key.
+func httpTracing(config stow.Config) bool {
+	v, ok := config.Config(ConfigHTTPTracing)
+	if !ok {
+		v, _ = config.Config(ConfigHTTPTracingLegacy)
+	}
+	return v == "true"
 }
 
 // Attempts to create a session based on the information given.
@@ -179,7 +230,7 @@ func newS3Client(config stow.Config, region string) (client *s3.Client, endpoint
 		MaxIdleConnsPerHost: 50,
 		IdleConnTimeout:     60 * time.Second,
 	})
-	if v, ok := config.Config(ConfigHTTPTracing); ok && v == "true" {
+	if httpTracing(config) {
 		transport = &tracingTransport{
 			transport: transport,
 		}
@@ -226,6 +277,9 @@ func newS3Client(config stow.Config, region string) (client *s3.Client, endpoint
 		if endpoint, ok := config.Config(ConfigEndpoint); ok && endpoint != "" {
 			options.UsePathStyle = true
 		}
+		if pathStyle, ok := config.Config(ConfigPathStyle); ok && pathStyle != "" {
+			options.UsePathStyle, _ = strconv.ParseBool(pathStyle)
+		}
 	})
 
 	return s3Client, endpoint, nil
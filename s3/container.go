@@ -1,14 +1,18 @@
 package s3
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/base64"
 	"fmt"
+	"hash"
 
 	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
-	"github.com/aws/smithy-go"
 
 	"io"
+	"net/url"
 	"strings"
 	"sync"
 
@@ -16,8 +20,11 @@ import (
 
 	"github.com/aldor007/stow"
 	"github.com/aws/aws-sdk-go-v2/aws"
+	awsmiddleware "github.com/aws/aws-sdk-go-v2/aws/middleware"
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/smithy-go/middleware"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/pkg/errors"
 )
 
@@ -34,10 +41,29 @@ type container struct {
 	lock           sync.Mutex
 }
 
+var (
+	_ stow.Container                = (*container)(nil)
+	_ stow.ContextContainer         = (*container)(nil)
+	_ stow.CapabilityReporter       = (*container)(nil)
+	_ stow.Copier                   = (*container)(nil)
+	_ stow.ContextCopier            = (*container)(nil)
+	_ stow.ConditionalPutter        = (*container)(nil)
+	_ stow.ContextConditionalPutter = (*container)(nil)
+	_ stow.ItemWriter               = (*container)(nil)
+	_ stow.DelimitedLister          = (*container)(nil)
+	_ stow.ContextDelimitedLister   = (*container)(nil)
+	_ stow.OptionsPutter            = (*container)(nil)
+	_ stow.ContextOptionsPutter     = (*container)(nil)
+	_ stow.Stater                   = (*container)(nil)
+	_ stow.ContextStater            = (*container)(nil)
+)
+
 type s3DataType struct {
 	contentType        *string
 	cacheControl       *string
 	contentDisposition *string
+	contentEncoding    *string
+	contentLanguage    *string
 	storageClass       string
 	contentMd5         *string
 	tags               *string
@@ -81,6 +107,11 @@ func (c *container) PreSignRequest(ctx context.Context,
 	return req.URL, nil
 }
 
+// Capabilities describes the features supported by this container.
+func (c *container) Capabilities() stow.Capabilities {
+	return capabilities()
+}
+
 // ID returns a string value which represents the name of the container.
 func (c *container) ID() string {
 	return c.name
@@ -95,12 +126,36 @@ func (c *container) Name() string {
 // retrieved item only contains metadata about the object. This ensures that only the minimum amount of information is
 // transferred. Calling item.Open() will actually do a get request and open a stream to read from.
 func (c *container) Item(id string) (stow.Item, error) {
-	return c.getItem(id)
+	return c.ItemCtx(context.Background(), id)
+}
+
+// ItemCtx is Item with a context.
+func (c *container) ItemCtx(ctx context.Context, id string) (stow.Item, error) {
+	return c.getItem(ctx, id)
+}
+
+// Stat gets the information of an object with a HEAD request.
+func (c *container) Stat(id string) (stow.ItemInfo, error) {
+	return c.StatCtx(context.Background(), id)
+}
+
+// StatCtx is Stat with a context.
+func (c *container) StatCtx(ctx context.Context, id string) (stow.ItemInfo, error) {
+	item, err := c.getItem(ctx, id)
+	if err != nil {
+		return stow.ItemInfo{}, err
+	}
+	return stow.NewItemInfo(item)
 }
 
 // Items sends a request to retrieve a list of items that are prepended with
 // the prefix argument. The 'cursor' variable facilitates pagination.
 func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
+	return c.ItemsCtx(context.Background(), prefix, cursor, count)
+}
+
+// ItemsCtx is Items with a context.
+func (c *container) ItemsCtx(ctx context.Context, prefix, cursor string, count int) ([]stow.Item, string, error) {
 	itemLimit := int32(count)
 
 	params := &s3.ListObjectsV2Input{
@@ -110,19 +165,68 @@ func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string
 		Prefix:     &prefix,
 	}
 
-	response, err := c.client.ListObjectsV2(context.TODO(), params)
+	response, err := c.client.ListObjectsV2(ctx, params)
 	if err != nil {
-		return nil, "", errors.Wrap(err, "Items, listing objects")
+		return nil, "", errors.Wrap(mapError(err), "Items, listing objects")
+	}
+
+	containerItems := c.objectItems(response.Contents)
+
+	// Create a marker and determine if the list of items to retrieve is complete.
+	// If not, the last file is the input to the value of after which item to start
+	startAfter := ""
+	if response.IsTruncated {
+		startAfter = containerItems[len(containerItems)-1].Name()
 	}
 
-	var containerItems []stow.Item
+	return containerItems, startAfter, nil
+}
+
+// ItemsDelimited lists the objects with the delimiter of
+// ListObjectsV2. The cursor is the continuation token of the listing.
+func (c *container) ItemsDelimited(prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	return c.ItemsDelimitedCtx(context.Background(), prefix, delimiter, cursor, count)
+}
+
+// ItemsDelimitedCtx is ItemsDelimited with a context.
+func (c *container) ItemsDelimitedCtx(ctx context.Context, prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	params := &s3.ListObjectsV2Input{
+		Bucket:  aws.String(c.Name()),
+		MaxKeys: int32(count),
+		Prefix:  aws.String(prefix),
+	}
+	if delimiter != "" {
+		params.Delimiter = aws.String(delimiter)
+	}
+	if cursor != stow.CursorStart {
+		params.ContinuationToken = aws.String(cursor)
+	}
+	response, err := c.client.ListObjectsV2(ctx, params)
+	if err != nil {
+		return nil, nil, "", errors.Wrap(mapError(err), "ItemsDelimited, listing objects")
+	}
+	prefixes := make([]string, len(response.CommonPrefixes))
+	for i, commonPrefix := range response.CommonPrefixes {
+		prefixes[i] = aws.ToString(commonPrefix.Prefix)
+	}
+	next := ""
+	if response.IsTruncated {
+		next = aws.ToString(response.NextContinuationToken)
+	}
+	return c.objectItems(response.Contents), prefixes, next, nil
+}
 
-	for _, object := range response.Contents {
+// objectItems makes items of the listed objects, leaving out those
+// archived in Glacier.
+func (c *container) objectItems(objects []types.Object) []stow.Item {
+	var items []stow.Item
+	for _, object := range objects {
 		if object.StorageClass == types.ObjectStorageClassGlacier {
 			continue
 		}
 		etag := cleanEtag(*object.ETag) // Copy etag value and remove the strings.
 		object.ETag = &etag             // Assign the value to the object field representing the item.
+		size := object.Size
 
 		newItem := &item{
 			container: c,
@@ -132,32 +236,29 @@ func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string
 				Key:          object.Key,
 				LastModified: object.LastModified,
 				Owner:        object.Owner,
-				Size:         &object.Size,
+				Size:         &size,
 				StorageClass: string(object.StorageClass),
 			},
 		}
-		containerItems = append(containerItems, newItem)
-	}
-
-	// Create a marker and determine if the list of items to retrieve is complete.
-	// If not, the last file is the input to the value of after which item to start
-	startAfter := ""
-	if response.IsTruncated {
-		startAfter = containerItems[len(containerItems)-1].Name()
+		items = append(items, newItem)
 	}
-
-	return containerItems, startAfter, nil
+	return items
 }
 
 func (c *container) RemoveItem(id string) error {
+	return c.RemoveItemCtx(context.Background(), id)
+}
+
+// RemoveItemCtx is RemoveItem with a context.
+func (c *container) RemoveItemCtx(ctx context.Context, id string) error {
 	params := &s3.DeleteObjectInput{
 		Bucket: aws.String(c.Name()),
 		Key:    aws.String(id),
 	}
 
-	_, err := c.client.DeleteObject(context.TODO(), params)
+	_, err := c.client.DeleteObject(ctx, params)
 	if err != nil {
-		return errors.Wrapf(err, "RemoveItem, deleting object %+v", params)
+		return errors.Wrapf(mapError(err), "RemoveItem, deleting object %+v", params)
 	}
 	return nil
 }
@@ -167,15 +268,62 @@ func (c *container) RemoveItem(id string) error {
 // content, and the size of the file. Many more attributes can be given to the
 // file, including metadata. Keeping it simple for now.
 func (c *container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.PutCtx(context.Background(), name, r, size, metadata)
+}
+
+// PutCtx is Put with a context.
+func (c *container) PutCtx(ctx context.Context, name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.put(ctx, name, r, size, metadata)
+}
+
+// PutIf is Put with a condition, which is sent with the If-Match and
+// If-None-Match headers of the upload.
+func (c *container) PutIf(name string, r io.Reader, size int64, metadata map[string]interface{}, cond stow.PutCondition) (stow.Item, error) {
+	return c.PutIfCtx(context.Background(), name, r, size, metadata, cond)
+}
+
+// PutIfCtx is PutIf with a context.
+func (c *container) PutIfCtx(ctx context.Context, name string, r io.Reader, size int64, metadata map[string]interface{}, cond stow.PutCondition) (stow.Item, error) {
+	return c.put(ctx, name, r, size, metadata, func(u *manager.Uploader) {
+		u.ClientOptions = append(u.ClientOptions, withPutCondition(cond))
+	})
+}
+
+// PutWithOptions is Put with the options mapped to the headers of the
+// upload, as the magic keys of the metadata given to Put are.
+// ContentMD5 is sent with uploads of a single part. The upload manager
+// splits the others in parts, which it doesn't send it with, so their
+// contents are hashed as they are read and checked before the upload
+// is completed; contents that don't match abort it.
+func (c *container) PutWithOptions(name string, r io.Reader, size int64, opts stow.PutOptions) (stow.Item, error) {
+	return c.PutWithOptionsCtx(context.Background(), name, r, size, opts)
+}
+
+// PutWithOptionsCtx is PutWithOptions with a context.
+func (c *container) PutWithOptionsCtx(ctx context.Context, name string, r io.Reader, size int64, opts stow.PutOptions) (stow.Item, error) {
+	mdPrepped, s3Data := prepPutOptions(opts)
+	if len(opts.ContentMD5) == 0 {
+		return c.upload(ctx, name, r, size, mdPrepped, s3Data)
+	}
+	sum := md5.New()
+	return c.upload(ctx, name, io.TeeReader(r, sum), size, mdPrepped, s3Data, func(u *manager.Uploader) {
+		u.ClientOptions = append(u.ClientOptions, withContentMD5(opts.ContentMD5, sum))
+	})
+}
+
+func (c *container) put(ctx context.Context, name string, r io.Reader, size int64, metadata map[string]interface{}, optFns ...func(*manager.Uploader)) (stow.Item, error) {
 	// Convert map[string]interface{} to map[string]*string
 	mdPrepped, s3Data, err := prepMetadata(metadata)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create or update item, preparing metadata")
 	}
+	return c.upload(ctx, name, r, size, mdPrepped, s3Data, optFns...)
+}
 
+func (c *container) upload(ctx context.Context, name string, r io.Reader, size int64, mdPrepped map[string]string, s3Data s3DataType, optFns ...func(*manager.Uploader)) (stow.Item, error) {
 	uploader := manager.NewUploader(c.client)
 	// Perform an upload.
-	_, err = uploader.Upload(context.TODO(), &s3.PutObjectInput{
+	_, err := uploader.Upload(ctx, &s3.PutObjectInput{
 		Bucket:             aws.String(c.name),
 		Key:                aws.String(name),
 		Body:               r,
@@ -183,22 +331,30 @@ func (c *container) Put(name string, r io.Reader, size int64, metadata map[strin
 		ContentType:        s3Data.contentType,
 		CacheControl:       s3Data.cacheControl,
 		ContentDisposition: s3Data.contentDisposition,
+		ContentEncoding:    s3Data.contentEncoding,
+		ContentLanguage:    s3Data.contentLanguage,
 		ContentMD5:         s3Data.contentMd5,
 		StorageClass:       types.StorageClass(s3Data.storageClass),
 		ACL:                types.ObjectCannedACL(s3Data.cannedAcl),
 		Tagging:            s3Data.tags,
-	})
+	}, optFns...)
 
 	if err != nil {
-		return nil, errors.Wrap(err, "Put, uploading object")
+		return nil, errors.Wrap(mapError(err), "Put, uploading object")
 	}
-	i, err := c.client.HeadObject(context.TODO(), &s3.HeadObjectInput{
+	i, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
 		Key:    aws.String(name),
 		Bucket: aws.String(c.name),
 	})
 	var etag string
-	if i.ETag != nil && err == nil {
-		etag = cleanEtag(*i.ETag)
+	if err == nil {
+		if i.ETag != nil {
+			etag = cleanEtag(*i.ETag)
+		}
+		// the upload manager streams readers of unknown size in parts
+		if size < 0 {
+			size = i.ContentLength
+		}
 	}
 
 	// Some fields are empty because this information isn't included in the response.
@@ -230,6 +386,294 @@ func (c *container) Put(name string, r io.Reader, size int64, metadata map[strin
 	return newItem, nil
 }
 
+// CreateItem creates a new object whose contents are written to the
+// returned writer. The upload manager uploads them in parts as they
+// are written, so their size doesn't need to be known up front.
+func (c *container) CreateItem(name string) (stow.Item, io.WriteCloser, error) {
+	newItem := &item{
+		container: c,
+		client:    c.client,
+		properties: properties{
+			Key: aws.String(name),
+		},
+	}
+	w := stow.PipeWriter(func(r io.Reader) error {
+		ctx := context.Background()
+		uploader := manager.NewUploader(c.client)
+		_, err := uploader.Upload(ctx, &s3.PutObjectInput{
+			Bucket: aws.String(c.name),
+			Key:    aws.String(name),
+			Body:   r,
+		})
+		if err != nil {
+			return errors.Wrap(mapError(err), "CreateItem, uploading object")
+		}
+		created, err := c.getItem(ctx, name)
+		if err != nil {
+			return err
+		}
+		newItem.properties = created.properties
+		return nil
+	})
+	return newItem, w, nil
+}
+
+// maxCopySize is the largest object CopyObject can copy; larger
+// objects are copied in parts.
+const maxCopySize = 5 << 30
+
+// copyPartSize is the size of the parts larger objects are copied in.
+const copyPartSize = 512 << 20
+
+// Copy copies the object srcID to dstID in dstContainer, which must be
+// an S3 container reachable with the same credentials.
+// Objects larger than 5 GiB are copied with a multipart upload.
+func (c *container) Copy(srcID string, dstContainer stow.Container, dstID string, metadata map[string]interface{}) (stow.Item, error) {
+	return c.CopyCtx(context.Background(), srcID, dstContainer, dstID, metadata)
+}
+
+// CopyCtx is Copy with a context.
+func (c *container) CopyCtx(ctx context.Context, srcID string, dstContainer stow.Container, dstID string, metadata map[string]interface{}) (stow.Item, error) {
+	dst, ok := dstContainer.(*container)
+	if !ok {
+		return nil, stow.NotSupported("copy to another kind of container")
+	}
+	if dst.client != c.client {
+		// the client of dst may not be allowed to read c, or may not
+		// reach it: another account, region or endpoint.
+		return nil, stow.NotSupported("copy to a container of another client")
+	}
+	src, err := c.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(c.name),
+		Key:    aws.String(srcID),
+	})
+	if err != nil {
+		err = mapError(err)
+		if errors.Is(err, stow.ErrNotFound) {
+			return nil, stow.ErrNotFound
+		}
+		return nil, errors.Wrap(err, "Copy, getting the source object")
+	}
+	if src.ContentLength > maxCopySize {
+		err = dst.copyMultipart(ctx, c, srcID, src, dstID, metadata)
+	} else {
+		err = dst.copyObject(ctx, c.copySource(srcID), dstID, metadata)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return dst.getItem(ctx, dstID)
+}
+
+// copySource gets the URL encoded source of a copy of id.
+func (c *container) copySource(id string) string {
+	return (&url.URL{Path: c.name + "/" + id}).EscapedPath()
+}
+
+func (c *container) copyObject(ctx context.Context, source, id string, metadata map[string]interface{}) error {
+	input := &s3.CopyObjectInput{
+		Bucket:            aws.String(c.name),
+		Key:               aws.String(id),
+		CopySource:        aws.String(source),
+		MetadataDirective: types.MetadataDirectiveCopy,
+	}
+	if metadata != nil {
+		mdPrepped, s3Data, err := prepMetadata(metadata)
+		if err != nil {
+			return errors.Wrap(err, "unable to copy item, preparing metadata")
+		}
+		input.MetadataDirective = types.MetadataDirectiveReplace
+		input.Metadata = mdPrepped
+		input.ContentType = s3Data.contentType
+		input.CacheControl = s3Data.cacheControl
+		input.ContentDisposition = s3Data.contentDisposition
+		input.ContentEncoding = s3Data.contentEncoding
+		input.ContentLanguage = s3Data.contentLanguage
+		input.StorageClass = types.StorageClass(s3Data.storageClass)
+		input.ACL = types.ObjectCannedACL(s3Data.cannedAcl)
+		if s3Data.tags != nil {
+			input.TaggingDirective = types.TaggingDirectiveReplace
+			input.Tagging = s3Data.tags
+		}
+	}
+	_, err := c.client.CopyObject(ctx, input)
+	if err != nil {
+		return errors.Wrap(mapError(err), "Copy, copying object")
+	}
+	return nil
+}
+
+// copyMultipart copies the object srcID of from, described by src, in
+// parts with UploadPartCopy. Unlike CopyObject, a multipart upload
+// doesn't copy the metadata and tags of the source, so they are set
+// from src and the tags of srcID unless metadata replaces them. The
+// ACL of the source isn't copied, as S3 doesn't report it as a canned
+// ACL: the copy gets the default ACL of the bucket, or the one set in
+// metadata.
+func (c *container) copyMultipart(ctx context.Context, from *container, srcID string, src *s3.HeadObjectOutput, id string, metadata map[string]interface{}) error {
+	source := from.copySource(srcID)
+	tagging, err := from.client.GetObjectTagging(ctx, &s3.GetObjectTaggingInput{
+		Bucket: aws.String(from.name),
+		Key:    aws.String(srcID),
+	})
+	if err != nil {
+		return errors.Wrap(mapError(err), "Copy, getting the source tags")
+	}
+	var tags *string
+	if len(tagging.TagSet) > 0 {
+		values := url.Values{}
+		for _, tag := range tagging.TagSet {
+			values.Set(aws.ToString(tag.Key), aws.ToString(tag.Value))
+		}
+		tags = aws.String(values.Encode())
+	}
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:             aws.String(c.name),
+		Key:                aws.String(id),
+		Metadata:           src.Metadata,
+		ContentType:        src.ContentType,
+		CacheControl:       src.CacheControl,
+		ContentDisposition: src.ContentDisposition,
+		ContentEncoding:    src.ContentEncoding,
+		ContentLanguage:    src.ContentLanguage,
+		StorageClass:       src.StorageClass,
+		Tagging:            tags,
+	}
+	if metadata != nil {
+		mdPrepped, s3Data, err := prepMetadata(metadata)
+		if err != nil {
+			return errors.Wrap(err, "unable to copy item, preparing metadata")
+		}
+		input = &s3.CreateMultipartUploadInput{
+			Bucket:             aws.String(c.name),
+			Key:                aws.String(id),
+			Metadata:           mdPrepped,
+			ContentType:        s3Data.contentType,
+			CacheControl:       s3Data.cacheControl,
+			ContentDisposition: s3Data.contentDisposition,
+			ContentEncoding:    s3Data.contentEncoding,
+			ContentLanguage:    s3Data.contentLanguage,
+			StorageClass:       types.StorageClass(s3Data.storageClass),
+			ACL:                types.ObjectCannedACL(s3Data.cannedAcl),
+			Tagging:            s3Data.tags,
+		}
+	}
+	upload, err := c.client.CreateMultipartUpload(ctx, input)
+	if err != nil {
+		return errors.Wrap(mapError(err), "Copy, creating multipart upload")
+	}
+
+	// S3 allows at most 10000 parts.
+	partSize := int64(copyPartSize)
+	if minSize := (src.ContentLength + 9999) / 10000; partSize < minSize {
+		partSize = minSize
+	}
+	var parts []types.CompletedPart
+	for start, number := int64(0), int32(1); start < src.ContentLength; start, number = start+partSize, number+1 {
+		end := start + partSize - 1
+		if end >= src.ContentLength {
+			end = src.ContentLength - 1
+		}
+		part, err := c.client.UploadPartCopy(ctx, &s3.UploadPartCopyInput{
+			Bucket:          aws.String(c.name),
+			Key:             aws.String(id),
+			UploadId:        upload.UploadId,
+			PartNumber:      number,
+			CopySource:      aws.String(source),
+			CopySourceRange: aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+		})
+		if err != nil {
+			c.abortMultipart(id, upload.UploadId)
+			return errors.Wrapf(mapError(err), "Copy, copying part %d", number)
+		}
+		parts = append(parts, types.CompletedPart{
+			ETag:       part.CopyPartResult.ETag,
+			PartNumber: number,
+		})
+	}
+
+	_, err = c.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(c.name),
+		Key:             aws.String(id),
+		UploadId:        upload.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		c.abortMultipart(id, upload.UploadId)
+		return errors.Wrap(mapError(err), "Copy, completing multipart upload")
+	}
+	return nil
+}
+
+// abortMultipart aborts a multipart upload so its parts are not kept
+// (and billed) after a failure. It uses its own context as the one
+// of the upload may be done already.
+func (c *container) abortMultipart(id string, uploadID *string) {
+	c.client.AbortMultipartUpload(context.Background(), &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(c.name),
+		Key:      aws.String(id),
+		UploadId: uploadID,
+	})
+}
+
+// withPutCondition adds the headers of cond to the requests that
+// create an object: PutObject for small uploads and
+// CompleteMultipartUpload for multipart ones.
+func withPutCondition(cond stow.PutCondition) func(*s3.Options) {
+	return func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Build.Add(middleware.BuildMiddlewareFunc("StowPutCondition", func(
+				ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler,
+			) (middleware.BuildOutput, middleware.Metadata, error) {
+				req, ok := in.Request.(*smithyhttp.Request)
+				if !ok {
+					return next.HandleBuild(ctx, in)
+				}
+				switch awsmiddleware.GetOperationName(ctx) {
+				case "PutObject", "CompleteMultipartUpload":
+					if cond.IfMatch != "" {
+						req.Header.Set("If-Match", quoteEtag(cond.IfMatch))
+					}
+					if cond.IfNoneMatch {
+						req.Header.Set("If-None-Match", "*")
+					}
+				}
+				return next.HandleBuild(ctx, in)
+			}), middleware.After)
+		})
+	}
+}
+
+// withContentMD5 makes CompleteMultipartUpload requests fail with a
+// stow.ChecksumError when sum, which hashes the contents of the upload
+// as they are read, doesn't match expected. The upload manager reads
+// all the contents before completing the upload.
+func withContentMD5(expected []byte, sum hash.Hash) func(*s3.Options) {
+	return func(o *s3.Options) {
+		o.APIOptions = append(o.APIOptions, func(stack *middleware.Stack) error {
+			return stack.Build.Add(middleware.BuildMiddlewareFunc("StowContentMD5", func(
+				ctx context.Context, in middleware.BuildInput, next middleware.BuildHandler,
+			) (middleware.BuildOutput, middleware.Metadata, error) {
+				if awsmiddleware.GetOperationName(ctx) == "CompleteMultipartUpload" {
+					if actual := sum.Sum(nil); !bytes.Equal(actual, expected) {
+						return middleware.BuildOutput{}, middleware.Metadata{}, &stow.ChecksumError{Expected: expected, Actual: actual}
+					}
+				}
+				return next.HandleBuild(ctx, in)
+			}), middleware.After)
+		})
+	}
+}
+
+// quoteEtag reverses cleanEtag, as conditional headers expect quoted
+// ETags.
+func quoteEtag(etag string) string {
+	if strings.HasPrefix(etag, `"`) {
+		return etag
+	}
+	return `"` + etag + `"`
+}
+
 // Region returns a string representing the region/availability zone of the container.
 func (c *container) Region() string {
 	return c.region
@@ -242,24 +686,27 @@ func (c *container) Region() string {
 // done only once since the requested information is retained.
 // May be simpler to just stick it in PUT and and do a request every time, please vouch
 // for this if so.
-func (c *container) getItem(id string) (*item, error) {
+func (c *container) getItem(ctx context.Context, id string) (*item, error) {
+	return c.getItemVersion(ctx, id, "")
+}
+
+// getItemVersion gets the specified version of an item, or its current
+// version when versionID is empty.
+func (c *container) getItemVersion(ctx context.Context, id, versionID string) (*item, error) {
 	params := &s3.HeadObjectInput{
 		Bucket: aws.String(c.name),
 		Key:    aws.String(id),
 	}
-	res, err := c.client.HeadObject(context.TODO(), params)
+	if versionID != "" {
+		params.VersionId = aws.String(versionID)
+	}
+	res, err := c.client.HeadObject(ctx, params)
 	if err != nil {
-		var apiError smithy.APIError
-		if errors.As(err, &apiError) {
-			switch apiError.ErrorCode() {
-			case "NotFound":
-				return nil, stow.ErrNotFound
-			default:
-				return nil, fmt.Errorf("getItem, getting the object %v. code %s, message: %s", apiError, apiError.ErrorCode(), apiError.Error())
-			}
-
+		err = mapError(err)
+		if errors.Is(err, stow.ErrNotFound) {
+			return nil, stow.ErrNotFound
 		}
-		return nil, fmt.Errorf("getItem, getting the object %v", err)
+		return nil, errors.Wrap(err, "getItem, getting the object")
 	}
 
 	var etag string
@@ -296,6 +743,7 @@ func (c *container) getItem(id string) (*item, error) {
 	i := &item{
 		container: c,
 		client:    c.client,
+		versionID: versionID,
 		properties: properties{
 			ETag:         &etag,
 			Key:          &id,
@@ -364,6 +812,10 @@ func prepMetadata(md map[string]interface{}) (map[string]string, s3DataType, err
 			s3Data.contentType = awsValue
 		case "content-disposition":
 			s3Data.contentDisposition = awsValue
+		case "content-encoding":
+			s3Data.contentEncoding = awsValue
+		case "content-language":
+			s3Data.contentLanguage = awsValue
 		case "x-amz-storage-class":
 			s3Data.storageClass = strValue
 		case "x-amz-tagging":
@@ -380,6 +832,40 @@ func prepMetadata(md map[string]interface{}) (map[string]string, s3DataType, err
 	return m, s3Data, nil
 }
 
+// prepPutOptions maps opts to the metadata and headers of an upload.
+func prepPutOptions(opts stow.PutOptions) (map[string]string, s3DataType) {
+	m := make(map[string]string, len(opts.Metadata))
+	for key, value := range opts.Metadata {
+		m[strings.ToLower(key)] = value
+	}
+	s3Data := s3DataType{
+		storageClass: opts.StorageClass,
+		cannedAcl:    opts.ACL,
+	}
+	optional := func(value string) *string {
+		if value == "" {
+			return nil
+		}
+		return aws.String(value)
+	}
+	s3Data.contentType = optional(opts.ContentType)
+	s3Data.cacheControl = optional(opts.CacheControl)
+	s3Data.contentDisposition = optional(opts.ContentDisposition)
+	s3Data.contentEncoding = optional(opts.ContentEncoding)
+	s3Data.contentLanguage = optional(opts.ContentLanguage)
+	if len(opts.ContentMD5) > 0 {
+		s3Data.contentMd5 = aws.String(base64.StdEncoding.EncodeToString(opts.ContentMD5))
+	}
+	if len(opts.Tags) > 0 {
+		tags := url.Values{}
+		for key, value := range opts.Tags {
+			tags.Set(key, value)
+		}
+		s3Data.tags = aws.String(tags.Encode())
+	}
+	return m, s3Data
+}
+
 // The first letter of a dash separated key value is capitalized, so perform a ToLower on it.
 // This Key transformation of returning lowercase is consistent with other locations..
 func parseMetadata(md map[string]string) (map[string]interface{}, error) {
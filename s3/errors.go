@@ -0,0 +1,59 @@
+package s3
+
+import (
+	"net/http"
+
+	"github.com/aldor007/stow"
+	"github.com/aws/smithy-go"
+	"github.com/pkg/errors"
+)
+
+// mapError maps errors returned by the S3 API to the error kinds
+// declared by stow. The original error is kept in the chain.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var apiError smithy.APIError
+	if errors.As(err, &apiError) {
+		switch apiError.ErrorCode() {
+		case "NotFound", "NoSuchKey", "NoSuchBucket", "NoSuchUpload", "NoSuchVersion":
+			return stow.WrapError(stow.ErrNotFound, err)
+		case "BucketAlreadyExists", "BucketAlreadyOwnedByYou":
+			return stow.WrapError(stow.ErrAlreadyExists, err)
+		case "AccessDenied", "AllAccessDisabled", "InvalidAccessKeyId", "SignatureDoesNotMatch", "Forbidden":
+			return stow.WrapError(stow.ErrPermissionDenied, err)
+		case "PreconditionFailed", "ConditionalRequestConflict":
+			return stow.WrapError(stow.ErrPreconditionFailed, err)
+		case "BucketNotEmpty":
+			return stow.WrapError(stow.ErrContainerNotEmpty, err)
+		case "SlowDown", "Throttling", "ThrottlingException", "RequestLimitExceeded", "TooManyRequests":
+			return stow.WrapError(stow.ErrThrottled, err)
+		case "InvalidBucketName", "KeyTooLongError":
+			return stow.WrapError(stow.ErrInvalidName, err)
+		case "NotModified":
+			return stow.WrapError(stow.ErrNotModified, err)
+		case "InvalidRange":
+			return stow.WrapError(stow.ErrInvalidRange, err)
+		}
+	}
+	// HEAD requests have no body, so only the status code is known.
+	var respError interface{ HTTPStatusCode() int }
+	if errors.As(err, &respError) {
+		switch respError.HTTPStatusCode() {
+		case http.StatusNotFound:
+			return stow.WrapError(stow.ErrNotFound, err)
+		case http.StatusForbidden:
+			return stow.WrapError(stow.ErrPermissionDenied, err)
+		case http.StatusPreconditionFailed:
+			return stow.WrapError(stow.ErrPreconditionFailed, err)
+		case http.StatusNotModified:
+			return stow.WrapError(stow.ErrNotModified, err)
+		case http.StatusRequestedRangeNotSatisfiable:
+			return stow.WrapError(stow.ErrInvalidRange, err)
+		case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+			return stow.WrapError(stow.ErrThrottled, err)
+		}
+	}
+	return err
+}
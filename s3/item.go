@@ -6,7 +6,6 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"io"
 	"net/url"
-	"strings"
 	"sync"
 	"time"
 
@@ -30,21 +29,38 @@ type item struct {
 	client *s3.Client
 	// properties represent the characteristics of the file. Name, Etag, etc.
 	properties properties
-	infoOnce   sync.Once
-	infoErr    error
-	tags       map[string]interface{}
-	tagsOnce   sync.Once
-	tagsErr    error
-	rangeData  stow.ContentRangeData
+	// versionID is set for items that are a specific version of an
+	// object, as got by ItemVersion.
+	versionID string
+	infoOnce  sync.Once
+	infoErr   error
+	tagsMu    sync.Mutex // protects tags, tagsDone and tagsErr
+	tags      map[string]interface{}
+	tagsDone  bool
+	tagsErr   error
+	rangeData stow.ContentRangeData
 }
 
+var (
+	_ stow.Item                 = (*item)(nil)
+	_ stow.ContextItem          = (*item)(nil)
+	_ stow.ItemRanger           = (*item)(nil)
+	_ stow.ContextItemRanger    = (*item)(nil)
+	_ stow.Taggable             = (*item)(nil)
+	_ stow.ContextTaggable      = (*item)(nil)
+	_ stow.TagSetter            = (*item)(nil)
+	_ stow.ContextTagSetter     = (*item)(nil)
+	_ stow.OptionsOpener        = (*item)(nil)
+	_ stow.ContextOptionsOpener = (*item)(nil)
+)
+
 type properties struct {
-	ETag         *string    `type:"string"`
-	Key          *string    `min:"1" type:"string"`
-	LastModified *time.Time `type:"timestamp" timestampFormat:"iso8601"`
-	Owner        *types.Owner  `type:"structure"`
-	Size         *int64     `type:"integer"`
-	StorageClass string    `type:"string" enum:"ObjectStorageClass"`
+	ETag         *string      `type:"string"`
+	Key          *string      `min:"1" type:"string"`
+	LastModified *time.Time   `type:"timestamp" timestampFormat:"iso8601"`
+	Owner        *types.Owner `type:"structure"`
+	Size         *int64       `type:"integer"`
+	StorageClass string       `type:"string" enum:"ObjectStorageClass"`
 	Metadata     map[string]interface{}
 }
 
@@ -63,22 +79,13 @@ func (i *item) Size() (int64, error) {
 	return *i.properties.Size, nil
 }
 
-// URL returns a formatted string which follows the predefined format
-// that every S3 asset is given.
+// URL returns the URL of the object, s3://<bucket>/<key>, which
+// ItemByURL accepts whichever the endpoint.
 func (i *item) URL() *url.URL {
-	if i.container.customEndpoint == "" {
-		genericURL := fmt.Sprintf("https://s3-%s.amazonaws.com/%s/%s", i.container.Region(), i.container.Name(), i.Name())
-
-		return &url.URL{
-			Scheme: "s3",
-			Path:   genericURL,
-		}
-	}
-
-	genericURL := fmt.Sprintf("%s/%s", i.container.Name(), i.Name())
 	return &url.URL{
-		Scheme: "s3",
-		Path:   genericURL,
+		Scheme: Kind,
+		Host:   i.container.Name(),
+		Path:   "/" + i.Name(),
 	}
 }
 
@@ -86,33 +93,88 @@ func (i *item) URL() *url.URL {
 // and path of the file within the container. This response includes the body of
 // resource which is returned along with an error.
 func (i *item) Open() (io.ReadCloser, error) {
+	return i.OpenCtx(context.Background())
+}
+
+// OpenCtx is Open with a context. The returned body stops reading once
+// ctx is canceled.
+func (i *item) OpenCtx(ctx context.Context) (io.ReadCloser, error) {
 	params := &s3.GetObjectInput{
-		Bucket: aws.String(i.container.Name()),
-		Key:    aws.String(i.ID()),
+		Bucket:    aws.String(i.container.Name()),
+		Key:       aws.String(i.ID()),
+		VersionId: i.version(),
 	}
 
-	response, err := i.client.GetObject(context.TODO(), params)
+	response, err := i.client.GetObject(ctx, params)
 	if err != nil {
-		return nil, errors.Wrap(err, "Open, getting the object")
+		return nil, errors.Wrap(mapError(err), "Open, getting the object")
 	}
 	return response.Body, nil
 }
 
+// OpenParams opens the object with the Range header set to the
+// "range" string of p, if any.
 func (i *item) OpenParams(p map[string]interface{}) (io.ReadCloser, error) {
+	return i.OpenParamsCtx(context.Background(), p)
+}
+
+// OpenParamsCtx is OpenParams with a context.
+func (i *item) OpenParamsCtx(ctx context.Context, p map[string]interface{}) (io.ReadCloser, error) {
 	var strRange string
-	objetRange, ok := p["range"]
-	if ok {
-		strRange = objetRange.(string)
+	if objectRange, ok := p["range"]; ok {
+		if strRange, ok = objectRange.(string); !ok {
+			return nil, errors.Errorf(`value of key 'range' in params must be of type string`)
+		}
+	}
+	params := &s3.GetObjectInput{
+		Bucket:    aws.String(i.container.Name()),
+		Key:       aws.String(i.ID()),
+		Range:     aws.String(strRange),
+		VersionId: i.version(),
 	}
+	return i.getObject(ctx, params)
+}
+
+// OpenWithOptions opens the object with the options sent as the
+// headers and parameters of the request.
+func (i *item) OpenWithOptions(opts stow.OpenOptions) (io.ReadCloser, error) {
+	return i.OpenWithOptionsCtx(context.Background(), opts)
+}
+
+// OpenWithOptionsCtx is OpenWithOptions with a context.
+func (i *item) OpenWithOptionsCtx(ctx context.Context, opts stow.OpenOptions) (io.ReadCloser, error) {
 	params := &s3.GetObjectInput{
-		Bucket: aws.String(i.container.Name()),
-		Key:    aws.String(i.ID()),
-		Range:  aws.String(strRange),
+		Bucket:    aws.String(i.container.Name()),
+		Key:       aws.String(i.ID()),
+		VersionId: i.version(),
 	}
+	if opts.Range != nil {
+		params.Range = aws.String(opts.Range.String())
+	}
+	if opts.IfNoneMatch != "" {
+		params.IfNoneMatch = aws.String(`"` + opts.IfNoneMatch + `"`)
+	}
+	if !opts.IfModifiedSince.IsZero() {
+		params.IfModifiedSince = aws.Time(opts.IfModifiedSince)
+	}
+	if opts.VersionID != "" {
+		params.VersionId = aws.String(opts.VersionID)
+	}
+	if opts.ResponseContentType != "" {
+		params.ResponseContentType = aws.String(opts.ResponseContentType)
+	}
+	if opts.ResponseContentDisposition != "" {
+		params.ResponseContentDisposition = aws.String(opts.ResponseContentDisposition)
+	}
+	return i.getObject(ctx, params)
+}
 
-	response, err := i.client.GetObject(context.TODO(), params)
+// getObject gets the object and keeps the content range of ranged
+// responses.
+func (i *item) getObject(ctx context.Context, params *s3.GetObjectInput) (io.ReadCloser, error) {
+	response, err := i.client.GetObject(ctx, params)
 	if err != nil {
-		return nil, errors.Wrap(err, "Open, getting the object")
+		return nil, errors.Wrap(mapError(err), "Open, getting the object")
 	}
 	if cr := response.ContentRange; cr != nil {
 		i.rangeData = stow.ContentRangeData{ContentRange: *cr, ContentLength: response.ContentLength}
@@ -184,57 +246,132 @@ func (i *item) ensureInfo() error {
 }
 
 func (i *item) getInfo() (stow.Item, error) {
-	itemInfo, err := i.container.getItem(i.ID())
+	itemInfo, err := i.container.getItemVersion(context.Background(), i.ID(), i.versionID)
 	if err != nil {
 		return nil, err
 	}
 	return itemInfo, nil
 }
 
+// version gets the version of the object requests are made for, nil
+// for the current one.
+func (i *item) version() *string {
+	if i.versionID == "" {
+		return nil
+	}
+	return aws.String(i.versionID)
+}
+
 // Tags returns a map of tags on an Item
 func (i *item) Tags() (map[string]interface{}, error) {
-	i.tagsOnce.Do(func() {
-		params := &s3.GetObjectTaggingInput{
-			Bucket: aws.String(i.container.name),
-			Key:    aws.String(i.ID()),
-		}
+	return i.TagsCtx(context.Background())
+}
 
-		res, err := i.client.GetObjectTagging(context.TODO(), params)
-		if err != nil {
-			if strings.Contains(err.Error(), "NoSuchKey") {
-				i.tagsErr = stow.ErrNotFound
-				return
-			}
-			i.tagsErr = errors.Wrap(err, "getObjectTagging")
-			return
-		}
+// TagsCtx is Tags with a context. The tags are got once; calls
+// canceled before getting them don't keep their error.
+func (i *item) TagsCtx(ctx context.Context) (map[string]interface{}, error) {
+	i.tagsMu.Lock()
+	defer i.tagsMu.Unlock()
+	if i.tagsDone {
+		return i.tags, i.tagsErr
+	}
+	params := &s3.GetObjectTaggingInput{
+		Bucket:    aws.String(i.container.name),
+		Key:       aws.String(i.ID()),
+		VersionId: i.version(),
+	}
 
-		i.tags = make(map[string]interface{})
-		for _, t := range res.TagSet {
-			i.tags[*t.Key] = *t.Value
+	res, err := i.client.GetObjectTagging(ctx, params)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
 		}
-	})
+		err = mapError(err)
+		if errors.Is(err, stow.ErrNotFound) {
+			err = stow.ErrNotFound
+		} else {
+			err = errors.Wrap(err, "getObjectTagging")
+		}
+		i.tagsDone, i.tagsErr = true, err
+		return nil, err
+	}
 
-	return i.tags, i.tagsErr
+	i.tags = make(map[string]interface{})
+	for _, t := range res.TagSet {
+		i.tags[*t.Key] = *t.Value
+	}
+	i.tagsDone = true
+	return i.tags, nil
 }
 
-// OpenRange opens the item for reading starting at byte start and ending
-// at byte end.
-func (i *item) OpenRange(start, end uint64) (io.ReadCloser, error) {
-	params := &s3.GetObjectInput{
-		Bucket: aws.String(i.container.Name()),
-		Key:    aws.String(i.ID()),
-		Range:  aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
-	}
+// SetTags replaces the tags of the object.
+func (i *item) SetTags(tags map[string]string) error {
+	return i.SetTagsCtx(context.Background(), tags)
+}
 
-	response, err := i.client.GetObject(context.TODO(), params)
+// SetTagsCtx is SetTags with a context.
+func (i *item) SetTagsCtx(ctx context.Context, tags map[string]string) error {
+	tagSet := make([]types.Tag, 0, len(tags))
+	for key, value := range tags {
+		tagSet = append(tagSet, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+	_, err := i.client.PutObjectTagging(ctx, &s3.PutObjectTaggingInput{
+		Bucket:    aws.String(i.container.name),
+		Key:       aws.String(i.ID()),
+		VersionId: i.version(),
+		Tagging:   &types.Tagging{TagSet: tagSet},
+	})
 	if err != nil {
-		return nil, errors.Wrap(err, "Open, getting the object")
+		return errors.Wrap(mapError(err), "SetTags, putting object tagging")
+	}
+	i.resetTags(tags)
+	return nil
+}
 
+// DeleteTags removes all the tags of the object.
+func (i *item) DeleteTags() error {
+	return i.DeleteTagsCtx(context.Background())
+}
+
+// DeleteTagsCtx is DeleteTags with a context.
+func (i *item) DeleteTagsCtx(ctx context.Context) error {
+	_, err := i.client.DeleteObjectTagging(ctx, &s3.DeleteObjectTaggingInput{
+		Bucket:    aws.String(i.container.name),
+		Key:       aws.String(i.ID()),
+		VersionId: i.version(),
+	})
+	if err != nil {
+		return errors.Wrap(mapError(err), "DeleteTags, deleting object tagging")
 	}
-	if cr := response.ContentRange; cr != nil {
-		i.rangeData = stow.ContentRangeData{ContentRange: *cr, ContentLength: response.ContentLength}
+	i.resetTags(nil)
+	return nil
+}
+
+// resetTags makes Tags return tags without getting them again.
+func (i *item) resetTags(tags map[string]string) {
+	i.tagsMu.Lock()
+	defer i.tagsMu.Unlock()
+	i.tagsDone = true
+	i.tagsErr = nil
+	i.tags = make(map[string]interface{}, len(tags))
+	for key, value := range tags {
+		i.tags[key] = value
 	}
+}
 
-	return response.Body, nil
+// OpenRange opens the item for reading starting at byte start and ending
+// at byte end.
+func (i *item) OpenRange(start, end uint64) (io.ReadCloser, error) {
+	return i.OpenRangeCtx(context.Background(), start, end)
+}
+
+// OpenRangeCtx is OpenRange with a context.
+func (i *item) OpenRangeCtx(ctx context.Context, start, end uint64) (io.ReadCloser, error) {
+	params := &s3.GetObjectInput{
+		Bucket:    aws.String(i.container.Name()),
+		Key:       aws.String(i.ID()),
+		Range:     aws.String(fmt.Sprintf("bytes=%d-%d", start, end)),
+		VersionId: i.version(),
+	}
+	return i.getObject(ctx, params)
 }
@@ -11,7 +11,6 @@ import (
 
 	"github.com/aldor007/stow"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
-	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/pkg/errors"
 )
 
@@ -22,21 +21,58 @@ type location struct {
 	client         *s3.Client
 }
 
+var (
+	_ stow.Location           = (*location)(nil)
+	_ stow.ContextLocation    = (*location)(nil)
+	_ stow.CapabilityReporter = (*location)(nil)
+	_ stow.URLParser          = (*location)(nil)
+)
+
 func (l *location) HasRanges() bool {
 	return true
 }
 
+// Capabilities describes the features supported by this location.
+func (l *location) Capabilities() stow.Capabilities {
+	return capabilities()
+}
+
+// capabilities describes the features supported by S3.
+func capabilities() stow.Capabilities {
+	return stow.Capabilities{
+		Ranges:            true,
+		Metadata:          true,
+		Tags:              true,
+		PresignMethods:    []stow.ClientMethod{stow.ClientMethodGet, stow.ClientMethodPut},
+		ServerSideCopy:    true,
+		Listing:           true,
+		Write:             true,
+		Delete:            true,
+		ConditionalWrites: true,
+		MultipartUploads:  true,
+		BatchRemove:       true,
+		DelimitedListing:  true,
+		Versioning:        true,
+		MetadataUpdates:   true,
+	}
+}
+
 // CreateContainer creates a new container, in this case an S3 bucket.
 // The bare minimum needed is a container name, but there are many other
 // options that can be provided.
 func (l *location) CreateContainer(containerName string) (stow.Container, error) {
+	return l.CreateContainerCtx(context.Background(), containerName)
+}
+
+// CreateContainerCtx is CreateContainer with a context.
+func (l *location) CreateContainerCtx(ctx context.Context, containerName string) (stow.Container, error) {
 	createBucketParams := &s3.CreateBucketInput{
 		Bucket: aws.String(containerName), // required
 	}
 
-	_, err := l.client.CreateBucket(context.TODO(), createBucketParams)
+	_, err := l.client.CreateBucket(ctx, createBucketParams)
 	if err != nil {
-		return nil, errors.Wrap(err, "CreateContainer, creating the bucket")
+		return nil, errors.Wrap(mapError(err), "CreateContainer, creating the bucket")
 	}
 
 	region, _ := l.config.Config("region")
@@ -60,11 +96,16 @@ func (l *location) CreateContainer(containerName string) (stow.Container, error)
 // to start a new client for every single container where the region matches, this would
 // also check the credentials on every new instance... Tabled for later.
 func (l *location) Containers(prefix, cursor string, count int) ([]stow.Container, string, error) {
+	return l.ContainersCtx(context.Background(), prefix, cursor, count)
+}
+
+// ContainersCtx is Containers with a context.
+func (l *location) ContainersCtx(ctx context.Context, prefix, cursor string, count int) ([]stow.Container, string, error) {
 	// Response returns exported Owner(*s3.Owner) and Bucket(*s3.[]Bucket)
 	var params *s3.ListBucketsInput
-	bucketList, err := l.client.ListBuckets(context.TODO(), params)
+	bucketList, err := l.client.ListBuckets(ctx, params)
 	if err != nil {
-		return nil, "", errors.Wrap(err, "Containers, listing the buckets")
+		return nil, "", errors.Wrap(mapError(err), "Containers, listing the buckets")
 	}
 
 	// Seek to the current bucket, according to cursor.
@@ -106,8 +147,8 @@ func (l *location) Containers(prefix, cursor string, count int) ([]stow.Containe
 		client := l.client
 		bucketRegion := region
 		if !endpointSet && endpoint == "" {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			bucketLocation, err := client.GetBucketLocation(ctx, &s3.GetBucketLocationInput{
+			locCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+			bucketLocation, err := client.GetBucketLocation(locCtx, &s3.GetBucketLocationInput{
 				Bucket: bucket.Name,
 			})
 			cancel()
@@ -121,7 +162,7 @@ func (l *location) Containers(prefix, cursor string, count int) ([]stow.Containe
 						// strong signal that the bucket has been deleted.
 						continue
 					default:
-						return nil, "", errors.Wrapf(err, "Containers, getting bucket region for: %s", *bucket.Name)
+						return nil, "", errors.Wrapf(mapError(err), "Containers, getting bucket region for: %s", *bucket.Name)
 					}
 				}
 			}
@@ -157,14 +198,19 @@ func (l *location) Close() error {
 // Container retrieves a stow.Container based on its name which must be
 // exact.
 func (l *location) Container(id string) (stow.Container, error) {
+	return l.ContainerCtx(context.Background(), id)
+}
+
+// ContainerCtx is Container with a context.
+func (l *location) ContainerCtx(ctx context.Context, id string) (stow.Container, error) {
 	client := l.client
 	bucketRegion, bucketRegionSet := l.config.Config(ConfigRegion)
 
 	// Endpoint would indicate that we are using s3-compatible storage, which
 	// does not support s3session.GetBucketRegion().
 	if endpoint, endpointSet := l.config.Config(ConfigEndpoint); !endpointSet && endpoint != "" {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		bucketLoc, _ := l.client.GetBucketLocation(ctx, &s3.GetBucketLocationInput{Bucket: aws.String(id)})
+		locCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		bucketLoc, _ := l.client.GetBucketLocation(locCtx, &s3.GetBucketLocationInput{Bucket: aws.String(id)})
 		cancel()
 
 		var err error
@@ -189,17 +235,13 @@ func (l *location) Container(id string) (stow.Container, error) {
 		Bucket: aws.String(id),
 	}
 
-	_, err := client.GetBucketLocation(context.TODO(), params)
+	_, err := client.GetBucketLocation(ctx, params)
 	if err != nil {
-		var apiError smithy.APIError
-		if errors.As(err, &apiError) {
-			switch apiError.(type) {
-			case *types.NotFound:
-				return nil, stow.ErrNotFound
-			default:
-				return nil, errors.Wrap(err, "GetBucketLocation")
-			}
+		err = mapError(err)
+		if errors.Is(err, stow.ErrNotFound) {
+			return nil, stow.ErrNotFound
 		}
+		return nil, errors.Wrap(err, "GetBucketLocation")
 	}
 
 	return c, nil
@@ -207,13 +249,18 @@ func (l *location) Container(id string) (stow.Container, error) {
 
 // RemoveContainer removes a container simply by name.
 func (l *location) RemoveContainer(id string) error {
+	return l.RemoveContainerCtx(context.Background(), id)
+}
+
+// RemoveContainerCtx is RemoveContainer with a context.
+func (l *location) RemoveContainerCtx(ctx context.Context, id string) error {
 	params := &s3.DeleteBucketInput{
 		Bucket: aws.String(id),
 	}
 
-	_, err := l.client.DeleteBucket(context.TODO(), params)
+	_, err := l.client.DeleteBucket(ctx, params)
 	if err != nil {
-		return errors.Wrap(err, "RemoveContainer, deleting the bucket")
+		return errors.Wrap(mapError(err), "RemoveContainer, deleting the bucket")
 	}
 
 	return nil
@@ -222,60 +269,59 @@ func (l *location) RemoveContainer(id string) error {
 // ItemByURL retrieves a stow.Item by parsing the URL, in this
 // case an item is an object.
 func (l *location) ItemByURL(url *url.URL) (stow.Item, error) {
-	if l.customEndpoint == "" {
-		genericURL := []string{"https://s3-", ".amazonaws.com/"}
-
-		// Remove genericURL[0] from URL:
-		// url = <genericURL[0]><region><genericURL[1]><bucket name><object path>
-		firstCut := strings.Replace(url.Path, genericURL[0], "", 1)
-
-		// find first dot so that we could extract region.
-		dotIndex := strings.Index(firstCut, ".")
-
-		// region of the s3 bucket.
-		region := firstCut[0:dotIndex]
-
-		// Remove <region><genericURL[1]> from
-		// <region><genericURL[1]><bucket name><object path>
-		secondCut := strings.Replace(firstCut, region+genericURL[1], "", 1)
-
-		// Get the index of the first slash to get the end of the bucket name.
-		firstSlash := strings.Index(secondCut, "/")
-
-		// Grab bucket name
-		bucketName := secondCut[:firstSlash]
-
-		// Everything afterwards pertains to object.
-		objectPath := secondCut[firstSlash+1:]
-
-		// Get the container by bucket name.
-		cont, err := l.Container(bucketName)
-		if err != nil {
-			return nil, errors.Wrapf(err, "ItemByURL, getting container by the bucketname %s", bucketName)
-		}
-
-		// Get the item by object name.
-		it, err := cont.Item(objectPath)
-		if err != nil {
-			return nil, errors.Wrapf(err, "ItemByURL, getting item by object name %s", objectPath)
-		}
+	return l.ItemByURLCtx(context.Background(), url)
+}
 
-		return it, err
+// ItemByURLCtx is ItemByURL with a context.
+func (l *location) ItemByURLCtx(ctx context.Context, url *url.URL) (stow.Item, error) {
+	containerName, itemName, err := l.ParseURL(url)
+	if err != nil {
+		return nil, err
 	}
 
-	// url looks like this: s3://<containerName>/<itemName>
-	// example: s3://aldor007-demo/DPtest.txt
-	containerName := url.Host
-	itemName := strings.TrimPrefix(url.Path, "/")
-
-	c, err := l.Container(containerName)
+	c, err := l.ContainerCtx(ctx, containerName)
 	if err != nil {
 		return nil, errors.Wrapf(err, "ItemByURL, getting container by the bucketname %s", containerName)
 	}
 
-	i, err := c.Item(itemName)
+	i, err := c.(*container).ItemCtx(ctx, itemName)
 	if err != nil {
 		return nil, errors.Wrapf(err, "ItemByURL, getting item by object name %s", itemName)
 	}
 	return i, nil
 }
+
+// ParseURL gets the bucket and the key of the object a URL points to.
+// URLs look like s3://<bucket>/<key>, as returned by Item.URL.
+// URLs with the address of the object on AWS as their path, which
+// items used to have, are accepted too:
+// s3:https://s3-<region>.amazonaws.com/<bucket>/<key>
+func (l *location) ParseURL(url *url.URL) (string, string, error) {
+	if url.Scheme != Kind {
+		return "", "", errors.New("not valid s3 URL")
+	}
+
+	legacy := url.Opaque
+	if legacy == "" && url.Host == "" {
+		legacy = url.Path
+	}
+	if legacy != "" {
+		// Drop the scheme and the host, leaving <bucket>/<key>.
+		pieces := strings.SplitN(legacy, "/", 4)
+		if len(pieces) != 4 || pieces[0] != "https:" || pieces[1] != "" {
+			return "", "", errors.Errorf("not valid s3 URL %q", legacy)
+		}
+		legacy = pieces[3]
+		slash := strings.Index(legacy, "/")
+		if slash < 1 || slash == len(legacy)-1 {
+			return "", "", errors.Errorf("not valid s3 URL %q", legacy)
+		}
+		return legacy[:slash], legacy[slash+1:], nil
+	}
+
+	itemName := strings.TrimPrefix(url.Path, "/")
+	if url.Host == "" || itemName == "" {
+		return "", "", errors.New("not valid s3 URL, expecting s3://<bucket>/<key>")
+	}
+	return url.Host, itemName, nil
+}
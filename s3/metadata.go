@@ -0,0 +1,37 @@
+package s3
+
+import (
+	"context"
+
+	"github.com/aldor007/stow"
+)
+
+var (
+	_ stow.MetadataSetter        = (*container)(nil)
+	_ stow.ContextMetadataSetter = (*container)(nil)
+)
+
+// SetMetadata copies the object over itself with the new metadata,
+// as S3 objects can't be changed in place. The storage class and tags
+// of the object are kept unless metadata sets them.
+func (c *container) SetMetadata(id string, metadata map[string]interface{}, replace bool) error {
+	return c.SetMetadataCtx(context.Background(), id, metadata, replace)
+}
+
+// SetMetadataCtx is SetMetadata with a context.
+func (c *container) SetMetadataCtx(ctx context.Context, id string, metadata map[string]interface{}, replace bool) error {
+	item, err := c.getItem(ctx, id)
+	if err != nil {
+		return err
+	}
+	md := map[string]interface{}{}
+	if !replace {
+		md = item.properties.Metadata
+	}
+	md = stow.MergeMetadata(md, metadata)
+	if _, ok := md["x-amz-storage-class"]; !ok && item.properties.StorageClass != "" {
+		md["x-amz-storage-class"] = item.properties.StorageClass
+	}
+	_, err = c.CopyCtx(ctx, id, c, id, md)
+	return err
+}
@@ -0,0 +1,197 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"time"
+
+	"github.com/aldor007/stow"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ stow.MultipartUploader        = (*container)(nil)
+	_ stow.ContextMultipartUploader = (*container)(nil)
+)
+
+// InitiateUpload creates a multipart upload. The metadata is set when
+// the upload is created, just like for Put.
+func (c *container) InitiateUpload(name string, metadata map[string]interface{}) (stow.Upload, error) {
+	return c.InitiateUploadCtx(context.Background(), name, metadata)
+}
+
+// InitiateUploadCtx is InitiateUpload with a context.
+func (c *container) InitiateUploadCtx(ctx context.Context, name string, metadata map[string]interface{}) (stow.Upload, error) {
+	mdPrepped, s3Data, err := prepMetadata(metadata)
+	if err != nil {
+		return stow.Upload{}, errors.Wrap(err, "unable to create upload, preparing metadata")
+	}
+	res, err := c.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:             aws.String(c.name),
+		Key:                aws.String(name),
+		Metadata:           mdPrepped,
+		ContentType:        s3Data.contentType,
+		CacheControl:       s3Data.cacheControl,
+		ContentDisposition: s3Data.contentDisposition,
+		ContentEncoding:    s3Data.contentEncoding,
+		ContentLanguage:    s3Data.contentLanguage,
+		StorageClass:       types.StorageClass(s3Data.storageClass),
+		ACL:                types.ObjectCannedACL(s3Data.cannedAcl),
+		Tagging:            s3Data.tags,
+	})
+	if err != nil {
+		return stow.Upload{}, errors.Wrap(mapError(err), "creating multipart upload")
+	}
+	return stow.Upload{
+		ID:        aws.ToString(res.UploadId),
+		Name:      name,
+		Initiated: time.Now(),
+	}, nil
+}
+
+// UploadPart uploads a part. S3 requires parts of at least 5MiB,
+// except for the last one, and at most 10000 of them.
+// Requests are signed with a hash of their body, so parts that aren't
+// read from an io.ReadSeeker are buffered in memory.
+func (c *container) UploadPart(upload stow.Upload, number int, r io.Reader, size int64) (stow.Part, error) {
+	return c.UploadPartCtx(context.Background(), upload, number, r, size)
+}
+
+// UploadPartCtx is UploadPart with a context.
+func (c *container) UploadPartCtx(ctx context.Context, upload stow.Upload, number int, r io.Reader, size int64) (stow.Part, error) {
+	body, ok := r.(io.ReadSeeker)
+	if !ok || size < 0 {
+		buf, err := io.ReadAll(r)
+		if err != nil {
+			return stow.Part{}, errors.Wrap(err, "reading part")
+		}
+		body, size = bytes.NewReader(buf), int64(len(buf))
+	}
+	res, err := c.client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:        aws.String(c.name),
+		Key:           aws.String(upload.Name),
+		UploadId:      aws.String(upload.ID),
+		PartNumber:    int32(number),
+		Body:          body,
+		ContentLength: size,
+	})
+	if err != nil {
+		return stow.Part{}, errors.Wrapf(mapError(err), "uploading part %d", number)
+	}
+	return stow.Part{
+		Number: number,
+		ETag:   cleanEtag(aws.ToString(res.ETag)),
+		Size:   size,
+	}, nil
+}
+
+// ListParts gets the parts that have been uploaded.
+func (c *container) ListParts(upload stow.Upload) ([]stow.Part, error) {
+	return c.ListPartsCtx(context.Background(), upload)
+}
+
+// ListPartsCtx is ListParts with a context.
+func (c *container) ListPartsCtx(ctx context.Context, upload stow.Upload) ([]stow.Part, error) {
+	var parts []stow.Part
+	paginator := s3.NewListPartsPaginator(c.client, &s3.ListPartsInput{
+		Bucket:   aws.String(c.name),
+		Key:      aws.String(upload.Name),
+		UploadId: aws.String(upload.ID),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(mapError(err), "listing parts")
+		}
+		for _, part := range page.Parts {
+			parts = append(parts, stow.Part{
+				Number: int(part.PartNumber),
+				ETag:   cleanEtag(aws.ToString(part.ETag)),
+				Size:   part.Size,
+			})
+		}
+	}
+	return parts, nil
+}
+
+// CompleteUpload assembles the parts into the object.
+func (c *container) CompleteUpload(upload stow.Upload, parts []stow.Part) (stow.Item, error) {
+	return c.CompleteUploadCtx(context.Background(), upload, parts)
+}
+
+// CompleteUploadCtx is CompleteUpload with a context.
+func (c *container) CompleteUploadCtx(ctx context.Context, upload stow.Upload, parts []stow.Part) (stow.Item, error) {
+	completed := make([]types.CompletedPart, len(parts))
+	for i, part := range parts {
+		completed[i] = types.CompletedPart{
+			ETag:       aws.String(quoteEtag(part.ETag)),
+			PartNumber: int32(part.Number),
+		}
+	}
+	_, err := c.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(c.name),
+		Key:             aws.String(upload.Name),
+		UploadId:        aws.String(upload.ID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completed},
+	})
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "completing multipart upload")
+	}
+	item, err := c.getItem(ctx, upload.Name)
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// AbortUpload aborts the upload, so its parts are no longer kept (and
+// billed).
+func (c *container) AbortUpload(upload stow.Upload) error {
+	return c.AbortUploadCtx(context.Background(), upload)
+}
+
+// AbortUploadCtx is AbortUpload with a context.
+func (c *container) AbortUploadCtx(ctx context.Context, upload stow.Upload) error {
+	_, err := c.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(c.name),
+		Key:      aws.String(upload.Name),
+		UploadId: aws.String(upload.ID),
+	})
+	if err != nil {
+		return errors.Wrap(mapError(err), "aborting multipart upload")
+	}
+	return nil
+}
+
+// ListPendingUploads gets the multipart uploads of the bucket that
+// have neither been completed nor aborted.
+func (c *container) ListPendingUploads(prefix string) ([]stow.Upload, error) {
+	return c.ListPendingUploadsCtx(context.Background(), prefix)
+}
+
+// ListPendingUploadsCtx is ListPendingUploads with a context.
+func (c *container) ListPendingUploadsCtx(ctx context.Context, prefix string) ([]stow.Upload, error) {
+	var uploads []stow.Upload
+	paginator := s3.NewListMultipartUploadsPaginator(c.client, &s3.ListMultipartUploadsInput{
+		Bucket: aws.String(c.name),
+		Prefix: aws.String(prefix),
+	})
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, errors.Wrap(mapError(err), "listing multipart uploads")
+		}
+		for _, upload := range page.Uploads {
+			uploads = append(uploads, stow.Upload{
+				ID:        aws.ToString(upload.UploadId),
+				Name:      aws.ToString(upload.Key),
+				Initiated: aws.ToTime(upload.Initiated),
+			})
+		}
+	}
+	return uploads, nil
+}
@@ -2,14 +2,29 @@
 package s3
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	smithyhttp "github.com/aws/smithy-go/transport/http"
 	"github.com/stretchr/testify/require"
 
 	"github.com/aldor007/stow"
@@ -158,3 +173,616 @@ func TestInvalidAuthtype(t *testing.T) {
 	r.Error(err)
 	r.True(strings.Contains(err.Error(), "invalid auth_type"))
 }
+
+func TestErrorMapping(t *testing.T) {
+	status := func(code int) error {
+		return &smithyhttp.ResponseError{
+			Response: &smithyhttp.Response{Response: &http.Response{StatusCode: code}},
+			Err:      fmt.Errorf("http status %d", code),
+		}
+	}
+	api := func(code string) error {
+		return &smithy.GenericAPIError{Code: code}
+	}
+
+	test.ErrorMapping(t, mapError, map[error][]error{
+		stow.ErrNotFound:           {api("NoSuchKey"), &types.NoSuchBucket{}, status(http.StatusNotFound)},
+		stow.ErrAlreadyExists:      {api("BucketAlreadyExists"), &types.BucketAlreadyOwnedByYou{}},
+		stow.ErrPermissionDenied:   {api("AccessDenied"), status(http.StatusForbidden)},
+		stow.ErrPreconditionFailed: {api("PreconditionFailed"), api("ConditionalRequestConflict"), status(http.StatusPreconditionFailed)},
+		stow.ErrContainerNotEmpty:  {api("BucketNotEmpty")},
+		stow.ErrThrottled:          {api("SlowDown"), status(http.StatusTooManyRequests)},
+		stow.ErrInvalidName:        {api("InvalidBucketName"), api("KeyTooLongError")},
+		stow.ErrInvalidRange:       {api("InvalidRange"), status(http.StatusRequestedRangeNotSatisfiable)},
+	})
+}
+
+// conditionClient records the conditional headers of the requests it
+// gets and fails them like S3 does when the condition doesn't hold.
+type conditionClient struct {
+	ifMatch, ifNoneMatch []string
+}
+
+func (c *conditionClient) Do(req *http.Request) (*http.Response, error) {
+	c.ifMatch = append(c.ifMatch, req.Header.Get("If-Match"))
+	c.ifNoneMatch = append(c.ifNoneMatch, req.Header.Get("If-None-Match"))
+	if req.Header.Get("If-Match") == "" && req.Header.Get("If-None-Match") == "" {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: http.NoBody, Request: req}, nil
+	}
+	body := `<Error><Code>PreconditionFailed</Code><Message>At least one of the pre-conditions you specified did not hold</Message></Error>`
+	return &http.Response{
+		StatusCode: http.StatusPreconditionFailed,
+		Header:     http.Header{"Content-Type": []string{"application/xml"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// newTestClient makes a client of a fake S3 at http://localhost, whose
+// requests are made by httpClient and aren't retried.
+func newTestClient(t *testing.T, httpClient s3.HTTPClient) *s3.Client {
+	t.Helper()
+	return s3.New(s3.Options{
+		Region:           "us-east-1",
+		Credentials:      credentials.NewStaticCredentialsProvider("key", "secret", ""),
+		EndpointResolver: s3.EndpointResolverFromURL("http://localhost"),
+		UsePathStyle:     true,
+		HTTPClient:       httpClient,
+		RetryMaxAttempts: 1,
+	})
+}
+
+func TestPutIf(t *testing.T) {
+	r := require.New(t)
+	httpClient := &conditionClient{}
+	c := &container{
+		name:   "bucket",
+		client: newTestClient(t, httpClient),
+	}
+
+	_, err := c.PutIf("item", strings.NewReader("item"), 4, nil, stow.PutCondition{IfNoneMatch: true})
+	r.True(errors.Is(err, stow.ErrPreconditionFailed))
+	r.Equal([]string{"*"}, httpClient.ifNoneMatch)
+
+	_, err = c.PutIf("item", strings.NewReader("item"), 4, nil, stow.PutCondition{IfMatch: "9c51403a"})
+	r.True(errors.Is(err, stow.ErrPreconditionFailed))
+	r.Equal(`"9c51403a"`, httpClient.ifMatch[1])
+
+	// Put and the HeadObject that follows are unconditional
+	_, err = c.Put("item", strings.NewReader("item"), 4, nil)
+	r.NoError(err)
+	r.Equal([]string{"", ""}, httpClient.ifMatch[2:])
+	r.Equal([]string{"", ""}, httpClient.ifNoneMatch[2:])
+}
+
+// objectClient stores the body of the last PutObject request and
+// serves it back to HeadObject requests.
+type objectClient struct {
+	body []byte
+}
+
+func (c *objectClient) Do(req *http.Request) (*http.Response, error) {
+	header := http.Header{}
+	if req.Method == http.MethodPut {
+		b, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		c.body = b
+	}
+	header.Set("Content-Length", strconv.Itoa(len(c.body)))
+	header.Set("ETag", `"etag"`)
+	return &http.Response{StatusCode: http.StatusOK, Header: header, Body: http.NoBody, Request: req}, nil
+}
+
+func TestCreateItem(t *testing.T) {
+	r := require.New(t)
+	httpClient := &objectClient{}
+	c := &container{
+		name:   "bucket",
+		client: newTestClient(t, httpClient),
+	}
+
+	item, w, err := c.CreateItem("item")
+	r.NoError(err)
+	r.Equal("item", item.ID())
+	_, err = io.WriteString(w, "streamed ")
+	r.NoError(err)
+	_, err = io.WriteString(w, "contents")
+	r.NoError(err)
+	r.NoError(w.Close())
+
+	r.Equal("streamed contents", string(httpClient.body))
+	size, err := item.Size()
+	r.NoError(err)
+	r.Equal(int64(len("streamed contents")), size)
+	etag, err := item.ETag()
+	r.NoError(err)
+	r.Equal("etag", etag)
+}
+
+// partClient records the query and body of the requests it gets.
+type partClient struct {
+	query url.Values
+	body  []byte
+}
+
+func (c *partClient) Do(req *http.Request) (*http.Response, error) {
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	c.query, c.body = req.URL.Query(), b
+	header := http.Header{}
+	header.Set("ETag", `"part-etag"`)
+	return &http.Response{StatusCode: http.StatusOK, Header: header, Body: http.NoBody, Request: req}, nil
+}
+
+func TestUploadPart(t *testing.T) {
+	r := require.New(t)
+	httpClient := &partClient{}
+	c := &container{
+		name:   "bucket",
+		client: newTestClient(t, httpClient),
+	}
+
+	upload := stow.Upload{ID: "upload-id", Name: "item"}
+	// readers that can't seek are buffered to sign the request
+	body := io.MultiReader(strings.NewReader("part "), strings.NewReader("contents"))
+	part, err := c.UploadPart(upload, 2, body, -1)
+	r.NoError(err)
+	r.Equal(stow.Part{Number: 2, ETag: "part-etag", Size: 13}, part)
+	r.Equal("part contents", string(httpClient.body))
+	r.Equal("2", httpClient.query.Get("partNumber"))
+	r.Equal("upload-id", httpClient.query.Get("uploadId"))
+}
+
+// deleteClient responds to DeleteObjects requests, failing to delete
+// the key "locked".
+type deleteClient struct {
+	requests int
+	keys     int
+}
+
+func (c *deleteClient) Do(req *http.Request) (*http.Response, error) {
+	var body struct {
+		Objects []struct{ Key string } `xml:"Object"`
+	}
+	if err := xml.NewDecoder(req.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	c.requests++
+	c.keys += len(body.Objects)
+	res := "<DeleteResult>"
+	for _, object := range body.Objects {
+		if object.Key == "locked" {
+			res += "<Error><Key>locked</Key><Code>AccessDenied</Code><Message>Access Denied</Message></Error>"
+		}
+	}
+	res += "</DeleteResult>"
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(res)), Request: req}, nil
+}
+
+func TestRemoveItems(t *testing.T) {
+	r := require.New(t)
+	httpClient := &deleteClient{}
+	c := &container{
+		name:   "bucket",
+		client: newTestClient(t, httpClient),
+	}
+
+	ids := []string{"locked"}
+	for i := 0; i < 1500; i++ {
+		ids = append(ids, fmt.Sprintf("item%d", i))
+	}
+	failed, err := c.RemoveItems(ids)
+	r.NoError(err)
+	r.Equal(2, httpClient.requests)
+	r.Equal(1501, httpClient.keys)
+	r.Len(failed, 1)
+	r.True(errors.Is(failed["locked"], stow.ErrPermissionDenied))
+}
+
+// versionsClient responds to ListObjectVersions requests with the
+// versions of "item" and of "item.bak", and records the query of
+// the other requests.
+type versionsClient struct {
+	query url.Values
+}
+
+func (c *versionsClient) Do(req *http.Request) (*http.Response, error) {
+	c.query = req.URL.Query()
+	header := http.Header{}
+	if !c.query.Has("versions") {
+		header.Set("Content-Length", "7")
+		header.Set("ETag", `"old"`)
+		return &http.Response{StatusCode: http.StatusOK, Header: header, Body: http.NoBody, Request: req}, nil
+	}
+	res := `<ListVersionsResult>
+<IsTruncated>true</IsTruncated>
+<NextKeyMarker>item.bak</NextKeyMarker>
+<NextVersionIdMarker>v4</NextVersionIdMarker>
+<Version><Key>item</Key><VersionId>v3</VersionId><IsLatest>false</IsLatest><LastModified>2023-01-03T00:00:00.000Z</LastModified><ETag>"new"</ETag><Size>3</Size></Version>
+<Version><Key>item</Key><VersionId>v1</VersionId><IsLatest>false</IsLatest><LastModified>2023-01-01T00:00:00.000Z</LastModified><ETag>"old"</ETag><Size>7</Size></Version>
+<Version><Key>item.bak</Key><VersionId>v4</VersionId><IsLatest>true</IsLatest><LastModified>2023-01-01T00:00:00.000Z</LastModified><ETag>"bak"</ETag><Size>3</Size></Version>
+<DeleteMarker><Key>item</Key><VersionId>v5</VersionId><IsLatest>true</IsLatest><LastModified>2023-01-05T00:00:00.000Z</LastModified></DeleteMarker>
+</ListVersionsResult>`
+	return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(res)), Request: req}, nil
+}
+
+func TestItemVersions(t *testing.T) {
+	r := require.New(t)
+	httpClient := &versionsClient{}
+	c := &container{
+		name:   "bucket",
+		client: newTestClient(t, httpClient),
+	}
+
+	versions, next, err := c.ItemVersions("item", stow.CursorStart, 4)
+	r.NoError(err)
+	r.Equal("item", httpClient.query.Get("prefix"))
+	r.Equal("4", httpClient.query.Get("max-keys"))
+	// the listing went past the versions of the item
+	r.Equal("", next)
+	r.Len(versions, 3)
+	r.Equal(stow.Version{ID: "v5", LastMod: time.Date(2023, 1, 5, 0, 0, 0, 0, time.UTC), Latest: true, Deleted: true}, versions[0])
+	r.Equal(stow.Version{ID: "v3", LastMod: time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC), Size: 3, ETag: "new"}, versions[1])
+	r.Equal("v1", versions[2].ID)
+
+	_, _, err = c.ItemVersions("item", "v3", 4)
+	r.NoError(err)
+	r.Equal("item", httpClient.query.Get("key-marker"))
+	r.Equal("v3", httpClient.query.Get("version-id-marker"))
+
+	version, err := c.ItemVersion("item", "v1")
+	r.NoError(err)
+	r.Equal("v1", httpClient.query.Get("versionId"))
+	size, err := version.Size()
+	r.NoError(err)
+	r.Equal(int64(7), size)
+	_, err = version.(*item).OpenCtx(context.Background())
+	r.NoError(err)
+	r.Equal("v1", httpClient.query.Get("versionId"))
+
+	r.NoError(c.RemoveItemVersion("item", "v3"))
+	r.Equal("v3", httpClient.query.Get("versionId"))
+}
+
+// metadataClient serves an object with some metadata to HeadObject
+// requests, and records the headers of CopyObject requests.
+type metadataClient struct {
+	copied http.Header
+}
+
+func (c *metadataClient) Do(req *http.Request) (*http.Response, error) {
+	header := http.Header{}
+	if req.Method == http.MethodPut {
+		c.copied = req.Header.Clone()
+		res := "<CopyObjectResult><ETag>\"etag\"</ETag></CopyObjectResult>"
+		return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(res)), Request: req}, nil
+	}
+	header.Set("Content-Length", "4")
+	header.Set("ETag", `"etag"`)
+	header.Set("Content-Type", "text/plain")
+	header.Set("X-Amz-Meta-Owner", "me")
+	header.Set("X-Amz-Storage-Class", "STANDARD_IA")
+	return &http.Response{StatusCode: http.StatusOK, Header: header, Body: http.NoBody, Request: req}, nil
+}
+
+func TestSetMetadata(t *testing.T) {
+	r := require.New(t)
+	httpClient := &metadataClient{}
+	c := &container{
+		name:   "bucket",
+		client: newTestClient(t, httpClient),
+	}
+
+	r.NoError(c.SetMetadata("item", map[string]interface{}{"cache-control": "no-cache"}, false))
+	r.Equal("bucket/item", httpClient.copied.Get("X-Amz-Copy-Source"))
+	r.Equal("REPLACE", httpClient.copied.Get("X-Amz-Metadata-Directive"))
+	r.Equal("no-cache", httpClient.copied.Get("Cache-Control"))
+	r.Equal("text/plain", httpClient.copied.Get("Content-Type"))
+	r.Equal("me", httpClient.copied.Get("X-Amz-Meta-Owner"))
+	r.Equal("STANDARD_IA", httpClient.copied.Get("X-Amz-Storage-Class"))
+
+	r.NoError(c.SetMetadata("item", map[string]interface{}{"cache-control": "no-cache"}, true))
+	r.Equal("no-cache", httpClient.copied.Get("Cache-Control"))
+	r.Equal("", httpClient.copied.Get("Content-Type"))
+	r.Equal("", httpClient.copied.Get("X-Amz-Meta-Owner"))
+	// the storage class isn't metadata
+	r.Equal("STANDARD_IA", httpClient.copied.Get("X-Amz-Storage-Class"))
+}
+
+func TestCopyToOtherClient(t *testing.T) {
+	r := require.New(t)
+	src := &container{name: "src", client: newTestClient(t, nil)}
+	dst := &container{name: "dst", client: newTestClient(t, nil)}
+	_, err := src.Copy("item", dst, "item", nil)
+	r.True(stow.IsNotSupported(err))
+}
+
+// taggingClient records the tagging requests it gets.
+type taggingClient struct {
+	method string
+	query  url.Values
+	body   string
+}
+
+func (c *taggingClient) Do(req *http.Request) (*http.Response, error) {
+	c.method = req.Method
+	c.query = req.URL.Query()
+	c.body = ""
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		c.body = string(body)
+	}
+	status := http.StatusOK
+	if req.Method == http.MethodDelete {
+		status = http.StatusNoContent
+	}
+	return &http.Response{StatusCode: status, Header: http.Header{}, Body: http.NoBody, Request: req}, nil
+}
+
+func TestSetTags(t *testing.T) {
+	r := require.New(t)
+	httpClient := &taggingClient{}
+	client := newTestClient(t, httpClient)
+	i := &item{
+		container:  &container{name: "bucket", client: client},
+		client:     client,
+		properties: properties{Key: aws.String("item")},
+		versionID:  "v1",
+	}
+
+	r.NoError(i.SetTags(map[string]string{"stage": "raw"}))
+	r.Equal(http.MethodPut, httpClient.method)
+	r.True(httpClient.query.Has("tagging"))
+	r.Equal("v1", httpClient.query.Get("versionId"))
+	r.Contains(httpClient.body, "<Key>stage</Key><Value>raw</Value>")
+	// the tags are known without getting them
+	tags, err := i.Tags()
+	r.NoError(err)
+	r.Equal(map[string]interface{}{"stage": "raw"}, tags)
+
+	r.NoError(i.DeleteTags())
+	r.Equal(http.MethodDelete, httpClient.method)
+	r.True(httpClient.query.Has("tagging"))
+	tags, err = i.Tags()
+	r.NoError(err)
+	r.Empty(tags)
+}
+
+// putClient records the headers of PutObject requests.
+type putClient struct {
+	put http.Header
+}
+
+func (c *putClient) Do(req *http.Request) (*http.Response, error) {
+	header := http.Header{}
+	header.Set("ETag", `"etag"`)
+	if req.Method == http.MethodPut {
+		c.put = req.Header.Clone()
+		if req.Body != nil {
+			io.Copy(io.Discard, req.Body)
+		}
+	}
+	return &http.Response{StatusCode: http.StatusOK, Header: header, Body: http.NoBody, Request: req}, nil
+}
+
+func TestPutWithOptions(t *testing.T) {
+	r := require.New(t)
+	httpClient := &putClient{}
+	c := &container{
+		name:   "bucket",
+		client: newTestClient(t, httpClient),
+	}
+
+	_, err := c.PutWithOptions("item", strings.NewReader("item"), 4, stow.PutOptions{
+		ContentType:  "text/plain",
+		CacheControl: "no-cache",
+		StorageClass: "STANDARD_IA",
+		ACL:          "public-read",
+		Tags:         map[string]string{"stage": "raw"},
+		ContentMD5:   []byte{0x01, 0x02},
+		Metadata:     map[string]string{"Owner": "me"},
+	})
+	r.NoError(err)
+	r.Equal("text/plain", httpClient.put.Get("Content-Type"))
+	r.Equal("no-cache", httpClient.put.Get("Cache-Control"))
+	r.Equal("STANDARD_IA", httpClient.put.Get("X-Amz-Storage-Class"))
+	r.Equal("public-read", httpClient.put.Get("X-Amz-Acl"))
+	r.Equal("stage=raw", httpClient.put.Get("X-Amz-Tagging"))
+	r.Equal("AQI=", httpClient.put.Get("Content-Md5"))
+	r.Equal("me", httpClient.put.Get("X-Amz-Meta-Owner"))
+}
+
+// multipartClient answers the requests of multipart uploads, and
+// records whether they were completed or aborted.
+type multipartClient struct {
+	parts     int
+	completed bool
+	aborted   bool
+}
+
+func (c *multipartClient) Do(req *http.Request) (*http.Response, error) {
+	query := req.URL.Query()
+	header := http.Header{}
+	body := ""
+	switch {
+	case req.Method == http.MethodPost && query.Has("uploads"):
+		body = "<InitiateMultipartUploadResult><Bucket>bucket</Bucket><Key>item</Key><UploadId>upload-id</UploadId></InitiateMultipartUploadResult>"
+	case req.Method == http.MethodPut:
+		io.Copy(io.Discard, req.Body)
+		c.parts++
+		header.Set("ETag", `"part-etag"`)
+	case req.Method == http.MethodPost:
+		c.completed = true
+		body = `<CompleteMultipartUploadResult><ETag>"etag"</ETag></CompleteMultipartUploadResult>`
+	case req.Method == http.MethodDelete:
+		c.aborted = true
+		return &http.Response{StatusCode: http.StatusNoContent, Header: header, Body: http.NoBody, Request: req}, nil
+	}
+	return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+}
+
+func TestPutWithOptionsMultipartMD5(t *testing.T) {
+	r := require.New(t)
+	contents := bytes.Repeat([]byte("0123456789"), int(manager.DefaultUploadPartSize)/10+1)
+	sum := md5.Sum(contents)
+
+	httpClient := &multipartClient{}
+	c := &container{
+		name:   "bucket",
+		client: newTestClient(t, httpClient),
+	}
+	_, err := c.PutWithOptions("item", bytes.NewReader(contents), int64(len(contents)), stow.PutOptions{ContentMD5: sum[:]})
+	r.NoError(err)
+	r.Equal(2, httpClient.parts)
+	r.True(httpClient.completed)
+
+	// contents that don't match are not completed
+	httpClient = &multipartClient{}
+	c.client = newTestClient(t, httpClient)
+	_, err = c.PutWithOptions("item", bytes.NewReader(contents), int64(len(contents)), stow.PutOptions{ContentMD5: []byte{0x01, 0x02}})
+	var checksumErr *stow.ChecksumError
+	r.True(errors.As(err, &checksumErr))
+	r.Equal(sum[:], checksumErr.Actual)
+	r.True(errors.Is(err, stow.ErrPreconditionFailed))
+	r.False(httpClient.completed)
+	r.True(httpClient.aborted)
+}
+
+// getClient records GetObject requests, answering 304 Not Modified
+// when the ETag of the object is matched.
+type getClient struct {
+	get *http.Request
+}
+
+func (c *getClient) Do(req *http.Request) (*http.Response, error) {
+	c.get = req
+	if req.Header.Get("If-None-Match") == `"etag"` {
+		return &http.Response{StatusCode: http.StatusNotModified, Header: http.Header{}, Body: http.NoBody, Request: req}, nil
+	}
+	header := http.Header{}
+	header.Set("ETag", `"etag"`)
+	header.Set("Content-Range", "bytes 0-1/4")
+	return &http.Response{StatusCode: http.StatusPartialContent, Header: header, Body: io.NopCloser(strings.NewReader("it")), Request: req}, nil
+}
+
+func TestOpenWithOptions(t *testing.T) {
+	r := require.New(t)
+	httpClient := &getClient{}
+	c := &container{
+		name:   "bucket",
+		client: newTestClient(t, httpClient),
+	}
+	i := &item{container: c, client: c.client, properties: properties{Key: aws.String("item")}}
+
+	rc, err := i.OpenWithOptions(stow.OpenOptions{
+		Range:                      &stow.ByteRange{Start: 0, End: 1},
+		VersionID:                  "v1",
+		ResponseContentType:        "text/plain",
+		ResponseContentDisposition: "attachment",
+	})
+	r.NoError(err)
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	r.NoError(err)
+	r.Equal("it", string(b))
+	r.Equal("bytes=0-1", httpClient.get.Header.Get("Range"))
+	query := httpClient.get.URL.Query()
+	r.Equal("v1", query.Get("versionId"))
+	r.Equal("text/plain", query.Get("response-content-type"))
+	r.Equal("attachment", query.Get("response-content-disposition"))
+	cr, err := i.ContentRange()
+	r.NoError(err)
+	r.Equal("bytes 0-1/4", cr.ContentRange)
+
+	_, err = i.OpenWithOptions(stow.OpenOptions{IfNoneMatch: "etag"})
+	r.True(errors.Is(err, stow.ErrNotModified))
+}
+
+func TestParseURL(t *testing.T) {
+	r := require.New(t)
+	l := &location{}
+
+	item := &item{
+		container:  &container{name: "bucket"},
+		properties: properties{Key: aws.String("a/the item")},
+	}
+	u, err := url.Parse(item.URL().String())
+	r.NoError(err)
+	bucket, key, err := l.ParseURL(u)
+	r.NoError(err)
+	r.Equal("bucket", bucket)
+	r.Equal("a/the item", key)
+
+	// the URLs items used to have outside of custom endpoints
+	for _, u := range []*url.URL{
+		{Scheme: "s3", Path: "https://s3-eu-west-1.amazonaws.com/bucket/a/the item"},
+		{Scheme: "s3", Opaque: "https://s3-eu-west-1.amazonaws.com/bucket/a/the item"},
+	} {
+		bucket, key, err := l.ParseURL(u)
+		r.NoError(err)
+		r.Equal("bucket", bucket)
+		r.Equal("a/the item", key)
+	}
+
+	_, _, err = l.ParseURL(&url.URL{Scheme: "s3", Host: "bucket"})
+	r.Error(err)
+	_, _, err = l.ParseURL(&url.URL{Scheme: "file", Path: "/bucket/key"})
+	r.Error(err)
+}
+
+func TestDSN(t *testing.T) {
+	r := require.New(t)
+	kind, config, err := stow.ParseDSN("s3://key:secret@minio.local:9000/?region=eu-west-1&path_style=true&tls=false")
+	r.NoError(err)
+	r.Equal(Kind, kind)
+	r.Equal(stow.ConfigMap{
+		ConfigAccessKeyID: "key",
+		ConfigSecretKey:   "secret",
+		ConfigEndpoint:    "http://minio.local:9000",
+		ConfigRegion:      "eu-west-1",
+		ConfigPathStyle:   "true",
+	}, config)
+	r.NoError(stow.Validate(kind, config))
+
+	dsn, err := stow.RedactedDSN(Kind, config)
+	r.NoError(err)
+	r.Equal("s3://key:xxxxx@minio.local:9000/?path_style=true&region=eu-west-1&tls=false", dsn)
+
+	config[ConfigPathStyle] = "maybe"
+	r.Error(stow.Validate(kind, config))
+}
+
+func TestSchema(t *testing.T) {
+	r := require.New(t)
+	schema, err := stow.ConfigSchema(Kind)
+	r.NoError(err)
+	key, ok := schema.Key(ConfigSecretKey)
+	r.True(ok)
+	r.True(key.Secret)
+
+	err = stow.Validate(Kind, stow.ConfigMap{
+		ConfigAuthType:  "password",
+		ConfigEndpoint:  "minio.local",
+		"secret_key_id": "typo",
+	})
+	var configErr *stow.ConfigError
+	r.ErrorAs(err, &configErr)
+	r.Len(configErr.Problems, 3)
+
+	// the legacy key of http tracing is still accepted
+	r.NoError(stow.Validate(Kind, stow.ConfigMap{
+		ConfigAccessKeyID:       "id",
+		ConfigSecretKey:         "secret",
+		ConfigHTTPTracingLegacy: "true",
+	}))
+	r.True(httpTracing(stow.ConfigMap{ConfigHTTPTracingLegacy: "true"}))
+	r.True(httpTracing(stow.ConfigMap{ConfigHTTPTracing: "true"}))
+	r.False(httpTracing(stow.ConfigMap{ConfigHTTPTracing: "false", ConfigHTTPTracingLegacy: "true"}))
+}
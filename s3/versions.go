@@ -0,0 +1,133 @@
+package s3
+
+import (
+	"context"
+	"sort"
+
+	"github.com/aldor007/stow"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/pkg/errors"
+)
+
+var (
+	_ stow.Versioned        = (*container)(nil)
+	_ stow.ContextVersioned = (*container)(nil)
+)
+
+// ItemVersions gets a page of the versions of an object, including the
+// delete markers left when it was removed. The cursor is the version
+// ID marker of the listing.
+func (c *container) ItemVersions(id, cursor string, count int) ([]stow.Version, string, error) {
+	return c.ItemVersionsCtx(context.Background(), id, cursor, count)
+}
+
+// ItemVersionsCtx is ItemVersions with a context.
+func (c *container) ItemVersionsCtx(ctx context.Context, id, cursor string, count int) ([]stow.Version, string, error) {
+	params := &s3.ListObjectVersionsInput{
+		Bucket:  aws.String(c.name),
+		Prefix:  aws.String(id),
+		MaxKeys: int32(count),
+	}
+	if cursor != "" {
+		params.KeyMarker = aws.String(id)
+		params.VersionIdMarker = aws.String(cursor)
+	}
+	res, err := c.client.ListObjectVersions(ctx, params)
+	if err != nil {
+		return nil, "", errors.Wrap(mapError(err), "ItemVersions, listing object versions")
+	}
+
+	// The prefix also matches the objects whose keys start with id,
+	// which are listed after it.
+	var versions []stow.Version
+	for _, version := range res.Versions {
+		if aws.ToString(version.Key) != id {
+			continue
+		}
+		versions = append(versions, stow.Version{
+			ID:      aws.ToString(version.VersionId),
+			LastMod: aws.ToTime(version.LastModified),
+			Size:    version.Size,
+			ETag:    cleanEtag(aws.ToString(version.ETag)),
+			Latest:  version.IsLatest,
+		})
+	}
+	for _, marker := range res.DeleteMarkers {
+		if aws.ToString(marker.Key) != id {
+			continue
+		}
+		versions = append(versions, stow.Version{
+			ID:      aws.ToString(marker.VersionId),
+			LastMod: aws.ToTime(marker.LastModified),
+			Latest:  marker.IsLatest,
+			Deleted: true,
+		})
+	}
+	sort.SliceStable(versions, func(i, j int) bool {
+		return versions[i].LastMod.After(versions[j].LastMod)
+	})
+
+	var next string
+	if res.IsTruncated && aws.ToString(res.NextKeyMarker) == id {
+		next = aws.ToString(res.NextVersionIdMarker)
+	}
+	return versions, next, nil
+}
+
+// ItemVersion gets a version of an object. Its contents, metadata and
+// tags are those of the version.
+func (c *container) ItemVersion(id, versionID string) (stow.Item, error) {
+	return c.ItemVersionCtx(context.Background(), id, versionID)
+}
+
+// ItemVersionCtx is ItemVersion with a context.
+func (c *container) ItemVersionCtx(ctx context.Context, id, versionID string) (stow.Item, error) {
+	if versionID == "" {
+		return nil, stow.ErrNotFound
+	}
+	return c.getItemVersion(ctx, id, versionID)
+}
+
+// RemoveItemVersion permanently removes a version of an object. The
+// object is left as it is unless the version is its current one.
+func (c *container) RemoveItemVersion(id, versionID string) error {
+	return c.RemoveItemVersionCtx(context.Background(), id, versionID)
+}
+
+// RemoveItemVersionCtx is RemoveItemVersion with a context.
+func (c *container) RemoveItemVersionCtx(ctx context.Context, id, versionID string) error {
+	if versionID == "" {
+		return stow.ErrNotFound
+	}
+	_, err := c.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket:    aws.String(c.name),
+		Key:       aws.String(id),
+		VersionId: aws.String(versionID),
+	})
+	if err != nil {
+		return errors.Wrap(mapError(err), "RemoveItemVersion, deleting object version")
+	}
+	return nil
+}
+
+// EnableVersioning enables versioning on the bucket. Once enabled,
+// versioning of a bucket can only be suspended.
+func (c *container) EnableVersioning() error {
+	return c.EnableVersioningCtx(context.Background())
+}
+
+// EnableVersioningCtx is EnableVersioning with a context.
+func (c *container) EnableVersioningCtx(ctx context.Context) error {
+	_, err := c.client.PutBucketVersioning(ctx, &s3.PutBucketVersioningInput{
+		Bucket: aws.String(c.name),
+		VersioningConfiguration: &types.VersioningConfiguration{
+			Status: types.BucketVersioningStatusEnabled,
+		},
+	})
+	if err != nil {
+		return errors.Wrap(mapError(err), "EnableVersioning, putting bucket versioning")
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package stow
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ConfigType is the type of the value of a configuration key. Values
+// are strings, which must be in the format of their type.
+type ConfigType string
+
+const (
+	// TypeString is any string.
+	TypeString ConfigType = "string"
+	// TypeBool is "true" or "false", or another value accepted by
+	// strconv.ParseBool.
+	TypeBool ConfigType = "bool"
+	// TypeInt is a decimal integer.
+	TypeInt ConfigType = "int"
+	// TypeURL is an absolute URL.
+	TypeURL ConfigType = "url"
+	// TypeJSON is a JSON document.
+	TypeJSON ConfigType = "json"
+	// TypeList is a comma-separated list of strings.
+	TypeList ConfigType = "list"
+)
+
+// KeySchema describes a configuration key.
+type KeySchema struct {
+	// Name is the name of the key.
+	Name string
+	// Type is the type of its values.
+	Type ConfigType
+	// Default is the value used when the key isn't set, if any.
+	Default string
+	// Required is true when the key must be set.
+	Required bool
+	// Secret is true for credentials, which mustn't be shown or
+	// logged.
+	Secret bool
+	// Description describes the key for people.
+	Description string
+	// Values lists the accepted values, when they are limited.
+	Values []string
+}
+
+// Schema describes the configuration of a kind of Location.
+type Schema struct {
+	// Keys lists the keys of the configuration.
+	Keys []KeySchema
+}
+
+// Key gets the schema of the key with the specified name.
+func (s Schema) Key(name string) (KeySchema, bool) {
+	for _, key := range s.Keys {
+		if key.Name == name {
+			return key, true
+		}
+	}
+	return KeySchema{}, false
+}
+
+// ConfigError is returned by Validate with all the problems of a
+// configuration.
+type ConfigError struct {
+	// Kind is the kind of Location the configuration is for.
+	Kind string
+	// Problems lists the problems, one per key.
+	Problems []error
+}
+
+func (e *ConfigError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, problem := range e.Problems {
+		problems[i] = problem.Error()
+	}
+	return fmt.Sprintf("stow: invalid %s config: %s", e.Kind, strings.Join(problems, "; "))
+}
+
+// Unwrap gets the problems, for errors.Is and errors.As.
+func (e *ConfigError) Unwrap() []error {
+	return e.Problems
+}
+
+// RegisterSchema declares the schema of the configuration of a kind,
+// which Validate checks configurations against. It is usually called
+// in an implementation package's init method, after Register.
+func RegisterSchema(kind string, schema Schema) {
+	DefaultRegistry.RegisterSchema(kind, schema)
+}
+
+// ConfigSchema gets the schema registered for a kind.
+func ConfigSchema(kind string) (Schema, error) {
+	return DefaultRegistry.ConfigSchema(kind)
+}
+
+// RegisterSchema declares the schema of the configuration of a kind,
+// as the package-level RegisterSchema does.
+func (r *Registry) RegisterSchema(kind string, schema Schema) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schemas[kind] = schema
+}
+
+// ConfigSchema gets the schema registered for a kind.
+func (r *Registry) ConfigSchema(kind string) (Schema, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	schema, ok := r.schemas[kind]
+	if !ok {
+		if r.registered(kind) {
+			return Schema{}, fmt.Errorf("stow: %s has no config schema", kind)
+		}
+		return Schema{}, errUnknownKind(kind)
+	}
+	return schema, nil
+}
+
+// check gets the problems of config: required keys that aren't set,
+// values that aren't of their types and, when config is a ConfigMap,
+// keys that aren't in the schema.
+func (s Schema) check(config Config) []error {
+	var problems []error
+	for _, key := range s.Keys {
+		value, ok := config.Config(key.Name)
+		if !ok {
+			if key.Required {
+				problems = append(problems, fmt.Errorf("%s is required", key.Name))
+			}
+			continue
+		}
+		if value == "" {
+			// empty values are as good as unset ones
+			continue
+		}
+		if err := key.checkValue(value); err != nil {
+			problems = append(problems, fmt.Errorf("%s: %w", key.Name, err))
+		}
+	}
+
+	if m, ok := config.(ConfigMap); ok {
+		var unknown []string
+		for name := range m {
+			if _, ok := s.Key(name); !ok {
+				unknown = append(unknown, name)
+			}
+		}
+		sort.Strings(unknown)
+		for _, name := range unknown {
+			problems = append(problems, fmt.Errorf("%s is not a known key", name))
+		}
+	}
+	return problems
+}
+
+// checkValue checks that value is of the type of the key and one of
+// its values.
+func (k KeySchema) checkValue(value string) error {
+	switch k.Type {
+	case TypeBool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%q is not a bool", value)
+		}
+	case TypeInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("%q is not an int", value)
+		}
+	case TypeURL:
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("%q is not an absolute URL", value)
+		}
+	case TypeJSON:
+		if !json.Valid([]byte(value)) {
+			return fmt.Errorf("not valid JSON")
+		}
+	}
+	if len(k.Values) == 0 {
+		return nil
+	}
+	for _, v := range k.Values {
+		if v == value {
+			return nil
+		}
+	}
+	return fmt.Errorf("%q is not one of %s", value, strings.Join(k.Values, ", "))
+}
@@ -0,0 +1,68 @@
+package stow_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/aldor007/stow"
+	"github.com/cheekybits/is"
+)
+
+func init() {
+	stow.RegisterSchema(testKind, stow.Schema{Keys: []stow.KeySchema{
+		{Name: "user", Required: true},
+		{Name: "password", Secret: true},
+		{Name: "token", Secret: true},
+		{Name: "endpoint", Type: stow.TypeURL},
+		{Name: "region", Values: []string{"eu-west-1", "us-east-1", "ap-south-1"}},
+		{Name: "port", Type: stow.TypeInt},
+		{Name: "mem"},
+		{Name: "other"},
+	}})
+}
+
+func TestConfigSchema(t *testing.T) {
+	is := is.New(t)
+	schema, err := stow.ConfigSchema(testKind)
+	is.NoErr(err)
+	key, ok := schema.Key("password")
+	is.True(ok)
+	is.True(key.Secret)
+	_, ok = schema.Key("nope")
+	is.False(ok)
+
+	_, err = stow.ConfigSchema("nope")
+	is.Err(err)
+}
+
+func TestValidateSchema(t *testing.T) {
+	is := is.New(t)
+	is.NoErr(stow.Validate(testKind, stow.ConfigMap{"user": "someone", "port": "9000", "region": "eu-west-1"}))
+
+	err := stow.Validate(testKind, stow.ConfigMap{
+		"port":     "ninety",
+		"endpoint": "minio.local",
+		"region":   "mars",
+		"pasword":  "typo",
+	})
+	var configErr *stow.ConfigError
+	is.True(errors.As(err, &configErr))
+	is.Equal(configErr.Kind, testKind)
+	// all the problems are reported at once
+	is.Equal(len(configErr.Problems), 5)
+	is.Equal(err.Error(), `stow: invalid test config: user is required; endpoint: "minio.local" is not an absolute URL; `+
+		`region: "mars" is not one of eu-west-1, us-east-1, ap-south-1; port: "ninety" is not an int; pasword is not a known key`)
+
+	// unknown keys are only found in ConfigMaps
+	is.NoErr(stow.Validate(testKind, stow.NewLayeredConfig(stow.ConfigMap{"user": "someone", "pasword": "typo"})))
+}
+
+func TestRedactedDSNSchemaSecrets(t *testing.T) {
+	is := is.New(t)
+	// token isn't in the Secrets of the DSN, but is secret in the schema
+	stow.RegisterDSN("schema", stow.DSN{Query: map[string]string{"token": "token"}})
+	stow.RegisterSchema("schema", stow.Schema{Keys: []stow.KeySchema{{Name: "token", Secret: true}}})
+	dsn, err := stow.RedactedDSN("schema", stow.ConfigMap{"token": "secret"})
+	is.NoErr(err)
+	is.Equal(dsn, "schema:?token=xxxxx")
+}
@@ -173,4 +173,26 @@ func init() {
 	}
 
 	stow.Register(Kind, makefn, kindfn, validatefn)
+	stow.RegisterDSN(Kind, stow.DSN{
+		User:     ConfigUsername,
+		Password: ConfigPassword,
+		Host:     ConfigHost,
+		Port:     ConfigPort,
+		Path:     ConfigBasePath,
+		Query: map[string]string{
+			ConfigPrivateKey:           ConfigPrivateKey,
+			ConfigPrivateKeyPassphrase: ConfigPrivateKeyPassphrase,
+			ConfigHostPublicKey:        ConfigHostPublicKey,
+		},
+	})
+	stow.RegisterSchema(Kind, stow.Schema{Keys: []stow.KeySchema{
+		{Name: ConfigHost, Required: true, Description: "The hostname or IP address of the server."},
+		{Name: ConfigPort, Type: stow.TypeInt, Required: true, Description: "The port the ssh daemon listens on."},
+		{Name: ConfigUsername, Required: true, Description: "The name of the user."},
+		{Name: ConfigPassword, Secret: true, Description: "The password of the user, if the server allows it."},
+		{Name: ConfigPrivateKey, Secret: true, Description: "The private key of the user, not the path of its file."},
+		{Name: ConfigPrivateKeyPassphrase, Secret: true, Description: "The passphrase of the private key."},
+		{Name: ConfigHostPublicKey, Description: "The public key of the host, as in known_hosts. Host keys aren't checked without it."},
+		{Name: ConfigBasePath, Default: ".", Description: "The root folder on the server, absolute or relative to the home directory."},
+	}})
 }
@@ -1,15 +1,19 @@
 package sftp
 
 import (
+	"bytes"
 	"context"
+	"crypto/md5"
 	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"github.com/aldor007/stow"
+	"github.com/pkg/sftp"
 )
 
 type container struct {
@@ -17,6 +21,19 @@ type container struct {
 	location *location
 }
 
+var (
+	_ stow.Container              = (*container)(nil)
+	_ stow.ContextContainer       = (*container)(nil)
+	_ stow.CapabilityReporter     = (*container)(nil)
+	_ stow.ItemWriter             = (*container)(nil)
+	_ stow.DelimitedLister        = (*container)(nil)
+	_ stow.ContextDelimitedLister = (*container)(nil)
+	_ stow.OptionsPutter          = (*container)(nil)
+	_ stow.ContextOptionsPutter   = (*container)(nil)
+	_ stow.Stater                 = (*container)(nil)
+	_ stow.ContextStater          = (*container)(nil)
+)
+
 // ID returns a string value which represents the name of the container.
 func (c *container) ID() string {
 	return c.name
@@ -32,16 +49,29 @@ func (c *container) PreSignRequest(_ context.Context, _ stow.ClientMethod, _ str
 	return "", fmt.Errorf("unsupported")
 }
 
+// Capabilities describes the features supported by this container.
+func (c *container) Capabilities() stow.Capabilities {
+	return capabilities()
+}
+
 // Item returns a stow.Item instance of a container based on the name of the
 // container and the file.
 func (c *container) Item(id string) (stow.Item, error) {
+	return c.ItemCtx(context.Background(), id)
+}
+
+// ItemCtx is Item with a context.
+func (c *container) ItemCtx(ctx context.Context, id string) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	path := filepath.Join(c.location.config.basePath, c.name, filepath.FromSlash(id))
 	info, err := c.location.sftpClient.Stat(path)
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, stow.ErrNotFound
 		}
-		return nil, err
+		return nil, mapError(err)
 	}
 
 	if info.IsDir() {
@@ -57,9 +87,31 @@ func (c *container) Item(id string) (stow.Item, error) {
 	}, nil
 }
 
+// Stat gets the information of a file with a single stat request.
+func (c *container) Stat(id string) (stow.ItemInfo, error) {
+	return c.StatCtx(context.Background(), id)
+}
+
+// StatCtx is Stat with a context.
+func (c *container) StatCtx(ctx context.Context, id string) (stow.ItemInfo, error) {
+	item, err := c.ItemCtx(ctx, id)
+	if err != nil {
+		return stow.ItemInfo{}, err
+	}
+	return stow.NewItemInfo(item)
+}
+
 // Items sends a request to retrieve a list of items that are prepended with
 // the prefix argument. The 'cursor' variable facilitates pagination.
 func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
+	return c.ItemsCtx(context.Background(), prefix, cursor, count)
+}
+
+// ItemsCtx is Items with a context.
+func (c *container) ItemsCtx(ctx context.Context, prefix, cursor string, count int) ([]stow.Item, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
 	var entries []entry
 	entries, cursor, err := c.getFolderItems([]entry{}, prefix, "", filepath.Join(c.location.config.basePath, c.name), cursor, count, false)
 	if err != nil {
@@ -75,6 +127,127 @@ func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string
 	return sItems, cursor, nil
 }
 
+// ItemsDelimited lists the files like Items does, except for those
+// whose names contain delimiter after prefix, which are listed under
+// their common prefix. Only the directories that may hold files whose
+// names start with prefix are read, and the directories listed as a
+// common prefix only until a file is found in them: with "/" as the
+// delimiter, listing a directory only reads its subdirectories to
+// check that they aren't empty.
+// The cursor is the name of the last file or common prefix listed.
+func (c *container) ItemsDelimited(prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	return c.ItemsDelimitedCtx(context.Background(), prefix, delimiter, cursor, count)
+}
+
+// ItemsDelimitedCtx is ItemsDelimited with a context.
+func (c *container) ItemsDelimitedCtx(ctx context.Context, prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, "", err
+	}
+	// listed maps the names of the files and common prefixes listed
+	// to the files, which are nil for the common prefixes.
+	listed := make(map[string]*item)
+	var walk func(dir, relPath string) error
+	walk = func(dir, relPath string) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		files, err := c.location.sftpClient.ReadDir(dir)
+		if err != nil {
+			return mapError(err)
+		}
+		for _, file := range files {
+			name := relPath + file.Name()
+			if file.IsDir() {
+				name += separator
+			}
+			if !strings.HasPrefix(name, prefix) {
+				if file.IsDir() && strings.HasPrefix(prefix, name) {
+					if err := walk(filepath.Join(dir, file.Name()), name); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			commonPrefix, ok := stow.CommonPrefix(name, prefix, delimiter)
+			switch {
+			case ok && file.IsDir():
+				if _, found := listed[commonPrefix]; found {
+					continue
+				}
+				holdsFiles, err := c.holdsFiles(filepath.Join(dir, file.Name()))
+				if err != nil {
+					return err
+				}
+				if holdsFiles {
+					listed[commonPrefix] = nil
+				}
+			case ok:
+				listed[commonPrefix] = nil
+			case file.IsDir():
+				if err := walk(filepath.Join(dir, file.Name()), name); err != nil {
+					return err
+				}
+			default:
+				listed[name] = &item{
+					container: c,
+					path:      name,
+					size:      file.Size(),
+					modTime:   file.ModTime(),
+					md:        getFileMetadata(file),
+				}
+			}
+		}
+		return nil
+	}
+	if err := walk(filepath.Join(c.location.config.basePath, c.name), ""); err != nil {
+		return nil, nil, "", err
+	}
+
+	names := make([]string, 0, len(listed))
+	for name := range listed {
+		if name > cursor {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	cursor = "" // end
+	if len(names) > count {
+		names = names[:count]
+		cursor = names[count-1]
+	}
+	var items []stow.Item
+	var prefixes []string
+	for _, name := range names {
+		if listed[name] == nil {
+			prefixes = append(prefixes, name)
+			continue
+		}
+		items = append(items, listed[name])
+	}
+	return items, prefixes, cursor, nil
+}
+
+// holdsFiles gets whether there are files in dir or its subdirectories.
+func (c *container) holdsFiles(dir string) (bool, error) {
+	files, err := c.location.sftpClient.ReadDir(dir)
+	if err != nil {
+		return false, mapError(err)
+	}
+	for _, file := range files {
+		if !file.IsDir() {
+			return true, nil
+		}
+	}
+	for _, file := range files {
+		holdsFiles, err := c.holdsFiles(filepath.Join(dir, file.Name()))
+		if holdsFiles || err != nil {
+			return holdsFiles, err
+		}
+	}
+	return false, nil
+}
+
 const separator = "/"
 
 // we use this struct to keep track of stuff when walking.
@@ -93,7 +266,7 @@ func (c *container) getFolderItems(entries []entry, prefix, relPath, id, cursor
 	cursorPieces := strings.Split(relCursor, separator)
 	files, err := c.location.sftpClient.ReadDir(id)
 	if err != nil {
-		return nil, "", err
+		return nil, "", mapError(err)
 	}
 
 	var start bool
@@ -168,11 +341,28 @@ func (c *container) getFolderItems(entries []entry, prefix, relPath, id, cursor
 
 // RemoveItem removes a file from the remote server.
 func (c *container) RemoveItem(id string) error {
-	return c.location.sftpClient.Remove(filepath.Join(c.location.config.basePath, c.name, filepath.FromSlash(id)))
+	return c.RemoveItemCtx(context.Background(), id)
+}
+
+// RemoveItemCtx is RemoveItem with a context.
+func (c *container) RemoveItemCtx(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return mapError(c.location.sftpClient.Remove(filepath.Join(c.location.config.basePath, c.name, filepath.FromSlash(id))))
 }
 
 // Put sends a request to upload content to the container.
 func (c *container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.PutCtx(context.Background(), name, r, size, metadata)
+}
+
+// PutCtx is Put with a context. Canceling ctx stops reading from r,
+// which aborts the upload.
+func (c *container) PutCtx(ctx context.Context, name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	if len(metadata) > 0 {
 		return nil, stow.NotSupported("metadata")
 	}
@@ -181,22 +371,21 @@ func (c *container) Put(name string, r io.Reader, size int64, metadata map[strin
 	item := &item{
 		container: c,
 		path:      name,
-		size:      size,
 	}
 	err := c.location.sftpClient.MkdirAll(filepath.Dir(path))
 	if err != nil {
-		return nil, err
+		return nil, mapError(err)
 	}
 	f, err := c.location.sftpClient.Create(path)
 	if err != nil {
-		return nil, err
+		return nil, mapError(err)
 	}
 	defer f.Close()
-	n, err := io.Copy(f, r)
+	n, err := io.Copy(f, stow.ContextReader(ctx, r))
 	if err != nil {
 		return nil, err
 	}
-	if n != size {
+	if size >= 0 && n != size {
 		return nil, errors.New("bad size")
 	}
 
@@ -204,8 +393,77 @@ func (c *container) Put(name string, r io.Reader, size int64, metadata map[strin
 	if err != nil {
 		return nil, err
 	}
+	item.size = n
 	item.modTime = info.ModTime()
 	item.md = getFileMetadata(info)
 
 	return item, nil
 }
+
+// PutWithOptions is Put with ContentMD5 checked once the file has been
+// written; the file is removed when it doesn't match. Files have no
+// headers, storage classes, ACLs or tags, so those options are ignored.
+func (c *container) PutWithOptions(name string, r io.Reader, size int64, opts stow.PutOptions) (stow.Item, error) {
+	return c.PutWithOptionsCtx(context.Background(), name, r, size, opts)
+}
+
+// PutWithOptionsCtx is PutWithOptions with a context.
+func (c *container) PutWithOptionsCtx(ctx context.Context, name string, r io.Reader, size int64, opts stow.PutOptions) (stow.Item, error) {
+	var metadata map[string]interface{}
+	if len(opts.Metadata) > 0 {
+		metadata = make(map[string]interface{}, len(opts.Metadata))
+		for key, value := range opts.Metadata {
+			metadata[key] = value
+		}
+	}
+	hash := md5.New()
+	item, err := c.PutCtx(ctx, name, io.TeeReader(r, hash), size, metadata)
+	if err != nil {
+		return nil, err
+	}
+	if len(opts.ContentMD5) > 0 && !bytes.Equal(hash.Sum(nil), opts.ContentMD5) {
+		c.RemoveItemCtx(ctx, name)
+		return nil, errors.New("contents don't match ContentMD5")
+	}
+	return item, nil
+}
+
+// CreateItem creates a new file whose contents are written to the
+// returned writer.
+func (c *container) CreateItem(name string) (stow.Item, io.WriteCloser, error) {
+	path := filepath.Join(c.location.config.basePath, c.name, filepath.FromSlash(name))
+	err := c.location.sftpClient.MkdirAll(filepath.Dir(path))
+	if err != nil {
+		return nil, nil, mapError(err)
+	}
+	f, err := c.location.sftpClient.Create(path)
+	if err != nil {
+		return nil, nil, mapError(err)
+	}
+	item := &item{
+		container: c,
+		path:      name,
+	}
+	return item, &fileWriter{File: f, item: item, path: path}, nil
+}
+
+// fileWriter fills in its item once the file has been written.
+type fileWriter struct {
+	*sftp.File
+	item *item
+	path string
+}
+
+func (w *fileWriter) Close() error {
+	if err := w.File.Close(); err != nil {
+		return mapError(err)
+	}
+	info, err := w.item.container.location.sftpClient.Stat(w.path)
+	if err != nil {
+		return mapError(err)
+	}
+	w.item.size = info.Size()
+	w.item.modTime = info.ModTime()
+	w.item.md = getFileMetadata(info)
+	return nil
+}
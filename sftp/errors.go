@@ -0,0 +1,46 @@
+package sftp
+
+import (
+	"errors"
+	"os"
+
+	"github.com/aldor007/stow"
+	"github.com/pkg/sftp"
+)
+
+// Status codes defined by later revisions of the SFTP protocol. Servers
+// speaking version 3, such as OpenSSH, report these as a generic
+// failure instead.
+const (
+	sshFxFileAlreadyExists = 11
+	sshFxDirNotEmpty       = 18
+	sshFxInvalidFilename   = 20
+)
+
+// mapError maps errors returned by the SFTP client to the error kinds
+// declared by stow. The original error is kept in the chain.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return stow.WrapError(stow.ErrNotFound, err)
+	case errors.Is(err, os.ErrExist):
+		return stow.WrapError(stow.ErrAlreadyExists, err)
+	case errors.Is(err, os.ErrPermission):
+		return stow.WrapError(stow.ErrPermissionDenied, err)
+	}
+	var statusError *sftp.StatusError
+	if errors.As(err, &statusError) {
+		switch statusError.Code {
+		case sshFxFileAlreadyExists:
+			return stow.WrapError(stow.ErrAlreadyExists, err)
+		case sshFxDirNotEmpty:
+			return stow.WrapError(stow.ErrContainerNotEmpty, err)
+		case sshFxInvalidFilename:
+			return stow.WrapError(stow.ErrInvalidName, err)
+		}
+	}
+	return err
+}
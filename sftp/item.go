@@ -1,6 +1,7 @@
 package sftp
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/aldor007/stow"
@@ -21,6 +22,13 @@ type item struct {
 	md        map[string]interface{}
 }
 
+var (
+	_ stow.Item                 = (*item)(nil)
+	_ stow.ContextItem          = (*item)(nil)
+	_ stow.OptionsOpener        = (*item)(nil)
+	_ stow.ContextOptionsOpener = (*item)(nil)
+)
+
 // ID returns a string value that represents the name of a file.
 func (i *item) ID() string {
 	return i.path
@@ -52,6 +60,15 @@ func (i *item) URL() *url.URL {
 // and path of the file within the container. This response includes the body of
 // resource which is returned along with an error.
 func (i *item) Open() (io.ReadCloser, error) {
+	return i.OpenCtx(context.Background())
+}
+
+// OpenCtx is Open with a context. ctx is only consulted before the
+// file is opened, so the returned file keeps its io.Seeker support.
+func (i *item) OpenCtx(ctx context.Context) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	return i.container.location.sftpClient.Open(
 		filepath.Join(
 			i.container.location.config.basePath,
@@ -61,6 +78,56 @@ func (i *item) Open() (io.ReadCloser, error) {
 	)
 }
 
+// OpenWithOptions opens the file as described by opts. The conditions
+// are checked against the info of the opened file, and ranges are read
+// by seeking in it. Versions aren't supported and the response
+// overrides are ignored.
+func (i *item) OpenWithOptions(opts stow.OpenOptions) (io.ReadCloser, error) {
+	return i.OpenWithOptionsCtx(context.Background(), opts)
+}
+
+// OpenWithOptionsCtx is OpenWithOptions with a context.
+func (i *item) OpenWithOptionsCtx(ctx context.Context, opts stow.OpenOptions) (io.ReadCloser, error) {
+	if opts.VersionID != "" {
+		return nil, stow.NotSupported("opening versions")
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f, err := i.container.location.sftpClient.Open(
+		filepath.Join(
+			i.container.location.config.basePath,
+			i.container.Name(),
+			i.Name(),
+		),
+	)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, mapError(err)
+	}
+	if opts.NotModified(info.ModTime().String(), info.ModTime()) {
+		f.Close()
+		return nil, stow.ErrNotModified
+	}
+	if opts.Range == nil {
+		return f, nil
+	}
+	offset, length, ok := opts.Range.Offsets(info.Size())
+	if !ok {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", opts.Range, stow.ErrInvalidRange)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return stow.LimitReadCloser(f, length), nil
+}
+
 // LastMod returns the last modified date of the item.
 func (i *item) LastMod() (time.Time, error) {
 	return i.modTime, nil
@@ -1,6 +1,7 @@
 package sftp
 
 import (
+	"context"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -22,11 +23,32 @@ type location struct {
 	sftpClient *sftp.Client
 }
 
+var (
+	_ stow.Location           = (*location)(nil)
+	_ stow.ContextLocation    = (*location)(nil)
+	_ stow.CapabilityReporter = (*location)(nil)
+	_ stow.URLParser          = (*location)(nil)
+)
+
 // CreateContainer creates a new container, in this case a directory on the remote server.
 func (l *location) CreateContainer(containerName string) (stow.Container, error) {
-	if err := l.sftpClient.Mkdir(filepath.Join(l.config.basePath, containerName)); err != nil {
+	return l.CreateContainerCtx(context.Background(), containerName)
+}
+
+// CreateContainerCtx is CreateContainer with a context.
+func (l *location) CreateContainerCtx(ctx context.Context, containerName string) (stow.Container, error) {
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
+	path := filepath.Join(l.config.basePath, containerName)
+	if err := l.sftpClient.Mkdir(path); err != nil {
+		// SFTP version 3 servers report an existing directory as a
+		// generic failure.
+		if _, statErr := l.sftpClient.Stat(path); statErr == nil {
+			return nil, stow.WrapError(stow.ErrAlreadyExists, err)
+		}
+		return nil, mapError(err)
+	}
 
 	return &container{
 		location: l,
@@ -36,9 +58,17 @@ func (l *location) CreateContainer(containerName string) (stow.Container, error)
 
 // Containers returns a slice of the Container interface, a cursor, and an error.
 func (l *location) Containers(prefix, cursor string, count int) ([]stow.Container, string, error) {
+	return l.ContainersCtx(context.Background(), prefix, cursor, count)
+}
+
+// ContainersCtx is Containers with a context.
+func (l *location) ContainersCtx(ctx context.Context, prefix, cursor string, count int) ([]stow.Container, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
 	infos, err := l.sftpClient.ReadDir(l.config.basePath)
 	if err != nil {
-		return nil, "", err
+		return nil, "", mapError(err)
 	}
 
 	sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
@@ -99,12 +129,20 @@ func (l *location) Close() error {
 
 // Container retrieves a stow.Container based on its name which must be exact.
 func (l *location) Container(id string) (stow.Container, error) {
+	return l.ContainerCtx(context.Background(), id)
+}
+
+// ContainerCtx is Container with a context.
+func (l *location) ContainerCtx(ctx context.Context, id string) (stow.Container, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	fi, err := l.sftpClient.Stat(filepath.Join(l.config.basePath, id))
 	if err != nil {
 		if os.IsNotExist(err) {
 			return nil, stow.ErrNotFound
 		}
-		return nil, err
+		return nil, mapError(err)
 	}
 	if !fi.IsDir() {
 		return nil, stow.ErrNotFound
@@ -117,6 +155,14 @@ func (l *location) Container(id string) (stow.Container, error) {
 
 // RemoveContainer removes a container by name.
 func (l *location) RemoveContainer(id string) error {
+	return l.RemoveContainerCtx(context.Background(), id)
+}
+
+// RemoveContainerCtx is RemoveContainer with a context.
+func (l *location) RemoveContainerCtx(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 	return recurseRemove(l.sftpClient, filepath.Join(l.config.basePath, id))
 }
 
@@ -124,40 +170,42 @@ func (l *location) RemoveContainer(id string) error {
 func recurseRemove(client *sftp.Client, path string) error {
 	infos, err := client.ReadDir(path)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
 
 	for _, v := range infos {
 		if !v.IsDir() {
-			return errors.Errorf("directory not empty - %q", v.Name())
+			return stow.WrapError(stow.ErrContainerNotEmpty, errors.Errorf("directory not empty - %q", v.Name()))
 		}
 		if err := recurseRemove(client, filepath.Join(path, v.Name())); err != nil {
 			return err
 		}
 	}
 
-	return client.RemoveDirectory(path)
+	return mapError(client.RemoveDirectory(path))
 }
 
 // ItemByURL retrieves a stow.Item by parsing the URL.
 func (l *location) ItemByURL(u *url.URL) (stow.Item, error) {
-	// expect sftp://<username>@<host>:<port>/<container>/<path to file>
-	// example: sftp://someuser@example.com:22/foo/blah/baz.txt
+	return l.ItemByURLCtx(context.Background(), u)
+}
 
-	urlParts := strings.Split(u.Path, "/")
-	if len(urlParts) < 3 {
-		return nil, errors.New("parsing ItemByURL unexpected length")
+// ItemByURLCtx is ItemByURL with a context.
+func (l *location) ItemByURLCtx(ctx context.Context, u *url.URL) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	containerName, itemName, err := l.ParseURL(u)
+	if err != nil {
+		return nil, err
 	}
 
-	containerName := urlParts[1]
-	itemName := strings.Join(urlParts[2:], "/")
-
-	c, err := l.Container(containerName)
+	c, err := l.ContainerCtx(ctx, containerName)
 	if err != nil {
 		return nil, errors.Wrapf(err, "ItemByURL, getting container %q", containerName)
 	}
 
-	i, err := c.Item(itemName)
+	i, err := c.(*container).ItemCtx(ctx, itemName)
 	if err != nil {
 		return nil, errors.Wrapf(err, "ItemByURL, getting item %q", itemName)
 	}
@@ -165,7 +213,35 @@ func (l *location) ItemByURL(u *url.URL) (stow.Item, error) {
 	return i, nil
 }
 
+// ParseURL gets the container and the name of the item a URL points to.
+func (l *location) ParseURL(u *url.URL) (string, string, error) {
+	// expect sftp://<username>@<host>:<port>/<container>/<path to file>
+	// example: sftp://someuser@example.com:22/foo/blah/baz.txt
+
+	urlParts := strings.Split(u.Path, "/")
+	if len(urlParts) < 3 {
+		return "", "", errors.New("parsing ItemByURL unexpected length")
+	}
+
+	return urlParts[1], strings.Join(urlParts[2:], "/"), nil
+}
+
 // ItemByURL retrieves a stow.Item by parsing the URL.
 func (l *location) HasRanges() bool {
 	return false
-}
\ No newline at end of file
+}
+
+// Capabilities describes the features supported by this location.
+func (l *location) Capabilities() stow.Capabilities {
+	return capabilities()
+}
+
+// capabilities describes the features supported by SFTP.
+func capabilities() stow.Capabilities {
+	return stow.Capabilities{
+		Listing:          true,
+		Write:            true,
+		Delete:           true,
+		DelimitedListing: true,
+	}
+}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/aldor007/stow"
 	"github.com/aldor007/stow/test"
+	"github.com/pkg/sftp"
 	"github.com/stretchr/testify/require"
 )
 
@@ -150,6 +151,16 @@ func TestStow(t *testing.T) {
 	})
 }
 
+func TestErrorMapping(t *testing.T) {
+	test.ErrorMapping(t, mapError, map[error][]error{
+		stow.ErrNotFound:          {os.ErrNotExist, &os.PathError{Op: "stat", Path: "/foo", Err: os.ErrNotExist}},
+		stow.ErrAlreadyExists:     {os.ErrExist, &sftp.StatusError{Code: sshFxFileAlreadyExists}},
+		stow.ErrPermissionDenied:  {os.ErrPermission},
+		stow.ErrContainerNotEmpty: {&sftp.StatusError{Code: sshFxDirNotEmpty}},
+		stow.ErrInvalidName:       {&sftp.StatusError{Code: sshFxInvalidFilename}},
+	})
+}
+
 func setupFiles(t *testing.T, c stow.Container, files []string) {
 	for _, file := range files {
 		_, err := c.Put(file, strings.NewReader(""), 0, nil)
@@ -0,0 +1,77 @@
+package stow
+
+import (
+	"context"
+	"errors"
+)
+
+// Stat gets the information of the Item id of container. When
+// container implements Stater the information is got with a single
+// request; otherwise it is read from the Item got with Item.
+// An error matching ErrNotFound is returned when there's no such Item.
+func Stat(container Container, id string) (ItemInfo, error) {
+	return StatCtx(context.Background(), container, id)
+}
+
+// StatCtx is Stat with a context, which is passed to the Ctx variants
+// of the methods it calls.
+func StatCtx(ctx context.Context, container Container, id string) (ItemInfo, error) {
+	if stater, ok := As[ContextStater](container); ok {
+		return stater.StatCtx(ctx, id)
+	}
+	if stater, ok := As[Stater](container); ok {
+		return stater.Stat(id)
+	}
+	item, err := itemCtx(ctx, container, id)
+	if err != nil {
+		return ItemInfo{}, err
+	}
+	return NewItemInfo(item)
+}
+
+// Exists gets whether container has an Item with the specified ID. It
+// fails only when that can't be known.
+func Exists(container Container, id string) (bool, error) {
+	return ExistsCtx(context.Background(), container, id)
+}
+
+// ExistsCtx is Exists with a context.
+func ExistsCtx(ctx context.Context, container Container, id string) (bool, error) {
+	_, err := StatCtx(ctx, container, id)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// NewItemInfo gets the information of item. The content type is the
+// "content-type" key of its metadata, if any. The metadata is copied,
+// so the ItemInfo doesn't share it with item.
+func NewItemInfo(item Item) (ItemInfo, error) {
+	size, err := item.Size()
+	if err != nil {
+		return ItemInfo{}, err
+	}
+	etag, err := item.ETag()
+	if err != nil {
+		return ItemInfo{}, err
+	}
+	lastMod, err := item.LastMod()
+	if err != nil {
+		return ItemInfo{}, err
+	}
+	md, err := item.Metadata()
+	if err != nil {
+		return ItemInfo{}, err
+	}
+	info := ItemInfo{
+		ID:       item.ID(),
+		Name:     item.Name(),
+		Size:     size,
+		ETag:     etag,
+		LastMod:  lastMod,
+		Metadata: MergeMetadata(nil, md),
+	}
+	info.ContentType, _ = md["content-type"].(string)
+	return info, nil
+}
@@ -0,0 +1,103 @@
+package stow_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/aldor007/stow"
+	"github.com/cheekybits/is"
+)
+
+// staterContainer is a memContainer that counts the calls to Stat.
+type staterContainer struct {
+	*memContainer
+	stats int
+}
+
+func (c *staterContainer) Stat(id string) (stow.ItemInfo, error) {
+	c.stats++
+	item, err := c.Item(id)
+	if err != nil {
+		return stow.ItemInfo{}, err
+	}
+	return stow.NewItemInfo(item)
+}
+
+func TestStat(t *testing.T) {
+	is := is.New(t)
+	c := &staterContainer{memContainer: newMemContainer(true)}
+	_, err := c.Put("item", strings.NewReader("item"), 4, map[string]interface{}{"content-type": "text/plain"})
+	is.NoErr(err)
+
+	info, err := stow.Stat(c, "item")
+	is.NoErr(err)
+	is.Equal(c.stats, 1)
+	is.Equal(info.ID, "item")
+	is.Equal(info.Size, int64(4))
+	is.Equal(info.ContentType, "text/plain")
+
+	// the metadata of the info isn't shared with the item
+	info.Metadata["owner"] = "me"
+	item, err := c.Item("item")
+	is.NoErr(err)
+	md, err := item.Metadata()
+	is.NoErr(err)
+	is.Equal(len(md), 1)
+}
+
+// contextStaterContainer is a staterContainer that records the
+// context passed to StatCtx.
+type contextStaterContainer struct {
+	*staterContainer
+	ctx context.Context
+}
+
+func (c *contextStaterContainer) StatCtx(ctx context.Context, id string) (stow.ItemInfo, error) {
+	c.ctx = ctx
+	return c.Stat(id)
+}
+
+func TestStatCtx(t *testing.T) {
+	is := is.New(t)
+	c := &contextStaterContainer{staterContainer: &staterContainer{memContainer: newMemContainer(true)}}
+	_, err := c.Put("item", strings.NewReader("item"), 4, nil)
+	is.NoErr(err)
+
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	info, err := stow.StatCtx(ctx, c, "item")
+	is.NoErr(err)
+	is.Equal(info.Size, int64(4))
+	is.Equal(c.ctx, ctx)
+}
+
+func TestStatFallback(t *testing.T) {
+	is := is.New(t)
+	c := newMemContainer(true)
+	_, err := c.Put("item", strings.NewReader("item"), 4, nil)
+	is.NoErr(err)
+
+	info, err := stow.Stat(c, "item")
+	is.NoErr(err)
+	is.Equal(info.Name, "item")
+	is.Equal(info.Size, int64(4))
+
+	_, err = stow.Stat(c, "missing")
+	is.True(errors.Is(err, stow.ErrNotFound))
+}
+
+func TestExists(t *testing.T) {
+	is := is.New(t)
+	c := newMemContainer(true)
+	_, err := c.Put("item", strings.NewReader("item"), 4, nil)
+	is.NoErr(err)
+
+	exists, err := stow.Exists(c, "item")
+	is.NoErr(err)
+	is.True(exists)
+	exists, err = stow.Exists(c, "missing")
+	is.NoErr(err)
+	is.False(exists)
+}
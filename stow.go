@@ -6,34 +6,40 @@ import (
 	"fmt"
 	"io"
 	"net/url"
-	"sync"
+	"strings"
 	"time"
 )
 
-var (
-	lock sync.RWMutex // protects locations, kinds and kindmatches
-	// kinds holds a list of location kinds.
-	kinds = []string{}
-	// locations is a map of installed location providers,
-	// supplying a function that creates a new instance of
-	// that Location.
-	locations = map[string]func(Config) (Location, error){}
-	// configurations is a map of installed location providers,
-	// supplying a function that validates the configuration
-	configurations = map[string]func(Config) error{}
-	// kindmatches is a slice of functions that take turns
-	// trying to match the kind of Location for a given
-	// URL. Functions return an empty string if it does not
-	// match.
-	kindmatches []func(*url.URL) string
-)
-
 var (
 	// ErrNotFound is returned when something could not be found.
 	ErrNotFound = errors.New("not found")
 	// ErrBadCursor is returned by paging methods when the specified
 	// cursor is invalid.
 	ErrBadCursor = errors.New("bad cursor")
+	// ErrAlreadyExists is returned when creating something that
+	// already exists, such as a container.
+	ErrAlreadyExists = errors.New("already exists")
+	// ErrPermissionDenied is returned when the credentials in use are
+	// not allowed to perform the operation.
+	ErrPermissionDenied = errors.New("permission denied")
+	// ErrPreconditionFailed is returned when a condition attached to
+	// the request, such as an ETag match, does not hold.
+	ErrPreconditionFailed = errors.New("precondition failed")
+	// ErrContainerNotEmpty is returned when removing a container that
+	// still holds items.
+	ErrContainerNotEmpty = errors.New("container not empty")
+	// ErrThrottled is returned when the storage service rejected the
+	// request because of rate limiting. The request may be retried.
+	ErrThrottled = errors.New("throttled")
+	// ErrInvalidName is returned when a container or item name is not
+	// accepted by the storage service.
+	ErrInvalidName = errors.New("invalid name")
+	// ErrNotModified is returned when opening an Item whose conditions,
+	// such as an IfNoneMatch ETag, show that it hasn't changed.
+	ErrNotModified = errors.New("not modified")
+	// ErrInvalidRange is returned when opening a range of an Item that
+	// starts past its end.
+	ErrInvalidRange = errors.New("range not satisfiable")
 )
 
 var (
@@ -123,11 +129,104 @@ type Container interface {
 	RemoveItem(id string) error
 	// Put creates a new Item with the specified name, and contents
 	// read from the reader.
+	// A negative size means the size isn't known, in which case the
+	// whole reader is stored.
 	Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (Item, error)
 	// PreSignRequest generates a pre-signed url for the given id (key after bucket/container) and a given clientMethod.
 	PreSignRequest(ctx context.Context, clientMethod ClientMethod, id string, params PresignRequestParams) (url string, err error)
 }
 
+// CapabilityReporter represents a Location or a Container that
+// describes the optional features it supports.
+type CapabilityReporter interface {
+	// Capabilities describes the optional features supported
+	// by this Location or Container.
+	Capabilities() Capabilities
+}
+
+// Capabilities describes the optional features supported by a
+// Location or a Container, so callers can choose a strategy up
+// front instead of probing with calls that fail.
+type Capabilities struct {
+	// Ranges is true when Items implement ItemRanger.
+	Ranges bool
+	// Metadata is true when the metadata passed to Put is stored
+	// and returned by Item.Metadata.
+	Metadata bool
+	// Tags is true when Items implement Taggable and TagSetter.
+	Tags bool
+	// TagQueries is true when Containers implement TagQuerier.
+	TagQueries bool
+	// PresignMethods lists the client methods supported by
+	// PreSignRequest.
+	PresignMethods []ClientMethod
+	// ServerSideCopy is true when Items can be copied without
+	// streaming their contents through the client.
+	ServerSideCopy bool
+	// Listing is true when Containers and Items are supported.
+	Listing bool
+	// Write is true when Put is supported.
+	Write bool
+	// Delete is true when RemoveItem and RemoveContainer are
+	// supported.
+	Delete bool
+	// ConditionalWrites is true when writes can be made
+	// conditional on the current state of the Item, with
+	// Containers implementing ConditionalPutter.
+	ConditionalWrites bool
+	// Versioning is true when Containers implement Versioned.
+	Versioning bool
+	// MetadataUpdates is true when Containers implement
+	// MetadataSetter.
+	MetadataUpdates bool
+	// MultipartUploads is true when Containers implement
+	// MultipartUploader.
+	MultipartUploads bool
+	// BatchRemove is true when Containers implement BatchRemover.
+	BatchRemove bool
+	// DelimitedListing is true when Containers implement
+	// DelimitedLister.
+	DelimitedListing bool
+}
+
+// CapabilitiesOf describes the optional features supported by a
+// Location or a Container. Those that don't implement
+// CapabilityReporter are described from the optional interfaces
+// they implement, which can't tell everything: Metadata, Tags and
+// PresignMethods are left unset.
+func CapabilitiesOf(x interface{}) Capabilities {
+	if r, ok := As[CapabilityReporter](x); ok {
+		return r.Capabilities()
+	}
+	var caps Capabilities
+	switch x.(type) {
+	case Location, Container:
+		caps.Listing, caps.Write, caps.Delete = true, true, true
+	}
+	if l, ok := x.(Location); ok {
+		caps.Ranges = l.HasRanges()
+	}
+	_, caps.TagQueries = As[TagQuerier](x)
+	_, caps.ServerSideCopy = As[Copier](x)
+	_, caps.ConditionalWrites = As[ConditionalPutter](x)
+	_, caps.Versioning = As[Versioned](x)
+	_, caps.MetadataUpdates = As[MetadataSetter](x)
+	_, caps.MultipartUploads = As[MultipartUploader](x)
+	_, caps.BatchRemove = As[BatchRemover](x)
+	_, caps.DelimitedListing = As[DelimitedLister](x)
+	return caps
+}
+
+// CanPresign gets whether method is listed in PresignMethods.
+func (c Capabilities) CanPresign(method ClientMethod) bool {
+	for _, m := range c.PresignMethods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
 // Item represents an item inside a Container.
 // Such as a file.
 type Item interface {
@@ -135,11 +234,12 @@ type Item interface {
 	ID() string
 	// Name gets a human-readable name describing this Item.
 	Name() string
-	// URL gets a URL for this item.
+	// URL gets a URL for this item, which the ItemByURL method of
+	// its Location accepts.
 	// For example:
 	// local: file:///path/to/something
 	// azure: azure://host:port/api/something
-	//    s3: s3://host:post/etc
+	//    s3: s3://bucket/key
 	URL() *url.URL
 	// Size gets the size of the Item's contents in bytes.
 	Size() (int64, error)
@@ -157,6 +257,8 @@ type Item interface {
 	Metadata() (map[string]interface{}, error)
 	// ContentRange will have value on range response
 	ContentRange() (ContentRangeData, error)
+	// OpenParams opens the Item for reading with provider specific
+	// parameters. Use OpenWithOptions instead.
 	OpenParams(_ map[string]interface{}) (io.ReadCloser, error)
 }
 
@@ -174,12 +276,560 @@ type ItemRanger interface {
 	ContentRange() (ContentRangeData, error)
 }
 
+// ContextItemRanger represents an ItemRanger whose ranges can be
+// opened with a context.
+type ContextItemRanger interface {
+	// OpenRangeCtx is OpenRange with a context.
+	OpenRangeCtx(ctx context.Context, start, end uint64) (io.ReadCloser, error)
+}
+
+// ByteRange is a range of the bytes of an Item.
+type ByteRange struct {
+	// Start is the offset of the first byte of the range. When it is
+	// negative the range is the last -Start bytes of the Item, and
+	// End is ignored.
+	Start int64
+	// End is the offset of the last byte of the range, which is
+	// included. When it is negative the range goes on to the end of
+	// the Item.
+	End int64
+}
+
+// String gets the value of the HTTP Range header for r, such as
+// "bytes=0-99".
+func (r ByteRange) String() string {
+	switch {
+	case r.Start < 0:
+		return fmt.Sprintf("bytes=%d", r.Start)
+	case r.End < 0:
+		return fmt.Sprintf("bytes=%d-", r.Start)
+	}
+	return fmt.Sprintf("bytes=%d-%d", r.Start, r.End)
+}
+
+// Offsets gets the offset and the length of r in an Item of the
+// specified size. ok is false when r starts after the end of the Item.
+func (r ByteRange) Offsets(size int64) (offset, length int64, ok bool) {
+	offset, end := r.Start, r.End
+	if offset < 0 {
+		offset, end = size+offset, size-1
+		if offset < 0 {
+			offset = 0
+		}
+	}
+	if end < 0 || end >= size {
+		end = size - 1
+	}
+	if offset >= size && size > 0 || offset > end+1 {
+		return 0, 0, false
+	}
+	return offset, end - offset + 1, true
+}
+
+// OpenOptions describes how an Item is read by OpenWithOptions. The
+// zero value reads the whole Item.
+type OpenOptions struct {
+	// Range, when set, reads only part of the Item.
+	Range *ByteRange
+	// IfNoneMatch, when set, fails the read with ErrNotModified if
+	// the ETag of the Item is this one.
+	IfNoneMatch string
+	// IfModifiedSince, when set, fails the read with ErrNotModified
+	// unless the Item was modified after it.
+	IfModifiedSince time.Time
+	// VersionID, when set, reads the version of the Item with this
+	// ID, as listed by Versioned.
+	VersionID string
+	// ResponseContentType and ResponseContentDisposition override
+	// the headers of the response, where the provider supports it.
+	// They don't change the contents read.
+	ResponseContentType        string
+	ResponseContentDisposition string
+}
+
+// NotModified gets whether the conditions of o show that an Item
+// with the specified ETag and modification time hasn't changed.
+// Modification times are compared to the second, as in HTTP.
+func (o OpenOptions) NotModified(etag string, lastMod time.Time) bool {
+	if o.IfNoneMatch != "" {
+		return o.IfNoneMatch == etag
+	}
+	if !o.IfModifiedSince.IsZero() && !lastMod.IsZero() {
+		return !lastMod.Truncate(time.Second).After(o.IfModifiedSince)
+	}
+	return false
+}
+
+// OptionsOpener represents an Item that can be read as described by
+// OpenOptions.
+// Use the OpenWithOptions function rather than calling OpenWithOptions
+// directly; it falls back to Open for other Items.
+type OptionsOpener interface {
+	// OpenWithOptions is Open, with the Item read as described by
+	// opts. An error matching ErrNotModified is returned when the
+	// conditions of opts show that the Item hasn't changed.
+	OpenWithOptions(opts OpenOptions) (io.ReadCloser, error)
+}
+
+// ContextOptionsOpener represents an OptionsOpener that can be read
+// with a context.
+type ContextOptionsOpener interface {
+	// OpenWithOptionsCtx is OpenWithOptions with a context.
+	OpenWithOptionsCtx(ctx context.Context, opts OpenOptions) (io.ReadCloser, error)
+}
+
 // Taggable represents a taggable Item
 type Taggable interface {
 	// Tags returns a list of tags that belong to a given Item
 	Tags() (map[string]interface{}, error)
 }
 
+// ContextTaggable represents a Taggable whose tags can be read with a
+// context.
+type ContextTaggable interface {
+	// TagsCtx is Tags with a context.
+	TagsCtx(ctx context.Context) (map[string]interface{}, error)
+}
+
+// TagSetter represents an Item whose tags can be changed without
+// uploading it again.
+type TagSetter interface {
+	// SetTags replaces the tags of the Item with tags.
+	SetTags(tags map[string]string) error
+	// DeleteTags removes all the tags of the Item.
+	DeleteTags() error
+}
+
+// ContextTagSetter represents a TagSetter whose operations accept a
+// context.Context.
+type ContextTagSetter interface {
+	// SetTagsCtx is SetTags with a context.
+	SetTagsCtx(ctx context.Context, tags map[string]string) error
+	// DeleteTagsCtx is DeleteTags with a context.
+	DeleteTagsCtx(ctx context.Context) error
+}
+
+// TagQuerier represents a Container that can find Items by their
+// tags.
+type TagQuerier interface {
+	// ItemsByTag gets a page of the Items which have the tag key set
+	// to value. The Items are listed in no particular order.
+	// Pass CursorStart for the first page and the returned cursor for
+	// the next ones; the cursor is empty after the last page.
+	ItemsByTag(key, value, cursor string, count int) ([]Item, string, error)
+}
+
+// ContextTagQuerier represents a TagQuerier that can find Items with
+// a context.
+type ContextTagQuerier interface {
+	// ItemsByTagCtx is ItemsByTag with a context.
+	ItemsByTagCtx(ctx context.Context, key, value, cursor string, count int) ([]Item, string, error)
+}
+
+// ContextLocation represents a Location whose operations accept a
+// context.Context. Implementations abort the underlying calls when the
+// context is canceled or its deadline is exceeded and return ctx.Err().
+type ContextLocation interface {
+	// CreateContainerCtx is CreateContainer with a context.
+	CreateContainerCtx(ctx context.Context, name string) (Container, error)
+	// ContainersCtx is Containers with a context.
+	ContainersCtx(ctx context.Context, prefix string, cursor string, count int) ([]Container, string, error)
+	// ContainerCtx is Container with a context.
+	ContainerCtx(ctx context.Context, id string) (Container, error)
+	// RemoveContainerCtx is RemoveContainer with a context.
+	RemoveContainerCtx(ctx context.Context, id string) error
+	// ItemByURLCtx is ItemByURL with a context.
+	ItemByURLCtx(ctx context.Context, url *url.URL) (Item, error)
+}
+
+// ContextContainer represents a Container whose operations accept a
+// context.Context.
+type ContextContainer interface {
+	// ItemCtx is Item with a context.
+	ItemCtx(ctx context.Context, id string) (Item, error)
+	// ItemsCtx is Items with a context.
+	ItemsCtx(ctx context.Context, prefix, cursor string, count int) ([]Item, string, error)
+	// RemoveItemCtx is RemoveItem with a context.
+	RemoveItemCtx(ctx context.Context, id string) error
+	// PutCtx is Put with a context. Canceling the context aborts the
+	// upload, including reading from r.
+	PutCtx(ctx context.Context, name string, r io.Reader, size int64, metadata map[string]interface{}) (Item, error)
+}
+
+// ContextItem represents an Item that can be opened with a context.
+type ContextItem interface {
+	// OpenCtx is Open with a context. For providers that stream the
+	// contents, canceling the context also aborts pending reads.
+	OpenCtx(ctx context.Context) (io.ReadCloser, error)
+}
+
+// DelimitedLister represents a Container that can list Items as a
+// hierarchy, the way a file system lists a directory.
+type DelimitedLister interface {
+	// ItemsDelimited gets a page of the Items whose names start with
+	// prefix and don't contain delimiter after it, along with the
+	// common prefixes of the other Items: prefix followed by the rest
+	// of their names up to and including the first delimiter, as
+	// returned by CommonPrefix. Each common prefix is listed once and
+	// counts towards count like an Item. An empty delimiter lists all
+	// the Items whose names start with prefix.
+	// The cursor works like the one of Items.
+	ItemsDelimited(prefix, delimiter, cursor string, count int) (items []Item, prefixes []string, next string, err error)
+}
+
+// ContextDelimitedLister represents a DelimitedLister that can list
+// with a context.
+type ContextDelimitedLister interface {
+	// ItemsDelimitedCtx is ItemsDelimited with a context.
+	ItemsDelimitedCtx(ctx context.Context, prefix, delimiter, cursor string, count int) (items []Item, prefixes []string, next string, err error)
+}
+
+// CommonPrefix gets the common prefix name is listed under by
+// ItemsDelimited: prefix followed by the rest of name up to and
+// including the first delimiter. ok is false when the rest of name
+// doesn't contain delimiter, and name is listed as an Item.
+// name must start with prefix.
+func CommonPrefix(name, prefix, delimiter string) (commonPrefix string, ok bool) {
+	if delimiter == "" {
+		return "", false
+	}
+	i := strings.Index(name[len(prefix):], delimiter)
+	if i < 0 {
+		return "", false
+	}
+	return name[:len(prefix)+i+len(delimiter)], true
+}
+
+// Copier represents a Container that can copy Items without
+// streaming their contents through the client.
+// Use the Copy function rather than calling Copy directly; it falls
+// back to streaming when a native copy isn't possible.
+type Copier interface {
+	// Copy copies the Item srcID of this Container to dstID in
+	// dstContainer, which may be this Container.
+	// When metadata is nil the copy keeps the metadata of the source,
+	// otherwise metadata replaces it.
+	// An error satisfying IsNotSupported is returned when dstContainer
+	// can't be copied to natively, for example because it belongs to
+	// another kind of Location.
+	Copy(srcID string, dstContainer Container, dstID string, metadata map[string]interface{}) (Item, error)
+}
+
+// ContextCopier represents a Copier that can copy with a context.
+type ContextCopier interface {
+	// CopyCtx is Copy with a context.
+	CopyCtx(ctx context.Context, srcID string, dstContainer Container, dstID string, metadata map[string]interface{}) (Item, error)
+}
+
+// PutCondition describes the state an Item must be in for a
+// conditional write to happen. The zero value has no conditions.
+type PutCondition struct {
+	// IfMatch, when set, requires the Item to exist with this ETag,
+	// as returned by Item.ETag.
+	IfMatch string
+	// IfNoneMatch requires the Item not to exist. No Item can
+	// satisfy both IfMatch and IfNoneMatch.
+	IfNoneMatch bool
+}
+
+// ConditionalPutter represents a Container that supports conditional
+// writes, for optimistic concurrency control.
+type ConditionalPutter interface {
+	// PutIf is Put, but the Item is only written when cond holds.
+	// Otherwise an error matching ErrPreconditionFailed is returned
+	// and the Item is left untouched.
+	PutIf(name string, r io.Reader, size int64, metadata map[string]interface{}, cond PutCondition) (Item, error)
+}
+
+// ContextConditionalPutter represents a ConditionalPutter that can
+// write with a context.
+type ContextConditionalPutter interface {
+	// PutIfCtx is PutIf with a context.
+	PutIfCtx(ctx context.Context, name string, r io.Reader, size int64, metadata map[string]interface{}, cond PutCondition) (Item, error)
+}
+
+// PutOptions describes how an Item is stored by PutWithOptions. Each
+// field is mapped to the closest feature of the provider; fields the
+// provider has no equivalent for are ignored.
+type PutOptions struct {
+	// ContentType is the MIME type of the contents.
+	ContentType string
+	// CacheControl is the Cache-Control header served with the Item.
+	CacheControl string
+	// ContentDisposition is the Content-Disposition header served
+	// with the Item.
+	ContentDisposition string
+	// ContentEncoding is the Content-Encoding of the contents, such
+	// as gzip.
+	ContentEncoding string
+	// ContentLanguage is the Content-Language of the contents.
+	ContentLanguage string
+	// StorageClass is the storage class or access tier of the Item,
+	// named as by the provider: STANDARD_IA on S3, NEARLINE on Google
+	// Cloud Storage or Cool on Azure.
+	StorageClass string
+	// ACL is the canned ACL of the Item, named as by the provider:
+	// public-read on S3 or publicRead on Google Cloud Storage.
+	ACL string
+	// Tags are the tags of the Item, as got by Taggable.
+	Tags map[string]string
+	// ContentMD5 is the MD5 digest of the contents. Providers that
+	// check it refuse contents that don't match it.
+	ContentMD5 []byte
+	// Metadata is the user metadata of the Item, as got by
+	// Item.Metadata.
+	Metadata map[string]string
+}
+
+// OptionsPutter represents a Container that stores Items as described
+// by PutOptions.
+// Use the PutWithOptions function rather than calling PutWithOptions
+// directly; it falls back to Put for other Containers.
+type OptionsPutter interface {
+	// PutWithOptions is Put, with the Item stored as described by
+	// opts.
+	PutWithOptions(name string, r io.Reader, size int64, opts PutOptions) (Item, error)
+}
+
+// ContextOptionsPutter represents an OptionsPutter that can write with
+// a context.
+type ContextOptionsPutter interface {
+	// PutWithOptionsCtx is PutWithOptions with a context.
+	PutWithOptionsCtx(ctx context.Context, name string, r io.Reader, size int64, opts PutOptions) (Item, error)
+}
+
+// MetadataSetter represents a Container that can change the metadata
+// of Items without uploading them again.
+type MetadataSetter interface {
+	// SetMetadata changes the metadata of the Item with the specified
+	// ID. When replace is true, metadata replaces all the metadata of
+	// the Item; otherwise it is merged into it as by MergeMetadata.
+	SetMetadata(id string, metadata map[string]interface{}, replace bool) error
+}
+
+// ContextMetadataSetter represents a MetadataSetter that can change
+// metadata with a context.
+type ContextMetadataSetter interface {
+	// SetMetadataCtx is SetMetadata with a context.
+	SetMetadataCtx(ctx context.Context, id string, metadata map[string]interface{}, replace bool) error
+}
+
+// MergeMetadata returns a copy of md with the keys of changes set to
+// their values, except for the keys whose values are nil, which are
+// removed.
+func MergeMetadata(md, changes map[string]interface{}) map[string]interface{} {
+	merged := make(map[string]interface{}, len(md)+len(changes))
+	for key, value := range md {
+		merged[key] = value
+	}
+	for key, value := range changes {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+// ItemWriter represents a Container that can create Items whose
+// contents are written as they are produced, without knowing their
+// size up front.
+type ItemWriter interface {
+	// CreateItem creates a new Item with the specified name and
+	// returns a writer for its contents. The Item is only complete,
+	// and its details such as Size and ETag only reliable, once the
+	// writer has been closed without error. The writer must always
+	// be closed.
+	CreateItem(name string) (Item, io.WriteCloser, error)
+}
+
+// Upload is a multipart upload in progress. Its ID and Name are all
+// that is needed to resume it, so they may be persisted to survive
+// restarts.
+type Upload struct {
+	// ID identifies the upload within its Container.
+	ID string
+	// Name is the name of the Item being uploaded.
+	Name string
+	// Initiated is when the upload was initiated, if known.
+	Initiated time.Time
+}
+
+// Part is an uploaded part of a multipart upload.
+type Part struct {
+	// Number is the number of the part, starting at 1. Parts are
+	// assembled in the order of their numbers.
+	Number int
+	// ETag identifies the contents of the part, if the implementation
+	// needs it to complete the upload.
+	ETag string
+	// Size is the size of the part in bytes.
+	Size int64
+}
+
+// MultipartUploader represents a Container that can upload Items in
+// parts, which are kept by the storage until the upload is completed
+// or aborted. Uploads can be resumed by other processes, so large
+// uploads survive restarts.
+// Implementations have their own limits on the number and sizes of
+// parts; 5MiB parts, except the last one, are accepted by all of them.
+type MultipartUploader interface {
+	// InitiateUpload starts uploading an Item with the specified
+	// name and metadata.
+	InitiateUpload(name string, metadata map[string]interface{}) (Upload, error)
+	// UploadPart uploads a part of the Item, replacing any part
+	// previously uploaded with the same number.
+	UploadPart(upload Upload, number int, r io.Reader, size int64) (Part, error)
+	// ListParts gets the parts that have been uploaded, in order.
+	ListParts(upload Upload) ([]Part, error)
+	// CompleteUpload assembles the specified parts into the Item and
+	// returns it.
+	CompleteUpload(upload Upload, parts []Part) (Item, error)
+	// AbortUpload aborts the upload and discards its parts.
+	AbortUpload(upload Upload) error
+	// ListPendingUploads gets the uploads whose names start with
+	// prefix that have neither been completed nor aborted.
+	ListPendingUploads(prefix string) ([]Upload, error)
+}
+
+// ContextMultipartUploader represents a MultipartUploader whose
+// operations accept a context.Context.
+type ContextMultipartUploader interface {
+	// InitiateUploadCtx is InitiateUpload with a context.
+	InitiateUploadCtx(ctx context.Context, name string, metadata map[string]interface{}) (Upload, error)
+	// UploadPartCtx is UploadPart with a context.
+	UploadPartCtx(ctx context.Context, upload Upload, number int, r io.Reader, size int64) (Part, error)
+	// ListPartsCtx is ListParts with a context.
+	ListPartsCtx(ctx context.Context, upload Upload) ([]Part, error)
+	// CompleteUploadCtx is CompleteUpload with a context.
+	CompleteUploadCtx(ctx context.Context, upload Upload, parts []Part) (Item, error)
+	// AbortUploadCtx is AbortUpload with a context.
+	AbortUploadCtx(ctx context.Context, upload Upload) error
+	// ListPendingUploadsCtx is ListPendingUploads with a context.
+	ListPendingUploadsCtx(ctx context.Context, prefix string) ([]Upload, error)
+}
+
+// BatchRemover represents a Container that can remove many Items
+// with few requests.
+// Use the RemoveItems function rather than calling RemoveItems
+// directly; it falls back to removing the Items one by one.
+type BatchRemover interface {
+	// RemoveItems removes the Items with the specified IDs. Items
+	// that couldn't be removed are returned in failed with the reason.
+	// Items that don't exist are not reported as failed.
+	// The returned error is for failures of the whole batch, in which
+	// case some Items may have been removed.
+	RemoveItems(ids []string) (failed map[string]error, err error)
+}
+
+// ContextBatchRemover represents a BatchRemover that can remove with
+// a context.
+type ContextBatchRemover interface {
+	// RemoveItemsCtx is RemoveItems with a context.
+	RemoveItemsCtx(ctx context.Context, ids []string) (failed map[string]error, err error)
+}
+
+// Version is a version of an Item kept by a Versioned Container.
+type Version struct {
+	// ID identifies the version among the versions of the Item.
+	ID string
+	// LastMod is when the version was created.
+	LastMod time.Time
+	// Size is the size of the version in bytes.
+	Size int64
+	// ETag is the ETag of the version, if known.
+	ETag string
+	// Latest is true for the current version of the Item.
+	Latest bool
+	// Deleted is true when the version marks the Item as removed,
+	// in which case it has no contents.
+	Deleted bool
+}
+
+// Versioned represents a Container that keeps previous versions of
+// its Items when they are overwritten or removed.
+// An Item is restored by copying one of its versions over it.
+type Versioned interface {
+	// ItemVersions gets a page of the versions of the Item with
+	// the specified ID, newest first.
+	ItemVersions(id, cursor string, count int) ([]Version, string, error)
+	// ItemVersion gets the specified version of an Item. Versions
+	// that mark the Item as removed can't be got.
+	ItemVersion(id, versionID string) (Item, error)
+	// RemoveItemVersion permanently removes the specified version of
+	// an Item.
+	RemoveItemVersion(id, versionID string) error
+	// EnableVersioning starts keeping the versions of the Items of
+	// the Container. It returns an error satisfying IsNotSupported
+	// when versioning can't be enabled per Container.
+	EnableVersioning() error
+}
+
+// ContextVersioned represents a Versioned Container whose operations
+// accept a context.Context.
+type ContextVersioned interface {
+	// ItemVersionsCtx is ItemVersions with a context.
+	ItemVersionsCtx(ctx context.Context, id, cursor string, count int) ([]Version, string, error)
+	// ItemVersionCtx is ItemVersion with a context.
+	ItemVersionCtx(ctx context.Context, id, versionID string) (Item, error)
+	// RemoveItemVersionCtx is RemoveItemVersion with a context.
+	RemoveItemVersionCtx(ctx context.Context, id, versionID string) error
+	// EnableVersioningCtx is EnableVersioning with a context.
+	EnableVersioningCtx(ctx context.Context) error
+}
+
+// ItemInfo is a snapshot of the information of an Item, as got by
+// Stat. It is a plain value that isn't updated afterwards, so it can
+// be cached and shared between goroutines as long as its Metadata is
+// not modified.
+type ItemInfo struct {
+	// ID is the ID of the Item.
+	ID string
+	// Name is the name of the Item.
+	Name string
+	// Size is the size of the Item in bytes.
+	Size int64
+	// ETag is the ETag of the Item, if known.
+	ETag string
+	// LastMod is when the Item was last modified, if known.
+	LastMod time.Time
+	// ContentType is the media type of the Item, if known.
+	ContentType string
+	// Metadata is the metadata of the Item, as returned by
+	// Item.Metadata.
+	Metadata map[string]interface{}
+}
+
+// Stater represents a Container that can get the information of its
+// Items with a single request, without getting the Items themselves.
+// Use the Stat and Exists functions rather than calling Stat directly;
+// they fall back to Item for other Containers.
+type Stater interface {
+	// Stat gets the information of the Item with the specified ID.
+	// An error matching ErrNotFound is returned when there's no such
+	// Item.
+	Stat(id string) (ItemInfo, error)
+}
+
+// ContextStater represents a Stater that can get information with a
+// context.
+type ContextStater interface {
+	// StatCtx is Stat with a context.
+	StatCtx(ctx context.Context, id string) (ItemInfo, error)
+}
+
+// URLParser represents a Location that can tell which Container and
+// Item a URL points to without making any request, so URLs of Items
+// that don't exist yet can be used too.
+// Use the PutURL and RemoveURL functions rather than calling ParseURL
+// directly.
+type URLParser interface {
+	// ParseURL gets the ID of the Container and the name of the Item
+	// u points to. URLs returned by Item.URL are always accepted.
+	ParseURL(u *url.URL) (containerID, name string, err error)
+}
+
 // Config represents key/value configuration.
 type Config interface {
 	// Config gets a string configuration value and a
@@ -195,64 +845,41 @@ type Config interface {
 // of this kind or not. Code can call KindByURL to get a kind string
 // for any given URL and all registered implementations will be consulted.
 // Register is usually called in an implementation package's init method.
+// A kind that is already registered is replaced.
 func Register(kind string, makefn func(Config) (Location, error), kindmatchfn func(*url.URL) bool, validatefn func(Config) error) {
-	lock.Lock()
-	defer lock.Unlock()
-	// if already registered, leave
-	if _, ok := locations[kind]; ok {
-		return
-	}
-	locations[kind] = makefn
-	configurations[kind] = validatefn
-	kinds = append(kinds, kind)
-	kindmatches = append(kindmatches, func(u *url.URL) string {
-		if kindmatchfn(u) {
-			return kind // match
-		}
-		return "" // empty string means no match
-	})
+	DefaultRegistry.Register(kind, makefn, kindmatchfn, validatefn)
+}
+
+// Unregister removes a kind, along with its schema and connection
+// strings.
+func Unregister(kind string) {
+	DefaultRegistry.Unregister(kind)
 }
 
 // Dial gets a new Location with the given kind and
 // configuration.
 func Dial(kind string, config Config) (Location, error) {
-	fn, ok := locations[kind]
-	if !ok {
-		return nil, errUnknownKind(kind)
-	}
-	return fn(config)
+	return DefaultRegistry.Dial(kind, config)
 }
 
-// Validate validates the config for a location
+// Validate validates the config for a location.
+// When the kind has a schema, the config is checked against it
+// first, and all its problems are returned at once as a
+// *ConfigError.
 func Validate(kind string, config Config) error {
-	fn, ok := configurations[kind]
-	if !ok {
-		return errUnknownKind(kind)
-	}
-	return fn(config)
+	return DefaultRegistry.Validate(kind, config)
 }
 
 // Kinds gets a list of installed location kinds.
 func Kinds() []string {
-	lock.RLock()
-	defer lock.RUnlock()
-	return kinds
+	return DefaultRegistry.Kinds()
 }
 
 // KindByURL gets the kind represented by the given URL.
 // It consults all registered locations.
 // Error returned if no match is found.
 func KindByURL(u *url.URL) (string, error) {
-	lock.RLock()
-	defer lock.RUnlock()
-	for _, fn := range kindmatches {
-		kind := fn(u)
-		if kind == "" {
-			continue
-		}
-		return kind, nil
-	}
-	return "", errUnknownKind("")
+	return DefaultRegistry.KindByURL(u)
 }
 
 // ConfigMap is a map[string]string that implements
@@ -301,6 +928,142 @@ func NotSupported(feature string) error {
 	return errNotSupported(feature)
 }
 
+// WrapError returns an error that matches kind when checked with
+// errors.Is, while keeping err, the native error of the implementation,
+// in the chain for errors.As. Implementations use it to map their SDK
+// errors onto the error kinds declared by this package, such as
+// ErrNotFound or ErrThrottled. WrapError returns nil if err is nil.
+func WrapError(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &kindError{kind: kind, err: err}
+}
+
+type kindError struct {
+	kind error
+	err  error
+}
+
+func (e *kindError) Error() string {
+	return e.kind.Error() + ": " + e.err.Error()
+}
+
+func (e *kindError) Unwrap() []error {
+	return []error{e.kind, e.err}
+}
+
+// ChecksumError is returned when contents don't match the checksum
+// given for them, such as PutOptions.ContentMD5. It matches
+// ErrPreconditionFailed when checked with errors.Is.
+type ChecksumError struct {
+	// Expected is the checksum given for the contents.
+	Expected []byte
+	// Actual is the checksum of the contents.
+	Actual []byte
+}
+
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("checksum mismatch: expected %x, got %x", e.Expected, e.Actual)
+}
+
+// Unwrap gets ErrPreconditionFailed, for errors.Is.
+func (e *ChecksumError) Unwrap() error {
+	return ErrPreconditionFailed
+}
+
+// ContextReader wraps r so that reads fail with ctx.Err() once ctx
+// is done. It lets implementations whose SDK has no context support
+// honor cancellation while streaming.
+// The returned reader is an io.Seeker when r is one, so that SDKs
+// that seek back rather than buffer the contents still can.
+func ContextReader(ctx context.Context, r io.Reader) io.Reader {
+	if ctx.Done() == nil {
+		return r
+	}
+	cr := &contextReader{ctx: ctx, r: r}
+	if s, ok := r.(io.Seeker); ok {
+		return &contextReadSeeker{contextReader: cr, s: s}
+	}
+	return cr
+}
+
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
+
+type contextReadSeeker struct {
+	*contextReader
+	s io.Seeker
+}
+
+func (c *contextReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	return c.s.Seek(offset, whence)
+}
+
+// ContextReadCloser is ContextReader for an io.ReadCloser.
+func ContextReadCloser(ctx context.Context, rc io.ReadCloser) io.ReadCloser {
+	if ctx.Done() == nil {
+		return rc
+	}
+	return &contextReadCloser{contextReader: contextReader{ctx: ctx, r: rc}, c: rc}
+}
+
+type contextReadCloser struct {
+	contextReader
+	c io.Closer
+}
+
+func (c *contextReadCloser) Close() error {
+	return c.c.Close()
+}
+
+// PipeWriter returns a writer whose contents are read by upload,
+// which runs in its own goroutine. Close waits for upload to return
+// and returns its error. The writer also has a CloseWithError method
+// that aborts the upload by failing its reads with the given error.
+// It lets implementations whose SDK uploads from an io.Reader
+// implement ItemWriter.
+func PipeWriter(upload func(r io.Reader) error) io.WriteCloser {
+	pr, pw := io.Pipe()
+	w := &pipeWriter{pw: pw, done: make(chan struct{})}
+	go func() {
+		w.err = upload(pr)
+		// Fails further writes when upload returns early.
+		pr.CloseWithError(w.err)
+		close(w.done)
+	}()
+	return w
+}
+
+type pipeWriter struct {
+	pw   *io.PipeWriter
+	done chan struct{}
+	err  error
+}
+
+func (w *pipeWriter) Write(p []byte) (int, error) {
+	return w.pw.Write(p)
+}
+
+func (w *pipeWriter) Close() error {
+	return w.CloseWithError(nil)
+}
+
+func (w *pipeWriter) CloseWithError(err error) error {
+	w.pw.CloseWithError(err)
+	<-w.done
+	return w.err
+}
+
 func GetContentRange(item Item, start, end uint64) (data ContentRangeData, err error) {
 	size, err := item.Size()
 	if err != nil {
@@ -1,8 +1,11 @@
 package stow_test
 
 import (
+	"context"
 	"errors"
+	"io"
 	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/aldor007/stow"
@@ -38,6 +41,17 @@ func TestErrNotSupported(t *testing.T) {
 	is.True(stow.IsNotSupported(err))
 }
 
+func TestWrapError(t *testing.T) {
+	is := is.New(t)
+	is.NoErr(stow.WrapError(stow.ErrThrottled, nil))
+	native := errors.New("slow down")
+	err := stow.WrapError(stow.ErrThrottled, native)
+	is.True(errors.Is(err, stow.ErrThrottled))
+	is.True(errors.Is(err, native))
+	is.False(errors.Is(err, stow.ErrNotFound))
+	is.Equal(err.Error(), "throttled: slow down")
+}
+
 func TestDuplicateKinds(t *testing.T) {
 	is := is.New(t)
 	stow.Register("example", nil, nil, nil)
@@ -45,3 +59,109 @@ func TestDuplicateKinds(t *testing.T) {
 	stow.Register("example", nil, nil, nil)
 	is.Equal(stow.Kinds(), []string{"test", "example"})
 }
+
+func TestCapabilitiesCanPresign(t *testing.T) {
+	is := is.New(t)
+	var caps stow.Capabilities
+	is.False(caps.CanPresign(stow.ClientMethodGet))
+	caps.PresignMethods = []stow.ClientMethod{stow.ClientMethodGet}
+	is.True(caps.CanPresign(stow.ClientMethodGet))
+	is.False(caps.CanPresign(stow.ClientMethodPut))
+}
+
+func TestCapabilitiesOf(t *testing.T) {
+	is := is.New(t)
+	c := newMemContainer(true)
+	is.Equal(stow.CapabilitiesOf(c), c.Capabilities())
+
+	// without Capabilities, they are worked out from the interfaces
+	caps := stow.CapabilitiesOf(&testLocation{})
+	is.True(caps.Listing)
+	is.False(caps.Ranges)
+	is.False(caps.Metadata)
+	is.False(caps.ServerSideCopy)
+}
+
+func TestPipeWriter(t *testing.T) {
+	is := is.New(t)
+	var got []byte
+	w := stow.PipeWriter(func(r io.Reader) error {
+		var err error
+		got, err = io.ReadAll(r)
+		return err
+	})
+	_, err := io.WriteString(w, "contents")
+	is.NoErr(err)
+	is.NoErr(w.Close())
+	is.Equal(string(got), "contents")
+
+	// the error of the upload is returned by Close
+	uploadErr := errors.New("upload failed")
+	w = stow.PipeWriter(func(r io.Reader) error {
+		return uploadErr
+	})
+	is.Equal(w.Close(), uploadErr)
+
+	// CloseWithError aborts the upload
+	abortErr := errors.New("aborted")
+	w = stow.PipeWriter(func(r io.Reader) error {
+		_, err := io.ReadAll(r)
+		return err
+	})
+	_, err = io.WriteString(w, "partial")
+	is.NoErr(err)
+	closer := w.(interface{ CloseWithError(error) error })
+	is.Equal(closer.CloseWithError(abortErr), abortErr)
+}
+
+func TestContextReader(t *testing.T) {
+	is := is.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// seekers are still seekers, so SDKs don't buffer them
+	r := stow.ContextReader(ctx, strings.NewReader("contents"))
+	b, err := io.ReadAll(r)
+	is.NoErr(err)
+	is.Equal(string(b), "contents")
+	seeker, ok := r.(io.Seeker)
+	is.True(ok)
+	_, err = seeker.Seek(0, io.SeekStart)
+	is.NoErr(err)
+	_, ok = stow.ContextReader(ctx, io.MultiReader()).(io.Seeker)
+	is.False(ok)
+
+	cancel()
+	_, err = r.Read(make([]byte, 1))
+	is.Equal(err, context.Canceled)
+}
+
+func TestCommonPrefix(t *testing.T) {
+	is := is.New(t)
+	for _, test := range []struct {
+		name, prefix, delimiter string
+		commonPrefix            string
+		ok                      bool
+	}{
+		{"photos/2020/a.jpg", "", "/", "photos/", true},
+		{"photos/2020/a.jpg", "photos/", "/", "photos/2020/", true},
+		{"photos/2020/a.jpg", "photos/2020/", "/", "", false},
+		{"photos/2020/a.jpg", "pho", "/", "photos/", true},
+		{"photos/2020/a.jpg", "", "", "", false},
+		{"logs-2020-01", "logs-", "-", "logs-2020-", true},
+		{"a::b::c", "", "::", "a::", true},
+	} {
+		commonPrefix, ok := stow.CommonPrefix(test.name, test.prefix, test.delimiter)
+		is.Equal(commonPrefix, test.commonPrefix)
+		is.Equal(ok, test.ok)
+	}
+}
+
+func TestMergeMetadata(t *testing.T) {
+	is := is.New(t)
+	md := map[string]interface{}{"content-type": "text/plain", "owner": "me"}
+	merged := stow.MergeMetadata(md, map[string]interface{}{"content-type": "text/html", "owner": nil, "new": "value"})
+	is.Equal(merged, map[string]interface{}{"content-type": "text/html", "new": "value"})
+	// md is left as it is
+	is.Equal(md["owner"], "me")
+	is.Equal(len(stow.MergeMetadata(nil, nil)), 0)
+}
@@ -0,0 +1,70 @@
+package swift
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	"github.com/aldor007/stow"
+	"github.com/pkg/errors"
+)
+
+// bulkDeleteLimit is the number of objects removed per bulk delete
+// request when the cluster doesn't tell its own limit.
+const bulkDeleteLimit = 1000
+
+var (
+	_ stow.BatchRemover        = (*container)(nil)
+	_ stow.ContextBatchRemover = (*container)(nil)
+)
+
+// RemoveItems removes the objects with the bulk delete middleware,
+// as many per request as the cluster accepts. Clusters that don't
+// have the middleware make it return an error satisfying
+// stow.IsNotSupported.
+func (c *container) RemoveItems(ids []string) (map[string]error, error) {
+	return c.RemoveItemsCtx(context.Background(), ids)
+}
+
+// RemoveItemsCtx is RemoveItems with a context. The swift client has
+// no context support, so ctx is only checked between requests.
+func (c *container) RemoveItemsCtx(ctx context.Context, ids []string) (map[string]error, error) {
+	limit := bulkDeleteLimit
+	// The cluster may not expose its info, in which case the request
+	// tells whether bulk deletes are supported.
+	if info, err := c.client.QueryInfo(); err == nil {
+		if !info.SupportsBulkDelete() {
+			return nil, stow.NotSupported("bulk delete")
+		}
+		if bulkDelete, ok := info["bulk_delete"].(map[string]interface{}); ok {
+			if n, ok := bulkDelete["max_deletes_per_request"].(float64); ok && n > 0 {
+				limit = int(n)
+			}
+		}
+	}
+	failed := make(map[string]error)
+	for len(ids) > 0 {
+		if err := ctx.Err(); err != nil {
+			return failed, err
+		}
+		batch := ids
+		if len(batch) > limit {
+			batch = batch[:limit]
+		}
+		ids = ids[len(batch):]
+		res, err := c.client.BulkDelete(c.id, batch)
+		if err != nil && len(res.Errors) == 0 {
+			return failed, errors.Wrap(mapError(err), "bulk delete")
+		}
+		for path, err := range res.Errors {
+			id, unescapeErr := url.PathUnescape(strings.TrimPrefix(path, "/"+c.id+"/"))
+			if unescapeErr != nil {
+				id = path
+			}
+			if err := mapError(err); !errors.Is(err, stow.ErrNotFound) {
+				failed[id] = err
+			}
+		}
+	}
+	return failed, nil
+}
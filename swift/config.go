@@ -71,6 +71,18 @@ func init() {
 		return u.Scheme == Kind
 	}
 	stow.Register(Kind, makefn, kindfn, validatefn)
+	stow.RegisterDSN(Kind, stow.DSN{
+		User:     ConfigUsername,
+		Password: ConfigKey,
+		Endpoint: ConfigTenantAuthURL,
+		Query:    map[string]string{ConfigTenantName: ConfigTenantName},
+	})
+	stow.RegisterSchema(Kind, stow.Schema{Keys: []stow.KeySchema{
+		{Name: ConfigUsername, Required: true, Description: "The name of the user."},
+		{Name: ConfigKey, Required: true, Secret: true, Description: "The API key or password of the user."},
+		{Name: ConfigTenantName, Required: true, Description: "The name of the tenant."},
+		{Name: ConfigTenantAuthURL, Type: stow.TypeURL, Required: true, Description: "The URL of the authentication service."},
+	}})
 }
 
 func newSwiftClient(cfg stow.Config) (*swift.Connection, error) {
@@ -2,9 +2,11 @@ package swift
 
 import (
 	"context"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/pkg/errors"
 
@@ -17,7 +19,22 @@ type container struct {
 	client *swift.Connection
 }
 
-var _ stow.Container = (*container)(nil)
+var (
+	_ stow.Container              = (*container)(nil)
+	_ stow.ContextContainer       = (*container)(nil)
+	_ stow.CapabilityReporter     = (*container)(nil)
+	_ stow.Copier                 = (*container)(nil)
+	_ stow.ContextCopier          = (*container)(nil)
+	_ stow.ItemWriter             = (*container)(nil)
+	_ stow.DelimitedLister        = (*container)(nil)
+	_ stow.ContextDelimitedLister = (*container)(nil)
+	_ stow.MetadataSetter         = (*container)(nil)
+	_ stow.ContextMetadataSetter  = (*container)(nil)
+	_ stow.OptionsPutter          = (*container)(nil)
+	_ stow.ContextOptionsPutter   = (*container)(nil)
+	_ stow.Stater                 = (*container)(nil)
+	_ stow.ContextStater          = (*container)(nil)
+)
 
 func (c *container) ID() string {
 	return c.id
@@ -32,48 +49,172 @@ func (c *container) PreSignRequest(_ context.Context, _ stow.ClientMethod, _ str
 	return "", fmt.Errorf("unsupported")
 }
 
+// Capabilities describes the features supported by this container.
+func (c *container) Capabilities() stow.Capabilities {
+	return capabilities()
+}
+
 func (c *container) Item(id string) (stow.Item, error) {
+	return c.ItemCtx(context.Background(), id)
+}
+
+// ItemCtx is Item with a context. The swift client has no context
+// support, so ctx is checked before the request is sent.
+func (c *container) ItemCtx(ctx context.Context, id string) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	return c.getItem(id)
 }
 
+// Stat gets the information of an object with a HEAD request.
+func (c *container) Stat(id string) (stow.ItemInfo, error) {
+	return c.StatCtx(context.Background(), id)
+}
+
+// StatCtx is Stat with a context, which is checked before the request
+// is sent.
+func (c *container) StatCtx(ctx context.Context, id string) (stow.ItemInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return stow.ItemInfo{}, err
+	}
+	info, headers, err := c.client.Object(c.id, id)
+	if err != nil {
+		return stow.ItemInfo{}, mapError(err)
+	}
+	md, err := parseMetadata(headers)
+	if err != nil {
+		return stow.ItemInfo{}, errors.Wrap(err, "unable to retrieve Item information, parsing metadata")
+	}
+	return stow.ItemInfo{
+		ID:          id,
+		Name:        id,
+		Size:        info.Bytes,
+		ETag:        info.Hash,
+		LastMod:     info.LastModified,
+		ContentType: info.ContentType,
+		Metadata:    md,
+	}, nil
+}
+
 func (c *container) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
+	return c.ItemsCtx(context.Background(), prefix, cursor, count)
+}
+
+// ItemsCtx is Items with a context.
+func (c *container) ItemsCtx(ctx context.Context, prefix, cursor string, count int) ([]stow.Item, string, error) {
+	items, _, marker, err := c.ItemsDelimitedCtx(ctx, prefix, "", cursor, count)
+	return items, marker, err
+}
+
+// ItemsDelimited lists the objects with the delimiter of the Swift
+// listing, which lists the common prefixes as pseudo directories.
+// Swift only supports delimiters of a single character.
+func (c *container) ItemsDelimited(prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	return c.ItemsDelimitedCtx(context.Background(), prefix, delimiter, cursor, count)
+}
+
+// ItemsDelimitedCtx is ItemsDelimited with a context.
+func (c *container) ItemsDelimitedCtx(ctx context.Context, prefix, delimiter, cursor string, count int) ([]stow.Item, []string, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, "", err
+	}
 	params := &swift.ObjectsOpts{
 		Limit:  count,
 		Marker: cursor,
 		Prefix: prefix,
 	}
+	if delimiter != "" {
+		if utf8.RuneCountInString(delimiter) > 1 {
+			return nil, nil, "", stow.NotSupported("delimiters of more than one character")
+		}
+		params.Delimiter, _ = utf8.DecodeRuneInString(delimiter)
+	}
 	objects, err := c.client.Objects(c.id, params)
 	if err != nil {
-		return nil, "", err
+		return nil, nil, "", mapError(err)
 	}
-	items := make([]stow.Item, len(objects))
-	for i, obj := range objects {
-
-		items[i] = &item{
+	items := make([]stow.Item, 0, len(objects))
+	var prefixes []string
+	for _, obj := range objects {
+		if obj.PseudoDirectory {
+			prefixes = append(prefixes, obj.Name)
+			continue
+		}
+		items = append(items, &item{
 			id:           obj.Name,
 			container:    c,
 			client:       c.client,
 			hash:         obj.Hash,
 			size:         obj.Bytes,
 			lastModified: obj.LastModified,
-		}
+		})
 	}
 	marker := ""
 	if len(objects) == count {
 		marker = objects[len(objects)-1].Name
 	}
-	return items, marker, nil
+	return items, prefixes, marker, nil
 }
 
 func (c *container) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	return c.PutCtx(context.Background(), name, r, size, metadata)
+}
+
+// PutCtx is Put with a context. Canceling ctx stops reading from r,
+// which aborts the upload. Objects larger than a segment, or of unknown
+// size, are uploaded as large objects.
+func (c *container) PutCtx(ctx context.Context, name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	// Objects are uploaded with chunked transfer encoding, so the size
+	// is counted rather than trusted.
+	counter := &countingReader{r: stow.ContextReader(ctx, r)}
 	mdPrepped, err := prepMetadata(metadata)
 	if err != nil {
 		return nil, errors.Wrap(err, "unable to create or update Item, preparing metadata")
 	}
 
-	headers, err := c.client.ObjectPut(c.id, name, r, false, "", "", mdPrepped)
+	headers, err := c.putObject(name, counter, size, "", "", mdPrepped)
+	if err != nil {
+		return nil, errors.Wrap(mapError(err), "unable to create or update Item")
+	}
+
+	mdParsed, err := parseMetadata(headers)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to create or update Item, parsing metadata")
+	}
+
+	item := &item{
+		id:        name,
+		container: c,
+		client:    c.client,
+		size:      counter.n,
+		metadata:  mdParsed,
+	}
+	return item, nil
+}
+
+// PutWithOptions is Put with the options sent as the headers of the
+// object, and ContentMD5 checked against the ETag of the upload, or the
+// contents read for large objects, whose ETag isn't their MD5. Swift
+// has no storage classes, ACLs or tags, so those options are ignored.
+func (c *container) PutWithOptions(name string, r io.Reader, size int64, opts stow.PutOptions) (stow.Item, error) {
+	return c.PutWithOptionsCtx(context.Background(), name, r, size, opts)
+}
+
+// PutWithOptionsCtx is PutWithOptions with a context.
+func (c *container) PutWithOptionsCtx(ctx context.Context, name string, r io.Reader, size int64, opts stow.PutOptions) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	counter := &countingReader{r: stow.ContextReader(ctx, r)}
+	headers, hash := prepPutOptions(opts)
+
+	headers, err := c.putObject(name, counter, size, hash, opts.ContentType, headers)
 	if err != nil {
-		return nil, errors.Wrap(err, "unable to create or update Item")
+		return nil, errors.Wrap(mapError(err), "unable to create or update Item")
 	}
 
 	mdParsed, err := parseMetadata(headers)
@@ -85,14 +226,136 @@ func (c *container) Put(name string, r io.Reader, size int64, metadata map[strin
 		id:        name,
 		container: c,
 		client:    c.client,
-		size:      size,
+		size:      counter.n,
 		metadata:  mdParsed,
 	}
 	return item, nil
 }
 
 func (c *container) RemoveItem(id string) error {
-	return c.client.ObjectDelete(c.id, id)
+	return c.RemoveItemCtx(context.Background(), id)
+}
+
+// RemoveItemCtx is RemoveItem with a context. The segments of large
+// objects are removed with them.
+func (c *container) RemoveItemCtx(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return mapError(c.client.LargeObjectDelete(c.id, id))
+}
+
+// Copy copies the object srcID to dstID in dstContainer, which must be
+// a Swift container of the same account, with a server side COPY.
+func (c *container) Copy(srcID string, dstContainer stow.Container, dstID string, metadata map[string]interface{}) (stow.Item, error) {
+	return c.CopyCtx(context.Background(), srcID, dstContainer, dstID, metadata)
+}
+
+// CopyCtx is Copy with a context.
+func (c *container) CopyCtx(ctx context.Context, srcID string, dstContainer stow.Container, dstID string, metadata map[string]interface{}) (stow.Item, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	dst, ok := dstContainer.(*container)
+	if !ok {
+		return nil, stow.NotSupported("copy to another kind of container")
+	}
+	var headers swift.Headers
+	if metadata != nil {
+		mdPrepped, err := prepMetadata(metadata)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to copy Item, preparing metadata")
+		}
+		// Without X-Fresh-Metadata the metadata of the source is
+		// merged with the new one.
+		headers = swift.Headers(mdPrepped)
+		headers["X-Fresh-Metadata"] = "true"
+	}
+	if _, err := c.client.ObjectCopy(c.id, srcID, dst.id, dstID, headers); err != nil {
+		return nil, errors.Wrap(mapError(err), "unable to copy Item")
+	}
+	return dst.getItem(dstID)
+}
+
+// SetMetadata sets the metadata of the object with a POST request,
+// which replaces all of it, so it is read first to be merged, and for
+// the manifest of dynamic large objects to be kept.
+func (c *container) SetMetadata(id string, metadata map[string]interface{}, replace bool) error {
+	return c.SetMetadataCtx(context.Background(), id, metadata, replace)
+}
+
+// SetMetadataCtx is SetMetadata with a context.
+func (c *container) SetMetadataCtx(ctx context.Context, id string, metadata map[string]interface{}, replace bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, headers, err := c.client.Object(c.id, id)
+	if err != nil {
+		return errors.Wrap(mapError(err), "unable to set metadata")
+	}
+	md := map[string]interface{}{}
+	if !replace {
+		if md, err = parseMetadata(headers); err != nil {
+			return errors.Wrap(err, "unable to set metadata, parsing metadata")
+		}
+	}
+	mdPrepped, err := prepMetadata(stow.MergeMetadata(md, metadata))
+	if err != nil {
+		return errors.Wrap(err, "unable to set metadata, preparing metadata")
+	}
+	if err := c.client.ObjectUpdate(c.id, id, keepManifest(mdPrepped, headers)); err != nil {
+		return errors.Wrap(mapError(err), "unable to set metadata")
+	}
+	return nil
+}
+
+// CreateItem creates a new object whose contents are written to the
+// returned writer. They are streamed to the object store as they are
+// written, and the object is complete once the writer is closed.
+func (c *container) CreateItem(name string) (stow.Item, io.WriteCloser, error) {
+	w, err := c.client.ObjectCreate(c.id, name, false, "", "", nil)
+	if err != nil {
+		return nil, nil, errors.Wrap(mapError(err), "unable to create Item")
+	}
+	item := &item{
+		id:        name,
+		container: c,
+		client:    c.client,
+	}
+	return item, &objectWriter{ObjectCreateFile: w, item: item}, nil
+}
+
+// objectWriter fills in its item once the object has been written.
+type objectWriter struct {
+	*swift.ObjectCreateFile
+	item *item
+}
+
+func (w *objectWriter) Close() error {
+	if err := w.ObjectCreateFile.Close(); err != nil {
+		return errors.Wrap(mapError(err), "unable to create Item")
+	}
+	created, err := w.item.container.getItem(w.item.id)
+	if err != nil {
+		return err
+	}
+	w.item.hash = created.hash
+	w.item.size = created.size
+	w.item.lastModified = created.lastModified
+	w.item.metadata = created.metadata
+	return nil
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
 }
 
 func (c *container) getItem(id string) (*item, error) {
@@ -101,7 +364,7 @@ func (c *container) getItem(id string) (*item, error) {
 		if strings.Contains(err.Error(), "Object Not Found") {
 			return nil, stow.ErrNotFound
 		}
-		return nil, errors.Wrap(err, "error retrieving item")
+		return nil, errors.Wrap(mapError(err), "error retrieving item")
 	}
 
 	md, err := parseMetadata(headers)
@@ -130,6 +393,27 @@ func parseMetadata(md swift.Headers) (map[string]interface{}, error) {
 	return m, nil
 }
 
+// prepPutOptions maps opts to the headers of an upload, other than the
+// content type, and the hex MD5 digest to check the contents against,
+// if any.
+func prepPutOptions(opts stow.PutOptions) (swift.Headers, string) {
+	headers := make(swift.Headers, len(opts.Metadata)+4)
+	for key, value := range opts.Metadata {
+		headers["X-Object-Meta-"+key] = value
+	}
+	for key, value := range map[string]string{
+		"Cache-Control":       opts.CacheControl,
+		"Content-Disposition": opts.ContentDisposition,
+		"Content-Encoding":    opts.ContentEncoding,
+		"Content-Language":    opts.ContentLanguage,
+	} {
+		if value != "" {
+			headers[key] = value
+		}
+	}
+	return headers, hex.EncodeToString(opts.ContentMD5)
+}
+
 // TODO determine invalid keys.
 func prepMetadata(md map[string]interface{}) (map[string]string, error) {
 	m := make(map[string]string, len(md))
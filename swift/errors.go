@@ -0,0 +1,40 @@
+package swift
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/aldor007/stow"
+	"github.com/ncw/swift"
+)
+
+// mapError maps errors returned by the Swift API to the error kinds
+// declared by stow. The original error is kept in the chain.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var swiftError *swift.Error
+	if !errors.As(err, &swiftError) {
+		return err
+	}
+	switch swiftError.StatusCode {
+	case http.StatusNotModified:
+		return stow.WrapError(stow.ErrNotModified, err)
+	case http.StatusNotFound:
+		return stow.WrapError(stow.ErrNotFound, err)
+	case http.StatusConflict:
+		if swiftError == swift.ContainerNotEmpty {
+			return stow.WrapError(stow.ErrContainerNotEmpty, err)
+		}
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return stow.WrapError(stow.ErrPermissionDenied, err)
+	case http.StatusPreconditionFailed:
+		return stow.WrapError(stow.ErrPreconditionFailed, err)
+	case http.StatusRequestedRangeNotSatisfiable:
+		return stow.WrapError(stow.ErrInvalidRange, err)
+	case http.StatusTooManyRequests, swift.RateLimit.StatusCode:
+		return stow.WrapError(stow.ErrThrottled, err)
+	}
+	return err
+}
@@ -1,15 +1,17 @@
 package swift
 
 import (
+	"context"
 	"io"
+	"net/http"
 	"net/url"
 	"path"
 	"sync"
 	"time"
 
-	"github.com/pkg/errors"
 	"github.com/aldor007/stow"
 	"github.com/ncw/swift"
+	"github.com/pkg/errors"
 )
 
 type item struct {
@@ -26,7 +28,12 @@ type item struct {
 	infoErr      error
 }
 
-var _ stow.Item = (*item)(nil)
+var (
+	_ stow.Item                 = (*item)(nil)
+	_ stow.ContextItem          = (*item)(nil)
+	_ stow.OptionsOpener        = (*item)(nil)
+	_ stow.ContextOptionsOpener = (*item)(nil)
+)
 
 func (i *item) ID() string {
 	return i.id
@@ -52,14 +59,58 @@ func (i *item) Size() (int64, error) {
 }
 
 func (i *item) Open() (io.ReadCloser, error) {
+	return i.OpenCtx(context.Background())
+}
+
+// OpenCtx is Open with a context. ctx is only consulted before the
+// request is sent, so the returned file keeps its io.Seeker support.
+func (i *item) OpenCtx(ctx context.Context) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	r, _, err := i.client.ObjectOpen(i.container.id, i.id, false, nil)
-	return r, err
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return r, nil
 }
 
 func (i *item) OpenParams(_ map[string]interface{}) (io.ReadCloser, error) {
 	return i.Open()
 }
 
+// OpenWithOptions opens the object as described by opts, which are
+// sent as the headers of the request. Versions of objects aren't
+// supported and the response overrides are ignored.
+func (i *item) OpenWithOptions(opts stow.OpenOptions) (io.ReadCloser, error) {
+	return i.OpenWithOptionsCtx(context.Background(), opts)
+}
+
+// OpenWithOptionsCtx is OpenWithOptions with a context.
+func (i *item) OpenWithOptionsCtx(ctx context.Context, opts stow.OpenOptions) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if opts.VersionID != "" {
+		return nil, stow.NotSupported("opening versions")
+	}
+	headers := swift.Headers{}
+	if opts.Range != nil {
+		headers["Range"] = opts.Range.String()
+	}
+	if opts.IfNoneMatch != "" {
+		headers["If-None-Match"] = `"` + opts.IfNoneMatch + `"`
+	}
+	if !opts.IfModifiedSince.IsZero() {
+		headers["If-Modified-Since"] = opts.IfModifiedSince.UTC().Format(http.TimeFormat)
+	}
+	r, _, err := i.client.ObjectOpen(i.container.id, i.id, false, headers)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return r, nil
+}
+
 func (i *item) ContentRange() (stow.ContentRangeData, error) {
 	return stow.ContentRangeData{}, errors.New("not implemented")
 }
@@ -0,0 +1,114 @@
+package swift
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aldor007/stow"
+	"github.com/ncw/swift"
+)
+
+// largeObjectSegmentSize is the size of the segments objects are
+// uploaded in by Put when they are larger, or of unknown size. Swift
+// refuses objects of more than 5GB, and a single segment is buffered
+// at a time, so it also bounds the memory used by uploads.
+var largeObjectSegmentSize int64 = 100 << 20
+
+// putObject uploads the contents of r as the object name and returns
+// its headers. Objects of at most largeObjectSegmentSize bytes are
+// uploaded with a single request. The others, and those of unknown
+// size which turn out to be larger, are uploaded as segments to the
+// container named after this one with a "_segments" suffix, joined by
+// a static large object manifest, or a dynamic one where the cluster
+// doesn't support them. A non empty hash is the hex encoded MD5 the
+// contents are checked against.
+func (c *container) putObject(name string, r io.Reader, size int64, hash, contentType string, headers swift.Headers) (swift.Headers, error) {
+	if size >= 0 && size <= largeObjectSegmentSize {
+		return c.client.ObjectPut(c.id, name, r, hash != "", hash, contentType, headers)
+	}
+	var segment bytes.Buffer
+	n, err := io.CopyN(&segment, r, largeObjectSegmentSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if n < largeObjectSegmentSize {
+		return c.client.ObjectPut(c.id, name, &segment, hash != "", hash, contentType, headers)
+	}
+
+	segments := c.id + "_segments"
+	if err := c.client.ContainerCreate(segments, nil); err != nil {
+		return nil, err
+	}
+	opts := &swift.LargeObjectOpts{
+		Container:        c.id,
+		ObjectName:       name,
+		ContentType:      contentType,
+		Headers:          headers,
+		ChunkSize:        largeObjectSegmentSize,
+		SegmentContainer: segments,
+		SegmentPrefix:    fmt.Sprintf("%s/%d", name, time.Now().UnixNano()),
+		// Each segment is written at once from the buffer.
+		NoBuffer: true,
+	}
+	file, err := c.client.StaticLargeObjectCreate(opts)
+	if err == swift.SLONotSupported {
+		file, err = c.client.DynamicLargeObjectCreate(opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := writeSegments(file, &segment, r, hash); err != nil {
+		c.removeSegments(segments, opts.SegmentPrefix)
+		return nil, err
+	}
+	_, objHeaders, err := c.client.Object(c.id, name)
+	return objHeaders, err
+}
+
+// writeSegments writes segment, then the rest of r a segment at a
+// time, to file, and closes it to write the manifest if the contents
+// match hash.
+func writeSegments(file swift.LargeObjectFile, segment *bytes.Buffer, r io.Reader, hash string) error {
+	sum := md5.New()
+	for segment.Len() > 0 {
+		sum.Write(segment.Bytes())
+		if _, err := file.Write(segment.Bytes()); err != nil {
+			return err
+		}
+		segment.Reset()
+		if _, err := io.CopyN(segment, r, largeObjectSegmentSize); err != nil && err != io.EOF {
+			return err
+		}
+	}
+	if actual := sum.Sum(nil); hash != "" && hex.EncodeToString(actual) != hash {
+		expected, _ := hex.DecodeString(hash)
+		return &stow.ChecksumError{Expected: expected, Actual: actual}
+	}
+	return file.Close()
+}
+
+// removeSegments removes the segments uploaded with prefix, which are
+// left behind when an upload fails before its manifest is written.
+func (c *container) removeSegments(segments, prefix string) {
+	names, err := c.client.ObjectNamesAll(segments, &swift.ObjectsOpts{Prefix: prefix + "/"})
+	if err != nil {
+		return
+	}
+	for _, name := range names {
+		c.client.ObjectDelete(segments, name)
+	}
+}
+
+// keepManifest adds the manifest of a dynamic large object, as found
+// in objHeaders, to the headers of an update, which would otherwise
+// turn it into an object with no contents.
+func keepManifest(headers, objHeaders swift.Headers) swift.Headers {
+	if manifest, ok := objHeaders["X-Object-Manifest"]; ok {
+		headers["X-Object-Manifest"] = manifest
+	}
+	return headers
+}
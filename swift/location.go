@@ -1,6 +1,7 @@
 package swift
 
 import (
+	"context"
 	"errors"
 	"net/url"
 	"strings"
@@ -14,6 +15,13 @@ type location struct {
 	client *swift.Connection
 }
 
+var (
+	_ stow.Location           = (*location)(nil)
+	_ stow.ContextLocation    = (*location)(nil)
+	_ stow.CapabilityReporter = (*location)(nil)
+	_ stow.URLParser          = (*location)(nil)
+)
+
 func (l *location) Close() error {
 	return nil // nothing to close
 }
@@ -23,10 +31,37 @@ func (l *location) HasRanges() bool {
 	return false
 }
 
+// Capabilities describes the features supported by this location.
+func (l *location) Capabilities() stow.Capabilities {
+	return capabilities()
+}
+
+// capabilities describes the features supported by Swift.
+func capabilities() stow.Capabilities {
+	return stow.Capabilities{
+		Metadata:         true,
+		ServerSideCopy:   true,
+		Listing:          true,
+		Write:            true,
+		Delete:           true,
+		BatchRemove:      true,
+		DelimitedListing: true,
+		MetadataUpdates:  true,
+	}
+}
+
 func (l *location) CreateContainer(name string) (stow.Container, error) {
+	return l.CreateContainerCtx(context.Background(), name)
+}
+
+// CreateContainerCtx is CreateContainer with a context.
+func (l *location) CreateContainerCtx(ctx context.Context, name string) (stow.Container, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	err := l.client.ContainerCreate(name, nil)
 	if err != nil {
-		return nil, err
+		return nil, mapError(err)
 	}
 	container := &container{
 		id:     name,
@@ -36,6 +71,14 @@ func (l *location) CreateContainer(name string) (stow.Container, error) {
 }
 
 func (l *location) Containers(prefix, cursor string, count int) ([]stow.Container, string, error) {
+	return l.ContainersCtx(context.Background(), prefix, cursor, count)
+}
+
+// ContainersCtx is Containers with a context.
+func (l *location) ContainersCtx(ctx context.Context, prefix, cursor string, count int) ([]stow.Container, string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, "", err
+	}
 	params := &swift.ContainersOpts{
 		Limit:  count,
 		Prefix: prefix,
@@ -43,7 +86,7 @@ func (l *location) Containers(prefix, cursor string, count int) ([]stow.Containe
 	}
 	response, err := l.client.Containers(params)
 	if err != nil {
-		return nil, "", err
+		return nil, "", mapError(err)
 	}
 	containers := make([]stow.Container, len(response))
 	for i, cont := range response {
@@ -62,6 +105,14 @@ func (l *location) Containers(prefix, cursor string, count int) ([]stow.Containe
 }
 
 func (l *location) Container(id string) (stow.Container, error) {
+	return l.ContainerCtx(context.Background(), id)
+}
+
+// ContainerCtx is Container with a context.
+func (l *location) ContainerCtx(ctx context.Context, id string) (stow.Container, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
 	_, _, err := l.client.Container(id)
 	// TODO: grab info + headers
 	if err != nil {
@@ -77,24 +128,50 @@ func (l *location) Container(id string) (stow.Container, error) {
 }
 
 func (l *location) ItemByURL(url *url.URL) (stow.Item, error) {
+	return l.ItemByURLCtx(context.Background(), url)
+}
 
-	if url.Scheme != Kind {
-		return nil, errors.New("not valid swift URL")
+// ItemByURLCtx is ItemByURL with a context.
+func (l *location) ItemByURLCtx(ctx context.Context, url *url.URL) (stow.Item, error) {
+	containerID, name, err := l.ParseURL(url)
+	if err != nil {
+		return nil, err
 	}
 
-	path := strings.TrimLeft(url.Path, "/")
-	pieces := strings.SplitN(path, "/", 4)
-
-	// swift://lax-proxy-03.storagesvc.sohonet.com/v1/AUTH_b04239c7467548678b4822e9dad96030/<container_name>/<path_to_object>
-
-	c, err := l.Container(pieces[2])
+	c, err := l.ContainerCtx(ctx, containerID)
 	if err != nil {
 		return nil, err
 	}
 
-	return c.Item(pieces[3])
+	return c.(*container).ItemCtx(ctx, name)
+}
+
+// ParseURL gets the container and the name of the object a URL points
+// to. The path of URLs is the path of the storage URL of the account,
+// /v1/<account>, followed by the container and the object.
+//
+// swift://lax-proxy-03.storagesvc.sohonet.com/v1/AUTH_b04239c7467548678b4822e9dad96030/<container_name>/<path_to_object>
+func (l *location) ParseURL(url *url.URL) (string, string, error) {
+	if url.Scheme != Kind {
+		return "", "", errors.New("not valid swift URL")
+	}
+
+	path := strings.TrimLeft(url.Path, "/")
+	pieces := strings.SplitN(path, "/", 4)
+	if len(pieces) != 4 || pieces[2] == "" || pieces[3] == "" {
+		return "", "", errors.New("not valid swift URL, expecting /v1/<account>/<container>/<object>")
+	}
+	return pieces[2], pieces[3], nil
 }
 
 func (l *location) RemoveContainer(id string) error {
-	return l.client.ContainerDelete(id)
+	return l.RemoveContainerCtx(context.Background(), id)
+}
+
+// RemoveContainerCtx is RemoveContainer with a context.
+func (l *location) RemoveContainerCtx(ctx context.Context, id string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return mapError(l.client.ContainerDelete(id))
 }
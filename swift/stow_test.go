@@ -1,13 +1,20 @@
 package swift
 
 import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"testing/iotest"
 
 	"github.com/cheekybits/is"
 	"github.com/aldor007/stow"
 	"github.com/aldor007/stow/test"
+	"github.com/ncw/swift"
+	"github.com/ncw/swift/swifttest"
 )
 
 func TestStow(t *testing.T) {
@@ -67,3 +74,117 @@ func TestPrepMetadataFailureWithNonStringValues(t *testing.T) {
 	_, err := prepMetadata(m)
 	is.Err(err)
 }
+
+func TestErrorMapping(t *testing.T) {
+	test.ErrorMapping(t, mapError, map[error][]error{
+		stow.ErrNotFound:           {swift.ObjectNotFound, swift.ContainerNotFound},
+		stow.ErrPermissionDenied:   {swift.Forbidden, swift.AuthorizationFailed},
+		stow.ErrPreconditionFailed: {&swift.Error{StatusCode: http.StatusPreconditionFailed, Text: "Precondition Failed"}},
+		stow.ErrContainerNotEmpty:  {swift.ContainerNotEmpty},
+		stow.ErrThrottled:          {swift.TooManyRequests, swift.RateLimit},
+		stow.ErrInvalidRange:       {&swift.Error{StatusCode: http.StatusRequestedRangeNotSatisfiable, Text: "Range Not Satisfiable"}},
+	})
+}
+
+func TestPutLargeObject(t *testing.T) {
+	is := is.New(t)
+	server, err := swifttest.NewSwiftServer("localhost")
+	is.NoErr(err)
+	defer server.Close()
+	client := &swift.Connection{
+		UserName: swifttest.TEST_ACCOUNT,
+		ApiKey:   swifttest.TEST_ACCOUNT,
+		AuthUrl:  server.AuthURL,
+	}
+	is.NoErr(client.Authenticate())
+	is.NoErr(client.ContainerCreate("container", nil))
+	defer func(size int64) { largeObjectSegmentSize = size }(largeObjectSegmentSize)
+	largeObjectSegmentSize = 4
+	c := &container{id: "container", client: client}
+
+	// streams of unknown size are uploaded a segment at a time
+	item, err := c.Put("large", iotest.OneByteReader(strings.NewReader("0123456789")), -1, nil)
+	is.NoErr(err)
+	is.Equal(item.ID(), "large")
+	size, err := item.Size()
+	is.NoErr(err)
+	is.Equal(size, int64(10))
+	contents, err := client.ObjectGetString("container", "large")
+	is.NoErr(err)
+	is.Equal(contents, "0123456789")
+	segments, err := client.ObjectNamesAll("container_segments", nil)
+	is.NoErr(err)
+	is.Equal(len(segments), 3)
+
+	// small ones are not
+	_, err = c.Put("small", strings.NewReader("012"), -1, nil)
+	is.NoErr(err)
+	_, headers, err := client.Object("container", "small")
+	is.NoErr(err)
+	is.False(headers.IsLargeObject())
+
+	// contents which don't match ContentMD5 leave nothing behind
+	_, err = c.PutWithOptions("corrupt", strings.NewReader("0123456789"), 10, stow.PutOptions{ContentMD5: []byte("0123456789abcdef")})
+	is.True(errors.Is(err, stow.ErrPreconditionFailed))
+	_, _, err = client.Object("container", "corrupt")
+	is.Equal(err, swift.ObjectNotFound)
+	segments, err = client.ObjectNamesAll("container_segments", nil)
+	is.NoErr(err)
+	is.Equal(len(segments), 3)
+
+	// the segments are removed with the object
+	is.NoErr(c.RemoveItem("large"))
+	segments, err = client.ObjectNamesAll("container_segments", nil)
+	is.NoErr(err)
+	is.Equal(len(segments), 0)
+}
+
+func TestSetMetadataLargeObject(t *testing.T) {
+	is := is.New(t)
+	server, err := swifttest.NewSwiftServer("localhost")
+	is.NoErr(err)
+	defer server.Close()
+	// Without the info of the cluster, dynamic large objects are used.
+	server.SetOverride("/info", func(w http.ResponseWriter, r *http.Request, recorder *httptest.ResponseRecorder) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	// The fake server keeps the manifest of updated objects, which
+	// Swift doesn't, so the updates are checked to send it.
+	var manifests []string
+	server.SetOverride("/v1/AUTH_"+swifttest.TEST_ACCOUNT+"/container/large", func(w http.ResponseWriter, r *http.Request, recorder *httptest.ResponseRecorder) {
+		if r.Method == http.MethodPost {
+			manifests = append(manifests, r.Header.Get("X-Object-Manifest"))
+		}
+		for key, values := range recorder.Header() {
+			w.Header()[key] = values
+		}
+		w.WriteHeader(recorder.Code)
+		w.Write(recorder.Body.Bytes())
+	})
+	client := &swift.Connection{
+		UserName: swifttest.TEST_ACCOUNT,
+		ApiKey:   swifttest.TEST_ACCOUNT,
+		AuthUrl:  server.AuthURL,
+	}
+	is.NoErr(client.Authenticate())
+	is.NoErr(client.ContainerCreate("container", nil))
+	defer func(size int64) { largeObjectSegmentSize = size }(largeObjectSegmentSize)
+	largeObjectSegmentSize = 4
+	c := &container{id: "container", client: client}
+
+	_, err = c.Put("large", strings.NewReader("0123456789"), -1, nil)
+	is.NoErr(err)
+	manifests = nil
+	is.NoErr(c.SetMetadata("large", map[string]interface{}{"key": "value"}, false))
+	is.NoErr(c.SetMetadata("large", map[string]interface{}{"other": "value"}, true))
+	is.Equal(len(manifests), 2)
+	is.Equal(manifests[0], manifests[1])
+	is.True(strings.HasPrefix(manifests[0], "container_segments/large/"))
+
+	contents, err := client.ObjectGetString("container", "large")
+	is.NoErr(err)
+	is.Equal(contents, "0123456789")
+	_, headers, err := client.Object("container", "large")
+	is.NoErr(err)
+	is.Equal(headers.ObjectMetadata()["other"], "value")
+}
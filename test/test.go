@@ -2,18 +2,22 @@ package test
 
 import (
 	"bytes"
+	"context"
+	"crypto/md5"
 	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"math/rand"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
 	"reflect"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -116,6 +120,43 @@ func All(t *testing.T, kind string, config stow.Config) {
 		is.NoErr(err)
 	}()
 
+	// capabilities must describe what the implementation does
+	caps := stow.CapabilitiesOf(location)
+	is.Equal(stow.CapabilitiesOf(c1).Listing, caps.Listing)
+	is.Equal(stow.CapabilitiesOf(c1).Write, caps.Write)
+	if caps.Metadata {
+		is.False(skip1)
+	}
+	if caps.Ranges {
+		_, ok := item1.(stow.ItemRanger)
+		is.True(ok)
+	}
+	if caps.Tags {
+		_, ok := item1.(stow.Taggable)
+		is.True(ok)
+		_, ok = item1.(stow.TagSetter)
+		is.True(ok)
+	}
+	_, ok := c1.(stow.ConditionalPutter)
+	is.Equal(ok, caps.ConditionalWrites)
+	_, ok = c1.(stow.TagQuerier)
+	is.Equal(ok, caps.TagQueries)
+	_, ok = c1.(stow.MultipartUploader)
+	is.Equal(ok, caps.MultipartUploads)
+	_, ok = c1.(stow.BatchRemover)
+	is.Equal(ok, caps.BatchRemove)
+	_, ok = c1.(stow.DelimitedLister)
+	is.Equal(ok, caps.DelimitedListing)
+	_, ok = c1.(stow.Versioned)
+	is.Equal(ok, caps.Versioning)
+	_, ok = c1.(stow.MetadataSetter)
+	is.Equal(ok, caps.MetadataUpdates)
+	for _, method := range stow.CapabilitiesOf(c1).PresignMethods {
+		u, err := c1.PreSignRequest(context.Background(), method, item1.ID(), stow.PresignRequestParams{ExpiresIn: time.Minute})
+		is.NoErr(err)
+		is.OK(u)
+	}
+
 	// make sure we can get a small set of paginated results
 	items, cursor, err := c1.Items(stow.NoPrefix, stow.CursorStart, 1)
 	is.NoErr(err)
@@ -220,6 +261,13 @@ func All(t *testing.T, kind string, config stow.Config) {
 	is.Equal(item1b.ID(), item1.ID())
 	is.Equal(etag(t, is, item1b), etag(t, is, item1copy))
 
+	// URLs round-trip through their strings too
+	u1b, err := url.Parse(u1.String())
+	is.NoErr(err)
+	item1c, err := location.ItemByURL(u1b)
+	is.NoErr(err)
+	is.Equal(item1c.ID(), item1.ID())
+
 	// get item by name
 	item1copy2, err := c1copy2.Item(item1Name)
 	is.NoErr(err)
@@ -287,6 +335,534 @@ func All(t *testing.T, kind string, config stow.Config) {
 	})
 	is.NoErr(err)
 	is.Equal(found, 3) // should find three items
+
+	// parallel walking
+	var walkedMu sync.Mutex
+	walkedContents := make(map[string]string)
+	err = stow.WalkParallel(context.Background(), c1, stow.NoPrefix, stow.WalkOptions{PageSize: 2, Workers: 3}, func(ctx context.Context, item stow.Item) error {
+		r, err := item.Open()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		walkedMu.Lock()
+		defer walkedMu.Unlock()
+		walkedContents[item.ID()] = string(b)
+		return nil
+	})
+	is.NoErr(err)
+	is.Equal(len(walkedContents), 3)
+	is.Equal(walkedContents[item1.ID()], "item one")
+
+	// failures are reported by item
+	err = stow.WalkParallel(context.Background(), c1, stow.NoPrefix, stow.WalkOptions{PageSize: 2, Workers: 3, ContinueOnError: true}, func(ctx context.Context, item stow.Item) error {
+		return testErr
+	})
+	var walkErr *stow.WalkError
+	is.True(errors.As(err, &walkErr))
+	is.Equal(len(walkErr.Items), 3)
+	is.NoErr(walkErr.Err)
+	is.True(errors.Is(err, testErr))
+
+	// the first failure stops the walk
+	err = stow.WalkParallel(context.Background(), c1, stow.NoPrefix, stow.WalkOptions{PageSize: 1, Workers: 1}, func(ctx context.Context, item stow.Item) error {
+		return testErr
+	})
+	is.True(errors.As(err, &walkErr))
+	is.Equal(len(walkErr.Items), 1)
+	is.NoErr(walkErr.Err)
+
+	// walks can be canceled
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = stow.WalkParallel(canceledCtx, c1, stow.NoPrefix, stow.WalkOptions{}, func(ctx context.Context, item stow.Item) error {
+		return nil
+	})
+	is.True(errors.Is(err, context.Canceled))
+
+	// **************************************************
+	// Copy and Move
+	// **************************************************
+
+	// copies keep the metadata of the source, whether they are
+	// made natively or streamed
+	copied, err := stow.Copy(c1, item1.ID(), c2, "copied/the item", nil)
+	is.NoErr(err)
+	is.OK(copied)
+	is.Equal(readItemContents(is, copied), item1Content)
+	if !skip1 {
+		copiedAgain, err := c2.Item(copied.ID())
+		is.NoErr(err)
+		is.NoErr(checkMetadata(t, is, copiedAgain, md1))
+	}
+	_, err = c1.Item(item1.ID())
+	is.NoErr(err)
+
+	moved, err := stow.Move(c2, copied.ID(), c2, "moved/the item", nil)
+	is.NoErr(err)
+	is.Equal(readItemContents(is, moved), item1Content)
+	_, err = c2.Item(copied.ID())
+	is.Equal(stow.ErrNotFound, err)
+	is.NoErr(c2.RemoveItem(moved.ID()))
+
+	if copier, ok := c1.(stow.Copier); ok {
+		is.True(stow.CapabilitiesOf(c1).ServerSideCopy)
+		_, err = copier.Copy(item1.ID()+"nope", c2, "missing", nil)
+		is.True(errors.Is(err, stow.ErrNotFound))
+	}
+
+	// **************************************************
+	// Conditional writes
+	// **************************************************
+
+	if putter, ok := c1.(stow.ConditionalPutter); ok {
+		name := "conditional/the item"
+		put := func(content string, cond stow.PutCondition) (stow.Item, error) {
+			return putter.PutIf(name, strings.NewReader(content), int64(len(content)), nil, cond)
+		}
+		_, err = put("first", stow.PutCondition{IfMatch: "nope"})
+		is.True(errors.Is(err, stow.ErrPreconditionFailed))
+		_, err = put("first", stow.PutCondition{IfNoneMatch: true})
+		is.NoErr(err)
+		// not all implementations return the ETag from a write
+		current, err := c1.Item(name)
+		is.NoErr(err)
+		_, err = put("second", stow.PutCondition{IfNoneMatch: true})
+		is.True(errors.Is(err, stow.ErrPreconditionFailed))
+		_, err = put("second", stow.PutCondition{IfMatch: etag(t, is, current) + "nope"})
+		is.True(errors.Is(err, stow.ErrPreconditionFailed))
+		_, err = put("second", stow.PutCondition{IfMatch: etag(t, is, current)})
+		is.NoErr(err)
+		current, err = c1.Item(name)
+		is.NoErr(err)
+		is.Equal(readItemContents(is, current), "second")
+		is.NoErr(c1.RemoveItem(current.ID()))
+	}
+
+	// **************************************************
+	// Unknown size
+	// **************************************************
+
+	// a size of -1 stores the whole reader
+	contents := strings.Repeat("unknown size ", 100)
+	r := io.MultiReader(strings.NewReader(contents[:500]), strings.NewReader(contents[500:]))
+	item, err := c1.Put("unknown/the item", r, -1, nil)
+	is.NoErr(err)
+	size, err := item.Size()
+	is.NoErr(err)
+	is.Equal(size, int64(len(contents)))
+	unknown, err := c1.Item(item.ID())
+	is.NoErr(err)
+	is.Equal(readItemContents(is, unknown), contents)
+	is.NoErr(c1.RemoveItem(item.ID()))
+
+	// **************************************************
+	// Streaming writes
+	// **************************************************
+
+	if writer, ok := c1.(stow.ItemWriter); ok {
+		item, w, err := writer.CreateItem("streamed/the item")
+		is.NoErr(err)
+		for i := 0; i < 3; i++ {
+			_, err = io.WriteString(w, "chunk ")
+			is.NoErr(err)
+		}
+		is.NoErr(w.Close())
+		// the ID of the Item is only known once the writer is closed
+		streamed, err := c1.Item(item.ID())
+		is.NoErr(err)
+		is.Equal(readItemContents(is, streamed), "chunk chunk chunk ")
+		is.NoErr(c1.RemoveItem(item.ID()))
+	}
+
+	// **************************************************
+	// Multipart uploads
+	// **************************************************
+
+	if uploader, ok := c1.(stow.MultipartUploader); ok {
+		// all implementations accept parts of 5MiB
+		first := strings.Repeat("m", 5<<20)
+		upload, err := uploader.InitiateUpload("multipart/the item", nil)
+		is.NoErr(err)
+		is.Equal(upload.Name, "multipart/the item")
+		part1, err := uploader.UploadPart(upload, 1, strings.NewReader(first), int64(len(first)))
+		is.NoErr(err)
+		part2, err := uploader.UploadPart(upload, 2, strings.NewReader("the end"), 7)
+		is.NoErr(err)
+		item, err := uploader.CompleteUpload(upload, []stow.Part{part1, part2})
+		is.NoErr(err)
+		multipart, err := c1.Item(item.ID())
+		is.NoErr(err)
+		is.Equal(readItemContents(is, multipart), first+"the end")
+		is.NoErr(c1.RemoveItem(item.ID()))
+
+		// an aborted upload leaves no item behind
+		upload, err = uploader.InitiateUpload("multipart/aborted", nil)
+		is.NoErr(err)
+		_, err = uploader.UploadPart(upload, 1, strings.NewReader(first), int64(len(first)))
+		is.NoErr(err)
+		is.NoErr(uploader.AbortUpload(upload))
+		_, err = c1.Item("multipart/aborted")
+		is.True(errors.Is(err, stow.ErrNotFound))
+	}
+
+	// **************************************************
+	// Batch removal
+	// **************************************************
+
+	var batch []string
+	for i := 0; i < 5; i++ {
+		item, _ := putItem(is, c1, fmt.Sprintf("batch/item%d", i), "batch", nil)
+		batch = append(batch, item.ID())
+	}
+	// items that don't exist are not failures
+	failed, err := stow.RemoveItems(c1, []string{batch[0], batch[1], "batch/missing"})
+	is.NoErr(err)
+	is.Equal(len(failed), 0)
+	_, err = c1.Item(batch[0])
+	is.True(errors.Is(err, stow.ErrNotFound))
+	is.NoErr(stow.RemovePrefix(c1, "batch/"))
+	items, _, err = c1.Items("batch/", stow.CursorStart, 10)
+	is.NoErr(err)
+	is.Equal(len(items), 0)
+
+	// **************************************************
+	// Delimited listing
+	// **************************************************
+
+	if lister, ok := c1.(stow.DelimitedLister); ok {
+		for _, name := range []string{"delimited/a", "delimited/dir/b", "delimited/dir/sub/c", "delimited/other/d"} {
+			putItem(is, c1, name, "delimited", nil)
+		}
+		items, prefixes, cursor, err := lister.ItemsDelimited("delimited/", "/", stow.CursorStart, 10)
+		is.NoErr(err)
+		is.True(stow.IsCursorEnd(cursor))
+		is.Equal(len(items), 1)
+		is.Equal(items[0].Name(), "delimited/a")
+		is.Equal(prefixes, []string{"delimited/dir/", "delimited/other/"})
+
+		// common prefixes count towards the page size
+		var names []string
+		cursor = stow.CursorStart
+		for {
+			var page []stow.Item
+			page, prefixes, cursor, err = lister.ItemsDelimited("delimited/dir/", "/", cursor, 1)
+			is.NoErr(err)
+			is.True(len(page)+len(prefixes) <= 1)
+			for _, item := range page {
+				names = append(names, item.Name())
+			}
+			names = append(names, prefixes...)
+			if stow.IsCursorEnd(cursor) {
+				break
+			}
+		}
+		is.Equal(names, []string{"delimited/dir/b", "delimited/dir/sub/"})
+
+		// without a delimiter all the items are listed
+		items, prefixes, _, err = lister.ItemsDelimited("delimited/", "", stow.CursorStart, 10)
+		is.NoErr(err)
+		is.Equal(len(items), 4)
+		is.Equal(len(prefixes), 0)
+		is.NoErr(stow.RemovePrefix(c1, "delimited/"))
+	}
+
+	// **************************************************
+	// Metadata updates
+	// **************************************************
+
+	if setter, ok := c1.(stow.MetadataSetter); ok {
+		item, _ := putItem(is, c1, "metadata", "contents", map[string]interface{}{"owner": "me", "color": "red"})
+		is.NoErr(setter.SetMetadata(item.ID(), map[string]interface{}{"color": "blue", "owner": nil}, false))
+		item, err = c1.Item(item.ID())
+		is.NoErr(err)
+		md, err := item.Metadata()
+		is.NoErr(err)
+		is.Equal(md["color"], "blue")
+		_, ok = md["owner"]
+		is.False(ok)
+
+		is.NoErr(setter.SetMetadata(item.ID(), map[string]interface{}{"shape": "round"}, true))
+		item, err = c1.Item(item.ID())
+		is.NoErr(err)
+		md, err = item.Metadata()
+		is.NoErr(err)
+		is.Equal(md["shape"], "round")
+		_, ok = md["color"]
+		is.False(ok)
+		// the contents are left as they are
+		is.Equal(readItemContents(is, item), "contents")
+		is.NoErr(c1.RemoveItem(item.ID()))
+	}
+
+	// **************************************************
+	// Tags
+	// **************************************************
+
+	if _, ok := item1.(stow.TagSetter); ok {
+		item, _ := putItem(is, c1, "tagged", "contents", nil)
+		is.NoErr(item.(stow.TagSetter).SetTags(map[string]string{"stage": "raw", "team": "data"}))
+		item, err = c1.Item(item.ID())
+		is.NoErr(err)
+		tags, err := item.(stow.Taggable).Tags()
+		is.NoErr(err)
+		is.Equal(len(tags), 2)
+		is.Equal(tags["stage"], "raw")
+
+		// tags are replaced, not merged
+		is.NoErr(item.(stow.TagSetter).SetTags(map[string]string{"stage": "done"}))
+		item, err = c1.Item(item.ID())
+		is.NoErr(err)
+		tags, err = item.(stow.Taggable).Tags()
+		is.NoErr(err)
+		is.Equal(len(tags), 1)
+		is.Equal(tags["stage"], "done")
+
+		is.NoErr(item.(stow.TagSetter).DeleteTags())
+		item, err = c1.Item(item.ID())
+		is.NoErr(err)
+		tags, err = item.(stow.Taggable).Tags()
+		is.NoErr(err)
+		is.Equal(len(tags), 0)
+		is.Equal(readItemContents(is, item), "contents")
+		is.NoErr(c1.RemoveItem(item.ID()))
+	}
+
+	// **************************************************
+	// Put options
+	// **************************************************
+
+	if _, ok := c1.(stow.OptionsPutter); ok {
+		sum := md5.Sum([]byte("contents"))
+		opts := stow.PutOptions{
+			ContentType: "text/plain",
+			ContentMD5:  sum[:],
+		}
+		if caps.Metadata {
+			opts.Metadata = map[string]string{"owner": "me"}
+		}
+		item, err := stow.PutWithOptions(c1, "options", strings.NewReader("contents"), 8, opts)
+		is.NoErr(err)
+		item, err = c1.Item(item.ID())
+		is.NoErr(err)
+		is.Equal(readItemContents(is, item), "contents")
+		if caps.Metadata {
+			md, err := item.Metadata()
+			is.NoErr(err)
+			is.Equal(md["owner"], "me")
+		}
+		is.NoErr(c1.RemoveItem(item.ID()))
+	}
+
+	// **************************************************
+	// Open options
+	// **************************************************
+
+	item6, _ := putItem(is, c1, "open-options", "0123456789", nil)
+	readOptions := func(opts stow.OpenOptions) string {
+		r, err := stow.OpenWithOptions(item6, opts)
+		is.NoErr(err)
+		defer r.Close()
+		b, err := io.ReadAll(r)
+		is.NoErr(err)
+		return string(b)
+	}
+	is.Equal(readOptions(stow.OpenOptions{Range: &stow.ByteRange{Start: 2, End: 4}}), "234")
+	is.Equal(readOptions(stow.OpenOptions{Range: &stow.ByteRange{Start: 7, End: -1}}), "789")
+	is.Equal(readOptions(stow.OpenOptions{Range: &stow.ByteRange{Start: -2}}), "89")
+	_, err = stow.OpenWithOptions(item6, stow.OpenOptions{Range: &stow.ByteRange{Start: 10, End: 12}})
+	is.True(errors.Is(err, stow.ErrInvalidRange))
+	etag, err := item6.ETag()
+	is.NoErr(err)
+	if etag != "" {
+		_, err = stow.OpenWithOptions(item6, stow.OpenOptions{IfNoneMatch: etag})
+		is.True(errors.Is(err, stow.ErrNotModified))
+	}
+	is.Equal(readOptions(stow.OpenOptions{IfNoneMatch: "other" + etag}), "0123456789")
+	is.NoErr(c1.RemoveItem(item6.ID()))
+
+	// **************************************************
+	// Stat
+	// **************************************************
+
+	item7, _ := putItem(is, c1, "stat", "contents", nil)
+	statInfo, err := stow.Stat(c1, item7.ID())
+	is.NoErr(err)
+	is.Equal(statInfo.ID, item7.ID())
+	is.Equal(statInfo.Size, int64(8))
+	exists, err := stow.Exists(c1, item7.ID())
+	is.NoErr(err)
+	is.True(exists)
+	is.NoErr(c1.RemoveItem(item7.ID()))
+	exists, err = stow.Exists(c1, item7.ID())
+	is.NoErr(err)
+	is.False(exists)
+
+	// **************************************************
+	// URLs
+	// **************************************************
+
+	if parser, ok := location.(stow.URLParser); ok {
+		containerID, name, err := parser.ParseURL(item1.URL())
+		is.NoErr(err)
+		is.Equal(containerID, c1.ID())
+		is.Equal(name, item1.Name())
+
+		// the URL of an item that has been removed is reused to put one
+		ctx := context.Background()
+		configs := map[string]stow.Config{kind: config}
+		item8, _ := putItem(is, c1, "url/the item", "removed", nil)
+		u8 := item8.URL().String()
+		is.NoErr(c1.RemoveItem(item8.ID()))
+		_, err = stow.StatURL(ctx, u8, configs)
+		is.True(errors.Is(err, stow.ErrNotFound))
+
+		item8, err = stow.PutURL(ctx, u8, configs, strings.NewReader("by url"), 6, nil)
+		is.NoErr(err)
+		is.Equal(item8.Name(), "url/the item")
+		is.Equal(item8.URL().String(), u8)
+		info, err := stow.StatURL(ctx, u8, configs)
+		is.NoErr(err)
+		is.Equal(info.ID, item8.ID())
+		is.Equal(info.Size, int64(6))
+		r, err := stow.OpenURL(ctx, u8, configs)
+		is.NoErr(err)
+		b, err := ioutil.ReadAll(r)
+		is.NoErr(err)
+		is.NoErr(r.Close())
+		is.Equal(string(b), "by url")
+		is.NoErr(stow.RemoveURL(ctx, u8, configs))
+		_, err = stow.StatURL(ctx, u8, configs)
+		is.True(errors.Is(err, stow.ErrNotFound))
+		is.NoErr(stow.CloseURLLocations())
+	}
+
+	// **************************************************
+	// Versioning
+	// **************************************************
+
+	// versions are kept in a container of their own, as they keep
+	// containers from being removed until they are removed too
+	if _, ok := c1.(stow.Versioned); ok {
+		c5 := createContainer(is, location, "stowtest"+randName(10))
+		versioned := c5.(stow.Versioned)
+		// versioning may only be enabled for the whole account
+		if err := versioned.EnableVersioning(); !stow.IsNotSupported(err) {
+			is.NoErr(err)
+		}
+		putItem(is, c5, "versioned", "first", nil)
+		item5, _ := putItem(is, c5, "versioned", "second", nil)
+
+		versions, cursor, err := versioned.ItemVersions(item5.ID(), stow.CursorStart, 10)
+		is.NoErr(err)
+		is.True(stow.IsCursorEnd(cursor))
+		is.Equal(len(versions), 2)
+		is.True(versions[0].Latest)
+		is.False(versions[1].Latest)
+		is.False(versions[0].LastMod.Before(versions[1].LastMod))
+		old, err := versioned.ItemVersion(item5.ID(), versions[1].ID)
+		is.NoErr(err)
+		is.Equal(readItemContents(is, old), "first")
+		r, err := stow.OpenWithOptions(item5, stow.OpenOptions{VersionID: versions[1].ID})
+		is.NoErr(err)
+		b, err := io.ReadAll(r)
+		r.Close()
+		is.NoErr(err)
+		is.Equal(string(b), "first")
+
+		// the item is left as it is when an older version is removed
+		is.NoErr(versioned.RemoveItemVersion(item5.ID(), versions[1].ID))
+		_, err = versioned.ItemVersion(item5.ID(), versions[1].ID)
+		is.Err(err)
+		versions, _, err = versioned.ItemVersions(item5.ID(), stow.CursorStart, 10)
+		is.NoErr(err)
+		is.Equal(len(versions), 1)
+		item5, err = c5.Item(item5.ID())
+		is.NoErr(err)
+		is.Equal(readItemContents(is, item5), "second")
+
+		is.NoErr(versioned.RemoveItemVersion(item5.ID(), versions[0].ID))
+		is.NoErr(location.RemoveContainer(c5.ID()))
+	}
+
+	// **************************************************
+	// Error kinds
+	// **************************************************
+
+	// creating an existing container either returns it or
+	// reports ErrAlreadyExists
+	c1again, err := location.CreateContainer(c1Name)
+	if err != nil {
+		is.True(errors.Is(err, stow.ErrAlreadyExists))
+	} else {
+		is.Equal(c1again.ID(), c1.ID())
+	}
+
+	// removing a container that still holds items either succeeds,
+	// when the implementation removes the items too, or reports
+	// ErrContainerNotEmpty
+	c4 := createContainer(is, location, "stowtest"+randName(10))
+	item4, _ := putItem(is, c4, "the item", "item four", nil)
+	err = location.RemoveContainer(c4.ID())
+	if err != nil {
+		is.True(errors.Is(err, stow.ErrContainerNotEmpty))
+		is.NoErr(c4.RemoveItem(item4.ID()))
+		is.NoErr(location.RemoveContainer(c4.ID()))
+	}
+}
+
+// ErrorKinds lists the error kinds declared by stow that
+// implementations map their native errors to.
+var ErrorKinds = []error{
+	stow.ErrNotFound,
+	stow.ErrAlreadyExists,
+	stow.ErrPermissionDenied,
+	stow.ErrPreconditionFailed,
+	stow.ErrContainerNotEmpty,
+	stow.ErrThrottled,
+	stow.ErrInvalidName,
+	stow.ErrInvalidRange,
+}
+
+// ErrorMapping runs a generic suite of tests for the function an
+// implementation uses to map its native errors to the kinds in
+// ErrorKinds.
+// samples holds, for each kind the implementation can report, native
+// errors that must map to it. Every mapped error must match its kind,
+// and only that kind, with errors.Is and keep the native error in the
+// chain. Errors that are not in samples must be left untouched.
+func ErrorMapping(t *testing.T, mapErr func(error) error, samples map[error][]error) {
+	is := is.New(t)
+
+	is.NoErr(mapErr(nil))
+	other := errors.New("some other error")
+	is.Equal(mapErr(other), other)
+
+	for _, kind := range ErrorKinds {
+		natives, ok := samples[kind]
+		if !ok {
+			t.Logf("%q is never reported", kind)
+			continue
+		}
+		for _, native := range natives {
+			err := mapErr(native)
+			if !errors.Is(err, kind) {
+				t.Errorf("%v: got %v, expected it to be %q", native, err, kind)
+			}
+			if !errors.Is(err, native) {
+				t.Errorf("%v: got %v, expected it to keep the native error", native, err)
+			}
+			for _, k := range ErrorKinds {
+				if k != kind && errors.Is(err, k) {
+					t.Errorf("%v: got %v, expected it not to be %q", native, err, k)
+				}
+			}
+		}
+	}
 }
 
 func totalNetFDs(t *testing.T) (int, []byte) {
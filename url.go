@@ -0,0 +1,376 @@
+package stow
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// maxURLLocations is the number of Locations the URL functions of a
+// Registry keep. The least recently used one is closed to make room.
+const maxURLLocations = 64
+
+// urlLocationKey identifies a Location dialed by the URL functions: the
+// kind and a hash of the ConfigMap, so that its secrets aren't kept.
+type urlLocationKey struct {
+	kind string
+	hash [sha256.Size]byte
+}
+
+// urlLocation is a Location used by the URL functions. It is closed
+// once it isn't kept anymore and the calls using it are done.
+type urlLocation struct {
+	key      urlLocationKey
+	location Location
+	// refs is the number of calls using the Location.
+	refs int
+	// dropped is true once the Location isn't kept anymore, for it to
+	// be closed when refs drops to 0.
+	dropped bool
+}
+
+// urlLocations holds the Locations dialed by the URL functions of a
+// Registry, so they are reused by later calls with the same kind and
+// ConfigMap.
+type urlLocations struct {
+	mu    sync.Mutex // protects all the fields, and those of the urlLocations
+	byKey map[urlLocationKey]*list.Element
+	// lru holds the *urlLocation of each Location, most recently
+	// used first.
+	lru list.List
+	// gen is incremented whenever Locations are dropped, so that
+	// Locations dialed before aren't kept.
+	gen uint64
+}
+
+// get gets the Location kept for key, to be released once used, and
+// the generation to add a Location with when there's none.
+func (c *urlLocations) get(key urlLocationKey) (*urlLocation, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.byKey[key]
+	if !ok {
+		return nil, c.gen, false
+	}
+	c.lru.MoveToFront(e)
+	l := e.Value.(*urlLocation)
+	l.refs++
+	return l, c.gen, true
+}
+
+// add keeps location for key, unless Locations have been dropped since
+// gen. It returns the Location kept for key, which is the one added
+// first when two are added at once, to be released once used.
+// The least recently used Locations are dropped to make room.
+func (c *urlLocations) add(key urlLocationKey, location Location, gen uint64) *urlLocation {
+	var evicted []Location
+	defer func() {
+		for _, location := range evicted {
+			location.Close()
+		}
+	}()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return &urlLocation{key: key, location: location, refs: 1, dropped: true}
+	}
+	if e, ok := c.byKey[key]; ok {
+		evicted = append(evicted, location)
+		c.lru.MoveToFront(e)
+		l := e.Value.(*urlLocation)
+		l.refs++
+		return l
+	}
+	if c.byKey == nil {
+		c.byKey = map[urlLocationKey]*list.Element{}
+	}
+	l := &urlLocation{key: key, location: location, refs: 1}
+	c.byKey[key] = c.lru.PushFront(l)
+	for c.lru.Len() > maxURLLocations {
+		if old := c.remove(c.lru.Back()); old != nil {
+			evicted = append(evicted, old)
+		}
+	}
+	return l
+}
+
+// remove stops keeping the Location of e, and returns it when it is to
+// be closed now, as no call is using it.
+func (c *urlLocations) remove(e *list.Element) Location {
+	l := c.lru.Remove(e).(*urlLocation)
+	delete(c.byKey, l.key)
+	l.dropped = true
+	if l.refs > 0 {
+		return nil
+	}
+	return l.location
+}
+
+// release is called by the calls using l once they are done with it.
+// It closes l when it isn't kept anymore and no call is using it.
+func (c *urlLocations) release(l *urlLocation) {
+	c.mu.Lock()
+	l.refs--
+	closing := l.dropped && l.refs == 0
+	c.mu.Unlock()
+	if closing {
+		l.location.Close()
+	}
+}
+
+// drop stops keeping the Locations of kind, or all of them when kind
+// is empty. Those no call is using are closed, and the first error
+// closing them is returned; the others are closed once the calls using
+// them are done.
+func (c *urlLocations) drop(kind string) error {
+	var dropped []Location
+	c.mu.Lock()
+	c.gen++
+	for e := c.lru.Front(); e != nil; {
+		next := e.Next()
+		if l := e.Value.(*urlLocation); kind == "" || l.key.kind == kind {
+			if location := c.remove(e); location != nil {
+				dropped = append(dropped, location)
+			}
+		}
+		e = next
+	}
+	c.mu.Unlock()
+	var first error
+	for _, location := range dropped {
+		if err := location.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// urlReader is the io.ReadCloser returned by OpenURL, which releases
+// the Location of the Item once closed.
+type urlReader struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (r *urlReader) Close() error {
+	err := r.ReadCloser.Close()
+	r.once.Do(r.release)
+	return err
+}
+
+// OpenURL opens the Item rawURL points to, as returned by Item.URL.
+// The kind of Location is found with KindByURL, and the Location is
+// dialed with the Config configs has for that kind. When the Config is
+// a ConfigMap, the Location is kept and reused by the later calls of
+// OpenURL, PutURL, RemoveURL and StatURL with the same kind and an
+// equal ConfigMap; Locations of other Configs are dialed every time,
+// and closed once the call is done.
+// At most 64 Locations are kept, the least recently used one being
+// dropped to make room. The Locations that aren't kept anymore are
+// closed once the calls using them are done, which for OpenURL is once
+// the returned reader is closed.
+func OpenURL(ctx context.Context, rawURL string, configs map[string]Config) (io.ReadCloser, error) {
+	return DefaultRegistry.OpenURL(ctx, rawURL, configs)
+}
+
+// OpenURL opens the Item rawURL points to, as the package-level
+// OpenURL does.
+func (r *Registry) OpenURL(ctx context.Context, rawURL string, configs map[string]Config) (io.ReadCloser, error) {
+	l, u, err := r.dialURL(rawURL, configs)
+	if err != nil {
+		return nil, err
+	}
+	release := func() { r.urlLocations.release(l) }
+	item, err := itemByURL(ctx, l.location, u)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	rc, err := openCtx(ctx, item)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	return &urlReader{ReadCloser: rc, release: release}, nil
+}
+
+// StatURL gets the information of the Item rawURL points to, dialing
+// the Location as OpenURL does.
+// An error matching ErrNotFound is returned when there's no such Item.
+func StatURL(ctx context.Context, rawURL string, configs map[string]Config) (ItemInfo, error) {
+	return DefaultRegistry.StatURL(ctx, rawURL, configs)
+}
+
+// StatURL gets the information of the Item rawURL points to, as the
+// package-level StatURL does.
+func (r *Registry) StatURL(ctx context.Context, rawURL string, configs map[string]Config) (ItemInfo, error) {
+	l, u, err := r.dialURL(rawURL, configs)
+	if err != nil {
+		return ItemInfo{}, err
+	}
+	defer r.urlLocations.release(l)
+	item, err := itemByURL(ctx, l.location, u)
+	if err != nil {
+		return ItemInfo{}, err
+	}
+	return NewItemInfo(item)
+}
+
+// PutURL puts an Item at rawURL, dialing the Location as OpenURL
+// does, and returns it. The Item needn't exist, so the Location must
+// be a URLParser to know its Container and name; an error satisfying
+// IsNotSupported is returned otherwise.
+func PutURL(ctx context.Context, rawURL string, configs map[string]Config, r io.Reader, size int64, metadata map[string]interface{}) (Item, error) {
+	return DefaultRegistry.PutURL(ctx, rawURL, configs, r, size, metadata)
+}
+
+// PutURL puts an Item at rawURL, as the package-level PutURL does.
+func (r *Registry) PutURL(ctx context.Context, rawURL string, configs map[string]Config, rd io.Reader, size int64, metadata map[string]interface{}) (Item, error) {
+	l, u, err := r.dialURL(rawURL, configs)
+	if err != nil {
+		return nil, err
+	}
+	defer r.urlLocations.release(l)
+	container, name, err := containerByURL(ctx, l.location, u)
+	if err != nil {
+		return nil, err
+	}
+	return putCtx(ctx, container, name, rd, size, metadata)
+}
+
+// RemoveURL removes the Item rawURL points to, dialing the Location as
+// OpenURL does. The Location must be a URLParser to know the Container
+// of the Item; an error satisfying IsNotSupported is returned
+// otherwise.
+// The Item is got first, as its ID may not be its name.
+func RemoveURL(ctx context.Context, rawURL string, configs map[string]Config) error {
+	return DefaultRegistry.RemoveURL(ctx, rawURL, configs)
+}
+
+// RemoveURL removes the Item rawURL points to, as the package-level
+// RemoveURL does.
+func (r *Registry) RemoveURL(ctx context.Context, rawURL string, configs map[string]Config) error {
+	l, u, err := r.dialURL(rawURL, configs)
+	if err != nil {
+		return err
+	}
+	defer r.urlLocations.release(l)
+	container, _, err := containerByURL(ctx, l.location, u)
+	if err != nil {
+		return err
+	}
+	item, err := itemByURL(ctx, l.location, u)
+	if err != nil {
+		return err
+	}
+	return removeItemCtx(ctx, container, item.ID())
+}
+
+// itemByURL gets the Item u points to with ItemByURL.
+func itemByURL(ctx context.Context, location Location, u *url.URL) (Item, error) {
+	if l, ok := location.(ContextLocation); ok {
+		return l.ItemByURLCtx(ctx, u)
+	}
+	return location.ItemByURL(u)
+}
+
+// containerByURL gets the Container u points to and the name of the
+// Item in it.
+func containerByURL(ctx context.Context, location Location, u *url.URL) (Container, string, error) {
+	parser, ok := As[URLParser](location)
+	if !ok {
+		return nil, "", NotSupported("parsing URLs")
+	}
+	containerID, name, err := parser.ParseURL(u)
+	if err != nil {
+		return nil, "", err
+	}
+	var container Container
+	if l, ok := location.(ContextLocation); ok {
+		container, err = l.ContainerCtx(ctx, containerID)
+	} else {
+		container, err = location.Container(containerID)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return container, name, nil
+}
+
+// dialURL parses rawURL and gets the Location of its kind, dialing it
+// unless it has been dialed before with an equal ConfigMap. The
+// Location must be released with r.urlLocations.release once used.
+func (r *Registry) dialURL(rawURL string, configs map[string]Config) (*urlLocation, *url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	kind, err := r.KindByURL(u)
+	if err != nil {
+		return nil, nil, err
+	}
+	config, ok := configs[kind]
+	if !ok {
+		return nil, nil, fmt.Errorf("stow: no config for kind %q", kind)
+	}
+
+	m, cached := config.(ConfigMap)
+	if !cached {
+		location, err := r.Dial(kind, config)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &urlLocation{location: location, refs: 1, dropped: true}, u, nil
+	}
+	key := urlLocationKey{kind: kind, hash: hashConfigMap(m)}
+	l, gen, ok := r.urlLocations.get(key)
+	if ok {
+		return l, u, nil
+	}
+
+	// Dialing may make requests, so it's done without the lock, and
+	// the Location dialed first is kept.
+	location, err := r.Dial(kind, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r.urlLocations.add(key, location, gen), u, nil
+}
+
+// hashConfigMap hashes the keys and values of m, in the order of the
+// keys.
+func hashConfigMap(m ConfigMap) [sha256.Size]byte {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, key := range keys {
+		// The lengths keep keys and values containing separators
+		// from making two configs look the same.
+		fmt.Fprintf(h, "%d:%s=%d:%s;", len(key), key, len(m[key]), m[key])
+	}
+	var sum [sha256.Size]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// CloseURLLocations closes the Locations kept by OpenURL, PutURL,
+// RemoveURL and StatURL, which are dialed again when needed. It
+// returns the first error closing them.
+func CloseURLLocations() error {
+	return DefaultRegistry.CloseURLLocations()
+}
+
+// CloseURLLocations closes the Locations kept by the URL functions of
+// r, as the package-level CloseURLLocations does.
+func (r *Registry) CloseURLLocations() error {
+	return r.urlLocations.drop("")
+}
@@ -0,0 +1,241 @@
+package stow_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/aldor007/stow"
+	"github.com/cheekybits/is"
+)
+
+// memDials counts the memLocations dialed.
+var memDials int32
+
+// memLocation is a Location of testKind with a single memContainer,
+// dialed when the Config has "mem" set. Its URLs are
+// test://mem/<id>.
+type memLocation struct {
+	testLocation
+	container *memContainer
+}
+
+func (l *memLocation) Container(id string) (stow.Container, error) {
+	if id != l.container.ID() {
+		return nil, stow.ErrNotFound
+	}
+	return l.container, nil
+}
+
+func (l *memLocation) ItemByURL(u *url.URL) (stow.Item, error) {
+	containerID, name, err := l.ParseURL(u)
+	if err != nil {
+		return nil, err
+	}
+	c, err := l.Container(containerID)
+	if err != nil {
+		return nil, err
+	}
+	return c.Item(name)
+}
+
+func (l *memLocation) ParseURL(u *url.URL) (string, string, error) {
+	if u.Host == "" || len(u.Path) < 2 {
+		return "", "", errors.New("expecting mem://<container>/<id>")
+	}
+	return u.Host, u.Path[1:], nil
+}
+
+func TestURLFunctions(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	is.NoErr(stow.CloseURLLocations())
+	configs := map[string]stow.Config{testKind: stow.ConfigMap{"mem": "url functions"}}
+	dials := atomic.LoadInt32(&memDials)
+
+	item, err := stow.PutURL(ctx, "test://mem/item", configs, strings.NewReader("contents"), 8, nil)
+	is.NoErr(err)
+	is.Equal(item.ID(), "item")
+
+	info, err := stow.StatURL(ctx, "test://mem/item", configs)
+	is.NoErr(err)
+	is.Equal(info.ID, "item")
+	is.Equal(info.Size, int64(8))
+
+	r, err := stow.OpenURL(ctx, "test://mem/item", configs)
+	is.NoErr(err)
+	b, err := io.ReadAll(r)
+	is.NoErr(err)
+	is.NoErr(r.Close())
+	is.Equal(string(b), "contents")
+
+	is.NoErr(stow.RemoveURL(ctx, "test://mem/item", configs))
+	_, err = stow.StatURL(ctx, "test://mem/item", configs)
+	is.True(errors.Is(err, stow.ErrNotFound))
+	is.True(errors.Is(stow.RemoveURL(ctx, "test://mem/item", configs), stow.ErrNotFound))
+
+	// the Location was dialed once and reused
+	is.Equal(atomic.LoadInt32(&memDials), dials+1)
+}
+
+func TestURLLocationsCache(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	is.NoErr(stow.CloseURLLocations())
+	dials := atomic.LoadInt32(&memDials)
+
+	configs := map[string]stow.Config{testKind: stow.ConfigMap{"mem": "cache"}}
+	_, err := stow.PutURL(ctx, "test://mem/item", configs, strings.NewReader("contents"), 8, nil)
+	is.NoErr(err)
+
+	// an equal ConfigMap gets the same Location
+	same := map[string]stow.Config{testKind: stow.ConfigMap{"mem": "cache"}}
+	_, err = stow.StatURL(ctx, "test://mem/item", same)
+	is.NoErr(err)
+	is.Equal(atomic.LoadInt32(&memDials), dials+1)
+
+	// another one gets another Location
+	other := map[string]stow.Config{testKind: stow.ConfigMap{"mem": "cache", "other": ""}}
+	_, err = stow.StatURL(ctx, "test://mem/item", other)
+	is.True(errors.Is(err, stow.ErrNotFound))
+	is.Equal(atomic.LoadInt32(&memDials), dials+2)
+
+	// closed Locations are dialed again
+	is.NoErr(stow.CloseURLLocations())
+	_, err = stow.StatURL(ctx, "test://mem/item", configs)
+	is.True(errors.Is(err, stow.ErrNotFound))
+	is.Equal(atomic.LoadInt32(&memDials), dials+3)
+}
+
+func TestURLLocationsRegistry(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	var dials int
+	r := stow.NewRegistry()
+	register := func() {
+		r.Register(testKind, func(stow.Config) (stow.Location, error) {
+			dials++
+			return &memLocation{container: newMemContainer(true)}, nil
+		}, func(u *url.URL) bool {
+			return u.Scheme == testKind
+		}, nil)
+	}
+	register()
+
+	configs := map[string]stow.Config{testKind: stow.ConfigMap{"secret": "registry"}}
+	_, err := r.PutURL(ctx, "test://mem/item", configs, strings.NewReader("contents"), 8, nil)
+	is.NoErr(err)
+	_, err = r.StatURL(ctx, "test://mem/item", configs)
+	is.NoErr(err)
+	is.Equal(dials, 1)
+
+	// registering the kind again drops its Locations
+	register()
+	_, err = r.StatURL(ctx, "test://mem/item", configs)
+	is.True(errors.Is(err, stow.ErrNotFound))
+	is.Equal(dials, 2)
+
+	// the least recently used Location is dropped to make room
+	for i := 0; i < 64; i++ {
+		other := map[string]stow.Config{testKind: stow.ConfigMap{"secret": strconv.Itoa(i)}}
+		_, err = r.StatURL(ctx, "test://mem/item", other)
+		is.True(errors.Is(err, stow.ErrNotFound))
+	}
+	is.Equal(dials, 66)
+	_, err = r.StatURL(ctx, "test://mem/item", configs)
+	is.True(errors.Is(err, stow.ErrNotFound))
+	is.Equal(dials, 67)
+
+	// and so is unregistering it
+	r.Unregister(testKind)
+	_, err = r.StatURL(ctx, "test://mem/item", configs)
+	is.Err(err)
+	is.Equal(dials, 67)
+}
+
+// closingLocation is a memLocation that counts its Close calls.
+type closingLocation struct {
+	memLocation
+	closes *int32
+}
+
+func (l *closingLocation) Close() error {
+	atomic.AddInt32(l.closes, 1)
+	return nil
+}
+
+// layered is a Config that isn't a ConfigMap, whose Locations aren't
+// kept.
+type layered struct {
+	stow.ConfigMap
+}
+
+func TestURLLocationsClose(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+	var closes int32
+	container := newMemContainer(true)
+	r := stow.NewRegistry()
+	r.Register(testKind, func(stow.Config) (stow.Location, error) {
+		return &closingLocation{memLocation: memLocation{container: container}, closes: &closes}, nil
+	}, func(u *url.URL) bool {
+		return u.Scheme == testKind
+	}, nil)
+	_, err := container.Put("item", strings.NewReader("contents"), 8, nil)
+	is.NoErr(err)
+
+	// the Locations of other Configs are closed once used, which for
+	// OpenURL is once the reader is closed
+	configs := map[string]stow.Config{testKind: layered{stow.ConfigMap{}}}
+	_, err = r.StatURL(ctx, "test://mem/item", configs)
+	is.NoErr(err)
+	is.Equal(atomic.LoadInt32(&closes), int32(1))
+	rc, err := r.OpenURL(ctx, "test://mem/item", configs)
+	is.NoErr(err)
+	is.Equal(atomic.LoadInt32(&closes), int32(1))
+	is.NoErr(rc.Close())
+	is.Equal(atomic.LoadInt32(&closes), int32(2))
+	_, err = r.OpenURL(ctx, "test://mem/nope", configs)
+	is.True(errors.Is(err, stow.ErrNotFound))
+	is.Equal(atomic.LoadInt32(&closes), int32(3))
+
+	// the Locations kept are only closed once dropped and no longer
+	// used
+	configs = map[string]stow.Config{testKind: stow.ConfigMap{}}
+	rc, err = r.OpenURL(ctx, "test://mem/item", configs)
+	is.NoErr(err)
+	is.NoErr(r.CloseURLLocations())
+	is.Equal(atomic.LoadInt32(&closes), int32(3))
+	b, err := io.ReadAll(rc)
+	is.NoErr(err)
+	is.Equal(string(b), "contents")
+	is.NoErr(rc.Close())
+	is.NoErr(rc.Close())
+	is.Equal(atomic.LoadInt32(&closes), int32(4))
+
+	_, err = r.StatURL(ctx, "test://mem/item", configs)
+	is.NoErr(err)
+	is.Equal(atomic.LoadInt32(&closes), int32(4))
+	is.NoErr(r.CloseURLLocations())
+	is.Equal(atomic.LoadInt32(&closes), int32(5))
+}
+
+func TestURLErrors(t *testing.T) {
+	is := is.New(t)
+	ctx := context.Background()
+
+	// no Config for the kind
+	_, err := stow.OpenURL(ctx, "test://mem/item", map[string]stow.Config{})
+	is.Err(err)
+
+	// Locations that can't parse URLs can't put by URL
+	configs := map[string]stow.Config{testKind: stow.ConfigMap{}}
+	_, err = stow.PutURL(ctx, "test://container/item", configs, strings.NewReader("contents"), 8, nil)
+	is.True(stow.IsNotSupported(err))
+	is.True(stow.IsNotSupported(stow.RemoveURL(ctx, "test://container/item", configs)))
+}
@@ -1,12 +1,22 @@
 package stow
 
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
 // DEV NOTE: tests for this are in test/test.go
 
 // WalkFunc is a function called for each Item visited
 // by Walk.
 // If there was a problem, the incoming error will describe
 // the problem and the function can decide how to handle
-// that error.
+// that error. Listing can't go on past the problem, so the
+// walk stops after it either way.
 // If an error is returned, processing stops.
 type WalkFunc func(item Item, err error) error
 
@@ -15,18 +25,26 @@ type WalkFunc func(item Item, err error) error
 // nil if no errors were returned.
 // The pageSize is the number of Items to get per request.
 func Walk(container Container, prefix string, pageSize int, fn WalkFunc) error {
+	return WalkCtx(context.Background(), container, prefix, pageSize, fn)
+}
+
+// WalkCtx is Walk with a context, which is passed to ItemsCtx when the
+// Container implements it. The walk stops with the error of ctx, passed
+// to fn, once ctx is done.
+func WalkCtx(ctx context.Context, container Container, prefix string, pageSize int, fn WalkFunc) error {
 	var (
 		err    error
 		items  []Item
 		cursor = CursorStart
 	)
 	for {
-		items, cursor, err = container.Items(prefix, cursor, pageSize)
+		if err = ctx.Err(); err == nil {
+			items, cursor, err = itemsCtx(ctx, container, prefix, cursor, pageSize)
+		}
 		if err != nil {
-			err = fn(nil, err)
-			if err != nil {
-				return err
-			}
+			// the cursor of the next page is unknown, so the walk
+			// can't go on even when fn ignores the error
+			return fn(nil, err)
 		}
 		for _, item := range items {
 			err = fn(item, nil)
@@ -45,7 +63,8 @@ func Walk(container Container, prefix string, pageSize int, fn WalkFunc) error {
 // by WalkContainers.
 // If there was a problem, the incoming error will describe
 // the problem and the function can decide how to handle
-// that error.
+// that error. Listing can't go on past the problem, so the
+// walk stops after it either way.
 // If an error is returned, processing stops.
 type WalkContainersFunc func(container Container, err error) error
 
@@ -62,10 +81,9 @@ func WalkContainers(location Location, prefix string, pageSize int, fn WalkConta
 	for {
 		containers, cursor, err = location.Containers(prefix, cursor, pageSize)
 		if err != nil {
-			err = fn(nil, err)
-			if err != nil {
-				return err
-			}
+			// the cursor of the next page is unknown, so the walk
+			// can't go on even when fn ignores the error
+			return fn(nil, err)
 		}
 		for _, container := range containers {
 			err = fn(container, nil)
@@ -79,3 +97,158 @@ func WalkContainers(location Location, prefix string, pageSize int, fn WalkConta
 	}
 	return nil
 }
+
+// Default options of WalkParallel.
+const (
+	defaultWalkPageSize = 100
+	defaultWalkWorkers  = 8
+)
+
+// WalkOptions are the options of WalkParallel.
+type WalkOptions struct {
+	// PageSize is the number of Items to get per request. It
+	// defaults to 100.
+	PageSize int
+	// Workers is the number of Items processed at once. It defaults
+	// to 8.
+	Workers int
+	// ContinueOnError keeps the walk going when processing an Item
+	// fails. Otherwise the walk is canceled at the first failure.
+	ContinueOnError bool
+}
+
+// ParallelWalkFunc is a function called for each Item visited by
+// WalkParallel. It is called by several goroutines at once, and ctx is
+// canceled when the walk stops early.
+type ParallelWalkFunc func(ctx context.Context, item Item) error
+
+// WalkError is the report of a WalkParallel that didn't process all
+// the Items.
+type WalkError struct {
+	// Items holds the errors returned for Items, by Item ID.
+	Items map[string]error
+	// Err is the error that stopped the listing of the Items, if
+	// any, such as the context being canceled.
+	Err error
+}
+
+func (e *WalkError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "walk: %d items failed", len(e.Items))
+	if e.Err != nil {
+		fmt.Fprintf(&b, ", listing failed: %v", e.Err)
+	}
+	ids := make([]string, 0, len(e.Items))
+	for id := range e.Items {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		fmt.Fprintf(&b, "\n%s: %v", id, e.Items[id])
+	}
+	return b.String()
+}
+
+// Unwrap returns the errors of the report, so that errors.Is and
+// errors.As match any of them.
+func (e *WalkError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Items)+1)
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	for _, err := range e.Items {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// WalkParallel walks the Items in the Container whose IDs start with
+// prefix, calling fn for opts.Workers of them at once. The next page
+// of Items is listed while the current one is processed.
+// It returns nil when all the Items were processed, and a *WalkError
+// otherwise.
+func WalkParallel(ctx context.Context, container Container, prefix string, opts WalkOptions, fn ParallelWalkFunc) error {
+	if opts.PageSize <= 0 {
+		opts.PageSize = defaultWalkPageSize
+	}
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWalkWorkers
+	}
+	list := container.Items
+	if c, ok := container.(ContextContainer); ok {
+		list = func(prefix, cursor string, count int) ([]Item, string, error) {
+			return c.ItemsCtx(ctx, prefix, cursor, count)
+		}
+	}
+
+	parent := ctx
+	ctx, cancel := context.WithCancel(parent)
+	defer cancel()
+	var (
+		mu     sync.Mutex
+		wg     sync.WaitGroup
+		report = &WalkError{Items: make(map[string]error)}
+		// the buffer holds a page, so that the next one is listed
+		// while it is processed
+		queue = make(chan Item, opts.PageSize)
+	)
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range queue {
+				if ctx.Err() != nil {
+					continue
+				}
+				if err := fn(ctx, item); err != nil {
+					mu.Lock()
+					report.Items[item.ID()] = err
+					mu.Unlock()
+					if !opts.ContinueOnError {
+						cancel()
+					}
+				}
+			}
+		}()
+	}
+
+	report.Err = listItems(ctx, prefix, opts.PageSize, list, queue)
+	close(queue)
+	wg.Wait()
+
+	if parent.Err() == nil && errors.Is(report.Err, context.Canceled) {
+		// the listing was canceled by the walk itself, after an
+		// Item failed
+		report.Err = nil
+	}
+	if len(report.Items) > 0 || report.Err != nil {
+		return report
+	}
+	return nil
+}
+
+// listItems lists the Items a page at a time into queue, until the
+// last page or until ctx is done.
+func listItems(ctx context.Context, prefix string, pageSize int, list func(prefix, cursor string, count int) ([]Item, string, error), queue chan<- Item) error {
+	cursor := CursorStart
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		items, next, err := list(prefix, cursor, pageSize)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			select {
+			case queue <- item:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if IsCursorEnd(next) || next == cursor {
+			return nil
+		}
+		cursor = next
+	}
+}
@@ -0,0 +1,1065 @@
+package stow
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"time"
+)
+
+// Call describes a call made through a Location wrapped by Wrap, as
+// seen by its Middlewares.
+type Call struct {
+	// Method is the name of the method called, without the Ctx
+	// suffix, such as "Put" or "Items".
+	Method string
+	// Container is the ID of the Container the call is made on or in,
+	// if any.
+	Container string
+	// Item is the ID of the Item the call is made on, if any.
+	Item string
+	// Args are the arguments of the method, without the context.
+	// Changing them doesn't change the call.
+	Args []interface{}
+	// Results are the results of the method, without the error. They
+	// are set by the Handler once the call is made, and nil when it
+	// failed. A Middleware may replace them with values of the same
+	// types, which are returned to the caller instead, for example to
+	// wrap the io.ReadCloser returned by Open.
+	Results []interface{}
+	// Duration is how long the wrapped method took. It is set by the
+	// Handler once the call is made.
+	Duration time.Duration
+}
+
+// Handler makes a Call. The Ctx variants of the methods of the wrapped
+// objects are called with ctx when they have them.
+type Handler func(ctx context.Context, call *Call) error
+
+// Middleware intercepts the calls made through a Location wrapped by
+// Wrap. It makes the call by calling next, which it may also skip, to
+// deny the call, or repeat, to retry it. The error it returns is
+// returned to the caller.
+type Middleware func(ctx context.Context, call *Call, next Handler) error
+
+// Wrap returns a Location whose calls, and the calls of the Containers
+// and Items got from it, go through middlewares. The first middleware
+// is the outermost one: it sees the calls first and their results
+// last.
+//
+// The methods that make requests are intercepted: CreateContainer,
+// Containers, Container, RemoveContainer and ItemByURL of the Location;
+// Item, Items, RemoveItem, Put, PreSignRequest and the methods of the
+// optional interfaces of the Containers; and Open, OpenParams,
+// OpenRange, OpenWithOptions and the Taggable and TagSetter methods of
+// the Items.
+// The other methods, such as Item.Size or Capabilities, are forwarded
+// as they are.
+//
+// The wrapped Location, Containers and Items implement CapabilityReporter,
+// and the wrapped Containers OptionsPutter and Stater, whose methods
+// fall back as CapabilitiesOf, PutWithOptions and Stat do. They also
+// implement the Ctx variants of these interfaces and of Location,
+// Container and Item, which pass the context on to the middlewares,
+// and an Unwrap method that gets the wrapped object.
+// The other optional interfaces of this package are got with As, which
+// gets those the wrapped objects implement, and only those, with their
+// calls going through the middlewares. Type assertions don't see them.
+func Wrap(location Location, middlewares ...Middleware) Location {
+	return &wrappedLocation{Location: location, mw: middlewares}
+}
+
+// middlewares are the Middlewares of a wrapped Location, shared with
+// the Containers and Items got from it.
+type middlewares []Middleware
+
+// do makes call through the middlewares. fn makes the call to the
+// wrapped object and returns its results.
+func (m middlewares) do(ctx context.Context, call *Call, fn func(ctx context.Context) ([]interface{}, error)) error {
+	h := Handler(func(ctx context.Context, call *Call) error {
+		start := time.Now()
+		results, err := fn(ctx)
+		call.Duration = time.Since(start)
+		call.Results = results
+		return err
+	})
+	for i := len(m) - 1; i >= 0; i-- {
+		mw, next := m[i], h
+		h = func(ctx context.Context, call *Call) error {
+			return mw(ctx, call, next)
+		}
+	}
+	return h(ctx, call)
+}
+
+// doResult is do for methods with a single result besides the error.
+func doResult[T any](ctx context.Context, m middlewares, call *Call, fn func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := m.do(ctx, call, func(ctx context.Context) ([]interface{}, error) {
+		var err error
+		if result, err = fn(ctx); err != nil {
+			return nil, err
+		}
+		return []interface{}{result}, nil
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return resultOf(call, 0, result), nil
+}
+
+// resultOf gets the i-th result of call, unless a Middleware replaced
+// it with a value of another type, in which case result is returned.
+func resultOf[T any](call *Call, i int, result T) T {
+	if i < len(call.Results) {
+		if r, ok := call.Results[i].(T); ok {
+			return r
+		}
+	}
+	return result
+}
+
+// doErr is do for methods with no result besides the error.
+func doErr(ctx context.Context, m middlewares, call *Call, fn func(ctx context.Context) error) error {
+	return m.do(ctx, call, func(ctx context.Context) ([]interface{}, error) {
+		return nil, fn(ctx)
+	})
+}
+
+// container wraps c, unless it is nil.
+func (m middlewares) container(c Container) Container {
+	if c == nil {
+		return nil
+	}
+	return &wrappedContainer{Container: c, mw: m}
+}
+
+// item wraps i of the Container containerID, unless it is nil.
+func (m middlewares) item(containerID string, i Item) Item {
+	if i == nil {
+		return nil
+	}
+	return &wrappedItem{Item: i, containerID: containerID, mw: m}
+}
+
+// items wraps the Items of the Container containerID.
+func (m middlewares) items(containerID string, items []Item) []Item {
+	if items == nil {
+		return nil
+	}
+	wrapped := make([]Item, len(items))
+	for i, item := range items {
+		wrapped[i] = m.item(containerID, item)
+	}
+	return wrapped
+}
+
+// unwrapContainer gets the Container c wraps, if it is wrapped, so
+// that the implementations of Copier get Containers of their own.
+func unwrapContainer(c Container) Container {
+	for {
+		w, ok := c.(interface{ Unwrap() Container })
+		if !ok {
+			return c
+		}
+		c = w.Unwrap()
+	}
+}
+
+// wrapper is implemented by the Location, Containers and Items wrapped
+// by Wrap.
+type wrapper interface {
+	// unwrap gets the wrapped object.
+	unwrap() interface{}
+	// views gets the objects implementing the optional interfaces the
+	// wrapped object implements, whose calls go through the
+	// middlewares.
+	views() []interface{}
+}
+
+// As gets x as a T, as the type assertion x.(T) does. When x is wrapped,
+// as by Wrap, and isn't a T, it gets x as the optional interface T of
+// the wrapped object, whose calls go through the middlewares, or else
+// the first object wrapped by x that is a T. This gets the optional
+// interfaces of the wrapped objects, and the implementations behind
+// them, whose calls don't go through the middlewares.
+func As[T any](x interface{}) (T, bool) {
+	for {
+		if t, ok := x.(T); ok {
+			return t, true
+		}
+		w, ok := x.(wrapper)
+		if !ok {
+			var zero T
+			return zero, false
+		}
+		for _, v := range w.views() {
+			if t, ok := v.(T); ok {
+				return t, true
+			}
+		}
+		x = w.unwrap()
+	}
+}
+
+// as gets x as a T with As, when x, or an object it wraps, is known to
+// be a T.
+func as[T any](x interface{}) T {
+	t, _ := As[T](x)
+	return t
+}
+
+// addView appends v to views when x, or an object it wraps, is a T.
+func addView[T any](views []interface{}, x interface{}, v T) []interface{} {
+	if _, ok := As[T](x); ok {
+		views = append(views, v)
+	}
+	return views
+}
+
+type wrappedLocation struct {
+	Location
+	mw middlewares
+}
+
+var (
+	_ ContextLocation    = (*wrappedLocation)(nil)
+	_ CapabilityReporter = (*wrappedLocation)(nil)
+)
+
+// Unwrap gets the wrapped Location.
+func (l *wrappedLocation) Unwrap() Location {
+	return l.Location
+}
+
+func (l *wrappedLocation) unwrap() interface{} {
+	return l.Location
+}
+
+func (l *wrappedLocation) views() []interface{} {
+	return addView[URLParser](nil, l.Location, wrappedURLParser{l})
+}
+
+// Capabilities describes the wrapped Location, which may not
+// implement CapabilityReporter itself.
+func (l *wrappedLocation) Capabilities() Capabilities {
+	return CapabilitiesOf(l.Location)
+}
+
+func (l *wrappedLocation) CreateContainer(name string) (Container, error) {
+	return l.CreateContainerCtx(context.Background(), name)
+}
+
+func (l *wrappedLocation) CreateContainerCtx(ctx context.Context, name string) (Container, error) {
+	call := &Call{Method: "CreateContainer", Container: name, Args: []interface{}{name}}
+	return doResult(ctx, l.mw, call, func(ctx context.Context) (Container, error) {
+		var c Container
+		var err error
+		if cl, ok := l.Location.(ContextLocation); ok {
+			c, err = cl.CreateContainerCtx(ctx, name)
+		} else {
+			c, err = l.Location.CreateContainer(name)
+		}
+		return l.mw.container(c), err
+	})
+}
+
+func (l *wrappedLocation) Containers(prefix string, cursor string, count int) ([]Container, string, error) {
+	return l.ContainersCtx(context.Background(), prefix, cursor, count)
+}
+
+func (l *wrappedLocation) ContainersCtx(ctx context.Context, prefix string, cursor string, count int) ([]Container, string, error) {
+	var containers []Container
+	var next string
+	call := &Call{Method: "Containers", Args: []interface{}{prefix, cursor, count}}
+	err := l.mw.do(ctx, call, func(ctx context.Context) ([]interface{}, error) {
+		var err error
+		if cl, ok := l.Location.(ContextLocation); ok {
+			containers, next, err = cl.ContainersCtx(ctx, prefix, cursor, count)
+		} else {
+			containers, next, err = l.Location.Containers(prefix, cursor, count)
+		}
+		if err != nil {
+			return nil, err
+		}
+		wrapped := make([]Container, len(containers))
+		for i, c := range containers {
+			wrapped[i] = l.mw.container(c)
+		}
+		containers = wrapped
+		return []interface{}{containers, next}, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return resultOf(call, 0, containers), resultOf(call, 1, next), nil
+}
+
+func (l *wrappedLocation) Container(id string) (Container, error) {
+	return l.ContainerCtx(context.Background(), id)
+}
+
+func (l *wrappedLocation) ContainerCtx(ctx context.Context, id string) (Container, error) {
+	call := &Call{Method: "Container", Container: id, Args: []interface{}{id}}
+	return doResult(ctx, l.mw, call, func(ctx context.Context) (Container, error) {
+		var c Container
+		var err error
+		if cl, ok := l.Location.(ContextLocation); ok {
+			c, err = cl.ContainerCtx(ctx, id)
+		} else {
+			c, err = l.Location.Container(id)
+		}
+		return l.mw.container(c), err
+	})
+}
+
+func (l *wrappedLocation) RemoveContainer(id string) error {
+	return l.RemoveContainerCtx(context.Background(), id)
+}
+
+func (l *wrappedLocation) RemoveContainerCtx(ctx context.Context, id string) error {
+	call := &Call{Method: "RemoveContainer", Container: id, Args: []interface{}{id}}
+	return doErr(ctx, l.mw, call, func(ctx context.Context) error {
+		if cl, ok := l.Location.(ContextLocation); ok {
+			return cl.RemoveContainerCtx(ctx, id)
+		}
+		return l.Location.RemoveContainer(id)
+	})
+}
+
+func (l *wrappedLocation) ItemByURL(u *url.URL) (Item, error) {
+	return l.ItemByURLCtx(context.Background(), u)
+}
+
+func (l *wrappedLocation) ItemByURLCtx(ctx context.Context, u *url.URL) (Item, error) {
+	call := &Call{Method: "ItemByURL", Args: []interface{}{u}}
+	return doResult(ctx, l.mw, call, func(ctx context.Context) (Item, error) {
+		item, err := itemByURL(ctx, l.Location, u)
+		return l.mw.item("", item), err
+	})
+}
+
+// The types below implement the optional interfaces of the wrapped
+// Locations, Containers and Items, each named wrapped followed by the
+// interface. They are got with As, only when the wrapped objects
+// implement the interfaces.
+
+type wrappedURLParser struct {
+	*wrappedLocation
+}
+
+var _ URLParser = wrappedURLParser{}
+
+func (l wrappedURLParser) ParseURL(u *url.URL) (string, string, error) {
+	return as[URLParser](l.Location).ParseURL(u)
+}
+
+type wrappedContainer struct {
+	Container
+	mw middlewares
+}
+
+var (
+	_ ContextContainer     = (*wrappedContainer)(nil)
+	_ CapabilityReporter   = (*wrappedContainer)(nil)
+	_ ContextOptionsPutter = (*wrappedContainer)(nil)
+	_ OptionsPutter        = (*wrappedContainer)(nil)
+	_ ContextStater        = (*wrappedContainer)(nil)
+	_ Stater               = (*wrappedContainer)(nil)
+)
+
+// Unwrap gets the wrapped Container.
+func (c *wrappedContainer) Unwrap() Container {
+	return c.Container
+}
+
+func (c *wrappedContainer) unwrap() interface{} {
+	return c.Container
+}
+
+func (c *wrappedContainer) views() []interface{} {
+	var views []interface{}
+	views = addView[DelimitedLister](views, c.Container, wrappedDelimitedLister{c})
+	views = addView[Copier](views, c.Container, wrappedCopier{c})
+	views = addView[ConditionalPutter](views, c.Container, wrappedConditionalPutter{c})
+	views = addView[MetadataSetter](views, c.Container, wrappedMetadataSetter{c})
+	views = addView[ItemWriter](views, c.Container, wrappedItemWriter{c})
+	views = addView[MultipartUploader](views, c.Container, wrappedMultipartUploader{c})
+	views = addView[BatchRemover](views, c.Container, wrappedBatchRemover{c})
+	views = addView[Versioned](views, c.Container, wrappedVersioned{c})
+	views = addView[TagQuerier](views, c.Container, wrappedTagQuerier{c})
+	return views
+}
+
+// Capabilities describes the wrapped Container, which may not
+// implement CapabilityReporter itself.
+func (c *wrappedContainer) Capabilities() Capabilities {
+	return CapabilitiesOf(c.Container)
+}
+
+// call describes a call to the method of the Container, on the Item
+// id if it isn't empty.
+func (c *wrappedContainer) call(method, id string, args ...interface{}) *Call {
+	return &Call{Method: method, Container: c.ID(), Item: id, Args: args}
+}
+
+func (c *wrappedContainer) Item(id string) (Item, error) {
+	return c.ItemCtx(context.Background(), id)
+}
+
+func (c *wrappedContainer) ItemCtx(ctx context.Context, id string) (Item, error) {
+	return doResult(ctx, c.mw, c.call("Item", id, id), func(ctx context.Context) (Item, error) {
+		item, err := itemCtx(ctx, c.Container, id)
+		return c.mw.item(c.ID(), item), err
+	})
+}
+
+func (c *wrappedContainer) Items(prefix, cursor string, count int) ([]Item, string, error) {
+	return c.ItemsCtx(context.Background(), prefix, cursor, count)
+}
+
+func (c *wrappedContainer) ItemsCtx(ctx context.Context, prefix, cursor string, count int) ([]Item, string, error) {
+	var items []Item
+	var next string
+	call := c.call("Items", "", prefix, cursor, count)
+	err := c.mw.do(ctx, call, func(ctx context.Context) ([]interface{}, error) {
+		var err error
+		if cc, ok := c.Container.(ContextContainer); ok {
+			items, next, err = cc.ItemsCtx(ctx, prefix, cursor, count)
+		} else {
+			items, next, err = c.Container.Items(prefix, cursor, count)
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = c.mw.items(c.ID(), items)
+		return []interface{}{items, next}, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return resultOf(call, 0, items), resultOf(call, 1, next), nil
+}
+
+func (c *wrappedContainer) RemoveItem(id string) error {
+	return c.RemoveItemCtx(context.Background(), id)
+}
+
+func (c *wrappedContainer) RemoveItemCtx(ctx context.Context, id string) error {
+	return doErr(ctx, c.mw, c.call("RemoveItem", id, id), func(ctx context.Context) error {
+		return removeItemCtx(ctx, c.Container, id)
+	})
+}
+
+func (c *wrappedContainer) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (Item, error) {
+	return c.PutCtx(context.Background(), name, r, size, metadata)
+}
+
+func (c *wrappedContainer) PutCtx(ctx context.Context, name string, r io.Reader, size int64, metadata map[string]interface{}) (Item, error) {
+	call := c.call("Put", name, name, r, size, metadata)
+	return doResult(ctx, c.mw, call, func(ctx context.Context) (Item, error) {
+		item, err := putCtx(ctx, c.Container, name, r, size, metadata)
+		return c.mw.item(c.ID(), item), err
+	})
+}
+
+func (c *wrappedContainer) PreSignRequest(ctx context.Context, clientMethod ClientMethod, id string, params PresignRequestParams) (string, error) {
+	call := c.call("PreSignRequest", id, clientMethod, id, params)
+	return doResult(ctx, c.mw, call, func(ctx context.Context) (string, error) {
+		return c.Container.PreSignRequest(ctx, clientMethod, id, params)
+	})
+}
+
+// PutWithOptions is implemented by all the wrapped Containers, falling
+// back to Put as the PutWithOptions function does for those that
+// don't implement OptionsPutter.
+func (c *wrappedContainer) PutWithOptions(name string, r io.Reader, size int64, opts PutOptions) (Item, error) {
+	return c.PutWithOptionsCtx(context.Background(), name, r, size, opts)
+}
+
+func (c *wrappedContainer) PutWithOptionsCtx(ctx context.Context, name string, r io.Reader, size int64, opts PutOptions) (Item, error) {
+	call := c.call("PutWithOptions", name, name, r, size, opts)
+	return doResult(ctx, c.mw, call, func(ctx context.Context) (Item, error) {
+		item, err := PutWithOptionsCtx(ctx, c.Container, name, r, size, opts)
+		return c.mw.item(c.ID(), item), err
+	})
+}
+
+// Stat is implemented by all the wrapped Containers, falling back to
+// Item as the Stat function does for those that don't implement
+// Stater.
+func (c *wrappedContainer) Stat(id string) (ItemInfo, error) {
+	return c.StatCtx(context.Background(), id)
+}
+
+func (c *wrappedContainer) StatCtx(ctx context.Context, id string) (ItemInfo, error) {
+	return doResult(ctx, c.mw, c.call("Stat", id, id), func(ctx context.Context) (ItemInfo, error) {
+		return StatCtx(ctx, c.Container, id)
+	})
+}
+
+type wrappedDelimitedLister struct {
+	*wrappedContainer
+}
+
+var (
+	_ DelimitedLister        = wrappedDelimitedLister{}
+	_ ContextDelimitedLister = wrappedDelimitedLister{}
+)
+
+func (c wrappedDelimitedLister) ItemsDelimited(prefix, delimiter, cursor string, count int) ([]Item, []string, string, error) {
+	return c.ItemsDelimitedCtx(context.Background(), prefix, delimiter, cursor, count)
+}
+
+func (c wrappedDelimitedLister) ItemsDelimitedCtx(ctx context.Context, prefix, delimiter, cursor string, count int) ([]Item, []string, string, error) {
+	var items []Item
+	var prefixes []string
+	var next string
+	call := c.call("ItemsDelimited", "", prefix, delimiter, cursor, count)
+	err := c.mw.do(ctx, call, func(ctx context.Context) ([]interface{}, error) {
+		var err error
+		if lister, ok := As[ContextDelimitedLister](c.Container); ok {
+			items, prefixes, next, err = lister.ItemsDelimitedCtx(ctx, prefix, delimiter, cursor, count)
+		} else {
+			items, prefixes, next, err = as[DelimitedLister](c.Container).ItemsDelimited(prefix, delimiter, cursor, count)
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = c.mw.items(c.ID(), items)
+		return []interface{}{items, prefixes, next}, nil
+	})
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return resultOf(call, 0, items), resultOf(call, 1, prefixes), resultOf(call, 2, next), nil
+}
+
+type wrappedCopier struct {
+	*wrappedContainer
+}
+
+var (
+	_ Copier        = wrappedCopier{}
+	_ ContextCopier = wrappedCopier{}
+)
+
+func (c wrappedCopier) Copy(srcID string, dstContainer Container, dstID string, metadata map[string]interface{}) (Item, error) {
+	return c.CopyCtx(context.Background(), srcID, dstContainer, dstID, metadata)
+}
+
+func (c wrappedCopier) CopyCtx(ctx context.Context, srcID string, dstContainer Container, dstID string, metadata map[string]interface{}) (Item, error) {
+	call := c.call("Copy", srcID, srcID, dstContainer.ID(), dstID, metadata)
+	return doResult(ctx, c.mw, call, func(ctx context.Context) (Item, error) {
+		dst := unwrapContainer(dstContainer)
+		var item Item
+		var err error
+		if copier, ok := As[ContextCopier](c.Container); ok {
+			item, err = copier.CopyCtx(ctx, srcID, dst, dstID, metadata)
+		} else {
+			item, err = as[Copier](c.Container).Copy(srcID, dst, dstID, metadata)
+		}
+		return c.mw.item(dstContainer.ID(), item), err
+	})
+}
+
+type wrappedConditionalPutter struct {
+	*wrappedContainer
+}
+
+var (
+	_ ConditionalPutter        = wrappedConditionalPutter{}
+	_ ContextConditionalPutter = wrappedConditionalPutter{}
+)
+
+func (c wrappedConditionalPutter) PutIf(name string, r io.Reader, size int64, metadata map[string]interface{}, cond PutCondition) (Item, error) {
+	return c.PutIfCtx(context.Background(), name, r, size, metadata, cond)
+}
+
+func (c wrappedConditionalPutter) PutIfCtx(ctx context.Context, name string, r io.Reader, size int64, metadata map[string]interface{}, cond PutCondition) (Item, error) {
+	call := c.call("PutIf", name, name, r, size, metadata, cond)
+	return doResult(ctx, c.mw, call, func(ctx context.Context) (Item, error) {
+		var item Item
+		var err error
+		if putter, ok := As[ContextConditionalPutter](c.Container); ok {
+			item, err = putter.PutIfCtx(ctx, name, r, size, metadata, cond)
+		} else {
+			item, err = as[ConditionalPutter](c.Container).PutIf(name, r, size, metadata, cond)
+		}
+		return c.mw.item(c.ID(), item), err
+	})
+}
+
+type wrappedMetadataSetter struct {
+	*wrappedContainer
+}
+
+var (
+	_ MetadataSetter        = wrappedMetadataSetter{}
+	_ ContextMetadataSetter = wrappedMetadataSetter{}
+)
+
+func (c wrappedMetadataSetter) SetMetadata(id string, metadata map[string]interface{}, replace bool) error {
+	return c.SetMetadataCtx(context.Background(), id, metadata, replace)
+}
+
+func (c wrappedMetadataSetter) SetMetadataCtx(ctx context.Context, id string, metadata map[string]interface{}, replace bool) error {
+	call := c.call("SetMetadata", id, id, metadata, replace)
+	return doErr(ctx, c.mw, call, func(ctx context.Context) error {
+		if setter, ok := As[ContextMetadataSetter](c.Container); ok {
+			return setter.SetMetadataCtx(ctx, id, metadata, replace)
+		}
+		return as[MetadataSetter](c.Container).SetMetadata(id, metadata, replace)
+	})
+}
+
+type wrappedItemWriter struct {
+	*wrappedContainer
+}
+
+var _ ItemWriter = wrappedItemWriter{}
+
+func (c wrappedItemWriter) CreateItem(name string) (Item, io.WriteCloser, error) {
+	var item Item
+	var w io.WriteCloser
+	call := c.call("CreateItem", name, name)
+	err := c.mw.do(context.Background(), call, func(ctx context.Context) ([]interface{}, error) {
+		var err error
+		if item, w, err = as[ItemWriter](c.Container).CreateItem(name); err != nil {
+			return nil, err
+		}
+		item = c.mw.item(c.ID(), item)
+		return []interface{}{item, w}, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return resultOf(call, 0, item), resultOf(call, 1, w), nil
+}
+
+type wrappedMultipartUploader struct {
+	*wrappedContainer
+}
+
+var (
+	_ MultipartUploader        = wrappedMultipartUploader{}
+	_ ContextMultipartUploader = wrappedMultipartUploader{}
+)
+
+// uploader gets the wrapped Container as a ContextMultipartUploader.
+func (c wrappedMultipartUploader) uploader() ContextMultipartUploader {
+	if uploader, ok := As[ContextMultipartUploader](c.Container); ok {
+		return uploader
+	}
+	return backgroundUploader{as[MultipartUploader](c.Container)}
+}
+
+func (c wrappedMultipartUploader) InitiateUpload(name string, metadata map[string]interface{}) (Upload, error) {
+	return c.InitiateUploadCtx(context.Background(), name, metadata)
+}
+
+func (c wrappedMultipartUploader) InitiateUploadCtx(ctx context.Context, name string, metadata map[string]interface{}) (Upload, error) {
+	call := c.call("InitiateUpload", name, name, metadata)
+	return doResult(ctx, c.mw, call, func(ctx context.Context) (Upload, error) {
+		return c.uploader().InitiateUploadCtx(ctx, name, metadata)
+	})
+}
+
+func (c wrappedMultipartUploader) UploadPart(upload Upload, number int, r io.Reader, size int64) (Part, error) {
+	return c.UploadPartCtx(context.Background(), upload, number, r, size)
+}
+
+func (c wrappedMultipartUploader) UploadPartCtx(ctx context.Context, upload Upload, number int, r io.Reader, size int64) (Part, error) {
+	call := c.call("UploadPart", upload.Name, upload, number, r, size)
+	return doResult(ctx, c.mw, call, func(ctx context.Context) (Part, error) {
+		return c.uploader().UploadPartCtx(ctx, upload, number, r, size)
+	})
+}
+
+func (c wrappedMultipartUploader) ListParts(upload Upload) ([]Part, error) {
+	return c.ListPartsCtx(context.Background(), upload)
+}
+
+func (c wrappedMultipartUploader) ListPartsCtx(ctx context.Context, upload Upload) ([]Part, error) {
+	call := c.call("ListParts", upload.Name, upload)
+	return doResult(ctx, c.mw, call, func(ctx context.Context) ([]Part, error) {
+		return c.uploader().ListPartsCtx(ctx, upload)
+	})
+}
+
+func (c wrappedMultipartUploader) CompleteUpload(upload Upload, parts []Part) (Item, error) {
+	return c.CompleteUploadCtx(context.Background(), upload, parts)
+}
+
+func (c wrappedMultipartUploader) CompleteUploadCtx(ctx context.Context, upload Upload, parts []Part) (Item, error) {
+	call := c.call("CompleteUpload", upload.Name, upload, parts)
+	return doResult(ctx, c.mw, call, func(ctx context.Context) (Item, error) {
+		item, err := c.uploader().CompleteUploadCtx(ctx, upload, parts)
+		return c.mw.item(c.ID(), item), err
+	})
+}
+
+func (c wrappedMultipartUploader) AbortUpload(upload Upload) error {
+	return c.AbortUploadCtx(context.Background(), upload)
+}
+
+func (c wrappedMultipartUploader) AbortUploadCtx(ctx context.Context, upload Upload) error {
+	return doErr(ctx, c.mw, c.call("AbortUpload", upload.Name, upload), func(ctx context.Context) error {
+		return c.uploader().AbortUploadCtx(ctx, upload)
+	})
+}
+
+func (c wrappedMultipartUploader) ListPendingUploads(prefix string) ([]Upload, error) {
+	return c.ListPendingUploadsCtx(context.Background(), prefix)
+}
+
+func (c wrappedMultipartUploader) ListPendingUploadsCtx(ctx context.Context, prefix string) ([]Upload, error) {
+	call := c.call("ListPendingUploads", "", prefix)
+	return doResult(ctx, c.mw, call, func(ctx context.Context) ([]Upload, error) {
+		return c.uploader().ListPendingUploadsCtx(ctx, prefix)
+	})
+}
+
+type wrappedBatchRemover struct {
+	*wrappedContainer
+}
+
+var (
+	_ BatchRemover        = wrappedBatchRemover{}
+	_ ContextBatchRemover = wrappedBatchRemover{}
+)
+
+func (c wrappedBatchRemover) RemoveItems(ids []string) (map[string]error, error) {
+	return c.RemoveItemsCtx(context.Background(), ids)
+}
+
+func (c wrappedBatchRemover) RemoveItemsCtx(ctx context.Context, ids []string) (map[string]error, error) {
+	call := c.call("RemoveItems", "", ids)
+	return doResult(ctx, c.mw, call, func(ctx context.Context) (map[string]error, error) {
+		if remover, ok := As[ContextBatchRemover](c.Container); ok {
+			return remover.RemoveItemsCtx(ctx, ids)
+		}
+		return as[BatchRemover](c.Container).RemoveItems(ids)
+	})
+}
+
+type wrappedVersioned struct {
+	*wrappedContainer
+}
+
+var (
+	_ Versioned        = wrappedVersioned{}
+	_ ContextVersioned = wrappedVersioned{}
+)
+
+// versioned gets the wrapped Container as a ContextVersioned one.
+func (c wrappedVersioned) versioned() ContextVersioned {
+	if versioned, ok := As[ContextVersioned](c.Container); ok {
+		return versioned
+	}
+	return backgroundVersioned{as[Versioned](c.Container)}
+}
+
+func (c wrappedVersioned) ItemVersions(id, cursor string, count int) ([]Version, string, error) {
+	return c.ItemVersionsCtx(context.Background(), id, cursor, count)
+}
+
+func (c wrappedVersioned) ItemVersionsCtx(ctx context.Context, id, cursor string, count int) ([]Version, string, error) {
+	var versions []Version
+	var next string
+	call := c.call("ItemVersions", id, id, cursor, count)
+	err := c.mw.do(ctx, call, func(ctx context.Context) ([]interface{}, error) {
+		var err error
+		if versions, next, err = c.versioned().ItemVersionsCtx(ctx, id, cursor, count); err != nil {
+			return nil, err
+		}
+		return []interface{}{versions, next}, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return resultOf(call, 0, versions), resultOf(call, 1, next), nil
+}
+
+func (c wrappedVersioned) ItemVersion(id, versionID string) (Item, error) {
+	return c.ItemVersionCtx(context.Background(), id, versionID)
+}
+
+func (c wrappedVersioned) ItemVersionCtx(ctx context.Context, id, versionID string) (Item, error) {
+	call := c.call("ItemVersion", id, id, versionID)
+	return doResult(ctx, c.mw, call, func(ctx context.Context) (Item, error) {
+		item, err := c.versioned().ItemVersionCtx(ctx, id, versionID)
+		return c.mw.item(c.ID(), item), err
+	})
+}
+
+func (c wrappedVersioned) RemoveItemVersion(id, versionID string) error {
+	return c.RemoveItemVersionCtx(context.Background(), id, versionID)
+}
+
+func (c wrappedVersioned) RemoveItemVersionCtx(ctx context.Context, id, versionID string) error {
+	call := c.call("RemoveItemVersion", id, id, versionID)
+	return doErr(ctx, c.mw, call, func(ctx context.Context) error {
+		return c.versioned().RemoveItemVersionCtx(ctx, id, versionID)
+	})
+}
+
+func (c wrappedVersioned) EnableVersioning() error {
+	return c.EnableVersioningCtx(context.Background())
+}
+
+func (c wrappedVersioned) EnableVersioningCtx(ctx context.Context) error {
+	return doErr(ctx, c.mw, c.call("EnableVersioning", ""), func(ctx context.Context) error {
+		return c.versioned().EnableVersioningCtx(ctx)
+	})
+}
+
+type wrappedTagQuerier struct {
+	*wrappedContainer
+}
+
+var (
+	_ TagQuerier        = wrappedTagQuerier{}
+	_ ContextTagQuerier = wrappedTagQuerier{}
+)
+
+func (c wrappedTagQuerier) ItemsByTag(key, value, cursor string, count int) ([]Item, string, error) {
+	return c.ItemsByTagCtx(context.Background(), key, value, cursor, count)
+}
+
+func (c wrappedTagQuerier) ItemsByTagCtx(ctx context.Context, key, value, cursor string, count int) ([]Item, string, error) {
+	var items []Item
+	var next string
+	call := c.call("ItemsByTag", "", key, value, cursor, count)
+	err := c.mw.do(ctx, call, func(ctx context.Context) ([]interface{}, error) {
+		var err error
+		if querier, ok := As[ContextTagQuerier](c.Container); ok {
+			items, next, err = querier.ItemsByTagCtx(ctx, key, value, cursor, count)
+		} else {
+			items, next, err = as[TagQuerier](c.Container).ItemsByTag(key, value, cursor, count)
+		}
+		if err != nil {
+			return nil, err
+		}
+		items = c.mw.items(c.ID(), items)
+		return []interface{}{items, next}, nil
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return resultOf(call, 0, items), resultOf(call, 1, next), nil
+}
+
+type wrappedItem struct {
+	Item
+	containerID string
+	mw          middlewares
+}
+
+var _ ContextItem = (*wrappedItem)(nil)
+
+// Unwrap gets the wrapped Item.
+func (i *wrappedItem) Unwrap() Item {
+	return i.Item
+}
+
+func (i *wrappedItem) unwrap() interface{} {
+	return i.Item
+}
+
+func (i *wrappedItem) views() []interface{} {
+	var views []interface{}
+	views = addView[ItemRanger](views, i.Item, wrappedItemRanger{i})
+	views = addView[OptionsOpener](views, i.Item, wrappedOptionsOpener{i})
+	views = addView[Taggable](views, i.Item, wrappedTaggable{i})
+	views = addView[TagSetter](views, i.Item, wrappedTagSetter{i})
+	return views
+}
+
+// call describes a call to the method of the Item.
+func (i *wrappedItem) call(method string, args ...interface{}) *Call {
+	return &Call{Method: method, Container: i.containerID, Item: i.ID(), Args: args}
+}
+
+func (i *wrappedItem) Open() (io.ReadCloser, error) {
+	return i.OpenCtx(context.Background())
+}
+
+func (i *wrappedItem) OpenCtx(ctx context.Context) (io.ReadCloser, error) {
+	return doResult(ctx, i.mw, i.call("Open"), func(ctx context.Context) (io.ReadCloser, error) {
+		return openCtx(ctx, i.Item)
+	})
+}
+
+func (i *wrappedItem) OpenParams(params map[string]interface{}) (io.ReadCloser, error) {
+	return doResult(context.Background(), i.mw, i.call("OpenParams", params), func(ctx context.Context) (io.ReadCloser, error) {
+		return i.Item.OpenParams(params)
+	})
+}
+
+type wrappedItemRanger struct {
+	*wrappedItem
+}
+
+var (
+	_ ItemRanger        = wrappedItemRanger{}
+	_ ContextItemRanger = wrappedItemRanger{}
+)
+
+func (i wrappedItemRanger) OpenRange(start, end uint64) (io.ReadCloser, error) {
+	return i.OpenRangeCtx(context.Background(), start, end)
+}
+
+func (i wrappedItemRanger) OpenRangeCtx(ctx context.Context, start, end uint64) (io.ReadCloser, error) {
+	call := i.call("OpenRange", start, end)
+	return doResult(ctx, i.mw, call, func(ctx context.Context) (io.ReadCloser, error) {
+		if ranger, ok := As[ContextItemRanger](i.Item); ok {
+			return ranger.OpenRangeCtx(ctx, start, end)
+		}
+		return as[ItemRanger](i.Item).OpenRange(start, end)
+	})
+}
+
+type wrappedOptionsOpener struct {
+	*wrappedItem
+}
+
+var (
+	_ OptionsOpener        = wrappedOptionsOpener{}
+	_ ContextOptionsOpener = wrappedOptionsOpener{}
+)
+
+func (i wrappedOptionsOpener) OpenWithOptions(opts OpenOptions) (io.ReadCloser, error) {
+	return i.OpenWithOptionsCtx(context.Background(), opts)
+}
+
+func (i wrappedOptionsOpener) OpenWithOptionsCtx(ctx context.Context, opts OpenOptions) (io.ReadCloser, error) {
+	call := i.call("OpenWithOptions", opts)
+	return doResult(ctx, i.mw, call, func(ctx context.Context) (io.ReadCloser, error) {
+		return OpenWithOptionsCtx(ctx, i.Item, opts)
+	})
+}
+
+type wrappedTaggable struct {
+	*wrappedItem
+}
+
+var (
+	_ Taggable        = wrappedTaggable{}
+	_ ContextTaggable = wrappedTaggable{}
+)
+
+func (i wrappedTaggable) Tags() (map[string]interface{}, error) {
+	return i.TagsCtx(context.Background())
+}
+
+func (i wrappedTaggable) TagsCtx(ctx context.Context) (map[string]interface{}, error) {
+	return doResult(ctx, i.mw, i.call("Tags"), func(ctx context.Context) (map[string]interface{}, error) {
+		if taggable, ok := As[ContextTaggable](i.Item); ok {
+			return taggable.TagsCtx(ctx)
+		}
+		return as[Taggable](i.Item).Tags()
+	})
+}
+
+type wrappedTagSetter struct {
+	*wrappedItem
+}
+
+var (
+	_ TagSetter        = wrappedTagSetter{}
+	_ ContextTagSetter = wrappedTagSetter{}
+)
+
+// tagSetter gets the wrapped Item as a ContextTagSetter.
+func (i wrappedTagSetter) tagSetter() ContextTagSetter {
+	if setter, ok := As[ContextTagSetter](i.Item); ok {
+		return setter
+	}
+	return backgroundTagSetter{as[TagSetter](i.Item)}
+}
+
+func (i wrappedTagSetter) SetTags(tags map[string]string) error {
+	return i.SetTagsCtx(context.Background(), tags)
+}
+
+func (i wrappedTagSetter) SetTagsCtx(ctx context.Context, tags map[string]string) error {
+	return doErr(ctx, i.mw, i.call("SetTags", tags), func(ctx context.Context) error {
+		return i.tagSetter().SetTagsCtx(ctx, tags)
+	})
+}
+
+func (i wrappedTagSetter) DeleteTags() error {
+	return i.DeleteTagsCtx(context.Background())
+}
+
+func (i wrappedTagSetter) DeleteTagsCtx(ctx context.Context) error {
+	return doErr(ctx, i.mw, i.call("DeleteTags"), func(ctx context.Context) error {
+		return i.tagSetter().DeleteTagsCtx(ctx)
+	})
+}
+
+// The types below give the Ctx variants of the optional interfaces
+// with several methods to the objects that only implement the
+// interfaces themselves. The contexts are ignored.
+
+type backgroundUploader struct {
+	MultipartUploader
+}
+
+func (u backgroundUploader) InitiateUploadCtx(_ context.Context, name string, metadata map[string]interface{}) (Upload, error) {
+	return u.InitiateUpload(name, metadata)
+}
+
+func (u backgroundUploader) UploadPartCtx(_ context.Context, upload Upload, number int, r io.Reader, size int64) (Part, error) {
+	return u.UploadPart(upload, number, r, size)
+}
+
+func (u backgroundUploader) ListPartsCtx(_ context.Context, upload Upload) ([]Part, error) {
+	return u.ListParts(upload)
+}
+
+func (u backgroundUploader) CompleteUploadCtx(_ context.Context, upload Upload, parts []Part) (Item, error) {
+	return u.CompleteUpload(upload, parts)
+}
+
+func (u backgroundUploader) AbortUploadCtx(_ context.Context, upload Upload) error {
+	return u.AbortUpload(upload)
+}
+
+func (u backgroundUploader) ListPendingUploadsCtx(_ context.Context, prefix string) ([]Upload, error) {
+	return u.ListPendingUploads(prefix)
+}
+
+type backgroundVersioned struct {
+	Versioned
+}
+
+func (v backgroundVersioned) ItemVersionsCtx(_ context.Context, id, cursor string, count int) ([]Version, string, error) {
+	return v.ItemVersions(id, cursor, count)
+}
+
+func (v backgroundVersioned) ItemVersionCtx(_ context.Context, id, versionID string) (Item, error) {
+	return v.ItemVersion(id, versionID)
+}
+
+func (v backgroundVersioned) RemoveItemVersionCtx(_ context.Context, id, versionID string) error {
+	return v.RemoveItemVersion(id, versionID)
+}
+
+func (v backgroundVersioned) EnableVersioningCtx(_ context.Context) error {
+	return v.EnableVersioning()
+}
+
+type backgroundTagSetter struct {
+	TagSetter
+}
+
+func (s backgroundTagSetter) SetTagsCtx(_ context.Context, tags map[string]string) error {
+	return s.SetTags(tags)
+}
+
+func (s backgroundTagSetter) DeleteTagsCtx(_ context.Context) error {
+	return s.DeleteTags()
+}
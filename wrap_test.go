@@ -0,0 +1,213 @@
+package stow_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/aldor007/stow"
+	"github.com/cheekybits/is"
+)
+
+// recorder is a Middleware that records the calls it sees.
+type recorder struct {
+	name  string
+	log   *[]string
+	calls []*stow.Call
+}
+
+func (r *recorder) middleware(ctx context.Context, call *stow.Call, next stow.Handler) error {
+	*r.log = append(*r.log, r.name+" "+call.Method)
+	err := next(ctx, call)
+	*r.log = append(*r.log, r.name+" done")
+	r.calls = append(r.calls, call)
+	return err
+}
+
+func TestWrap(t *testing.T) {
+	is := is.New(t)
+	var log []string
+	outer := &recorder{name: "outer", log: &log}
+	inner := &recorder{name: "inner", log: &log}
+	location := stow.Wrap(&memLocation{container: newMemContainer(true)}, outer.middleware, inner.middleware)
+
+	container, err := location.Container("mem")
+	is.NoErr(err)
+	is.Equal(log, []string{"outer Container", "inner Container", "inner done", "outer done"})
+	call := outer.calls[0]
+	is.Equal(call.Container, "mem")
+	is.Equal(call.Args, []interface{}{"mem"})
+	is.Equal(call.Results, []interface{}{container})
+
+	item, err := container.Put("item", strings.NewReader("contents"), 8, nil)
+	is.NoErr(err)
+	call = outer.calls[1]
+	is.Equal(call.Method, "Put")
+	is.Equal(call.Item, "item")
+	is.Equal(call.Results, []interface{}{item})
+
+	// Items got from wrapped Containers are wrapped too
+	items, _, err := container.Items("", stow.CursorStart, 10)
+	is.NoErr(err)
+	is.Equal(len(items), 1)
+	r, err := stow.OpenWithOptions(items[0], stow.OpenOptions{Range: &stow.ByteRange{Start: 2, End: 4}})
+	is.NoErr(err)
+	b, err := io.ReadAll(r)
+	is.NoErr(err)
+	is.Equal(string(b), "nte")
+	// memItem isn't an ItemRanger, so the range is read with Open
+	call = outer.calls[len(outer.calls)-1]
+	is.Equal(call.Method, "Open")
+	is.Equal(call.Container, "mem")
+	is.Equal(call.Item, "item")
+
+	// errors are seen by the middlewares and returned
+	_, err = container.Item("nope")
+	is.True(errors.Is(err, stow.ErrNotFound))
+	call = outer.calls[len(outer.calls)-1]
+	is.Nil(call.Results)
+	is.Equal(len(inner.calls), len(outer.calls))
+}
+
+func TestWrapDenyAndRetry(t *testing.T) {
+	is := is.New(t)
+	denied := errors.New("denied")
+	deny := func(ctx context.Context, call *stow.Call, next stow.Handler) error {
+		if call.Method == "RemoveItem" {
+			return denied
+		}
+		return next(ctx, call)
+	}
+	var attempts int
+	retry := func(ctx context.Context, call *stow.Call, next stow.Handler) error {
+		err := next(ctx, call)
+		for i := 0; i < 2 && errors.Is(err, stow.ErrNotFound); i++ {
+			attempts++
+			err = next(ctx, call)
+		}
+		return err
+	}
+	location := stow.Wrap(&memLocation{container: newMemContainer(true)}, deny, retry)
+	container, err := location.Container("mem")
+	is.NoErr(err)
+	_, err = container.Put("item", strings.NewReader("contents"), 8, nil)
+	is.NoErr(err)
+
+	is.Equal(container.RemoveItem("item"), denied)
+	_, err = container.Item("item")
+	is.NoErr(err)
+	_, err = container.Item("nope")
+	is.True(errors.Is(err, stow.ErrNotFound))
+	is.Equal(attempts, 2)
+}
+
+// copierLocation is a Location with a single copierContainer.
+type copierLocation struct {
+	memLocation
+	container *copierContainer
+}
+
+func (l *copierLocation) Container(id string) (stow.Container, error) {
+	if id != l.container.ID() {
+		return nil, stow.ErrNotFound
+	}
+	return l.container, nil
+}
+
+func TestWrapOptionalInterfaces(t *testing.T) {
+	is := is.New(t)
+	var log []string
+	rec := &recorder{name: "rec", log: &log}
+	location := stow.Wrap(&memLocation{container: newMemContainer(true)}, rec.middleware)
+	container, err := location.Container("mem")
+	is.NoErr(err)
+	item, err := container.Put("src", strings.NewReader("contents"), 8, map[string]interface{}{"a": "b"})
+	is.NoErr(err)
+
+	// only the interfaces of the wrapped objects are got, with As
+	_, ok := stow.As[stow.Copier](container)
+	is.False(ok)
+	_, ok = stow.As[stow.ItemRanger](item)
+	is.False(ok)
+	_, ok = stow.As[stow.Taggable](item)
+	is.False(ok)
+	_, ok = location.(stow.URLParser)
+	is.False(ok)
+	parser, ok := stow.As[stow.URLParser](location)
+	is.True(ok)
+	containerID, name, err := parser.ParseURL(&url.URL{Scheme: testKind, Host: "mem", Path: "/src"})
+	is.NoErr(err)
+	is.Equal(containerID, "mem")
+	is.Equal(name, "src")
+	is.Equal(container.(interface{ Unwrap() stow.Container }).Unwrap(), location.(interface{ Unwrap() stow.Location }).Unwrap().(*memLocation).container)
+
+	// memContainer isn't a Copier, so Copy streams, through the
+	// wrapped Container
+	log = nil
+	item, err = stow.Copy(container, "src", container, "dst", nil)
+	is.NoErr(err)
+	is.Equal(item.ID(), "dst")
+	is.Equal(log, []string{"rec Item", "rec done", "rec Open", "rec done", "rec Put", "rec done"})
+
+	info, err := stow.Stat(container, "dst")
+	is.NoErr(err)
+	is.Equal(info.Size, int64(8))
+
+	failed, err := stow.RemoveItems(container, []string{"src", "dst"})
+	is.NoErr(err)
+	is.Equal(len(failed), 0)
+	_, err = container.Item("src")
+	is.True(errors.Is(err, stow.ErrNotFound))
+}
+
+func TestWrapAs(t *testing.T) {
+	is := is.New(t)
+	type key struct{}
+	var methods []string
+	var values []interface{}
+	mw := func(ctx context.Context, call *stow.Call, next stow.Handler) error {
+		methods = append(methods, call.Method)
+		values = append(values, ctx.Value(key{}))
+		return next(ctx, call)
+	}
+	inner := &copierContainer{memContainer: newMemContainer(true)}
+	location := stow.Wrap(stow.Wrap(&copierLocation{container: inner}), mw)
+	container, err := location.Container("mem")
+	is.NoErr(err)
+	_, err = container.Put("src", strings.NewReader("contents"), 8, nil)
+	is.NoErr(err)
+
+	_, ok := container.(stow.Copier)
+	is.False(ok)
+	copier, ok := stow.As[stow.Copier](container)
+	is.True(ok)
+	_, ok = copier.(stow.Container)
+	is.True(ok)
+	is.Equal(stow.CapabilitiesOf(container), inner.Capabilities())
+
+	// the Ctx variant is got for a Copier, and the context reaches
+	// the middlewares, through both wrappers
+	methods, values = nil, nil
+	ctx := context.WithValue(context.Background(), key{}, "value")
+	item, err := stow.CopyCtx(ctx, container, "src", container, "dst", nil)
+	is.NoErr(err)
+	is.Equal(item.ID(), "dst")
+	is.Equal(inner.copies, 1)
+	is.Equal(methods, []string{"Copy"})
+	is.Equal(values, []interface{}{"value"})
+
+	// interfaces without Ctx variants are got through both wrappers
+	parser, ok := stow.As[stow.URLParser](location)
+	is.True(ok)
+	containerID, _, err := parser.ParseURL(&url.URL{Scheme: testKind, Host: "mem", Path: "/src"})
+	is.NoErr(err)
+	is.Equal(containerID, "mem")
+
+	// the implementation is got for types that aren't intercepted
+	got, ok := stow.As[*copierContainer](container)
+	is.True(ok)
+	is.Equal(got, inner)
+}